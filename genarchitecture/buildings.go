@@ -9,7 +9,15 @@ import (
 	"github.com/Flokey82/go_gens/vectors"
 )
 
-func GenerateSampleCathedral() {
+// Materials used for the sample buildings.
+var (
+	sampleWallMaterial = &gengeometry.Material{Name: "wall", Diffuse: vectors.NewVec3(0.8, 0.75, 0.65)}
+	sampleRoofMaterial = &gengeometry.Material{Name: "roof", Diffuse: vectors.NewVec3(0.55, 0.2, 0.15)}
+)
+
+// GenerateSampleCathedral generates a sample building and writes it to
+// test_2.obj (and test_2.mtl).
+func GenerateSampleCathedral() error {
 	// Generate a path for a building.
 	procCrossPath := gengeometry.PlusShape{
 		Width:     1,
@@ -21,12 +29,14 @@ func GenerateSampleCathedral() {
 	path := procCrossPath.GetPath()
 	mesh, err := gengeometry.ExtrudePath(path, 0.2)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
+	mesh.SetGroup("walls", sampleWallMaterial)
 	roofMesh, err := gengeometry.TaperPath(path, 0.2)
 	if err != nil {
 		log.Println(err)
 	} else {
+		roofMesh.SetGroup("roof", sampleRoofMaterial)
 		mesh.AddMesh(roofMesh, vectors.NewVec3(0, 0, 0.2))
 	}
 
@@ -43,6 +53,7 @@ func GenerateSampleCathedral() {
 			log.Println(err)
 		} else {
 			// Add the corner to the mesh.
+			cornerMesh.SetGroup("walls", sampleWallMaterial)
 			mesh.AddMesh(cornerMesh, vectors.NewVec3(s.Start.X-corner.Width/2, s.Start.Y-corner.Length/2, 0))
 		}
 
@@ -51,6 +62,7 @@ func GenerateSampleCathedral() {
 		if err != nil {
 			log.Println(err)
 		} else {
+			roofMesh.SetGroup("roof", sampleRoofMaterial)
 			mesh.AddMesh(roofMesh, vectors.NewVec3(s.Start.X-corner.Width/2, s.Start.Y-corner.Length/2, heightCorner))
 		}
 
@@ -76,6 +88,7 @@ func GenerateSampleCathedral() {
 				log.Println(err)
 			} else {
 				// Add the strut to the mesh.
+				strutMesh.SetGroup("walls", sampleWallMaterial)
 				mesh.AddMesh(strutMesh, vectors.NewVec3(midP.X-strutPath.Width/2, midP.Y-strutPath.Length/2, 0))
 			}
 
@@ -84,13 +97,17 @@ func GenerateSampleCathedral() {
 			if err != nil {
 				log.Println(err)
 			} else {
+				roofMesh.SetGroup("roof", sampleRoofMaterial)
 				mesh.AddMesh(roofMesh, vectors.NewVec3(midP.X-strutPath.Width/2, midP.Y-strutPath.Length/2, 0.15))
 			}
 		}
 	}
 
+	// Use flat shading, since the buildings have sharp edges.
+	mesh.ComputeFlatNormals()
+
 	// Save the mesh to a file.
-	mesh.ExportToObj("test_2.obj")
+	return mesh.ExportToObj("test_2.obj")
 }
 
 const (
@@ -401,6 +418,7 @@ type ShapeData struct {
 
 // A graph holds the result of a graph grammar.
 // WARNING: This is not working properly yet!!!!
+// NOTE: See ShapeGrammar for a configurable version loaded from grammar files.
 // We either run the evaluation until until we reached terminal nodes or we run it for a fixed number of iterations.
 func Eval() *Node {
 	var stack []*Node
@@ -564,7 +582,25 @@ func Eval() *Node {
 	return root
 }
 
-func ConvertNodeToMesh(node *Node, mesh *gengeometry.Mesh) {
+// ConvertNodeToMesh adds the geometry of the node and its children to the mesh.
+// NOTE: Nodes of shape grammars (with *Scope data) are converted without
+// materials, use ShapeGrammar.Generate instead.
+func ConvertNodeToMesh(node *Node, mesh *gengeometry.Mesh) error {
+	if s, ok := node.Data.(*Scope); ok {
+		if !node.Replaced {
+			me, err := s.geometry()
+			if err != nil {
+				return err
+			}
+			mesh.AddMesh(me, vectors.Vec3{})
+		}
+		for _, child := range node.Children {
+			if err := ConvertNodeToMesh(child, mesh); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
 	dat := node.Data.(*ShapeData)
 	path := dat.Shape.GetPath()
 	if dat.Reorient {
@@ -576,13 +612,15 @@ func ConvertNodeToMesh(node *Node, mesh *gengeometry.Mesh) {
 	if !node.Replaced {
 		me, err := gengeometry.ExtrudePath(path, dat.HeightScale)
 		if err != nil {
-			panic(err)
-		} else {
-			mesh.AddMesh(me, dat.CurrentPos)
+			return err
 		}
+		mesh.AddMesh(me, dat.CurrentPos)
 	}
 
 	for _, child := range node.Children {
-		ConvertNodeToMesh(child, mesh)
+		if err := ConvertNodeToMesh(child, mesh); err != nil {
+			return err
+		}
 	}
+	return nil
 }
@@ -1,10 +1,12 @@
 package main
 
 import (
+	"fmt"
 	"log"
 
 	"github.com/Flokey82/go_gens/genarchitecture"
 	"github.com/Flokey82/go_gens/gengeometry"
+	"github.com/Flokey82/go_gens/vectors"
 )
 
 func main() {
@@ -13,7 +15,9 @@ func main() {
 	log.Println(st.Description())
 
 	// Generate a sample building.
-	genarchitecture.GenerateSampleCathedral()
+	if err := genarchitecture.GenerateSampleCathedral(); err != nil {
+		log.Fatal(err)
+	}
 
 	// Set up the sample rules.
 	rc := genarchitecture.NewRuleCollection()
@@ -24,7 +28,9 @@ func main() {
 
 	// Run the rules.
 	mesh := rc.Run()
-	mesh.ExportToObj("test_3.obj")
+	if err := mesh.ExportToObj("test_3.obj"); err != nil {
+		log.Fatal(err)
+	}
 
 	// Set up the sample rules.
 	rc = genarchitecture.NewRuleCollection()
@@ -35,13 +41,49 @@ func main() {
 
 	// Run the rules.
 	mesh = rc.Run()
-	mesh.ExportToObj("test_4.obj")
+	if err := mesh.ExportToObj("test_4.obj"); err != nil {
+		log.Fatal(err)
+	}
 
 	root := genarchitecture.Eval()
 	// Draw the tree.
 
 	mesh1 := &gengeometry.Mesh{}
 
-	genarchitecture.ConvertNodeToMesh(root, mesh1)
-	mesh1.ExportToObj("test_5.obj")
+	if err := genarchitecture.ConvertNodeToMesh(root, mesh1); err != nil {
+		log.Fatal(err)
+	}
+	if err := mesh1.ExportToObj("test_5.obj"); err != nil {
+		log.Fatal(err)
+	}
+
+	// Generate buildings from the sample shape grammars.
+	footprints := map[string][]vectors.Vec2{
+		"house":     gengeometry.LShape{Width: 14, Length: 12, WingWidth: 6}.GetPath(),
+		"tower":     gengeometry.PlusShape{Width: 30, Length: 30, WingWidth: 10}.GetPath(),
+		"cathedral": gengeometry.PlusShape{Width: 30, Length: 50, WingWidth: 12}.GetPath(),
+	}
+	for _, name := range []string{"house", "tower", "cathedral"} {
+		g, err := genarchitecture.LoadShapeGrammar(fmt.Sprintf("../grammars/%s.json", name))
+		if err != nil {
+			log.Fatal(err)
+		}
+		m, err := g.Generate(footprints[name], 1234)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := m.ExportToObj(fmt.Sprintf("test_grammar_%s.obj", name)); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// Generate a building in a random style.
+	style, mesh2, err := genarchitecture.GenerateBuilding(genarchitecture.Materials, gengeometry.LShape{Width: 14, Length: 12, WingWidth: 6}.GetPath(), 1234)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Println(style.Description())
+	if err := mesh2.ExportToObj("test_style.obj"); err != nil {
+		log.Fatal(err)
+	}
 }
@@ -1,6 +1,10 @@
 package genarchitecture
 
-import "github.com/Flokey82/go_gens/genlanguage"
+import (
+	"math/rand"
+
+	"github.com/Flokey82/go_gens/genlanguage"
+)
 
 const (
 	ComplexityNone      = 0
@@ -40,16 +44,16 @@ func (d Decoration) Description() string {
 		" depicting " + d.Motif
 }
 
-func genDecoration() *Decoration {
+func genDecoration(rng *rand.Rand) *Decoration {
 	dec := &Decoration{
-		Type: randomString(decorationTypes),
+		Type: randomString(rng, decorationTypes),
 	}
 	if dec.Type == DecorationTypeNone {
 		return dec
 	}
 
-	dec.Complexity = randomInt(ComplexityNone, ComplexityMasterful+1)
-	dec.Motif = randomString(motifs) + " " + randomString(motifsActions)
+	dec.Complexity = randomInt(rng, ComplexityNone, ComplexityMasterful+1)
+	dec.Motif = randomString(rng, motifs) + " " + randomString(rng, motifsActions)
 	return dec
 }
 
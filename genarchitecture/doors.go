@@ -1,6 +1,8 @@
 package genarchitecture
 
 import (
+	"math/rand"
+
 	svg "github.com/ajstarks/svgo"
 )
 
@@ -19,7 +21,7 @@ const doorOuterStyle = "fill:white;stroke:black;stroke-width:2"
 
 func (s DoorStyle) DrawToSVG(sv *svg.SVG, x, y, width, height int) {
 	marginFrame := 16
-	drawShape(sv, x, y, width, height, randomString(shapeDoors))
+	drawShape(sv, x, y, width, height, shapeDoors[rand.Intn(len(shapeDoors))])
 	drawShape(sv, x, y, width, height, s.Shape)
 	drawShape(sv, x+marginFrame/2, y+marginFrame/2, width-marginFrame, height-marginFrame, s.Shape)
 }
@@ -62,10 +64,10 @@ var shapeDoors = []string{
 	ShapeArch,
 }
 
-func generateDoorStyle(availableMaterials []string) DoorStyle {
+func generateDoorStyle(rng *rand.Rand, availableMaterials []string) DoorStyle {
 	return DoorStyle{
-		Shape:     randomString(shapeDoors),
-		Size:      randomInt(1, 3),
-		BaseStyle: generateBaseStyle(availableMaterials),
+		Shape:     randomString(rng, shapeDoors),
+		Size:      randomInt(rng, 1, 3),
+		BaseStyle: generateBaseStyle(rng, availableMaterials),
 	}
 }
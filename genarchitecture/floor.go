@@ -1,12 +1,14 @@
 package genarchitecture
 
+import "math/rand"
+
 type FloorStyle struct {
 	BaseStyle
 }
 
-func generateFloorStyle(availableMaterials []string) FloorStyle {
+func generateFloorStyle(rng *rand.Rand, availableMaterials []string) FloorStyle {
 	return FloorStyle{
-		BaseStyle: generateBaseStyle(availableMaterials),
+		BaseStyle: generateBaseStyle(rng, availableMaterials),
 	}
 }
 
@@ -14,8 +16,8 @@ type CeilingStyle struct {
 	BaseStyle
 }
 
-func generateCeilingStyle(availableMaterials []string) CeilingStyle {
+func generateCeilingStyle(rng *rand.Rand, availableMaterials []string) CeilingStyle {
 	return CeilingStyle{
-		BaseStyle: generateBaseStyle(availableMaterials),
+		BaseStyle: generateBaseStyle(rng, availableMaterials),
 	}
 }
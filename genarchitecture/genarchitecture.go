@@ -8,15 +8,21 @@ import (
 )
 
 func GenerateStyle(availableMaterials []string) Style {
+	return generateStyle(rand.New(rand.NewSource(rand.Int63())), availableMaterials)
+}
+
+// generateStyle generates a random style using the given source of
+// randomness.
+func generateStyle(rng *rand.Rand, availableMaterials []string) Style {
 	return Style{
-		OuterDoorStyle: generateDoorStyle(availableMaterials),
-		InnerDoorStyle: generateDoorStyle(availableMaterials),
-		WindowStyle:    generateWindowStyle(availableMaterials),
-		InnerWallStyle: generateWallStyle(availableMaterials),
-		OuterWallStyle: generateWallStyle(availableMaterials),
-		FloorStyle:     generateFloorStyle(availableMaterials),
-		CeilingStyle:   generateCeilingStyle(availableMaterials),
-		RoofStyle:      generateRoofStyle(availableMaterials),
+		OuterDoorStyle: generateDoorStyle(rng, availableMaterials),
+		InnerDoorStyle: generateDoorStyle(rng, availableMaterials),
+		WindowStyle:    generateWindowStyle(rng, availableMaterials),
+		InnerWallStyle: generateWallStyle(rng, availableMaterials),
+		OuterWallStyle: generateWallStyle(rng, availableMaterials),
+		FloorStyle:     generateFloorStyle(rng, availableMaterials),
+		CeilingStyle:   generateCeilingStyle(rng, availableMaterials),
+		RoofStyle:      generateRoofStyle(rng, availableMaterials),
 	}
 }
 
@@ -29,6 +35,8 @@ type Style struct {
 	FloorStyle     FloorStyle
 	CeilingStyle   CeilingStyle
 	RoofStyle      RoofStyle
+	Courtyard      bool // the building is built around a courtyard
+	Stilts         bool // the building is raised on stilts
 }
 
 func (s *Style) ExportSvg(path string) error {
@@ -55,6 +63,12 @@ func (s Style) Description() string {
 	description += "The ceiling is made of " + s.CeilingStyle.Description() + ". "
 	description += "The outer door is made of " + s.OuterDoorStyle.Description() + ". "
 	description += "The windows are made of " + s.WindowStyle.Description() + ". "
+	if s.Courtyard {
+		description += "The building is built around a courtyard. "
+	}
+	if s.Stilts {
+		description += "The building is raised on stilts. "
+	}
 
 	// Describe the inside of the building.
 	description += "The inside of the building is made of " + s.InnerWallStyle.Description() + ". "
@@ -85,17 +99,17 @@ func (b BaseStyle) Description() string {
 	return leader + " " + b.Ornate.Description()
 }
 
-func generateBaseStyle(availableMaterials []string) BaseStyle {
+func generateBaseStyle(rng *rand.Rand, availableMaterials []string) BaseStyle {
 	// Generate a random decoration
 	var ornate *Decoration
-	if rand.Intn(4) == 0 {
-		ornate = genDecoration()
+	if rng.Intn(4) == 0 {
+		ornate = genDecoration(rng)
 	}
 
 	return BaseStyle{
 		Ornate:   ornate,
-		Material: randomString(availableMaterials),
-		Finish:   randomString(finishes),
+		Material: randomString(rng, availableMaterials),
+		Finish:   randomString(rng, finishes),
 	}
 }
 
@@ -162,13 +176,13 @@ var finishes = []string{
 	FinishPlastered,
 }
 
-func randomString(options []string) string {
+func randomString(rng *rand.Rand, options []string) string {
 	if len(options) == 0 {
 		return ""
 	}
-	return options[rand.Intn(len(options)-1)]
+	return options[rng.Intn(len(options))]
 }
 
-func randomInt(min, max int) int {
-	return rand.Intn(max-min) + min
+func randomInt(rng *rand.Rand, min, max int) int {
+	return rng.Intn(max-min) + min
 }
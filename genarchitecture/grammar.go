@@ -0,0 +1,649 @@
+package genarchitecture
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Flokey82/go_gens/gengeometry"
+	"github.com/Flokey82/go_gens/vectors"
+)
+
+// ShapeGrammar is a CGA-style shape grammar, which generates buildings by
+// successively applying rules to shapes (scopes), starting with a footprint.
+//
+// Each rule has one or more alternatives, one of which is chosen randomly
+// (according to their weights) when the rule is applied. An alternative is
+// a list of operations, which are applied to the scope in order. Operations
+// that produce new scopes either pass them on to the rule given in the
+// operation, or (if no rule is given) apply the remaining operations to them.
+// Scopes without a rule (or with a rule without operations) are turned into
+// geometry, except for the rule "NIL", which discards the scope (e.g. for
+// holes). All other rules have to be defined.
+//
+// The available operations are:
+//
+//	extrude   Extrudes a footprint upwards or a face outwards by 'height'
+//	          (up to 'height_max' if set) and returns the resulting mass.
+//	taper     Generates a hip roof-like solid with 'height' on a footprint
+//	          (or the top of a mass).
+//	roof      Generates a roof of the given 'type' (hip, gable, mansard,
+//	          flat or shed) with 'pitch' (in degrees), 'overhang' and
+//	          'parapet_height'/'parapet_width' on a footprint (or the top of
+//	          a mass). The walls of the roof use 'wall_material'.
+//	comp      Splits a mass into components: 'sides' (faces, the first side
+//	          uses 'front' if set), 'top' and 'bottom' (footprints) and
+//	          'corners' (square footprints with 'corner_size').
+//	split     Splits a face along 'axis' (x or y) or a mass vertically into
+//	          parts with the given 'sizes', which are passed to 'rules'.
+//	repeat    Splits a face along 'axis' or a mass vertically into as many
+//	          parts of roughly 'size' as fit.
+//	offset    Grows or shrinks a footprint or mass by 'distance'.
+//	opening   Cuts an opening of the given 'shape' (rectangle, arch, oval,
+//	          ...) into a face and recesses it by 'depth'. The wall around
+//	          the opening keeps the material of the face, while the back of
+//	          the opening (e.g. the glass of a window) is passed to the rule.
+//	material  Sets the material used for the geometry of the scope.
+//
+// The geometry generated by taper and roof is passed to the given rule (or
+// added as is), while the remaining operations are applied to the original
+// scope. This allows e.g. adding a flat roof before a setback.
+//
+// Sizes can be absolute ("2"), relative to the size of the scope ("0.5r")
+// or floating ("~1"), which means that the remaining space is distributed
+// between floating sizes.
+type ShapeGrammar struct {
+	Name      string                      `json:"name"`
+	Axiom     string                      `json:"axiom"`     // Rule applied to the footprint.
+	Materials map[string]*GrammarMaterial `json:"materials"` // Materials by name.
+	Rules     map[string][]*GrammarRule   `json:"rules"`     // Alternatives by rule name.
+	materials map[string]*gengeometry.Material
+}
+
+// GrammarMaterial is a material defined in a shape grammar.
+type GrammarMaterial struct {
+	Diffuse [3]float64 `json:"diffuse"` // RGB color (0.0-1.0).
+	Texture string     `json:"texture,omitempty"`
+}
+
+// GrammarRule is an alternative of a shape grammar rule.
+type GrammarRule struct {
+	Weight float64      `json:"weight,omitempty"` // Weight of the alternative (default 1).
+	Ops    []*GrammarOp `json:"ops"`
+}
+
+// GrammarOp is an operation of a shape grammar rule (see ShapeGrammar).
+type GrammarOp struct {
+	Op   string `json:"op"`
+	Rule string `json:"rule,omitempty"` // Rule applied to the resulting scopes.
+
+	// extrude, taper
+	Height    float64 `json:"height,omitempty"`
+	HeightMax float64 `json:"height_max,omitempty"`
+
+	// split, repeat
+	Axis  string        `json:"axis,omitempty"`
+	Sizes []GrammarSize `json:"sizes,omitempty"`
+	Rules []string      `json:"rules,omitempty"`
+	Size  GrammarSize   `json:"size"`
+
+	// comp
+	Sides      string  `json:"sides,omitempty"`
+	Front      string  `json:"front,omitempty"`
+	Top        string  `json:"top,omitempty"`
+	Bottom     string  `json:"bottom,omitempty"`
+	Corners    string  `json:"corners,omitempty"`
+	CornerSize float64 `json:"corner_size,omitempty"`
+
+	// offset
+	Distance float64 `json:"distance,omitempty"`
+
+	// opening
+	Shape string  `json:"shape,omitempty"`
+	Depth float64 `json:"depth,omitempty"`
+
+	// roof
+	Type          string  `json:"type,omitempty"`
+	Pitch         float64 `json:"pitch,omitempty"`
+	Overhang      float64 `json:"overhang,omitempty"`
+	ParapetHeight float64 `json:"parapet_height,omitempty"`
+	ParapetWidth  float64 `json:"parapet_width,omitempty"`
+	WallMaterial  string  `json:"wall_material,omitempty"`
+
+	// material
+	Material string `json:"material,omitempty"`
+}
+
+// SizeMode determines how a size of a split is interpreted.
+type SizeMode int
+
+// The supported size modes.
+const (
+	SizeAbsolute SizeMode = iota // Size in units.
+	SizeRelative                 // Fraction of the size of the scope.
+	SizeFloating                 // Share of the remaining space.
+)
+
+// GrammarSize is a size used by split and repeat operations. In grammar
+// files, sizes are either numbers or strings like "2", "0.5r" or "~1".
+type GrammarSize struct {
+	Value float64
+	Mode  SizeMode
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (s *GrammarSize) UnmarshalJSON(b []byte) error {
+	var str string
+	if err := json.Unmarshal(b, &str); err != nil {
+		// Plain numbers are absolute sizes.
+		s.Mode = SizeAbsolute
+		return json.Unmarshal(b, &s.Value)
+	}
+	size, err := ParseGrammarSize(str)
+	if err != nil {
+		return err
+	}
+	*s = size
+	return nil
+}
+
+// ParseGrammarSize parses a size like "2", "0.5r" or "~1".
+func ParseGrammarSize(str string) (GrammarSize, error) {
+	str = strings.TrimSpace(str)
+	var s GrammarSize
+	switch {
+	case strings.HasPrefix(str, "~"):
+		s.Mode, str = SizeFloating, str[1:]
+	case strings.HasSuffix(str, "r"):
+		s.Mode, str = SizeRelative, str[:len(str)-1]
+	}
+	v, err := strconv.ParseFloat(str, 64)
+	if err != nil {
+		return s, fmt.Errorf("invalid size %q", str)
+	}
+	s.Value = v
+	return s, nil
+}
+
+// ruleNil is the rule discarding a scope.
+const ruleNil = "NIL"
+
+// maxGrammarNodes is the maximum number of nodes generated by a shape
+// grammar, which prevents endless recursion.
+const maxGrammarNodes = 100000
+
+// LoadShapeGrammar loads a shape grammar from the given JSON file.
+func LoadShapeGrammar(filename string) (*ShapeGrammar, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	g, err := ParseShapeGrammar(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	return g, nil
+}
+
+// ParseShapeGrammar parses a shape grammar from the given JSON data.
+func ParseShapeGrammar(data []byte) (*ShapeGrammar, error) {
+	var g ShapeGrammar
+	if err := json.Unmarshal(data, &g); err != nil {
+		return nil, err
+	}
+	if err := g.Validate(); err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+// Validate checks the grammar for unknown operations, rules, materials and
+// opening shapes.
+func (g *ShapeGrammar) Validate() error {
+	if g.Axiom == "" {
+		return errors.New("missing axiom")
+	}
+	if _, ok := g.Rules[g.Axiom]; !ok {
+		return fmt.Errorf("unknown axiom %q", g.Axiom)
+	}
+	checkMaterial := func(name string) error {
+		if _, ok := g.Materials[name]; name != "" && !ok {
+			return fmt.Errorf("unknown material %q", name)
+		}
+		return nil
+	}
+	for name, alts := range g.Rules {
+		if len(alts) == 0 {
+			return fmt.Errorf("rule %q: no alternatives", name)
+		}
+		for _, alt := range alts {
+			for _, op := range alt.Ops {
+				var err error
+				switch op.Op {
+				case "extrude", "taper", "comp", "offset":
+				case "opening":
+					err = checkOpeningShape(op.Shape)
+				case "split":
+					if len(op.Sizes) != len(op.Rules) {
+						err = errors.New("split needs a rule for each size")
+					}
+				case "repeat":
+					if op.Size.Value <= 0 {
+						err = errors.New("repeat needs a positive size")
+					}
+				case "roof":
+					if _, err = roofType(op.Type); err == nil {
+						err = checkMaterial(op.WallMaterial)
+					}
+				case "material":
+					err = checkMaterial(op.Material)
+				default:
+					err = fmt.Errorf("unknown operation %q", op.Op)
+				}
+				if err == nil {
+					err = g.checkRules(op)
+				}
+				if err != nil {
+					return fmt.Errorf("rule %q: %v", name, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// checkRules checks that all rules referenced by the operation exist.
+func (g *ShapeGrammar) checkRules(op *GrammarOp) error {
+	for _, rule := range append([]string{op.Rule, op.Sides, op.Front, op.Top, op.Bottom, op.Corners}, op.Rules...) {
+		if _, ok := g.Rules[rule]; rule != "" && rule != ruleNil && !ok {
+			return fmt.Errorf("unknown rule %q", rule)
+		}
+	}
+	return nil
+}
+
+// checkOpeningShape checks that openings with the given shape are supported.
+func checkOpeningShape(shape string) error {
+	switch shape {
+	case "", ShapeRectangle, ShapeCircle, ShapeTrapazoid, ShapeOval, ShapeTriangle, ShapeHexagon, ShapeOctagon, ShapeArch:
+		return nil
+	}
+	return fmt.Errorf("unsupported opening shape %q", shape)
+}
+
+// roofType returns the roof type for the given name.
+func roofType(name string) (gengeometry.RoofType, error) {
+	switch name {
+	case RoofShapeHip, "":
+		return gengeometry.RoofHip, nil
+	case RoofShapeGable:
+		return gengeometry.RoofGable, nil
+	case RoofShapeMansard:
+		return gengeometry.RoofMansard, nil
+	case RoofShapeFlat:
+		return gengeometry.RoofFlat, nil
+	case RoofShapeShed:
+		return gengeometry.RoofShed, nil
+	}
+	return 0, fmt.Errorf("unsupported roof type %q", name)
+}
+
+// material returns the material with the given name (nil if not found).
+func (g *ShapeGrammar) material(name string) *gengeometry.Material {
+	if g.materials == nil {
+		g.materials = make(map[string]*gengeometry.Material)
+	}
+	if m, ok := g.materials[name]; ok {
+		return m
+	}
+	gm, ok := g.Materials[name]
+	if !ok {
+		return nil
+	}
+	m := &gengeometry.Material{
+		Name:    name,
+		Diffuse: vectors.NewVec3(gm.Diffuse[0], gm.Diffuse[1], gm.Diffuse[2]),
+		Texture: gm.Texture,
+	}
+	g.materials[name] = m
+	return m
+}
+
+// Eval applies the rules of the grammar to the given footprint and returns
+// the resulting tree of nodes. The data of each node is a *Scope, and the
+// nodes that were not replaced by a rule are the leaves to be turned into
+// geometry (see ConvertNodeToMesh).
+func (g *ShapeGrammar) Eval(footprint []vectors.Vec2, seed int64) (*Node, error) {
+	rng := rand.New(rand.NewSource(seed))
+	var evalErr error
+	rulesByName := make(map[string]*NodeRule)
+	for name, alts := range g.Rules {
+		name, alts := name, alts
+		rulesByName[name] = &NodeRule{
+			ID:          name,
+			ReplaceNode: true,
+			F: func(node *Node) []*Node {
+				res, err := g.apply(node.Data.(*Scope), g.chooseAlternative(alts, rng), rng)
+				if err != nil && evalErr == nil {
+					evalErr = fmt.Errorf("rule %q: %v", name, err)
+				}
+				return res
+			},
+		}
+	}
+
+	root := newNode(g.Axiom, newFootprintScope(footprint, 0))
+	stack := []*Node{root}
+	numNodes := 1
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		rule, ok := rulesByName[node.ID]
+		if !ok || node.Replaced {
+			continue
+		}
+		newNodes := rule.Apply(node)
+		if evalErr != nil {
+			return nil, evalErr
+		}
+		if numNodes += len(newNodes); numNodes > maxGrammarNodes {
+			return nil, errors.New("too many nodes, the grammar might be recursive")
+		}
+
+		// Add the new nodes in reverse order, so they are processed in order.
+		for i := len(newNodes) - 1; i >= 0; i-- {
+			stack = append(stack, newNodes[i])
+		}
+	}
+	return root, nil
+}
+
+// Generate applies the rules of the grammar to the given footprint and
+// returns the resulting mesh.
+func (g *ShapeGrammar) Generate(footprint []vectors.Vec2, seed int64) (*gengeometry.Mesh, error) {
+	root, err := g.Eval(footprint, seed)
+	if err != nil {
+		return nil, err
+	}
+	mesh := &gengeometry.Mesh{}
+	if err := g.addNodeGeometry(root, mesh); err != nil {
+		return nil, err
+	}
+
+	// Use flat shading, since the buildings have sharp edges.
+	mesh.ComputeFlatNormals()
+	return mesh, nil
+}
+
+// addNodeGeometry adds the geometry of the leaves of the given node to the mesh.
+func (g *ShapeGrammar) addNodeGeometry(node *Node, mesh *gengeometry.Mesh) error {
+	if !node.Replaced {
+		s := node.Data.(*Scope)
+		m, err := s.geometry()
+		if err != nil {
+			return err
+		}
+		if s.Type != ScopeMesh || len(m.Groups) == 0 {
+			m.SetGroup(groupName(s.Material), g.material(s.Material))
+		}
+		mesh.AddMesh(m, vectors.Vec3{})
+	}
+	for _, child := range node.Children {
+		if err := g.addNodeGeometry(child, mesh); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// groupName returns the name of the mesh group for the given material.
+func groupName(material string) string {
+	if material == "" {
+		return "default"
+	}
+	return material
+}
+
+// chooseAlternative returns a random alternative according to the weights.
+func (g *ShapeGrammar) chooseAlternative(alts []*GrammarRule, rng *rand.Rand) *GrammarRule {
+	if len(alts) == 1 {
+		return alts[0]
+	}
+	var total float64
+	for _, alt := range alts {
+		total += alternativeWeight(alt)
+	}
+	r := rng.Float64() * total
+	for _, alt := range alts {
+		if r -= alternativeWeight(alt); r < 0 {
+			return alt
+		}
+	}
+	return alts[len(alts)-1]
+}
+
+func alternativeWeight(alt *GrammarRule) float64 {
+	if alt.Weight <= 0 {
+		return 1
+	}
+	return alt.Weight
+}
+
+// apply applies the operations of the given alternative to the scope and
+// returns the resulting nodes.
+func (g *ShapeGrammar) apply(scope *Scope, alt *GrammarRule, rng *rand.Rand) ([]*Node, error) {
+	var res []*Node
+
+	// emit adds a node for the given scope, which is processed by the given
+	// rule (or turned into geometry if there is no such rule or no rule is
+	// given).
+	emit := func(rule string, s *Scope) {
+		if rule != ruleNil {
+			res = append(res, newNode(rule, s))
+		}
+	}
+
+	// Apply the operations to all current scopes. Scopes that are passed to
+	// a rule are not processed any further.
+	current := []*Scope{scope}
+	for _, op := range alt.Ops {
+		var next []*Scope
+		for _, s := range current {
+			out, rules, err := g.applyOp(s, op, rng, emit)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", op.Op, err)
+			}
+			for i, o := range out {
+				if rules[i] != "" {
+					emit(rules[i], o)
+				} else {
+					next = append(next, o)
+				}
+			}
+		}
+		current = next
+	}
+
+	// The remaining scopes are turned into geometry, except for footprints
+	// covered by a roof. Masses are kept, so their walls are still generated
+	// below the roof on their top.
+	covered := false
+	if n := len(alt.Ops); n > 0 {
+		covered = alt.Ops[n-1].Op == "roof" || alt.Ops[n-1].Op == "taper"
+	}
+	for _, s := range current {
+		if !covered || s.Type != ScopeFootprint {
+			emit("", s)
+		}
+	}
+	return res, nil
+}
+
+// applyOp applies the operation to the scope and returns the resulting
+// scopes and the rules that should be applied to them. Generated geometry
+// (roofs) is passed to emit, while the scope is kept for the remaining
+// operations.
+func (g *ShapeGrammar) applyOp(s *Scope, op *GrammarOp, rng *rand.Rand, emit func(rule string, s *Scope)) ([]*Scope, []string, error) {
+	// single returns the scope with the rule of the operation.
+	single := func(s *Scope) ([]*Scope, []string, error) {
+		return []*Scope{s}, []string{op.Rule}, nil
+	}
+
+	switch op.Op {
+	case "material":
+		s.Material = op.Material
+		return []*Scope{s}, []string{""}, nil
+	case "extrude":
+		height := op.Height
+		if op.HeightMax > height {
+			height += rng.Float64() * (op.HeightMax - height)
+		}
+		// Masses are extruded from the top, so they can be stacked.
+		m, err := s.top().extrude(height)
+		if err != nil {
+			return nil, nil, err
+		}
+		return single(m)
+	case "taper":
+		t := s.top()
+		if t.Type != ScopeFootprint {
+			return nil, nil, errors.New("taper needs a footprint or mass")
+		}
+		mesh, err := gengeometry.TaperPath(t.Path, op.Height)
+		if err != nil {
+			return nil, nil, err
+		}
+		mesh.Translate3(vectors.NewVec3(0, 0, t.Z))
+		m := t.derive(ScopeMesh, t.Index)
+		m.Mesh = mesh
+		emit(op.Rule, m)
+		return []*Scope{s}, []string{""}, nil
+	case "roof":
+		t := s.top()
+		if t.Type != ScopeFootprint {
+			return nil, nil, errors.New("roof needs a footprint or mass")
+		}
+		typ, err := roofType(op.Type)
+		if err != nil {
+			return nil, nil, err
+		}
+		wallMaterial := op.WallMaterial
+		if wallMaterial == "" {
+			wallMaterial = t.Material
+		}
+		mesh, err := gengeometry.GenerateRoof(t.Path, gengeometry.RoofParams{
+			Type:          typ,
+			Pitch:         op.Pitch * math.Pi / 180,
+			Overhang:      op.Overhang,
+			MansardPitch:  math.Min(op.Pitch*2, 75) * math.Pi / 180,
+			ParapetHeight: op.ParapetHeight,
+			ParapetWidth:  op.ParapetWidth,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		mesh.Groups[0] = gengeometry.Group{Name: groupName(t.Material), Material: g.material(t.Material)}
+		mesh.Groups[1] = gengeometry.Group{Name: groupName(wallMaterial), Material: g.material(wallMaterial)}
+		mesh.Translate3(vectors.NewVec3(0, 0, t.Z))
+		m := t.derive(ScopeMesh, t.Index)
+		m.Mesh = mesh
+		emit(op.Rule, m)
+		return []*Scope{s}, []string{""}, nil
+	case "comp":
+		if s.Type != ScopeMass && s.Type != ScopeFootprint {
+			return nil, nil, errors.New("comp needs a mass or footprint")
+		}
+		var out []*Scope
+		var rules []string
+		add := func(rule string, scopes ...*Scope) {
+			if rule == "" {
+				return
+			}
+			for _, sc := range scopes {
+				out = append(out, sc)
+				rules = append(rules, rule)
+			}
+		}
+		if s.Type == ScopeMass {
+			for i, side := range s.sides() {
+				if i == 0 && op.Front != "" {
+					add(op.Front, side)
+				} else {
+					add(op.Sides, side)
+				}
+			}
+			add(op.Top, s.top())
+		}
+		bottom := s.derive(ScopeFootprint, 0)
+		bottom.Path, bottom.Z = s.Path, s.Z
+		add(op.Bottom, bottom)
+		if op.CornerSize > 0 {
+			add(op.Corners, s.corners(op.CornerSize)...)
+		}
+		return out, rules, nil
+	case "split", "repeat":
+		if s.Type != ScopeFace && s.Type != ScopeMass {
+			return nil, nil, errors.New(op.Op + " needs a face or mass")
+		}
+		total := s.size(op.Axis)
+		sizes := op.Sizes
+		rules := op.Rules
+		if op.Op == "repeat" {
+			// The size of the parts is adjusted to fill the scope.
+			target := op.Size.Value
+			if op.Size.Mode == SizeRelative {
+				target *= total
+			}
+			n := int(math.Max(1, math.Round(total/target)))
+			if op.Size.Mode == SizeAbsolute {
+				n = int(math.Max(1, math.Floor(total/target)))
+			}
+			sizes = make([]GrammarSize, n)
+			rules = make([]string, n)
+			for i := range sizes {
+				sizes[i] = GrammarSize{Value: 1, Mode: SizeFloating}
+				rules[i] = op.Rule
+			}
+		}
+		res := resolveSizes(sizes, total)
+		var out []*Scope
+		if s.Type == ScopeMass {
+			out = s.splitMass(res)
+		} else {
+			out = s.splitFace(op.Axis, res)
+		}
+		return out, rules[:len(out)], nil
+	case "opening":
+		wall, panel, err := s.opening(op.Shape, op.Depth)
+		if err != nil {
+			return nil, nil, err
+		}
+		w := s.derive(ScopeMesh, s.Index)
+		w.Mesh = wall
+		emit("", w)
+		p := s.derive(ScopeMesh, s.Index)
+		p.Mesh = panel
+		emit(op.Rule, p)
+		return nil, nil, nil
+	case "offset":
+		if s.Type != ScopeMass && s.Type != ScopeFootprint {
+			return nil, nil, errors.New("offset needs a mass or footprint")
+		}
+		var out []*Scope
+		var rules []string
+		polys := gengeometry.OffsetPolygon(gengeometry.Polygon{Points: s.Path}, op.Distance, gengeometry.JoinMiter)
+		for i, p := range polys {
+			o := s.derive(s.Type, i)
+			o.Path, o.Z, o.Height = ccwPath(p.Points), s.Z, s.Height
+			out = append(out, o)
+			rules = append(rules, op.Rule)
+		}
+		return out, rules, nil
+	}
+	return nil, nil, fmt.Errorf("unknown operation %q", op.Op)
+}
@@ -0,0 +1,102 @@
+package genarchitecture
+
+import (
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Flokey82/go_gens/vectors"
+)
+
+func TestShapeGrammarRoof(t *testing.T) {
+	footprint := []vectors.Vec2{{X: 0, Y: 0}, {X: 8, Y: 0}, {X: 8, Y: 6}, {X: 0, Y: 6}}
+	for _, tc := range []struct {
+		name      string
+		ops       []*GrammarOp
+		height    float64 // Height of the walls.
+		wantWalls bool
+		wantRoof  bool
+	}{
+		{"mass", []*GrammarOp{{Op: "extrude", Height: 3}}, 3, true, false},
+		{"mass with roof", []*GrammarOp{{Op: "extrude", Height: 3}, {Op: "roof", Type: "hip", Pitch: 30}}, 3, true, true},
+		{"mass with taper", []*GrammarOp{{Op: "extrude", Height: 3}, {Op: "taper", Height: 2}}, 3, true, true},
+		{"roof on footprint", []*GrammarOp{{Op: "roof", Type: "hip", Pitch: 30}}, 0, false, true},
+	} {
+		g := &ShapeGrammar{
+			Axiom: "Lot",
+			Rules: map[string][]*GrammarRule{"Lot": {{Ops: tc.ops}}},
+		}
+		if err := g.Validate(); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		m, err := g.Generate(footprint, 1)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+
+		// Walls are vertical triangles below the given height, while the roof
+		// is above it.
+		var walls, roof bool
+		for i := 0; i < len(m.Triangles); i += 3 {
+			a, b, c := m.Vertices[m.Triangles[i]], m.Vertices[m.Triangles[i+1]], m.Vertices[m.Triangles[i+2]]
+			n := vectors.Cross3(vectors.Sub3(b, a), vectors.Sub3(c, a))
+			maxZ := math.Max(a.Z, math.Max(b.Z, c.Z))
+			if maxZ > tc.height+1e-6 {
+				roof = true
+			} else if math.Abs(n.Z) < 1e-6 {
+				walls = true
+			}
+		}
+		if walls != tc.wantWalls || roof != tc.wantRoof {
+			t.Errorf("%s: got walls %t and roof %t, want %t and %t", tc.name, walls, roof, tc.wantWalls, tc.wantRoof)
+		}
+	}
+}
+
+func TestShapeGrammarValidate(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		ops     []*GrammarOp
+		wantErr string
+	}{
+		{"valid", []*GrammarOp{{Op: "extrude", Height: 3, Rule: "Mass"}, {Op: "comp", Sides: "Mass", Top: ruleNil}}, ""},
+		{"unknown operation", []*GrammarOp{{Op: "bend"}}, "unknown operation"},
+		{"unknown rule", []*GrammarOp{{Op: "extrude", Height: 3, Rule: "Building"}}, "unknown rule"},
+		{"unknown comp rule", []*GrammarOp{{Op: "comp", Top: "Roof"}}, "unknown rule"},
+		{"unknown split rule", []*GrammarOp{{Op: "split", Sizes: []GrammarSize{{Value: 1}}, Rules: []string{"Floor"}}}, "unknown rule"},
+		{"unknown material", []*GrammarOp{{Op: "material", Material: "gold"}}, "unknown material"},
+		{"unknown roof type", []*GrammarOp{{Op: "roof", Type: "onion"}}, "unsupported roof type"},
+		{"unknown opening shape", []*GrammarOp{{Op: "opening", Shape: "star"}}, "unsupported opening shape"},
+	} {
+		g := &ShapeGrammar{
+			Axiom: "Lot",
+			Rules: map[string][]*GrammarRule{
+				"Lot":  {{Ops: tc.ops}},
+				"Mass": {{}},
+			},
+		}
+		err := g.Validate()
+		if tc.wantErr == "" && err != nil || tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)) {
+			t.Errorf("%s: got error %v, want %q", tc.name, err, tc.wantErr)
+		}
+	}
+}
+
+func TestLoadShapeGrammar(t *testing.T) {
+	files, err := filepath.Glob("grammars/*.json")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no grammars found (%v)", err)
+	}
+	footprint := []vectors.Vec2{{X: 0, Y: 0}, {X: 12, Y: 0}, {X: 12, Y: 8}, {X: 0, Y: 8}}
+	for _, f := range files {
+		g, err := LoadShapeGrammar(f)
+		if err != nil {
+			t.Errorf("%s: %v", f, err)
+			continue
+		}
+		if _, err := g.Generate(footprint, 1); err != nil {
+			t.Errorf("%s: %v", f, err)
+		}
+	}
+}
@@ -1,14 +1,17 @@
 package genarchitecture
 
+import "math/rand"
+
 type RoofStyle struct {
 	Shape string
+	Pitch float64 // roof pitch in degrees (0 = default of the shape)
 	BaseStyle
 }
 
-func generateRoofStyle(availableMaterials []string) RoofStyle {
+func generateRoofStyle(rng *rand.Rand, availableMaterials []string) RoofStyle {
 	return RoofStyle{
-		Shape:     randomString(roofShapes),
-		BaseStyle: generateBaseStyle(roofMaterials),
+		Shape:     randomString(rng, roofShapes),
+		BaseStyle: generateBaseStyle(rng, roofMaterials),
 	}
 }
 
@@ -33,6 +36,7 @@ const (
 	RoofShapeButterfly = "butterfly"
 	RoofShapeSawtooth  = "sawtooth"
 	RoofShapeDormer    = "dormer"
+	RoofShapeShed      = "shed"
 )
 
 var roofShapes = []string{
@@ -52,6 +56,8 @@ var roofShapes = []string{
 	RoofShapeButterfly,
 	RoofShapeSawtooth,
 	RoofShapeDormer,
+	// NOTE: RoofShapeShed is only used by shape grammars, so the shapes
+	// picked by GenerateStyle don't change for existing seeds.
 }
 
 const (
@@ -0,0 +1,366 @@
+package genarchitecture
+
+import (
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/Flokey82/go_gens/gengeometry"
+	"github.com/Flokey82/go_gens/vectors"
+)
+
+// ScopeType is the type of shape a scope represents.
+type ScopeType int
+
+// The supported scope types.
+const (
+	ScopeFootprint ScopeType = iota // A horizontal polygon.
+	ScopeMass                       // A polygon extruded upwards.
+	ScopeFace                       // A vertical rectangle (e.g. a facade).
+	ScopeMesh                       // Generated geometry (e.g. a roof).
+)
+
+// Scope is the shape a shape grammar rule is applied to.
+type Scope struct {
+	Type     ScopeType
+	Path     []vectors.Vec2    // Polygon of footprints and masses (counter-clockwise).
+	Origin   vectors.Vec3      // Bottom left corner of faces (seen from the outside).
+	U, V     vectors.Vec3      // Horizontal and vertical axis of faces (normalized).
+	Z        float64           // Elevation of footprints and masses.
+	Width    float64           // Width of faces.
+	Height   float64           // Height of masses and faces.
+	Mesh     *gengeometry.Mesh // Geometry of mesh scopes.
+	Material string            // Name of the material used for the geometry.
+	Index    int               // Index of the scope within its split, repeat or component.
+}
+
+// newFootprintScope returns a footprint scope for the given path at the given
+// elevation.
+func newFootprintScope(path []vectors.Vec2, z float64) *Scope {
+	return &Scope{
+		Type: ScopeFootprint,
+		Path: ccwPath(path),
+		Z:    z,
+	}
+}
+
+// derive returns a copy of the scope (keeping the material).
+func (s *Scope) derive(typ ScopeType, index int) *Scope {
+	return &Scope{
+		Type:     typ,
+		Material: s.Material,
+		Index:    index,
+	}
+}
+
+// Normal returns the outward normal of a face.
+func (s *Scope) Normal() vectors.Vec3 {
+	return vectors.Cross3(s.U, s.V)
+}
+
+// top returns the footprint at the top of a mass (or the footprint itself).
+func (s *Scope) top() *Scope {
+	if s.Type != ScopeMass {
+		return s
+	}
+	t := s.derive(ScopeFootprint, s.Index)
+	t.Path, t.Z = s.Path, s.Z+s.Height
+	return t
+}
+
+// sides returns a face for each side of a mass.
+func (s *Scope) sides() []*Scope {
+	var res []*Scope
+	for i, p := range s.Path {
+		q := s.Path[(i+1)%len(s.Path)]
+		d := vectors.Sub2(q, p)
+		f := s.derive(ScopeFace, i)
+		f.Origin = vectors.NewVec3(p.X, p.Y, s.Z)
+		f.U = vectors.NewVec3(d.X, d.Y, 0).Normalize()
+		f.V = vectors.NewVec3(0, 0, 1)
+		f.Width, f.Height = d.Len(), s.Height
+		res = append(res, f)
+	}
+	return res
+}
+
+// corners returns a square footprint with the given size centered on each
+// corner of a footprint or mass, aligned with the outgoing side.
+func (s *Scope) corners(size float64) []*Scope {
+	var res []*Scope
+	for i, p := range s.Path {
+		d := vectors.Sub2(s.Path[(i+1)%len(s.Path)], p).Normalize().Mul(size / 2)
+		n := vectors.NewVec2(-d.Y, d.X)
+		c := s.derive(ScopeFootprint, i)
+		c.Path = []vectors.Vec2{
+			p.Sub(d).Sub(n),
+			p.Add(d).Sub(n),
+			p.Add(d).Add(n),
+			p.Sub(d).Add(n),
+		}
+		c.Z = s.Z
+		res = append(res, c)
+	}
+	return res
+}
+
+// splitFace splits a face along the given axis ("x" or "y") into parts
+// with the given sizes.
+func (s *Scope) splitFace(axis string, sizes []float64) []*Scope {
+	var res []*Scope
+	var pos float64
+	for i, size := range sizes {
+		f := s.derive(ScopeFace, i)
+		f.U, f.V = s.U, s.V
+		if axis == "x" {
+			f.Origin = vectors.Add3(s.Origin, s.U.Mul(pos))
+			f.Width, f.Height = size, s.Height
+		} else {
+			f.Origin = vectors.Add3(s.Origin, s.V.Mul(pos))
+			f.Width, f.Height = s.Width, size
+		}
+		pos += size
+		res = append(res, f)
+	}
+	return res
+}
+
+// splitMass splits a mass vertically into parts with the given heights.
+func (s *Scope) splitMass(sizes []float64) []*Scope {
+	var res []*Scope
+	z := s.Z
+	for i, size := range sizes {
+		m := s.derive(ScopeMass, i)
+		m.Path, m.Z, m.Height = s.Path, z, size
+		z += size
+		res = append(res, m)
+	}
+	return res
+}
+
+// extrude extrudes a footprint upwards or a face outwards (negative values
+// extrude into the face) and returns the resulting mass.
+func (s *Scope) extrude(height float64) (*Scope, error) {
+	m := s.derive(ScopeMass, s.Index)
+	switch s.Type {
+	case ScopeFootprint:
+		m.Path, m.Z, m.Height = s.Path, s.Z, height
+	case ScopeFace:
+		n := s.Normal().Mul(height)
+		o := vectors.NewVec2(s.Origin.X, s.Origin.Y)
+		u := vectors.NewVec2(s.U.X, s.U.Y).Mul(s.Width)
+		d := vectors.NewVec2(n.X, n.Y)
+		m.Path = ccwPath([]vectors.Vec2{o, o.Add(u), o.Add(u).Add(d), o.Add(d)})
+		m.Z, m.Height = s.Origin.Z, s.Height
+	default:
+		return nil, errors.New("extrude needs a footprint or face")
+	}
+	return m, nil
+}
+
+// opening cuts an opening with the given shape into a face and recesses it
+// by the given depth. It returns the wall around the opening including the
+// reveals, and the panel at the back of the opening (e.g. a window or door).
+func (s *Scope) opening(shape string, depth float64) (wall, panel *gengeometry.Mesh, err error) {
+	if s.Type != ScopeFace {
+		return nil, nil, errors.New("opening needs a face")
+	}
+	outline := openingOutline(shape, s.Width, s.Height)
+	n := s.Normal()
+
+	// point returns the given point in face coordinates at the given depth.
+	point := func(p vectors.Vec2, d float64) vectors.Vec3 {
+		return vectors.Add3(vectors.Add3(s.Origin, s.U.Mul(p.X)), vectors.Add3(s.V.Mul(p.Y), n.Mul(-d)))
+	}
+	wall = &gengeometry.Mesh{}
+	addTriangle := func(a, b, c vectors.Vec3) {
+		// Skip degenerate triangles (e.g. where the opening touches the
+		// border of the face).
+		if vectors.Cross3(vectors.Sub3(b, a), vectors.Sub3(c, a)).Len() < 1e-9 {
+			return
+		}
+		i := len(wall.Vertices)
+		wall.Vertices = append(wall.Vertices, a, b, c)
+		wall.Triangles = append(wall.Triangles, i, i+1, i+2)
+	}
+
+	// Fill the space between the border of the face and the outline by
+	// casting rays from the center through the vertices of the outline and
+	// the corners of the face.
+	c := vectors.NewVec2(s.Width/2, s.Height/2)
+	var angles []float64
+	for _, p := range outline {
+		angles = append(angles, math.Atan2(p.Y-c.Y, p.X-c.X))
+	}
+	for _, p := range []vectors.Vec2{{X: 0, Y: 0}, {X: s.Width, Y: 0}, {X: s.Width, Y: s.Height}, {X: 0, Y: s.Height}} {
+		angles = append(angles, math.Atan2(p.Y-c.Y, p.X-c.X))
+	}
+	sort.Float64s(angles)
+	for i, a := range angles {
+		b := angles[(i+1)%len(angles)]
+		oa, ob := rayOutline(c, a, outline), rayOutline(c, b, outline)
+		ra, rb := rayRect(c, a, s.Width, s.Height), rayRect(c, b, s.Width, s.Height)
+		addTriangle(point(oa, 0), point(ra, 0), point(rb, 0))
+		addTriangle(point(oa, 0), point(rb, 0), point(ob, 0))
+	}
+
+	// Add the reveals facing into the opening.
+	for i, p := range outline {
+		q := outline[(i+1)%len(outline)]
+		addTriangle(point(p, 0), point(q, 0), point(q, depth))
+		addTriangle(point(p, 0), point(q, depth), point(p, depth))
+	}
+
+	// Add the panel at the back of the opening.
+	panel = &gengeometry.Mesh{Vertices: []vectors.Vec3{point(c, depth)}}
+	for i, p := range outline {
+		panel.Vertices = append(panel.Vertices, point(p, depth))
+		panel.Triangles = append(panel.Triangles, 0, i+1, (i+1)%len(outline)+1)
+	}
+	return wall, panel, nil
+}
+
+// rayOutline returns the intersection of the ray from c in the direction of
+// the given angle with the given convex outline containing c.
+func rayOutline(c vectors.Vec2, angle float64, outline []vectors.Vec2) vectors.Vec2 {
+	d := vectors.NewVec2(math.Cos(angle), math.Sin(angle))
+	best := c
+	for i, p := range outline {
+		e := vectors.Sub2(outline[(i+1)%len(outline)], p)
+		denom := vectors.Cross2(d, e)
+		if math.Abs(denom) < 1e-12 {
+			continue
+		}
+		w := vectors.Sub2(p, c)
+		t := vectors.Cross2(w, e) / denom
+		u := vectors.Cross2(w, d) / denom
+		if t > 0 && u >= -1e-9 && u <= 1+1e-9 {
+			best = c.Add(d.Mul(t))
+		}
+	}
+	return best
+}
+
+// rayRect returns the intersection of the ray from the center c of a
+// rectangle with the given size in the direction of the given angle with the
+// border of the rectangle.
+func rayRect(c vectors.Vec2, angle float64, width, height float64) vectors.Vec2 {
+	d := vectors.NewVec2(math.Cos(angle), math.Sin(angle))
+	t := math.Inf(1)
+	if math.Abs(d.X) > 1e-12 {
+		t = width / 2 / math.Abs(d.X)
+	}
+	if math.Abs(d.Y) > 1e-12 {
+		t = math.Min(t, height/2/math.Abs(d.Y))
+	}
+	return c.Add(d.Mul(t))
+}
+
+// size returns the size of the scope along the given axis.
+func (s *Scope) size(axis string) float64 {
+	switch {
+	case s.Type == ScopeMass:
+		return s.Height
+	case axis == "x":
+		return s.Width
+	}
+	return s.Height
+}
+
+// geometry returns the mesh of the scope.
+func (s *Scope) geometry() (*gengeometry.Mesh, error) {
+	switch s.Type {
+	case ScopeFootprint:
+		tris, err := gengeometry.Triangulate(s.Path)
+		if err != nil {
+			return nil, err
+		}
+		m := &gengeometry.Mesh{}
+		for _, p := range s.Path {
+			m.Vertices = append(m.Vertices, vectors.NewVec3(p.X, p.Y, s.Z))
+		}
+		// NOTE: Triangulate returns triangles facing down.
+		for i := 0; i < len(tris); i += 3 {
+			m.Triangles = append(m.Triangles, tris[i], tris[i+2], tris[i+1])
+		}
+		return m, nil
+	case ScopeMass:
+		m, err := gengeometry.ExtrudePath(s.Path, s.Height)
+		if err != nil {
+			return nil, err
+		}
+		m.Translate3(vectors.NewVec3(0, 0, s.Z))
+		return m, nil
+	case ScopeFace:
+		u, v := s.U.Mul(s.Width), s.V.Mul(s.Height)
+		return &gengeometry.Mesh{
+			Vertices: []vectors.Vec3{
+				s.Origin,
+				vectors.Add3(s.Origin, u),
+				vectors.Add3(vectors.Add3(s.Origin, u), v),
+				vectors.Add3(s.Origin, v),
+			},
+			Triangles: []int{0, 1, 2, 0, 2, 3},
+		}, nil
+	case ScopeMesh:
+		return s.Mesh, nil
+	}
+	return nil, errors.New("unknown scope type")
+}
+
+// ccwPath returns the given path in counter-clockwise order.
+func ccwPath(path []vectors.Vec2) []vectors.Vec2 {
+	var area float64
+	for i, p := range path {
+		q := path[(i+1)%len(path)]
+		area += p.X*q.Y - q.X*p.Y
+	}
+	if area >= 0 {
+		return path
+	}
+	res := make([]vectors.Vec2, len(path))
+	for i, p := range path {
+		res[len(path)-1-i] = p
+	}
+	return res
+}
+
+// resolveSizes returns the actual sizes of the given split sizes for the
+// given total size. Absolute and relative sizes are kept, while the
+// remaining space is distributed between floating sizes according to their
+// nominal size. Parts beyond the total size are dropped.
+func resolveSizes(sizes []GrammarSize, total float64) []float64 {
+	res := make([]float64, len(sizes))
+	var fixed, floating float64
+	for i, s := range sizes {
+		switch s.Mode {
+		case SizeRelative:
+			res[i] = s.Value * total
+			fixed += res[i]
+		case SizeFloating:
+			floating += s.Value
+		default:
+			res[i] = s.Value
+			fixed += res[i]
+		}
+	}
+	if floating > 0 {
+		rest := math.Max(0, total-fixed)
+		for i, s := range sizes {
+			if s.Mode == SizeFloating {
+				res[i] = s.Value / floating * rest
+			}
+		}
+	}
+
+	// Drop the parts that don't fit.
+	var sum float64
+	for i, r := range res {
+		if sum+r > total+1e-9 {
+			res[i] = total - sum
+			return res[:i+1]
+		}
+		sum += r
+	}
+	return res
+}
@@ -1,6 +1,10 @@
 package genarchitecture
 
-import "math"
+import (
+	"math"
+
+	"github.com/Flokey82/go_gens/vectors"
+)
 
 const (
 	ShapeRectangle = "rectangle"
@@ -76,3 +80,61 @@ func convertToPairSlices(points [][2]int) ([]int, []int) {
 	}
 	return x, y
 }
+
+// openingSegments is the number of segments used for curved openings.
+const openingSegments = 16
+
+// openingOutline returns the counter-clockwise outline of an opening with the
+// given shape, which fills a rectangle of the given size with the origin at
+// the bottom left. All outlines are convex and contain the center of the
+// rectangle.
+func openingOutline(shape string, width, height float64) []vectors.Vec2 {
+	var points []vectors.Vec2
+	add := func(x, y float64) {
+		points = append(points, vectors.NewVec2(x, y))
+	}
+	switch shape {
+	case ShapeTrapazoid:
+		add(0, 0)
+		add(width, 0)
+		add(width*3/4, height)
+		add(width/4, height)
+	case ShapeTriangle:
+		add(0, 0)
+		add(width, 0)
+		add(width/2, height)
+	case ShapeHexagon:
+		add(width/3, 0)
+		add(width*2/3, 0)
+		add(width, height/2)
+		add(width*2/3, height)
+		add(width/3, height)
+		add(0, height/2)
+	case ShapeOctagon:
+		for i := 0; i < 8; i++ {
+			a := float64(i)*math.Pi/4 - math.Pi*3/8
+			add(width/2+width/2*math.Cos(a), height/2+height/2*math.Sin(a))
+		}
+	case ShapeOval, ShapeCircle:
+		for i := 0; i < openingSegments; i++ {
+			a := float64(i)*2*math.Pi/openingSegments - math.Pi/2
+			add(width/2+width/2*math.Cos(a), height/2+height/2*math.Sin(a))
+		}
+	case ShapeArch:
+		// A rectangle with a half circle (or half ellipse if the opening is
+		// too low) on top.
+		r := math.Min(width/2, height/2)
+		add(0, 0)
+		add(width, 0)
+		for i := 0; i <= openingSegments/2; i++ {
+			a := float64(i) * 2 * math.Pi / openingSegments
+			add(width/2+width/2*math.Cos(a), height-r+r*math.Sin(a))
+		}
+	default:
+		add(0, 0)
+		add(width, 0)
+		add(width, height)
+		add(0, height)
+	}
+	return points
+}
@@ -0,0 +1,304 @@
+package genarchitecture
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/Flokey82/go_gens/gengeometry"
+	"github.com/Flokey82/go_gens/vectors"
+)
+
+// Names of the materials (and mesh groups) of the surfaces of a building
+// generated from a style.
+const (
+	SurfaceWall   = "wall"
+	SurfaceRoof   = "roof"
+	SurfaceDoor   = "door"
+	SurfaceWindow = "window"
+)
+
+// Dimensions of buildings generated from a style.
+const (
+	styleFloorHeight  = 3.0  // Height of a floor.
+	styleWallDepth    = 0.25 // Depth of the reveals of doors and windows.
+	styleSillHeight   = 0.9  // Height of the bottom of windows above the floor.
+	styleWindowSpaces = 1.5  // Minimum wall width between windows.
+	styleStiltHeight  = 1.5  // Height of stilts.
+	styleStiltSize    = 0.3  // Width of stilts.
+	styleWingWidth    = 4.0  // Width of the wings around a courtyard.
+	styleMinCourtyard = 9.0  // Minimum area of a courtyard.
+)
+
+// GenerateBuilding generates a style with the given seed and a building in
+// this style with the given footprint, so the description of the style
+// matches the generated geometry.
+func GenerateBuilding(availableMaterials []string, footprint []vectors.Vec2, seed int64) (Style, *gengeometry.Mesh, error) {
+	st := generateStyle(rand.New(rand.NewSource(seed)), availableMaterials)
+	m, err := st.GenerateMesh(footprint, seed)
+	if err != nil {
+		return st, nil, err
+	}
+	return st, m, nil
+}
+
+// GenerateMesh generates a building in this style with the given footprint.
+// The surfaces are grouped by the Surface* constants.
+func (s Style) GenerateMesh(footprint []vectors.Vec2, seed int64) (*gengeometry.Mesh, error) {
+	g := s.ShapeGrammar()
+	if !s.Courtyard {
+		return g.Generate(footprint, seed)
+	}
+
+	// Generate the wings around the courtyard separately.
+	mesh := &gengeometry.Mesh{}
+	for i, part := range courtyardFootprints(footprint, styleWingWidth) {
+		m, err := g.Generate(part, seed+int64(i))
+		if err != nil {
+			return nil, err
+		}
+		mesh.AddMesh(m, vectors.Vec3{})
+	}
+	return mesh, nil
+}
+
+// courtyardFootprints returns the footprints of the wings of a building with
+// the given footprint around a courtyard. If the footprint is too small for a
+// courtyard, the footprint itself is returned.
+func courtyardFootprints(footprint []vectors.Vec2, wingWidth float64) [][]vectors.Vec2 {
+	outer := gengeometry.Polygon{Points: footprint}
+	inner := gengeometry.OffsetPolygon(outer, -wingWidth, gengeometry.JoinMiter)
+	if len(inner) != 1 || inner[0].Area() < styleMinCourtyard {
+		return [][]vectors.Vec2{footprint}
+	}
+	ring := gengeometry.Difference([]gengeometry.Polygon{outer}, inner)
+
+	// Cut the ring in half through the courtyard, so we get wings without
+	// holes.
+	minX, minY, maxX, maxY := pathBounds(footprint)
+	_, innerMinY, _, innerMaxY := pathBounds(inner[0].Points)
+	cut := (innerMinY + innerMaxY) / 2
+	var res [][]vectors.Vec2
+	for _, half := range [][2]float64{{minY - 1, cut}, {cut, maxY + 1}} {
+		box := gengeometry.Polygon{Points: []vectors.Vec2{
+			{X: minX - 1, Y: half[0]},
+			{X: maxX + 1, Y: half[0]},
+			{X: maxX + 1, Y: half[1]},
+			{X: minX - 1, Y: half[1]},
+		}}
+		for _, p := range gengeometry.Intersection(ring, []gengeometry.Polygon{box}) {
+			if len(p.Holes) > 0 {
+				return [][]vectors.Vec2{footprint}
+			}
+			res = append(res, p.Points)
+		}
+	}
+	return res
+}
+
+// pathBounds returns the bounding box of the given path.
+func pathBounds(path []vectors.Vec2) (minX, minY, maxX, maxY float64) {
+	minX, minY = math.Inf(1), math.Inf(1)
+	maxX, maxY = math.Inf(-1), math.Inf(-1)
+	for _, p := range path {
+		minX, maxX = math.Min(minX, p.X), math.Max(maxX, p.X)
+		minY, maxY = math.Min(minY, p.Y), math.Max(maxY, p.Y)
+	}
+	return minX, minY, maxX, maxY
+}
+
+// ShapeGrammar returns a shape grammar generating buildings in this style.
+//
+// The number of floors is given by the height of the outer walls, the doors
+// and windows are sized and shaped according to their styles and recessed by
+// the thickness of the walls, and the roof is approximated by the closest
+// supported roof type.
+func (s Style) ShapeGrammar() *ShapeGrammar {
+	floors := s.OuterWallStyle.Height
+	if floors < 1 {
+		floors = 1
+	}
+	doorWidth, doorHeight := s.OuterDoorStyle.dimensions()
+	windowWidth, windowHeight := s.WindowStyle.dimensions()
+	depth := s.OuterWallStyle.Thickness
+	if depth <= 0 {
+		depth = styleWallDepth
+	}
+
+	g := &ShapeGrammar{
+		Name:  "style",
+		Axiom: "Lot",
+		Materials: map[string]*GrammarMaterial{
+			SurfaceWall:   {Diffuse: materialColor(s.OuterWallStyle.Material)},
+			SurfaceRoof:   {Diffuse: materialColor(s.RoofStyle.Material)},
+			SurfaceDoor:   {Diffuse: materialColor(s.OuterDoorStyle.Material)},
+			SurfaceWindow: {Diffuse: s.WindowStyle.Glass.color()},
+		},
+		Rules: make(map[string][]*GrammarRule),
+	}
+	rule := func(name string, ops ...*GrammarOp) {
+		g.Rules[name] = []*GrammarRule{{Ops: ops}}
+	}
+	split := func(axis string, sizes []GrammarSize, rules ...string) *GrammarOp {
+		return &GrammarOp{Op: "split", Axis: axis, Sizes: sizes, Rules: rules}
+	}
+	abs := func(v float64) GrammarSize {
+		return GrammarSize{Value: v}
+	}
+	rest := GrammarSize{Value: 1, Mode: SizeFloating}
+
+	body := &GrammarOp{Op: "extrude", Height: float64(floors) * styleFloorHeight, Rule: "Building"}
+	if s.Stilts {
+		// Raise the building on stilts at its corners. The corners are inset
+		// by half the size of the stilts, so the stilts are flush with the
+		// walls.
+		rule("Lot",
+			&GrammarOp{Op: "material", Material: SurfaceWall},
+			&GrammarOp{Op: "extrude", Height: styleStiltHeight, Rule: "Stilts"})
+		rule("Stilts", &GrammarOp{Op: "comp", Bottom: "StiltBase", Top: "Body"})
+		rule("StiltBase", &GrammarOp{Op: "offset", Distance: -styleStiltSize / 2, Rule: "StiltCorners"})
+		rule("StiltCorners", &GrammarOp{Op: "comp", Corners: "Stilt", CornerSize: styleStiltSize})
+		rule("Stilt", &GrammarOp{Op: "extrude", Height: styleStiltHeight})
+		rule("Body", body)
+	} else {
+		rule("Lot", &GrammarOp{Op: "material", Material: SurfaceWall}, body)
+	}
+	rule("Building", &GrammarOp{Op: "comp", Front: "FrontFacade", Sides: "Facade", Top: "Roof"})
+
+	// The roof.
+	roofOps := []*GrammarOp{{Op: "material", Material: SurfaceRoof}}
+	if op := s.RoofStyle.roofOp(); op != nil {
+		roofOps = append(roofOps, op)
+	}
+	rule("Roof", roofOps...)
+
+	// The front facade has the door on the ground floor.
+	if floors > 1 {
+		rule("FrontFacade", split("y", []GrammarSize{abs(styleFloorHeight), rest}, "GroundFloor", "Facade"))
+	} else {
+		rule("FrontFacade", split("x", []GrammarSize{rest, abs(doorWidth), rest}, "Floor", "DoorTile", "Floor"))
+	}
+	rule("GroundFloor", split("x", []GrammarSize{rest, abs(doorWidth), rest}, "Floor", "DoorTile", "Floor"))
+	rule("DoorTile", split("y", []GrammarSize{abs(doorHeight), rest}, "Door", "Wall"))
+	rule("Wall")
+	rule("Door", &GrammarOp{Op: "opening", Shape: s.OuterDoorStyle.Shape, Depth: depth, Rule: "DoorPanel"})
+	rule("DoorPanel", &GrammarOp{Op: "material", Material: SurfaceDoor})
+
+	// The other facades are split into floors and tiles with a window each.
+	rule("Facade", &GrammarOp{Op: "repeat", Axis: "y", Size: abs(styleFloorHeight), Rule: "Floor"})
+	if s.WindowStyle.Size == SizeNone {
+		rule("Floor")
+	} else {
+		tile := GrammarSize{Value: windowWidth + styleWindowSpaces, Mode: SizeFloating}
+		rule("Floor", &GrammarOp{Op: "repeat", Axis: "x", Size: tile, Rule: "Tile"})
+		rule("Tile", split("x", []GrammarSize{rest, abs(windowWidth), rest}, "Wall", "WindowColumn", "Wall"))
+		rule("WindowColumn", split("y", []GrammarSize{abs(styleSillHeight), abs(windowHeight), rest}, "Wall", "Window", "Wall"))
+		rule("Window", &GrammarOp{Op: "opening", Shape: s.WindowStyle.Shape, Depth: depth, Rule: "WindowPanel"})
+		rule("WindowPanel", &GrammarOp{Op: "material", Material: SurfaceWindow})
+	}
+	return g
+}
+
+// dimensions returns the width and height of the door in meters.
+func (s DoorStyle) dimensions() (width, height float64) {
+	size := float64(s.Size)
+	return 0.6 + 0.3*size, math.Min(1.8+0.2*size, styleFloorHeight-0.3)
+}
+
+// dimensions returns the width and height of the windows in meters.
+func (s WindowStyle) dimensions() (width, height float64) {
+	size := float64(s.Size)
+	return 0.3 + 0.3*size, math.Min(0.5+0.4*size, styleFloorHeight-styleSillHeight-0.3)
+}
+
+// roofOp returns the grammar operation generating the roof (nil if the
+// building has no roof). Roof shapes that are not supported by the roof
+// generator are replaced by the closest supported shape, and the pitch
+// defaults to a typical pitch of the shape.
+func (s RoofStyle) roofOp() *GrammarOp {
+	op := &GrammarOp{Op: "roof", Overhang: 0.4, WallMaterial: SurfaceWall}
+	switch s.Shape {
+	case RoofShapeNone:
+		return nil
+	case RoofShapeGable, RoofShapeSaltbox, RoofShapeDormer:
+		op.Type, op.Pitch = RoofShapeGable, 40
+	case RoofShapeMansard, RoofShapeGambrel:
+		op.Type, op.Pitch = RoofShapeMansard, 30
+	case RoofShapeFlat:
+		op.Type, op.Overhang = RoofShapeFlat, 0
+		op.ParapetHeight, op.ParapetWidth = 0.6, 0.3
+	case RoofShapeShed, RoofShapeButterfly, RoofShapeSawtooth:
+		op.Type, op.Pitch = RoofShapeShed, 15
+	case RoofShapePyramid:
+		op.Type, op.Pitch = RoofShapeHip, 45
+	default:
+		op.Type, op.Pitch = RoofShapeHip, 30
+	}
+	if s.Pitch > 0 && op.Type != RoofShapeFlat {
+		op.Pitch = s.Pitch
+	}
+	return op
+}
+
+// materialColors are the diffuse colors of the materials.
+var materialColors = map[string][3]float64{
+	MaterialStone:            {0.6, 0.58, 0.55},
+	MaterialWood:             {0.45, 0.3, 0.18},
+	MaterialBrick:            {0.6, 0.3, 0.22},
+	MaterialGlass:            {0.6, 0.7, 0.75},
+	MaterialMetal:            {0.55, 0.57, 0.6},
+	MaterialMarble:           {0.9, 0.89, 0.86},
+	MaterialPlaster:          {0.86, 0.81, 0.7},
+	MaterialPaper:            {0.93, 0.9, 0.82},
+	MaterialClay:             {0.7, 0.45, 0.3},
+	MaterialCeramic:          {0.8, 0.78, 0.72},
+	MaterialPlastic:          {0.8, 0.8, 0.8},
+	MaterialLeather:          {0.5, 0.33, 0.2},
+	MaterialFur:              {0.55, 0.45, 0.35},
+	MaterialBone:             {0.88, 0.85, 0.75},
+	MaterialShell:            {0.92, 0.88, 0.82},
+	RoofMaterialWoodPlanks:   {0.45, 0.32, 0.2},
+	RoofMaterialWoodShingles: {0.4, 0.3, 0.22},
+	RoofMaterialHide:         {0.55, 0.42, 0.3},
+	RoofMaterialThatch:       {0.7, 0.6, 0.35},
+	RoofMaterialStraw:        {0.8, 0.7, 0.4},
+	RoofMaterialSlate:        {0.3, 0.32, 0.35},
+	RoofMaterialTile:         {0.6, 0.25, 0.18},
+}
+
+// materialColor returns the diffuse color of the given material (grey if
+// the material is unknown).
+func materialColor(material string) [3]float64 {
+	if c, ok := materialColors[material]; ok {
+		return c
+	}
+	return [3]float64{0.5, 0.5, 0.5}
+}
+
+// colorValues are the RGB values of the colors.
+var colorValues = map[string][3]float64{
+	"red":    {0.8, 0.2, 0.2},
+	"orange": {0.9, 0.55, 0.2},
+	"yellow": {0.9, 0.85, 0.3},
+	"green":  {0.3, 0.65, 0.3},
+	"blue":   {0.25, 0.4, 0.8},
+	"indigo": {0.3, 0.25, 0.6},
+	"violet": {0.55, 0.3, 0.7},
+	"black":  {0.1, 0.1, 0.1},
+	"white":  {0.95, 0.95, 0.95},
+	"grey":   {0.5, 0.5, 0.5},
+	"brown":  {0.45, 0.3, 0.18},
+}
+
+// color returns the diffuse color of the glass. Clear glass is a bluish
+// grey, while other glass types are tinted with the color of the glass.
+func (s GlassStyle) color() [3]float64 {
+	res := [3]float64{0.35, 0.45, 0.55}
+	c, ok := colorValues[s.Color]
+	if !ok || s.Type == GlassTypeClear {
+		return res
+	}
+	for i := range res {
+		res[i] = (res[i] + c[i]) / 2
+	}
+	return res
+}
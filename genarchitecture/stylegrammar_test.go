@@ -0,0 +1,33 @@
+package genarchitecture
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/Flokey82/go_gens/vectors"
+)
+
+func TestStyleStilts(t *testing.T) {
+	footprint := []vectors.Vec2{{X: 0, Y: 0}, {X: 8, Y: 0}, {X: 8, Y: 6}, {X: 0, Y: 6}}
+	for seed := int64(0); seed < 10; seed++ {
+		st := generateStyle(rand.New(rand.NewSource(seed)), Materials)
+		st.Stilts, st.Courtyard = true, false
+		m, err := st.GenerateMesh(footprint, seed)
+		if err != nil {
+			t.Fatalf("seed %d: %v", seed, err)
+		}
+
+		// The stilts are within the footprint and reach its corners.
+		var stilts []vectors.Vec2
+		for _, v := range m.Vertices {
+			if v.Z < styleStiltHeight-1e-6 {
+				stilts = append(stilts, vectors.NewVec2(v.X, v.Y))
+			}
+		}
+		minX, minY, maxX, maxY := pathBounds(stilts)
+		if math.Abs(minX) > 1e-6 || math.Abs(minY) > 1e-6 || math.Abs(maxX-8) > 1e-6 || math.Abs(maxY-6) > 1e-6 {
+			t.Errorf("seed %d: got stilts within %f, %f - %f, %f, want 0, 0 - 8, 6", seed, minX, minY, maxX, maxY)
+		}
+	}
+}
@@ -1,7 +1,10 @@
 package genarchitecture
 
-// NOTE: This is currently a collection of ideas, research, and sources related to
-// vernacular architecture.
+import "math/rand"
+
+// NOTE: This is a collection of ideas, research, and sources related to
+// vernacular architecture. Some of the strategies below are encoded in
+// VernacularStyle at the end of this file.
 //
 // Useful links:
 // - https://en.wikipedia.org/wiki/Vernacular_architecture
@@ -221,3 +224,246 @@ package genarchitecture
 // Main strategies:
 // - Buildings in flood-prone areas should be designed to withstand flooding.
 // - Buildings might be raised on stilts or built on higher ground.
+
+// Climate zones (see above).
+const (
+	ClimateHotArid       = "hot arid"
+	ClimateHotHumid      = "hot humid"
+	ClimateCold          = "cold"
+	ClimateContinental   = "continental"
+	ClimateTemperate     = "temperate"
+	ClimateMediterranean = "mediterranean"
+)
+
+// Climate describes the local climate a building is built in.
+type Climate struct {
+	MinTemperature float64 // Lowest (winter) temperature in °C.
+	MaxTemperature float64 // Highest (summer) temperature in °C.
+	Precipitation  float64 // Annual precipitation in mm.
+	Wind           float64 // Average wind speed in m/s.
+	Flooding       bool    // The area is prone to flooding.
+}
+
+// ClimateFromAverages returns a climate based on normalized average
+// temperature and rain (0-1) and the average wind speed, like the average
+// climate maps of genmap2derosion.
+func ClimateFromAverages(temperature, rain, wind float64) Climate {
+	// Map the average temperature to -20°C to 30°C. Dry climates have larger
+	// temperature swings.
+	avg := -20 + 50*temperature
+	swing := 10 + 10*(1-rain)
+	return Climate{
+		MinTemperature: avg - swing,
+		MaxTemperature: avg + swing,
+		Precipitation:  3000 * rain,
+		Wind:           wind,
+	}
+}
+
+// Zone returns the climate zone.
+func (c Climate) Zone() string {
+	switch {
+	case c.MinTemperature <= -15 || c.MaxTemperature < 15:
+		return ClimateCold
+	case c.MaxTemperature >= 30 && c.Precipitation < 400:
+		return ClimateHotArid
+	case c.MinTemperature >= 15 && c.Precipitation >= 1000:
+		return ClimateHotHumid
+	case c.MaxTemperature-c.MinTemperature >= 35:
+		return ClimateContinental
+	case c.MinTemperature >= 0 && c.MaxTemperature >= 25:
+		return ClimateMediterranean
+	}
+	return ClimateTemperate
+}
+
+// Snow returns true if the climate has significant snowfall.
+func (c Climate) Snow() bool {
+	return c.MinTemperature < 0 && c.Precipitation >= 500
+}
+
+// vernacularRule describes the typical buildings of a climate zone.
+type vernacularRule struct {
+	wallMaterials []string // Preferred wall materials (in order).
+	roofMaterials []string // Preferred roof materials (in order).
+	finishes      []string // Typical finishes of the walls.
+	thickness     float64  // Wall thickness in meters.
+	roofShapes    []string // Typical roof shapes.
+	pitch         float64  // Roof pitch in degrees.
+	windowSize    int      // Size of the windows.
+	floors        int      // Maximum number of floors.
+	courtyard     bool     // Buildings are built around a courtyard.
+	stilts        bool     // Buildings are raised on stilts.
+}
+
+// vernacularRules are the typical buildings for each climate zone.
+var vernacularRules = map[string]vernacularRule{
+	// Thick walls of adobe or stone with light colours, small windows, flat
+	// roofs and courtyards.
+	ClimateHotArid: {
+		wallMaterials: []string{MaterialClay, MaterialStone, MaterialBrick},
+		roofMaterials: []string{RoofMaterialWoodPlanks, RoofMaterialStraw},
+		finishes:      []string{FinishPlastered, FinishPainted},
+		thickness:     0.6,
+		roofShapes:    []string{RoofShapeFlat},
+		windowSize:    SizeTiny,
+		floors:        2,
+		courtyard:     true,
+	},
+	// Lightweight buildings with large openings for ventilation, steep
+	// pyramidal roofs and raised floors.
+	ClimateHotHumid: {
+		wallMaterials: []string{MaterialWood, MaterialPlaster},
+		roofMaterials: []string{RoofMaterialThatch, RoofMaterialStraw, RoofMaterialWoodShingles},
+		finishes:      []string{FinishNone, FinishPainted},
+		thickness:     0.1,
+		roofShapes:    []string{RoofShapeHip, RoofShapePyramid},
+		pitch:         45,
+		windowSize:    SizeLarge,
+		floors:        1,
+		stilts:        true,
+	},
+	// Compact buildings with thick, insulated walls, small windows and steep
+	// roofs that shed snow.
+	ClimateCold: {
+		wallMaterials: []string{MaterialStone, MaterialWood, MaterialBrick},
+		roofMaterials: []string{RoofMaterialSlate, RoofMaterialWoodShingles, RoofMaterialHide},
+		finishes:      []string{FinishNone, FinishRough},
+		thickness:     0.5,
+		roofShapes:    []string{RoofShapeGable},
+		pitch:         45,
+		windowSize:    SizeTiny,
+		floors:        1,
+	},
+	// Well insulated walls of wood, brick or clay and steep pitched roofs.
+	ClimateContinental: {
+		wallMaterials: []string{MaterialWood, MaterialBrick, MaterialClay, MaterialStone},
+		roofMaterials: []string{RoofMaterialWoodShingles, RoofMaterialThatch, RoofMaterialTile},
+		finishes:      []string{FinishNone, FinishPlastered, FinishPainted},
+		thickness:     0.4,
+		roofShapes:    []string{RoofShapeGable, RoofShapeHip},
+		pitch:         40,
+		windowSize:    SizeMedium,
+		floors:        2,
+	},
+	// Moderately thick walls and pitched roofs.
+	ClimateTemperate: {
+		wallMaterials: []string{MaterialBrick, MaterialStone, MaterialWood, MaterialPlaster},
+		roofMaterials: []string{RoofMaterialTile, RoofMaterialSlate, RoofMaterialThatch},
+		finishes:      []string{FinishNone, FinishPlastered, FinishPainted},
+		thickness:     0.3,
+		roofShapes:    []string{RoofShapeGable, RoofShapeHip},
+		pitch:         35,
+		windowSize:    SizeMedium,
+		floors:        2,
+	},
+	// Compact buildings of stone or clay with low-pitched or flat roofs and
+	// courtyards.
+	ClimateMediterranean: {
+		wallMaterials: []string{MaterialStone, MaterialClay, MaterialPlaster, MaterialBrick},
+		roofMaterials: []string{RoofMaterialTile},
+		finishes:      []string{FinishPlastered, FinishPainted},
+		thickness:     0.45,
+		roofShapes:    []string{RoofShapeHip, RoofShapeFlat},
+		pitch:         20,
+		windowSize:    SizeSmall,
+		floors:        2,
+		courtyard:     true,
+	},
+}
+
+// roofMaterialSources are the wall materials required for roof materials.
+// Roof materials not listed here (e.g. thatch) are always available.
+var roofMaterialSources = map[string]string{
+	RoofMaterialWoodPlanks:   MaterialWood,
+	RoofMaterialWoodShingles: MaterialWood,
+	RoofMaterialSlate:        MaterialStone,
+	RoofMaterialTile:         MaterialClay,
+	RoofMaterialMetal:        MaterialMetal,
+	RoofMaterialGlass:        MaterialGlass,
+}
+
+// VernacularStyle returns a style suited for the given climate, using the
+// given available materials. Wall thickness and material, roof shape and
+// pitch, window size, the number of floors and whether the building has a
+// courtyard or stilts are chosen according to the climate zone and adjusted
+// for snow, heavy rain, strong winds and flooding. The remaining details
+// (e.g. door shapes and decorations) are chosen randomly based on the given
+// seed.
+func VernacularStyle(c Climate, availableMaterials []string, seed int64) Style {
+	rule := vernacularRules[c.Zone()]
+	rng := rand.New(rand.NewSource(seed))
+	st := generateStyle(rng, availableMaterials)
+
+	// Walls.
+	st.OuterWallStyle.Material = preferredMaterial(rng, rule.wallMaterials, availableMaterials)
+	st.OuterWallStyle.Finish = rule.finishes[rng.Intn(len(rule.finishes))]
+	st.OuterWallStyle.Thickness = rule.thickness
+	st.OuterWallStyle.Height = randomInt(rng, 1, rule.floors+1)
+
+	// Roof.
+	var availableRoofMaterials []string
+	for _, m := range roofMaterials {
+		if src, ok := roofMaterialSources[m]; !ok || containsString(availableMaterials, src) {
+			availableRoofMaterials = append(availableRoofMaterials, m)
+		}
+	}
+	st.RoofStyle.Material = preferredMaterial(rng, rule.roofMaterials, availableRoofMaterials)
+	st.RoofStyle.Shape = rule.roofShapes[rng.Intn(len(rule.roofShapes))]
+	st.RoofStyle.Pitch = rule.pitch
+	if c.Snow() && st.RoofStyle.Pitch < 45 {
+		// Steep roofs shed snow (see below for the pitch).
+		st.RoofStyle.Shape = RoofShapeGable
+	}
+	if c.Precipitation >= 1500 {
+		// Heavy rain needs pitched roofs to drain.
+		if st.RoofStyle.Shape == RoofShapeFlat {
+			st.RoofStyle.Shape = RoofShapeHip
+		}
+		if st.RoofStyle.Pitch < 35 {
+			st.RoofStyle.Pitch = 35
+		}
+	}
+	if c.Wind >= 10 {
+		// Strong winds require low buildings with low-pitched hip roofs.
+		st.OuterWallStyle.Height = 1
+		if st.RoofStyle.Shape == RoofShapeGable {
+			st.RoofStyle.Shape = RoofShapeHip
+		}
+		st.RoofStyle.Pitch *= 0.75
+	}
+	if c.Snow() && st.RoofStyle.Pitch < 45 {
+		// The minimum pitch for snow is applied last, so that it isn't
+		// lowered by the other rules.
+		st.RoofStyle.Pitch = 45
+	}
+
+	// Openings, courtyards and stilts.
+	st.WindowStyle.Size = rule.windowSize
+	st.Courtyard = rule.courtyard
+	st.Stilts = rule.stilts || c.Flooding
+	return st
+}
+
+// preferredMaterial returns the first of the preferred materials that is
+// available, or a random available material if none of them is.
+func preferredMaterial(rng *rand.Rand, preferred, available []string) string {
+	for _, m := range preferred {
+		if containsString(available, m) {
+			return m
+		}
+	}
+	if len(available) == 0 {
+		return ""
+	}
+	return available[rng.Intn(len(available))]
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,43 @@
+package genarchitecture
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestVernacularStyleSingleMaterial(t *testing.T) {
+	for _, temp := range []float64{0, 0.5, 1} {
+		c := ClimateFromAverages(temp, 0.5, 5)
+		st := VernacularStyle(c, []string{MaterialClay}, 1)
+		if st.OuterWallStyle.Material != MaterialClay {
+			t.Errorf("%s: wall material = %q, want %q", c.Zone(), st.OuterWallStyle.Material, MaterialClay)
+		}
+	}
+}
+
+func TestVernacularStyleSnowAndWind(t *testing.T) {
+	c := Climate{
+		MinTemperature: -10,
+		MaxTemperature: 20,
+		Precipitation:  800,
+		Wind:           15,
+	}
+	for seed := int64(0); seed < 10; seed++ {
+		st := VernacularStyle(c, Materials, seed)
+		if st.RoofStyle.Pitch < 45 {
+			t.Fatalf("seed %d: roof pitch = %f, want at least 45 for snow", seed, st.RoofStyle.Pitch)
+		}
+		if st.OuterWallStyle.Height != 1 {
+			t.Fatalf("seed %d: wall height = %d, want 1 for strong winds", seed, st.OuterWallStyle.Height)
+		}
+	}
+}
+
+func TestVernacularStyleSeed(t *testing.T) {
+	c := ClimateFromAverages(0.8, 0.7, 5)
+	for seed := int64(0); seed < 10; seed++ {
+		if a, b := VernacularStyle(c, Materials, seed), VernacularStyle(c, Materials, seed); !reflect.DeepEqual(a, b) {
+			t.Errorf("seed %d: got different styles %v and %v", seed, a, b)
+		}
+	}
+}
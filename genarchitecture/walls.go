@@ -1,5 +1,7 @@
 package genarchitecture
 
+import "math/rand"
+
 var shapeWalls = []string{
 	ShapeRectangle,
 	ShapeTrapazoid,
@@ -7,19 +9,26 @@ var shapeWalls = []string{
 }
 
 type WallStyle struct {
-	Shape  string // wall shape
-	Height int    // wall height
+	Shape     string  // wall shape
+	Height    int     // wall height
+	Thickness float64 // wall thickness in meters (0 = default)
 	BaseStyle
 }
 
-func generateWallStyle(availableMaterials []string) WallStyle {
+func generateWallStyle(rng *rand.Rand, availableMaterials []string) WallStyle {
 	return WallStyle{
-		Shape:     randomString(shapeWalls),
-		Height:    randomInt(1, 3),
-		BaseStyle: generateBaseStyle(availableMaterials),
+		Shape:     randomString(rng, shapeWalls),
+		Height:    randomInt(rng, 1, 3),
+		BaseStyle: generateBaseStyle(rng, availableMaterials),
 	}
 }
 
 func (s WallStyle) Description() string {
-	return s.Shape + " walls of " + s.BaseStyle.Description()
+	var thickness string
+	if s.Thickness >= 0.5 {
+		thickness = "thick "
+	} else if s.Thickness > 0 && s.Thickness <= 0.15 {
+		thickness = "thin "
+	}
+	return s.Shape + " " + thickness + "walls of " + s.BaseStyle.Description()
 }
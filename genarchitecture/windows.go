@@ -1,5 +1,7 @@
 package genarchitecture
 
+import "math/rand"
+
 type WindowStyle struct {
 	Shape string
 	Size  int
@@ -80,23 +82,23 @@ const (
 	FabricTypePoplin   = "poplin"
 )
 
-func generateWindowStyle(availableMaterials []string) WindowStyle {
+func generateWindowStyle(rng *rand.Rand, availableMaterials []string) WindowStyle {
 	return WindowStyle{
-		Shape:     randomString(shapeWindows),
-		Size:      randomInt(1, 3),
-		BaseStyle: generateBaseStyle(availableMaterials),
+		Shape:     randomString(rng, shapeWindows),
+		Size:      randomInt(rng, 1, 3),
+		BaseStyle: generateBaseStyle(rng, availableMaterials),
 		Glass: GlassStyle{
-			Thickness: randomInt(1, 3),
-			Color:     randomString(colors),
-			Type:      randomString([]string{GlassTypeClear, GlassTypeObscured, GlassTypeStained, GlassTypeBottle, GlassTypeFrosted, GlassTypeTinted}),
-			Shape:     randomString(shapeWindows),
-			BaseStyle: generateBaseStyle(availableMaterials),
+			Thickness: randomInt(rng, 1, 3),
+			Color:     randomString(rng, colors),
+			Type:      randomString(rng, []string{GlassTypeClear, GlassTypeObscured, GlassTypeStained, GlassTypeBottle, GlassTypeFrosted, GlassTypeTinted}),
+			Shape:     randomString(rng, shapeWindows),
+			BaseStyle: generateBaseStyle(rng, availableMaterials),
 		},
 		Curtains: CurtainsStyle{
-			Thickness: randomInt(1, 3),
-			Size:      randomInt(1, 3),
-			Shape:     randomString(shapeWindows),
-			BaseStyle: generateBaseStyle(availableMaterials),
+			Thickness: randomInt(rng, 1, 3),
+			Size:      randomInt(rng, 1, 3),
+			Shape:     randomString(rng, shapeWindows),
+			BaseStyle: generateBaseStyle(rng, availableMaterials),
 		},
 	}
 }
@@ -10,9 +10,7 @@ import (
 // Animator is a cell culture that can be animated and exported to a GIF.
 type Animator struct {
 	*Culture
-	images  []*image.Paletted // Generated frame used to construct the GIF.
-	palette []color.Color     // Default color palette.
-	delays  []int             // Delay for each individual frame (0 for now).
+	*gifFrames
 }
 
 // NewAnimator returns a new cell culture animator with the given height and width.
@@ -24,21 +22,15 @@ func NewAnimator(height, width int) *Animator {
 func NewAnimatorCustom(height, width int, sf SeedFunc, ef EvalFunc) *Animator {
 	c := NewCustom(height, width, sf, ef)
 	return &Animator{
-		Culture: c,
-		palette: []color.Color{
-			color.RGBA{0x00, 0x00, 0x00, 0xff}, color.RGBA{0x00, 0x00, 0xff, 0xff},
-			color.RGBA{0x00, 0xff, 0x00, 0xff}, color.RGBA{0x00, 0xff, 0xff, 0xff},
-			color.RGBA{0xff, 0x00, 0x00, 0xff}, color.RGBA{0xff, 0x00, 0xff, 0xff},
-			color.RGBA{0xff, 0xff, 0x00, 0xff}, color.RGBA{0xff, 0xff, 0xff, 0xff},
-		},
+		Culture:   c,
+		gifFrames: newGifFrames(),
 	}
 }
 
 // Reset the simulation and clear all frames.
 func (c *Animator) Reset() {
 	c.Culture.Reset()
-	c.images = nil
-	c.delays = nil
+	c.gifFrames.reset()
 }
 
 // Tick advances the culture by one tick and stores the current state in the frame.
@@ -46,16 +38,72 @@ func (c *Animator) Tick() {
 	c.Culture.Tick()
 
 	// Store the previous frame since we have already advanced by one.
-	c.storeGifFrame(c.Cells[(c.Generation-1)%2])
+	cells := c.Cells[(c.Generation-1)%2]
+	c.storeGifFrame(c.Width, c.Height, func(x, y int) bool {
+		return cells[x][y]
+	})
+}
+
+// BitAnimator is a bit-packed cell culture that can be animated and exported
+// to a GIF.
+type BitAnimator struct {
+	*BitCulture
+	*gifFrames
+}
+
+// NewBitAnimator returns a new bit-packed cell culture animator with the given
+// width, height, and rule, which is initialized using the given seed function.
+func NewBitAnimator(width, height int, rule *Rule, border Border, sf SeedFunc) (*BitAnimator, error) {
+	c, err := NewBitCulture(width, height, rule, border)
+	if err != nil {
+		return nil, err
+	}
+	c.Seed(sf)
+	return &BitAnimator{
+		BitCulture: c,
+		gifFrames:  newGifFrames(),
+	}, nil
+}
+
+// Tick stores the current state in the frame and advances the culture by one tick.
+func (c *BitAnimator) Tick() {
+	c.storeGifFrame(c.Width, c.Height, c.Get)
+	c.BitCulture.Tick()
+}
+
+// gifFrames holds the frames of an animated culture.
+type gifFrames struct {
+	images  []*image.Paletted // Generated frame used to construct the GIF.
+	palette []color.Color     // Default color palette.
+	delays  []int             // Delay for each individual frame (0 for now).
+}
+
+func newGifFrames() *gifFrames {
+	return &gifFrames{
+		palette: []color.Color{
+			color.RGBA{0x00, 0x00, 0x00, 0xff}, color.RGBA{0x00, 0x00, 0xff, 0xff},
+			color.RGBA{0x00, 0xff, 0x00, 0xff}, color.RGBA{0x00, 0xff, 0xff, 0xff},
+			color.RGBA{0xff, 0x00, 0x00, 0xff}, color.RGBA{0xff, 0x00, 0xff, 0xff},
+			color.RGBA{0xff, 0xff, 0x00, 0xff}, color.RGBA{0xff, 0xff, 0xff, 0xff},
+		},
+	}
+}
+
+// reset clears all frames.
+func (c *gifFrames) reset() {
+	c.images = nil
+	c.delays = nil
 }
 
-func (c *Animator) storeGifFrame(cells [][]bool) {
-	img := image.NewPaletted(image.Rect(0, 0, c.Width, c.Height), c.palette)
+// storeGifFrame stores a frame of the given size, where the given function
+// returns the state of each cell.
+func (c *gifFrames) storeGifFrame(width, height int, alive func(x, y int) bool) {
+	img := image.NewPaletted(image.Rect(0, 0, width, height), c.palette)
 	c.images = append(c.images, img)
 	c.delays = append(c.delays, 0)
-	for x := 0; x < c.Width; x++ {
-		for y := 0; y < c.Height; y++ {
-			if cells[x][y] {
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			if alive(x, y) {
 				img.Set(x, y, color.RGBA{0xFF, 0x00, 0x00, 255})
 			} else {
 				img.Set(x, y, color.RGBA{0x00, 0x00, 0x00, 255})
@@ -65,7 +113,7 @@ func (c *Animator) storeGifFrame(cells [][]bool) {
 }
 
 // Export all frames to a GIF under the given path.
-func (c *Animator) ExportGif(path string) error {
+func (c *gifFrames) ExportGif(path string) error {
 	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE, 0600)
 	if err != nil {
 		return err
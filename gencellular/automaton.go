@@ -0,0 +1,196 @@
+package gencellular
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// The states shared by all rules.
+const (
+	StateDead  uint8 = 0
+	StateAlive uint8 = 1
+)
+
+// Neighborhood determines which cells are considered neighbors of a cell.
+type Neighborhood int
+
+// The available neighborhoods.
+const (
+	NeighborhoodMoore      Neighborhood = iota // The 8 surrounding cells.
+	NeighborhoodVonNeumann                     // The 4 orthogonally adjacent cells.
+	NeighborhoodHex                            // The 6 adjacent cells of a hex grid (odd rows shifted right).
+)
+
+var (
+	offsetsMoore      = [][2]int{{-1, -1}, {0, -1}, {1, -1}, {-1, 0}, {1, 0}, {-1, 1}, {0, 1}, {1, 1}}
+	offsetsVonNeumann = [][2]int{{0, -1}, {-1, 0}, {1, 0}, {0, 1}}
+	offsetsHexEven    = [][2]int{{-1, -1}, {0, -1}, {-1, 0}, {1, 0}, {-1, 1}, {0, 1}}
+	offsetsHexOdd     = [][2]int{{0, -1}, {1, -1}, {-1, 0}, {1, 0}, {0, 1}, {1, 1}}
+)
+
+// offsets returns the offsets of the neighbors of the cells in row y.
+func (n Neighborhood) offsets(y int) [][2]int {
+	switch n {
+	case NeighborhoodVonNeumann:
+		return offsetsVonNeumann
+	case NeighborhoodHex:
+		if y&1 == 1 {
+			return offsetsHexOdd
+		}
+		return offsetsHexEven
+	}
+	return offsetsMoore
+}
+
+// Border determines how neighbors outside of the grid are handled.
+type Border int
+
+// The available border modes.
+const (
+	BorderClamp Border = iota // Cells outside of the grid are dead.
+	BorderWrap                // The grid wraps around (torus).
+)
+
+// TransitionFunc returns the next state of a cell given its current state and
+// the number of neighbors in each state (indexed by state).
+type TransitionFunc func(state uint8, counts []int) uint8
+
+// Automaton is a generic cellular automaton with an arbitrary number of
+// states per cell.
+type Automaton struct {
+	Width        int            // Number of cells over x.
+	Height       int            // Number of cells over y.
+	States       int            // Number of states.
+	Neighborhood Neighborhood   // Neighborhood of each cell.
+	Border       Border         // Handling of neighbors outside of the grid.
+	Transition   TransitionFunc // Function used to determine the next state of a cell.
+	Cells        []uint8        // State of each cell (indexed by x + y * Width).
+	Generation   int            // Number of steps.
+	next         []uint8        // Buffer for the next generation.
+}
+
+// NewAutomaton returns a new automaton with the given dimensions, number of
+// states and transition function, where all cells are dead.
+// NOTE: With BorderWrap and NeighborhoodHex, the height needs to be even.
+func NewAutomaton(width, height, states int, nb Neighborhood, border Border, tf TransitionFunc) *Automaton {
+	return &Automaton{
+		Width:        width,
+		Height:       height,
+		States:       states,
+		Neighborhood: nb,
+		Border:       border,
+		Transition:   tf,
+		Cells:        make([]uint8, width*height),
+		next:         make([]uint8, width*height),
+	}
+}
+
+// NewAutomatonFromRule returns a new automaton with the given dimensions
+// using the given rule string (see ParseRule).
+func NewAutomatonFromRule(width, height int, rule string, nb Neighborhood, border Border) (*Automaton, error) {
+	r, err := ParseRule(rule)
+	if err != nil {
+		return nil, err
+	}
+	if max := len(nb.offsets(0)); r.Birth>>uint(max+1) != 0 || r.Survive>>uint(max+1) != 0 {
+		return nil, fmt.Errorf("rule %q uses more than %d neighbors", rule, max)
+	}
+	return NewAutomaton(width, height, r.States, nb, border, r.Transition()), nil
+}
+
+// Get returns the state of the cell at the given position.
+func (a *Automaton) Get(x, y int) uint8 {
+	return a.Cells[x+y*a.Width]
+}
+
+// Set sets the state of the cell at the given position.
+func (a *Automaton) Set(x, y int, state uint8) {
+	a.Cells[x+y*a.Width] = state
+}
+
+// Fill sets the state of each cell to the value returned by the given function.
+func (a *Automaton) Fill(f func(x, y int) uint8) {
+	for y := 0; y < a.Height; y++ {
+		for x := 0; x < a.Width; x++ {
+			a.Cells[x+y*a.Width] = f(x, y)
+		}
+	}
+}
+
+// FillRandom sets each cell to alive with the given probability, and to dead
+// otherwise.
+func (a *Automaton) FillRandom(r *rand.Rand, density float64) {
+	a.Fill(func(x, y int) uint8 {
+		if r.Float64() < density {
+			return StateAlive
+		}
+		return StateDead
+	})
+}
+
+// Step advances the automaton by one generation.
+func (a *Automaton) Step() {
+	counts := make([]int, a.States)
+	for y := 0; y < a.Height; y++ {
+		offsets := a.Neighborhood.offsets(y)
+		for x := 0; x < a.Width; x++ {
+			for i := range counts {
+				counts[i] = 0
+			}
+			for _, o := range offsets {
+				if s, ok := a.neighbor(x+o[0], y+o[1]); ok {
+					counts[s]++
+				} else {
+					counts[StateDead]++
+				}
+			}
+			a.next[x+y*a.Width] = a.Transition(a.Cells[x+y*a.Width], counts)
+		}
+	}
+	a.Cells, a.next = a.next, a.Cells
+	a.Generation++
+}
+
+// Run advances the automaton by the given number of generations.
+func (a *Automaton) Run(generations int) {
+	for i := 0; i < generations; i++ {
+		a.Step()
+	}
+}
+
+// neighbor returns the state of the cell at the given position, wrapping
+// around if necessary. If the position is outside of the grid and the
+// border is clamped, false is returned.
+func (a *Automaton) neighbor(x, y int) (uint8, bool) {
+	if x < 0 || x >= a.Width || y < 0 || y >= a.Height {
+		if a.Border != BorderWrap {
+			return StateDead, false
+		}
+		x = (x + a.Width) % a.Width
+		y = (y + a.Height) % a.Height
+	}
+	return a.Cells[x+y*a.Width], true
+}
+
+// Count returns the number of cells in the given state.
+func (a *Automaton) Count(state uint8) int {
+	var n int
+	for _, s := range a.Cells {
+		if s == state {
+			n++
+		}
+	}
+	return n
+}
+
+// Mask returns a two dimensional slice (indexed by [x][y]) indicating which
+// cells are in the given state.
+func (a *Automaton) Mask(state uint8) [][]bool {
+	mask := initCells(a.Width, a.Height)
+	for y := 0; y < a.Height; y++ {
+		for x := 0; x < a.Width; x++ {
+			mask[x][y] = a.Cells[x+y*a.Width] == state
+		}
+	}
+	return mask
+}
@@ -0,0 +1,250 @@
+package gencellular
+
+import (
+	"errors"
+	"math/bits"
+	"runtime"
+	"sync"
+)
+
+// BitCulture is a two state cell culture (like Culture), where the cells are
+// packed into 64 bit words, so that the neighbors of 64 cells can be counted
+// at once using bitwise operations.
+//
+// The rows are stepped in parallel and the cells are double buffered, so no
+// memory is allocated while advancing the culture.
+type BitCulture struct {
+	Width      int      // Number of cells over x.
+	Height     int      // Number of cells over y.
+	Generation int      // Number of ticks.
+	Rule       *Rule    // Rule used to evaluate the cells (only two states are supported).
+	Border     Border   // Handling of neighbors outside of the grid.
+	Workers    int      // Number of goroutines used for stepping (defaults to the number of CPUs).
+	words      int      // Number of words per row.
+	lastMask   uint64   // Mask of the valid bits in the last word of each row.
+	empty      []uint64 // Empty row used for neighbors outside of the grid.
+	cells      [2][]uint64
+}
+
+// NewBitCulture returns a new bit-packed culture with the given width and
+// height, where all cells are dead.
+func NewBitCulture(width, height int, rule *Rule, border Border) (*BitCulture, error) {
+	if rule.States != 2 {
+		return nil, errors.New("bit-packed cultures only support rules with two states")
+	}
+	words := (width + 63) / 64
+	lastMask := ^uint64(0)
+	if width%64 != 0 {
+		lastMask = 1<<uint(width%64) - 1
+	}
+	return &BitCulture{
+		Width:    width,
+		Height:   height,
+		Rule:     rule,
+		Border:   border,
+		Workers:  runtime.NumCPU(),
+		words:    words,
+		lastMask: lastMask,
+		empty:    make([]uint64, words),
+		cells: [2][]uint64{
+			make([]uint64, words*height),
+			make([]uint64, words*height),
+		},
+	}, nil
+}
+
+// current returns the words of the current generation.
+func (c *BitCulture) current() []uint64 {
+	return c.cells[c.Generation%2]
+}
+
+// Get returns true if the cell at the given position is alive.
+func (c *BitCulture) Get(x, y int) bool {
+	return c.current()[y*c.words+x/64]&(1<<uint(x%64)) != 0
+}
+
+// Set sets the state of the cell at the given position.
+func (c *BitCulture) Set(x, y int, alive bool) {
+	cur := c.current()
+	if alive {
+		cur[y*c.words+x/64] |= 1 << uint(x%64)
+	} else {
+		cur[y*c.words+x/64] &^= 1 << uint(x%64)
+	}
+}
+
+// Seed initializes the cells using the given seed function.
+func (c *BitCulture) Seed(sf SeedFunc) {
+	cells := initCells(c.Width, c.Height)
+	sf(cells, c.Width, c.Height)
+	c.SetCells(cells)
+}
+
+// SetCells sets the state of all cells from the given two dimensional slice
+// (indexed by [x][y], like Culture.Cells).
+func (c *BitCulture) SetCells(cells [][]bool) {
+	for x := 0; x < c.Width; x++ {
+		for y := 0; y < c.Height; y++ {
+			c.Set(x, y, cells[x][y])
+		}
+	}
+}
+
+// Cells returns the state of all cells as a two dimensional slice (indexed
+// by [x][y], like Culture.Cells).
+func (c *BitCulture) Cells() [][]bool {
+	cells := initCells(c.Width, c.Height)
+	for x := 0; x < c.Width; x++ {
+		for y := 0; y < c.Height; y++ {
+			cells[x][y] = c.Get(x, y)
+		}
+	}
+	return cells
+}
+
+// Count returns the number of alive cells.
+func (c *BitCulture) Count() int {
+	var n int
+	for _, w := range c.current() {
+		n += bits.OnesCount64(w)
+	}
+	return n
+}
+
+// Tick advances the culture by one tick.
+func (c *BitCulture) Tick() {
+	workers := c.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	// Don't bother with goroutines for tiny cultures.
+	if workers == 1 || c.Height < 2*workers {
+		c.stepRows(0, c.Height)
+	} else {
+		var wg sync.WaitGroup
+		band := (c.Height + workers - 1) / workers
+		for y := 0; y < c.Height; y += band {
+			end := y + band
+			if end > c.Height {
+				end = c.Height
+			}
+			wg.Add(1)
+			go func(start, end int) {
+				defer wg.Done()
+				c.stepRows(start, end)
+			}(y, end)
+		}
+		wg.Wait()
+	}
+	c.Generation++
+}
+
+// Run advances the culture by the given number of ticks.
+func (c *BitCulture) Run(ticks int) {
+	for i := 0; i < ticks; i++ {
+		c.Tick()
+	}
+}
+
+// stepRows calculates the next generation of the rows from start to end (exclusive).
+func (c *BitCulture) stepRows(start, end int) {
+	cur := c.current()
+	next := c.cells[(c.Generation+1)%2]
+
+	// row returns the words of the given row (or an empty row if the row is
+	// outside of the grid).
+	row := func(y int) []uint64 {
+		if y < 0 || y >= c.Height {
+			if c.Border != BorderWrap {
+				return c.empty
+			}
+			y = (y + c.Height) % c.Height
+		}
+		return cur[y*c.words : (y+1)*c.words]
+	}
+
+	for y := start; y < end; y++ {
+		rows := [3][]uint64{row(y - 1), row(y), row(y + 1)}
+		dst := next[y*c.words : (y+1)*c.words]
+		for w := 0; w < c.words; w++ {
+			// Add up the eight neighbors of all cells in this word using
+			// bit-sliced counters.
+			var s [4]uint64
+			for i, r := range rows {
+				s = bitAdd(s, c.west(r, w))
+				s = bitAdd(s, c.east(r, w))
+				if i != 1 {
+					s = bitAdd(s, r[w])
+				}
+			}
+
+			// Apply the rule.
+			alive := rows[1][w]
+			var res uint64
+			for n := 0; n <= 8; n++ {
+				born := c.Rule.Birth&(1<<uint(n)) != 0
+				survive := c.Rule.Survive&(1<<uint(n)) != 0
+				if !born && !survive {
+					continue
+				}
+				eq := bitEq(s[0], n&1) & bitEq(s[1], n&2) & bitEq(s[2], n&4) & bitEq(s[3], n&8)
+				if born {
+					res |= eq &^ alive
+				}
+				if survive {
+					res |= eq & alive
+				}
+			}
+			if w == c.words-1 {
+				res &= c.lastMask
+			}
+			dst[w] = res
+		}
+	}
+}
+
+// bitAdd adds the given word to the bit-sliced counters (s[0] holds the
+// lowest bit of each count).
+func bitAdd(s [4]uint64, v uint64) [4]uint64 {
+	c0 := s[0] & v
+	s[0] ^= v
+	c1 := s[1] & c0
+	s[1] ^= c0
+	c2 := s[2] & c1
+	s[2] ^= c1
+	s[3] |= c2
+	return s
+}
+
+// bitEq returns the given bit slice if 'bit' is set, or its complement otherwise.
+func bitEq(s uint64, bit int) uint64 {
+	if bit != 0 {
+		return s
+	}
+	return ^s
+}
+
+// west returns the word w of the given row shifted so that each bit holds
+// the state of the western neighbor.
+func (c *BitCulture) west(r []uint64, w int) uint64 {
+	v := r[w] << 1
+	if w > 0 {
+		v |= r[w-1] >> 63
+	} else if c.Border == BorderWrap {
+		v |= (r[c.words-1] >> uint((c.Width-1)%64)) & 1
+	}
+	return v
+}
+
+// east returns the word w of the given row shifted so that each bit holds
+// the state of the eastern neighbor.
+func (c *BitCulture) east(r []uint64, w int) uint64 {
+	v := r[w] >> 1
+	if w < c.words-1 {
+		v |= r[w+1] << 63
+	} else if c.Border == BorderWrap {
+		v |= (r[0] & 1) << uint((c.Width-1)%64)
+	}
+	return v
+}
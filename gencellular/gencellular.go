@@ -1,4 +1,4 @@
-// Package gencellular implements cellular automata (for now Conway's Game of Life).
+// Package gencellular implements cellular automata like Conway's Game of Life.
 package gencellular
 
 import (
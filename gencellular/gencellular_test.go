@@ -0,0 +1,166 @@
+package gencellular
+
+import (
+	"math/rand"
+	"runtime"
+	"testing"
+)
+
+func TestParseRule(t *testing.T) {
+	for _, tc := range []struct {
+		in  string
+		out string
+		err bool
+	}{
+		{RuleLife, "B3/S23", false},
+		{"S23/B3", "B3/S23", false},
+		{"23/3", "B3/S23", false},
+		{RuleCaves, "B678/S345678", false},
+		{RuleBriansBrain, "B2/S/C3", false},
+		{"/2/3", "B2/S/C3", false},
+		{"b36/s23", "B36/S23", false},
+		{"B3", "", true},
+		{"B9/S23", "", true},
+		{"B3/S23/C1", "", true},
+		{"B3/B3", "", true},
+	} {
+		r, err := ParseRule(tc.in)
+		if tc.err {
+			if err == nil {
+				t.Errorf("ParseRule(%q) = %v, want error", tc.in, r)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseRule(%q) returned error: %v", tc.in, err)
+		} else if r.String() != tc.out {
+			t.Errorf("ParseRule(%q) = %q, want %q", tc.in, r.String(), tc.out)
+		}
+	}
+}
+
+func TestAutomatonBlinker(t *testing.T) {
+	for _, border := range []Border{BorderClamp, BorderWrap} {
+		a, err := NewAutomatonFromRule(5, 5, RuleLife, NeighborhoodMoore, border)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for x := 1; x < 4; x++ {
+			a.Set(x, 2, StateAlive)
+		}
+		a.Step()
+		for y := 0; y < 5; y++ {
+			for x := 0; x < 5; x++ {
+				want := StateDead
+				if x == 2 && y >= 1 && y <= 3 {
+					want = StateAlive
+				}
+				if got := a.Get(x, y); got != want {
+					t.Errorf("border %d: cell %d,%d = %d, want %d", border, x, y, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestAutomatonDecay(t *testing.T) {
+	a, err := NewAutomatonFromRule(4, 4, RuleBriansBrain, NeighborhoodMoore, BorderClamp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.Set(0, 0, StateAlive)
+	a.Step()
+	if got := a.Get(0, 0); got != 2 {
+		t.Errorf("alive cell decayed to %d, want 2", got)
+	}
+	a.Step()
+	if got := a.Get(0, 0); got != StateDead {
+		t.Errorf("decaying cell became %d, want dead", got)
+	}
+}
+
+func TestBitCulture(t *testing.T) {
+	// Compare the bit-packed culture with the reference implementations
+	// using a width that is not a multiple of 64.
+	const width, height = 100, 70
+	seed := func(cells [][]bool, w, h int) {
+		r := rand.New(rand.NewSource(1234))
+		for x := 0; x < w; x++ {
+			for y := 0; y < h; y++ {
+				cells[x][y] = r.Intn(3) == 0
+			}
+		}
+	}
+	for _, border := range []Border{BorderClamp, BorderWrap} {
+		b, err := NewBitCulture(width, height, MustParseRule(RuleLife), border)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b.Seed(seed)
+		a := NewAutomaton(width, height, 2, NeighborhoodMoore, border, b.Rule.Transition())
+		cells := b.Cells()
+		a.Fill(func(x, y int) uint8 {
+			if cells[x][y] {
+				return StateAlive
+			}
+			return StateDead
+		})
+		c := NewCustom(height, width, seed, EvalDefault)
+		for i := 0; i < 50; i++ {
+			b.Tick()
+			a.Step()
+			if border == BorderClamp {
+				c.Tick()
+			}
+		}
+		for x := 0; x < width; x++ {
+			for y := 0; y < height; y++ {
+				if got, want := b.Get(x, y), a.Get(x, y) == StateAlive; got != want {
+					t.Fatalf("border %d: cell %d,%d = %t, want %t", border, x, y, got, want)
+				}
+				if got, want := b.Get(x, y), c.Cells[c.Generation%2][x][y]; border == BorderClamp && got != want {
+					t.Fatalf("cell %d,%d = %t, culture has %t", x, y, got, want)
+				}
+			}
+		}
+	}
+}
+
+const benchSize = 512
+
+func BenchmarkTick(b *testing.B) {
+	c := New(benchSize, benchSize)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.Tick()
+	}
+}
+
+func BenchmarkAutomatonStep(b *testing.B) {
+	a, err := NewAutomatonFromRule(benchSize, benchSize, RuleLife, NeighborhoodMoore, BorderClamp)
+	if err != nil {
+		b.Fatal(err)
+	}
+	a.FillRandom(rand.New(rand.NewSource(1234)), 0.6)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		a.Step()
+	}
+}
+
+func BenchmarkBitCultureTick(b *testing.B) {
+	for name, workers := range map[string]int{"serial": 1, "parallel": runtime.NumCPU()} {
+		b.Run(name, func(b *testing.B) {
+			c, err := NewBitCulture(benchSize, benchSize, MustParseRule(RuleLife), BorderClamp)
+			if err != nil {
+				b.Fatal(err)
+			}
+			c.Workers = workers
+			c.Seed(SeedDefault)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				c.Tick()
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package gencellular
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Some well known rules.
+const (
+	RuleLife        = "B3/S23"       // Conway's Game of Life.
+	RuleHighLife    = "B36/S23"      // Like Life, but with replicators.
+	RuleSeeds       = "B2/S"         // Explosive, cells never survive.
+	RuleMaze        = "B3/S12345"    // Grows maze-like structures.
+	RuleCaves       = "B678/S345678" // Smooths random noise into caves.
+	RuleBriansBrain = "B2/S/C3"      // Generations rule with one decay state.
+	RuleStarWars    = "B2/S345/C4"   // Generations rule with two decay states.
+)
+
+// Rule is a life-like (outer totalistic) rule, where the next state of a cell
+// only depends on its current state and the number of alive neighbors.
+//
+// Rules with more than two states are Generations rules: Alive cells that do
+// not survive don't die immediately, but decay through the states 2 to
+// States-1 before they die. Decaying cells don't count as alive neighbors and
+// can't be reborn until they are dead.
+type Rule struct {
+	Birth   uint16 // Bit n set: Dead cells with n alive neighbors become alive.
+	Survive uint16 // Bit n set: Alive cells with n alive neighbors survive.
+	States  int    // Number of states (2 for life-like rules).
+}
+
+// ParseRule parses a rule string in B/S notation (e.g. "B3/S23"), with an
+// optional number of states for Generations rules (e.g. "B2/S/C3").
+// The classic S/B notation (e.g. "23/3" or "345/2/4") is supported as well.
+func ParseRule(s string) (*Rule, error) {
+	parts := strings.Split(strings.TrimSpace(s), "/")
+	if len(parts) < 2 || len(parts) > 3 {
+		return nil, fmt.Errorf("invalid rule %q: expected 2 or 3 parts", s)
+	}
+	r := &Rule{States: 2}
+	var err error
+	if !hasRulePrefix(parts[0]) {
+		// Classic notation: S/B or S/B/C.
+		if r.Survive, err = parseRuleCounts(parts[0]); err != nil {
+			return nil, fmt.Errorf("invalid rule %q: %v", s, err)
+		}
+		if r.Birth, err = parseRuleCounts(parts[1]); err != nil {
+			return nil, fmt.Errorf("invalid rule %q: %v", s, err)
+		}
+		if len(parts) == 3 {
+			if r.States, err = parseRuleStates(parts[2]); err != nil {
+				return nil, fmt.Errorf("invalid rule %q: %v", s, err)
+			}
+		}
+		return r, nil
+	}
+
+	// B/S notation, where the parts can be in any order.
+	var seen [3]bool
+	for _, p := range parts {
+		if !hasRulePrefix(p) {
+			return nil, fmt.Errorf("invalid rule %q: part %q has no B, S or C prefix", s, p)
+		}
+		var idx int
+		switch strings.ToUpper(p[:1]) {
+		case "B":
+			idx = 0
+			r.Birth, err = parseRuleCounts(p[1:])
+		case "S":
+			idx = 1
+			r.Survive, err = parseRuleCounts(p[1:])
+		case "C", "G":
+			idx = 2
+			r.States, err = parseRuleStates(p[1:])
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid rule %q: %v", s, err)
+		}
+		if seen[idx] {
+			return nil, fmt.Errorf("invalid rule %q: duplicate part %q", s, p)
+		}
+		seen[idx] = true
+	}
+	if !seen[0] || !seen[1] {
+		return nil, fmt.Errorf("invalid rule %q: missing B or S part", s)
+	}
+	return r, nil
+}
+
+// MustParseRule is like ParseRule, but panics if the rule is invalid.
+func MustParseRule(s string) *Rule {
+	r, err := ParseRule(s)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// hasRulePrefix returns true if the given rule part starts with a B, S, C or G.
+func hasRulePrefix(p string) bool {
+	return p != "" && strings.ContainsAny(p[:1], "BSCGbscg")
+}
+
+// parseRuleCounts parses a list of neighbor counts (0-8) into a bit mask.
+func parseRuleCounts(p string) (uint16, error) {
+	var mask uint16
+	for _, c := range p {
+		if c < '0' || c > '8' {
+			return 0, fmt.Errorf("invalid neighbor count %q", c)
+		}
+		mask |= 1 << uint(c-'0')
+	}
+	return mask, nil
+}
+
+// parseRuleStates parses the number of states of a Generations rule.
+func parseRuleStates(p string) (int, error) {
+	n, err := strconv.Atoi(p)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number of states %q", p)
+	}
+	if n < 2 || n > 256 {
+		return 0, fmt.Errorf("number of states %d out of range (2-256)", n)
+	}
+	return n, nil
+}
+
+// String returns the rule in B/S notation.
+func (r *Rule) String() string {
+	counts := func(mask uint16) string {
+		var sb strings.Builder
+		for n := 0; n <= 8; n++ {
+			if mask&(1<<uint(n)) != 0 {
+				sb.WriteByte(byte('0' + n))
+			}
+		}
+		return sb.String()
+	}
+	s := "B" + counts(r.Birth) + "/S" + counts(r.Survive)
+	if r.States > 2 {
+		s += "/C" + strconv.Itoa(r.States)
+	}
+	return s
+}
+
+// Next returns the next state of a cell in the given state with the given
+// number of alive neighbors.
+func (r *Rule) Next(state uint8, alive int) uint8 {
+	switch state {
+	case StateDead:
+		if r.Birth&(1<<uint(alive)) != 0 {
+			return StateAlive
+		}
+		return StateDead
+	case StateAlive:
+		if r.Survive&(1<<uint(alive)) != 0 {
+			return StateAlive
+		}
+	}
+
+	// Decay towards death.
+	if int(state)+1 >= r.States {
+		return StateDead
+	}
+	return state + 1
+}
+
+// Transition returns a transition function for an Automaton using this rule.
+func (r *Rule) Transition() TransitionFunc {
+	return func(state uint8, counts []int) uint8 {
+		return r.Next(state, counts[StateAlive])
+	}
+}
+
+// EvalFunc returns an evaluation function for a Culture using this rule.
+// NOTE: Since a Culture only has two states, decay states are ignored.
+func (r *Rule) EvalFunc() EvalFunc {
+	return func(currState bool, numNeighbors int) bool {
+		if currState {
+			return r.Survive&(1<<uint(numNeighbors)) != 0
+		}
+		return r.Birth&(1<<uint(numNeighbors)) != 0
+	}
+}
@@ -172,9 +172,19 @@ func SubdividePolygon(poly []vectors.Vec2, minArea float64) [][]vectors.Vec2 {
 	return divided
 }
 
-// ResizeGeometry resizes a polygon to a given spacing.
+// ResizeGeometry resizes a polygon to a given spacing (negative values shrink
+// the polygon).
+// NOTE: If the polygon is split into multiple parts while shrinking, only the
+// largest part is returned. If it vanishes, nil is returned.
 func ResizeGeometry(geometry []vectors.Vec2, spacing float64, isPolygon bool) []vectors.Vec2 {
-	return gengeometry.StraightSkeleton(geometry, 1, spacing)
+	var res []vectors.Vec2
+	var maxArea float64
+	for _, poly := range gengeometry.OffsetPolygon(gengeometry.Polygon{Points: geometry}, spacing, gengeometry.JoinMiter) {
+		if a := poly.Area(); a > maxArea {
+			res, maxArea = poly.Points, a
+		}
+	}
+	return res
 }
 
 // AveragePoint returns the average point of a polygon.
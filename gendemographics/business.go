@@ -3,8 +3,13 @@ package gendemographics
 // GenBusinesses returns a map of business to number of businesses for the given population size.
 // NOTE: Meh, not happy about it being a map.
 func GenBusinesses(population int) map[string]int {
+	return genBusinesses(BusinessTypes, population)
+}
+
+// genBusinesses returns a map of business to number of businesses of the given types for the given population size.
+func genBusinesses(businessTypes []*BusinessType, population int) map[string]int {
 	res := make(map[string]int)
-	for _, bt := range BusinessTypes {
+	for _, bt := range businessTypes {
 		if population > bt.Serves {
 			res[bt.Name] = population / bt.Serves
 		}
@@ -1,12 +1,34 @@
 package main
 
 import (
+	"log"
+
 	"github.com/Flokey82/go_gens/gendemographics"
+	"github.com/Flokey82/go_gens/genmap2d"
 )
 
 func main() {
 	c := gendemographics.New()
 	n := c.NewNation(41000, gendemographics.PopulationDensityMedium)
 	n.Log()
+
+	// Log the composition of the capital and export the nation for the wiki.
+	capital := n.Settlements[0].Composition()
+	log.Printf("%s: %d households, %d guilds", capital.Type, capital.Households, len(capital.Guilds))
+	for _, cs := range capital.Classes {
+		log.Printf("  %s: %d", cs.Class, cs.Count)
+	}
+	if err := n.ExportJSON("nation.json"); err != nil {
+		log.Fatal(err)
+	}
+
+	// Place the settlements on a map with 1 mile per tile.
+	m := genmap2d.New(200, 200, 1234)
+	g, err := n.Place(&gendemographics.Map2DTerrain{Map: m}, gendemographics.DefaultGeographyParams, 1234)
+	if err != nil {
+		log.Fatal(err)
+	}
+	g.Log()
+	log.Printf("%d settlements are far from a market", len(g.Remote(gendemographics.DefaultGeographyParams)))
 	//log.Println(gendemographics.GenSettlementSizes(100000))
 }
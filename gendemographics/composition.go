@@ -0,0 +1,321 @@
+package gendemographics
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"sort"
+
+	"github.com/Flokey82/go_gens/gameconstants"
+)
+
+// Composition is the detailed composition of a settlement.
+type Composition struct {
+	Population       int              `json:"population"`
+	Type             string           `json:"type"`               // Settlement type (see gameconstants.GetSettlementType).
+	Households       int              `json:"households"`         // Number of households.
+	AvgHouseholdSize float64          `json:"avg_household_size"` // Average number of people per household.
+	HouseholdSizes   []*HouseholdSize `json:"household_sizes"`    // Number of households by size (adding up to the population).
+	AgeGroups        []*AgeGroup      `json:"age_groups"`         // Age pyramid.
+	Classes          []*ClassShare    `json:"classes"`            // Population by social class.
+	Businesses       map[string]int   `json:"businesses"`         // Number of businesses by type.
+	Guilds           []*Guild         `json:"guilds"`             // Guilds of related businesses.
+	Farmland         float64          `json:"farmland_sqmi"`      // Farmland required to feed the population.
+	Pasture          float64          `json:"pasture_sqmi"`       // Pasture required for the livestock.
+	Farms            int              `json:"farms"`              // Number of farms.
+	Livestock        map[string]int   `json:"livestock"`          // Number of animals by type.
+}
+
+// HouseholdSize is the number of households of a given size.
+type HouseholdSize struct {
+	Size  int `json:"size"`
+	Count int `json:"count"`
+}
+
+// AgeGroup is the number of people within an age range.
+type AgeGroup struct {
+	MinAge int `json:"min_age"`
+	MaxAge int `json:"max_age"` // -1 for the oldest age group.
+	Count  int `json:"count"`
+}
+
+// ClassShare is the number of people belonging to a social class.
+type ClassShare struct {
+	Class SocialClass `json:"class"`
+	Count int         `json:"count"`
+}
+
+// Guild is a guild of a settlement and its member businesses.
+type Guild struct {
+	Name       string         `json:"name"`
+	Businesses map[string]int `json:"businesses"` // Number of member businesses by type.
+	Members    int            `json:"members"`    // Total number of member businesses.
+}
+
+// SocialClass is a social class.
+type SocialClass string
+
+// The social classes.
+const (
+	ClassNobility  SocialClass = "nobility"
+	ClassClergy    SocialClass = "clergy"
+	ClassMerchants SocialClass = "merchants"
+	ClassCraftsmen SocialClass = "craftsmen"
+	ClassPeasants  SocialClass = "peasants"
+)
+
+// SocialClasses is a list of all social classes.
+var SocialClasses = []SocialClass{
+	ClassNobility,
+	ClassClergy,
+	ClassMerchants,
+	ClassCraftsmen,
+	ClassPeasants,
+}
+
+// classShares are the fractions of the population belonging to each social
+// class (in the order of SocialClasses) by settlement type.
+// NOTE: These values are guesswork. Larger settlements attract nobles,
+// merchants and craftsmen, while the countryside is mostly peasants.
+var classShares = map[gameconstants.SettlementType][]float64{
+	gameconstants.SettlementTypeThorpe:     {0, 0, 0, 0.02, 0.98},
+	gameconstants.SettlementTypeHamlet:     {0.005, 0.005, 0.005, 0.05, 0.935},
+	gameconstants.SettlementTypeVillage:    {0.01, 0.01, 0.02, 0.10, 0.86},
+	gameconstants.SettlementTypeTownSmall:  {0.01, 0.02, 0.06, 0.25, 0.66},
+	gameconstants.SettlementTypeTownMedium: {0.015, 0.025, 0.08, 0.30, 0.58},
+	gameconstants.SettlementTypeTownLarge:  {0.02, 0.03, 0.10, 0.33, 0.52},
+	gameconstants.SettlementTypeCitySmall:  {0.02, 0.035, 0.12, 0.35, 0.475},
+	gameconstants.SettlementTypeCityMedium: {0.025, 0.04, 0.13, 0.36, 0.445},
+	gameconstants.SettlementTypeCityLarge:  {0.025, 0.04, 0.14, 0.37, 0.425},
+	gameconstants.SettlementTypeMetropolis: {0.03, 0.04, 0.15, 0.38, 0.40},
+}
+
+// Average household sizes. Rural households are larger since they include
+// farm hands and more children.
+const (
+	householdSizeRural = 5.0
+	householdSizeTown  = 4.5
+	householdSizeCity  = 4.0
+	householdSizeMax   = 10
+)
+
+// ageGroupShares are the fractions of the population in 5 year age groups
+// (the last one being 70+) for a pre-modern population with high child
+// mortality.
+var ageGroupShares = []float64{
+	0.15, 0.115, 0.105, 0.095, 0.087, 0.079, 0.071, 0.063,
+	0.055, 0.046, 0.038, 0.030, 0.023, 0.017, 0.026,
+}
+
+// urbanChildFactor is the factor applied to the share of children in towns
+// and cities, which had a higher child mortality and attracted adults from
+// the countryside.
+const urbanChildFactor = 0.85
+
+// Livestock per farm and the pasture in acres required per animal.
+var livestockPerFarm = []struct {
+	Name    string
+	PerFarm float64
+	Pasture float64
+}{
+	{"oxen", 1.5, 2},
+	{"horses", 0.3, 2},
+	{"cattle", 2, 2},
+	{"sheep", 8, 0.5},
+	{"pigs", 3, 0.25},
+	{"poultry", 12, 0},
+}
+
+// GuildType is a guild grouping related business types.
+type GuildType struct {
+	Name    string
+	Members []*BusinessType
+}
+
+// Guilds are only formed in towns and cities with at least minGuildMembers
+// member businesses.
+const minGuildMembers = 3
+
+// GuildTypes is a list of all guild types.
+var GuildTypes = []*GuildType{
+	{Name: "Clothworkers", Members: []*BusinessType{BizWeaver, BizTailor, BizMercer, BizFurrier, BizHatmaker, BizOldClothes, BizBleacher, BizRugmaker}},
+	{Name: "Leatherworkers", Members: []*BusinessType{BizShoeMaker, BizTanner, BizSaddler, BizPursemaker, BizHarnessMaker, BizGlovemaker, BizScabbardmaker}},
+	{Name: "Smiths", Members: []*BusinessType{BizBlacksmith, BizLocksmith, BizCutler, BizBucklemaker, BizJeweler}},
+	{Name: "Builders", Members: []*BusinessType{BizMason, BizCarpenter, BizPlasterer, BizRoofer, BizPainter, BizSculptor, BizWoodcarver}},
+	{Name: "Victuallers", Members: []*BusinessType{BizBaker, BizPastryCook, BizButcher, BizChickenButcher, BizFishmonger}},
+	{Name: "Vintners and Brewers", Members: []*BusinessType{BizWineSeller, BizBeerSeller, BizTavern, BizInn}},
+	{Name: "Merchants", Members: []*BusinessType{BizSpiceMerchant, BizHayMerchant, BizWoodseller, BizChandler, BizCooper, BizRopemaker}},
+	{Name: "Scriveners", Members: []*BusinessType{BizCopyist, BizBookbinder, BizBookseller, BizIlluminator}},
+	{Name: "Barber-surgeons", Members: []*BusinessType{BizBarber, BizDoctor, BizBather}},
+}
+
+// Composition returns the detailed composition of the settlement.
+func (s *Settlement) Composition() *Composition {
+	st := gameconstants.GetSettlementType(s.Population)
+	c := &Composition{
+		Population: s.Population,
+		Type:       st.String(),
+		Businesses: s.Businesses,
+		Farmland:   s.Farmland(),
+		Farms:      CalcNumberFarms(s.Population),
+		Livestock:  make(map[string]int),
+	}
+	if len(c.Businesses) == 0 {
+		c.Businesses = GenBusinesses(s.Population)
+	}
+
+	// Households.
+	urban := st >= gameconstants.SettlementTypeTownSmall
+	c.AvgHouseholdSize = householdSizeRural
+	if st >= gameconstants.SettlementTypeCitySmall {
+		c.AvgHouseholdSize = householdSizeCity
+	} else if urban {
+		c.AvgHouseholdSize = householdSizeTown
+	}
+	c.Households = int(math.Ceil(float64(s.Population) / c.AvgHouseholdSize))
+	c.HouseholdSizes = householdSizes(s.Population, c.Households, c.AvgHouseholdSize)
+
+	// Age pyramid.
+	shares := make([]float64, len(ageGroupShares))
+	copy(shares, ageGroupShares)
+	if urban {
+		for i := 0; i < 3; i++ {
+			shares[i] *= urbanChildFactor
+		}
+	}
+	for i, n := range apportion(s.Population, shares) {
+		g := &AgeGroup{MinAge: i * 5, MaxAge: i*5 + 4, Count: n}
+		if i == len(shares)-1 {
+			g.MaxAge = -1
+		}
+		c.AgeGroups = append(c.AgeGroups, g)
+	}
+
+	// Social classes.
+	if cs, ok := classShares[st]; ok {
+		for i, n := range apportion(s.Population, cs) {
+			c.Classes = append(c.Classes, &ClassShare{Class: SocialClasses[i], Count: n})
+		}
+	}
+
+	// Guilds.
+	if urban {
+		for _, gt := range GuildTypes {
+			g := &Guild{Name: gt.Name, Businesses: make(map[string]int)}
+			for _, bt := range gt.Members {
+				if n := c.Businesses[bt.Name]; n > 0 {
+					g.Businesses[bt.Name] = n
+					g.Members += n
+				}
+			}
+			if g.Members >= minGuildMembers {
+				c.Guilds = append(c.Guilds, g)
+			}
+		}
+	}
+
+	// Livestock and pasture.
+	var pasture float64
+	for _, l := range livestockPerFarm {
+		n := int(math.Round(l.PerFarm * float64(c.Farms)))
+		c.Livestock[l.Name] = n
+		pasture += float64(n) * l.Pasture
+	}
+	c.Pasture = pasture / acrePerSqMile
+	return c
+}
+
+// householdSizes distributes the households over sizes from 1 to
+// householdSizeMax, following a Poisson distribution around the average.
+// The sizes are adjusted so that the households hold exactly the given
+// population (which requires households <= population <= households *
+// householdSizeMax).
+func householdSizes(population, households int, avg float64) []*HouseholdSize {
+	// Size - 1 follows a Poisson distribution with mean avg - 1.
+	lambda := avg - 1
+	weights := make([]float64, householdSizeMax)
+	p := math.Exp(-lambda)
+	for k := range weights {
+		weights[k] = p
+		p *= lambda / float64(k+1)
+	}
+	counts := apportion(households, weights)
+
+	// Move single households to the next larger or smaller size until the
+	// total matches the population, starting with the most common sizes to
+	// keep the shape of the distribution.
+	diff := population
+	for i, n := range counts {
+		diff -= (i + 1) * n
+	}
+	for diff != 0 {
+		step := 1
+		if diff < 0 {
+			step = -1
+		}
+		best := -1
+		for i, n := range counts {
+			if n > 0 && i+step >= 0 && i+step < len(counts) && (best < 0 || n > counts[best]) {
+				best = i
+			}
+		}
+		if best < 0 {
+			break // The population can't be distributed over the households.
+		}
+		counts[best]--
+		counts[best+step]++
+		diff -= step
+	}
+
+	var res []*HouseholdSize
+	for i, n := range counts {
+		if n > 0 {
+			res = append(res, &HouseholdSize{Size: i + 1, Count: n})
+		}
+	}
+	return res
+}
+
+// apportion distributes the total over the given weights, so that the
+// resulting integers add up to the total (largest remainder method).
+func apportion(total int, weights []float64) []int {
+	var sum float64
+	for _, w := range weights {
+		sum += w
+	}
+	res := make([]int, len(weights))
+	if sum <= 0 {
+		return res
+	}
+	remainders := make([]float64, len(weights))
+	left := total
+	for i, w := range weights {
+		v := float64(total) * w / sum
+		res[i] = int(v)
+		remainders[i] = v - float64(res[i])
+		left -= res[i]
+	}
+	order := make([]int, len(weights))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return remainders[order[a]] > remainders[order[b]] })
+	for i := 0; i < left; i++ {
+		res[order[i%len(order)]]++
+	}
+	return res
+}
+
+// ExportJSON exports the composition as JSON to the given file.
+func (c *Composition) ExportJSON(filename string) error {
+	return writeJSON(filename, c)
+}
+
+// writeJSON writes the given value as indented JSON to the given file.
+func writeJSON(filename string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, data, 0o644)
+}
@@ -21,7 +21,9 @@ func NewCustom(businessTypes []*BusinessType) *Client {
 // NewNation returns a new nation of given size in square miles (sorry) and population.
 func (c *Client) NewNation(size, density int) *Nation {
 	n := NewNation(size, density)
-	for _, sz := range GenSettlementPopulations(n.Population()) {
+	pops, dispersed := GenSettlementSizes(n.Population())
+	n.Dispersed = dispersed
+	for _, sz := range pops {
 		n.Settlements = append(n.Settlements, c.NewSettlement(sz))
 	}
 	return n
@@ -29,5 +31,7 @@ func (c *Client) NewNation(size, density int) *Nation {
 
 // NewSettlement returns a new settlement with the given population size.
 func (c *Client) NewSettlement(population int) *Settlement {
-	return NewSettlement(population)
+	s := NewSettlement(population)
+	s.Businesses = genBusinesses(c.BT, population)
+	return s
 }
@@ -0,0 +1,250 @@
+package gendemographics
+
+import (
+	"errors"
+	"log"
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/Flokey82/go_gens/gameconstants"
+	"github.com/Flokey82/go_gens/genmap2d"
+)
+
+// Terrain is a map on which the settlements of a nation can be placed.
+// It consists of cells, each with a position and a habitability score.
+type Terrain interface {
+	NumCells() int
+	CellPosition(i int) (x, y float64) // Position of the cell in map units.
+	CellHabitability(i int) float64    // Suitability for settlements (<= 0 if uninhabitable).
+}
+
+// GeographyParams are the parameters for placing settlements on a terrain.
+type GeographyParams struct {
+	Scale          float64 // Miles per map unit.
+	CapitalSpacing float64 // Minimum distance in miles between the capital and other settlements.
+	MinSpacing     float64 // Minimum distance in miles between any two settlements.
+	MarketDistance float64 // Maximum distance in miles to a market town (a day's return trip).
+}
+
+// DefaultGeographyParams are the default parameters for placing settlements.
+var DefaultGeographyParams = GeographyParams{
+	Scale:          1,
+	CapitalSpacing: 40,
+	MinSpacing:     1.5,
+	MarketDistance: 7,
+}
+
+// Geography is the geographic distribution of the settlements of a nation.
+type Geography struct {
+	Settlements []*PlacedSettlement // Placed settlements by rank (largest first).
+	Unplaced    []*Settlement       // Settlements that could not be placed.
+}
+
+// PlacedSettlement is a settlement placed on a terrain.
+type PlacedSettlement struct {
+	*Settlement
+	Rank           int               // Rank by population (1 is the largest).
+	Cell           int               // Cell of the terrain.
+	X, Y           float64           // Position in map units.
+	FarmlandRadius float64           // Radius of the surrounding farmland in miles.
+	Market         *PlacedSettlement // Market town this settlement feeds (nil for market towns).
+}
+
+// IsMarketTown returns true if the settlement is a market town (a town or city).
+func (s *PlacedSettlement) IsMarketTown() bool {
+	return gameconstants.GetSettlementType(s.Population) >= gameconstants.SettlementTypeTownSmall
+}
+
+// Place distributes the settlements of the nation across the habitable cells
+// of the given terrain.
+//
+// Settlements are placed in order of their rank (by population), with larger
+// settlements getting the more habitable cells. Following the rank-size rule,
+// the spacing to other settlements shrinks with the rank (CapitalSpacing /
+// sqrt(rank)), so large settlements have large hinterlands, while villages
+// cluster more closely. Farmland must not overlap either. If no cell meets the
+// spacing, it is relaxed (but never below MinSpacing) before giving up on a
+// settlement.
+//
+// Finally, each settlement that isn't a market town is assigned to the
+// closest market town it feeds.
+func (n *Nation) Place(t Terrain, params GeographyParams, seed int64) (*Geography, error) {
+	if params.Scale <= 0 {
+		return nil, errors.New("invalid scale")
+	}
+	rng := rand.New(rand.NewSource(seed))
+
+	// Collect the habitable cells.
+	type cell struct {
+		idx   int
+		x, y  float64
+		score float64
+	}
+	var cells []*cell
+	for i := 0; i < t.NumCells(); i++ {
+		if h := t.CellHabitability(i); h > 0 {
+			x, y := t.CellPosition(i)
+			cells = append(cells, &cell{idx: i, x: x, y: y, score: h})
+		}
+	}
+	if len(cells) == 0 {
+		return nil, errors.New("no habitable cells")
+	}
+
+	// Rank the settlements by population.
+	ranked := make([]*Settlement, len(n.Settlements))
+	copy(ranked, n.Settlements)
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].Population > ranked[j].Population })
+
+	// For each cell, we keep track of the distance to the closest settlement
+	// and the closest farmland (both in miles).
+	distSettlement := make([]float64, len(cells))
+	distFarmland := make([]float64, len(cells))
+	for i := range cells {
+		distSettlement[i] = math.Inf(1)
+		distFarmland[i] = math.Inf(1)
+	}
+
+	g := &Geography{}
+	for i, s := range ranked {
+		ps := &PlacedSettlement{
+			Settlement:     s,
+			Rank:           i + 1,
+			FarmlandRadius: math.Sqrt(s.Farmland() / math.Pi),
+		}
+		spacing := math.Max(params.CapitalSpacing/math.Sqrt(float64(ps.Rank)), params.MinSpacing)
+
+		// Find the best cell that satisfies the spacing, relaxing it if
+		// necessary.
+		best := -1
+		for attempt := 0; attempt < 4 && best < 0; attempt++ {
+			bestScore := math.Inf(-1)
+			for j, c := range cells {
+				if distSettlement[j] == 0 || distSettlement[j] < spacing || distFarmland[j] < ps.FarmlandRadius {
+					continue
+				}
+				if score := c.score * (1 + 0.2*rng.Float64()); score > bestScore {
+					best, bestScore = j, score
+				}
+			}
+			spacing = math.Max(spacing/2, params.MinSpacing)
+		}
+		if best < 0 {
+			g.Unplaced = append(g.Unplaced, s)
+			continue
+		}
+		c := cells[best]
+		ps.Cell, ps.X, ps.Y = c.idx, c.x, c.y
+		g.Settlements = append(g.Settlements, ps)
+		for j, o := range cells {
+			d := math.Hypot(c.x-o.x, c.y-o.y) * params.Scale
+			distSettlement[j] = math.Min(distSettlement[j], d)
+			distFarmland[j] = math.Min(distFarmland[j], d-ps.FarmlandRadius)
+		}
+	}
+
+	// Assign each settlement to the closest market town.
+	for _, s := range g.Settlements {
+		if s.IsMarketTown() {
+			continue
+		}
+		bestDist := math.Inf(1)
+		for _, m := range g.Settlements {
+			if !m.IsMarketTown() {
+				continue
+			}
+			if d := math.Hypot(s.X-m.X, s.Y-m.Y) * params.Scale; d < bestDist {
+				s.Market, bestDist = m, d
+			}
+		}
+	}
+	return g, nil
+}
+
+// Hinterland returns the settlements feeding the given market town.
+func (g *Geography) Hinterland(market *PlacedSettlement) []*PlacedSettlement {
+	var res []*PlacedSettlement
+	for _, s := range g.Settlements {
+		if s.Market == market {
+			res = append(res, s)
+		}
+	}
+	return res
+}
+
+// Remote returns the settlements whose market town is further away than
+// params.MarketDistance (or that have no market town at all).
+func (g *Geography) Remote(params GeographyParams) []*PlacedSettlement {
+	var res []*PlacedSettlement
+	for _, s := range g.Settlements {
+		if s.IsMarketTown() {
+			continue
+		}
+		if s.Market == nil || math.Hypot(s.X-s.Market.X, s.Y-s.Market.Y)*params.Scale > params.MarketDistance {
+			res = append(res, s)
+		}
+	}
+	return res
+}
+
+// Log prints information on the geography to the console.
+func (g *Geography) Log() {
+	for _, s := range g.Settlements {
+		if !s.IsMarketTown() {
+			continue
+		}
+		log.Printf("#%d %s (%d) at %.1f,%.1f is fed by %d settlements\n", s.Rank, gameconstants.GetSettlementType(s.Population), s.Population, s.X, s.Y, len(g.Hinterland(s)))
+	}
+	if len(g.Unplaced) > 0 {
+		log.Printf("%d settlements could not be placed\n", len(g.Unplaced))
+	}
+}
+
+// Map2DTerrain adapts a genmap2d.Map for placing settlements, with one cell
+// per tile.
+type Map2DTerrain struct {
+	*genmap2d.Map
+}
+
+// NumCells returns the number of tiles of the map.
+func (m *Map2DTerrain) NumCells() int {
+	return len(m.Cells)
+}
+
+// CellPosition returns the position of the tile.
+func (m *Map2DTerrain) CellPosition(i int) (x, y float64) {
+	tx, ty := m.GetCoordinates(i)
+	return float64(tx), float64(ty)
+}
+
+// map2DHabitability is the habitability by tile.
+var map2DHabitability = map[byte]float64{
+	genmap2d.TileIDGrass:    1.0,
+	genmap2d.TileIDTree:     0.5,
+	genmap2d.TileIDSand:     0.4,
+	genmap2d.TileIDMountain: 0.1,
+	genmap2d.TileIDVillage:  1.0,
+}
+
+// map2DWaterRadius is the radius in tiles within which water increases the
+// habitability of a tile.
+const map2DWaterRadius = 3
+
+// CellHabitability returns the habitability of the tile, which is increased
+// near water.
+func (m *Map2DTerrain) CellHabitability(i int) float64 {
+	h := map2DHabitability[m.Cells[i]]
+	if h <= 0 {
+		return 0
+	}
+	tx, ty := m.GetCoordinates(i)
+	for y := ty - map2DWaterRadius; y <= ty+map2DWaterRadius; y++ {
+		for x := tx - map2DWaterRadius; x <= tx+map2DWaterRadius; x++ {
+			if x >= 0 && y >= 0 && x < m.Width && y < m.Height && m.Cells[m.GetIndex(x, y)] == genmap2d.TileIDWater {
+				return h + 0.5
+			}
+		}
+	}
+	return h
+}
@@ -0,0 +1,49 @@
+package gendemographics
+
+import (
+	"math"
+	"testing"
+)
+
+// gridTerrain is a square terrain of evenly habitable cells.
+type gridTerrain int
+
+func (g gridTerrain) NumCells() int {
+	return int(g) * int(g)
+}
+
+func (g gridTerrain) CellPosition(i int) (x, y float64) {
+	return float64(i % int(g)), float64(i / int(g))
+}
+
+func (g gridTerrain) CellHabitability(i int) float64 {
+	return 1
+}
+
+func TestNationPlaceMinSpacing(t *testing.T) {
+	// Many small settlements on a small terrain, so the spacing needs to be
+	// relaxed.
+	n := NewNation(100, 30)
+	for i := 0; i < 200; i++ {
+		n.Settlements = append(n.Settlements, NewSettlement(10))
+	}
+	params := DefaultGeographyParams
+	params.MinSpacing = 3
+	g, err := n.Place(gridTerrain(30), params, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Settlements)+len(g.Unplaced) != len(n.Settlements) {
+		t.Fatalf("got %d placed and %d unplaced settlements, want %d", len(g.Settlements), len(g.Unplaced), len(n.Settlements))
+	}
+	if len(g.Unplaced) == 0 {
+		t.Errorf("all settlements placed, want some unplaced")
+	}
+	for i, a := range g.Settlements {
+		for _, b := range g.Settlements[i+1:] {
+			if d := math.Hypot(a.X-b.X, a.Y-b.Y) * params.Scale; d < params.MinSpacing {
+				t.Errorf("settlements at %.0f,%.0f and %.0f,%.0f are %.2f miles apart, want at least %.2f", a.X, a.Y, b.X, b.Y, d, params.MinSpacing)
+			}
+		}
+	}
+}
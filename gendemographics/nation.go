@@ -10,6 +10,7 @@ type Nation struct {
 	Size        int           // geographic size in square miles
 	Density     int           // population density in people per square mile
 	Settlements []*Settlement // settlements within the nation
+	Dispersed   int           // population living in dispersed dwellings outside of settlements
 }
 
 // Various constants.
@@ -30,12 +31,38 @@ func NewNation(size, density int) *Nation {
 
 // Log prints information on the nation to the console.
 func (n *Nation) Log() {
-	log.Printf("Population: %d\n", n.Population())
+	log.Printf("Population: %d (%d dispersed)\n", n.Population(), n.Dispersed)
 	for _, s := range n.Settlements {
 		s.Log()
 	}
 }
 
+// ExportJSON exports the nation and the composition of its settlements as
+// JSON to the given file.
+func (n *Nation) ExportJSON(filename string) error {
+	type nationJSON struct {
+		Size        int            `json:"size_sqmi"`
+		Density     int            `json:"density"`
+		Population  int            `json:"population"`
+		Agriculture int            `json:"agriculture_sqmi"`
+		Castles     int            `json:"castles"`
+		Dispersed   int            `json:"dispersed"`
+		Settlements []*Composition `json:"settlements"`
+	}
+	res := &nationJSON{
+		Size:        n.Size,
+		Density:     n.Density,
+		Population:  n.Population(),
+		Agriculture: n.Agriculture(),
+		Castles:     n.Castles(),
+		Dispersed:   n.Dispersed,
+	}
+	for _, s := range n.Settlements {
+		res.Settlements = append(res.Settlements, s.Composition())
+	}
+	return writeJSON(filename, res)
+}
+
 // Population returns the population given the population density.
 func (n *Nation) Population() int {
 	return n.Size * n.Density
@@ -17,10 +17,40 @@ var (
 )
 
 // GenSettlementPopulations generates a number of cities, towns, settlements represented as population counts.
+// The last entry is the remaining population living in dispersed dwellings (if any).
 // NOTE: This is just a placeholder function and should be reworked.
 func GenSettlementPopulations(population int) []int {
+	res, dispersed := GenSettlementSizes(population)
+
+	// The rest would be in small / tiny settlements, individual dwellings, etc.
+	if dispersed > 0 {
+		res = append(res, dispersed)
+	}
+
+	log.Println(res)
+	return res
+}
+
+// GenSettlementSizes generates the population counts of the cities, towns and
+// settlements of a nation with the given population. The second return value
+// is the remaining population living in dispersed dwellings rather than in a
+// settlement. The settlements never exceed the given population.
+func GenSettlementSizes(population int) ([]int, int) {
 	var res []int
 
+	// add adds a settlement with the given population, which is limited to
+	// the remaining population, and returns the added population.
+	add := func(pop int) int {
+		if pop > population {
+			pop = population
+		}
+		if pop > 0 {
+			res = append(res, pop)
+			population -= pop
+		}
+		return pop
+	}
+
 	// TODO: Fix distribution.
 	// Determine the population of the largest city in the kingdom.
 	// This is equal to (P × M), where P is equal to the square root
@@ -30,18 +60,14 @@ func GenSettlementPopulations(population int) []int {
 	// NOTE: I decided to double that to steer away from excessive
 	// numbers living in individual dwellings, which would be
 	// unrealistic.
-	capitalPopulation := int(36 * math.Sqrt(float64(population)))
-	res = append(res, capitalPopulation)
-	population -= capitalPopulation
+	capitalPopulation := add(int(36 * math.Sqrt(float64(population))))
 
 	// The second-ranking city will be from 20-80% the size of the largest.
 	// To randomly determine this, roll 2d4 times 10% (the average result is 50%)
 	//
 	// NOTE: I decided to use 10% shrinking instead to prevent a too excessive
 	// rural population that aren't part of a settlement.
-	secondaryCityPopulation := int(float64(capitalPopulation) * (1.0 - 0.1)) // 90%
-	res = append(res, secondaryCityPopulation)
-	population -= secondaryCityPopulation
+	secondaryCityPopulation := add(int(float64(capitalPopulation) * (1.0 - 0.1))) // 90%
 
 	// Each remaining city will be from 10% to 40% smaller than the previous
 	// one (2d4 times 5% – the average result is 25%); continue listing cities
@@ -59,12 +85,14 @@ func GenSettlementPopulations(population int) []int {
 	for {
 		// We use a randomized fraction of 8% shrinking.
 		pop := int(float64(prevPopulation) * (1.0 - (0.08 * rand.Float64())))
+		if pop > population {
+			// Don't place more people in settlements than there are left.
+			pop = population
+		}
 		if pop < minPopCity {
 			break
 		}
-		res = append(res, pop)
-		population -= pop
-		prevPopulation = pop
+		prevPopulation = add(pop)
 	}
 
 	// To determine the number of towns, start with the number of cities,
@@ -81,13 +109,13 @@ func GenSettlementPopulations(population int) []int {
 	for remTowns := numTowns; remTowns > 0; remTowns-- {
 		// We use a randomized fraction of 3% shrinking.
 		pop := int(float64(prevPopulation) * (1.0 - (0.03 * rand.Float64())))
+		if pop > population {
+			pop = population
+		}
 		if pop < minPopTown {
 			break
 		}
-		res = append(res, pop)
-		population -= pop
-		prevPopulation = pop
-
+		prevPopulation = add(pop)
 	}
 
 	// Now we'd need to determine the number of villages and hamlets.
@@ -109,21 +137,15 @@ func GenSettlementPopulations(population int) []int {
 	for population > minPopHamlet {
 		// We use a randomized fraction of 1% shrinking right now.
 		pop := int(float64(prevPopulation) * (1.0 - (0.01 * rand.Float64())))
+		if pop > population {
+			pop = population
+		}
 		if pop < minPopHamlet {
 			break
 		}
-		res = append(res, pop)
-		population -= pop
-		prevPopulation = pop
+		prevPopulation = add(pop)
 	}
-
-	// The rest would be in small / tiny settlements, individual dwellings, etc.
-	if population > 0 {
-		res = append(res, population)
-	}
-
-	log.Println(res)
-	return res
+	return res, population
 }
 
 // Settlement represents a settlement.
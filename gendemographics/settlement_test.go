@@ -0,0 +1,47 @@
+package gendemographics
+
+import "testing"
+
+func TestGenSettlementSizes(t *testing.T) {
+	for _, population := range []int{0, 50, 1000, 40000, 400000, 4000000} {
+		sizes, dispersed := GenSettlementSizes(population)
+		total := dispersed
+		for _, s := range sizes {
+			if s <= 0 {
+				t.Errorf("population %d: got settlement with population %d", population, s)
+			}
+			total += s
+		}
+		if dispersed < 0 || total != population {
+			t.Errorf("population %d: got %d in settlements and %d dispersed", population, total-dispersed, dispersed)
+		}
+
+		// The old signature includes the dispersed population.
+		total = 0
+		for _, s := range GenSettlementPopulations(population) {
+			total += s
+		}
+		if total != population {
+			t.Errorf("population %d: got total population %d", population, total)
+		}
+	}
+}
+
+func TestNewNation(t *testing.T) {
+	for _, tc := range []struct {
+		size, density int
+	}{
+		{1000, 40},
+		{10000, 40},
+		{100000, 30},
+	} {
+		n := New().NewNation(tc.size, tc.density)
+		total := n.Dispersed
+		for _, s := range n.Settlements {
+			total += s.Population
+		}
+		if total != n.Population() {
+			t.Errorf("%d sq mi: got population %d, want %d", tc.size, total, n.Population())
+		}
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"os"
 
 	"github.com/Flokey82/go_gens/genfloortxt"
+	"github.com/Flokey82/go_gens/genfurnishing"
+	"github.com/Flokey82/go_gens/vectors"
 )
 
 func main() {
@@ -16,4 +18,38 @@ func main() {
 	for _, line := range p.Render() {
 		log.Println(line)
 	}
+
+	// Export the plan as a blockout.
+	m := p.Model()
+	if err := m.ExportSVG("sample.svg", 16); err != nil {
+		log.Fatal(err)
+	}
+	if err := m.ExportPNG("sample.png", 8); err != nil {
+		log.Fatal(err)
+	}
+	mesh, err := m.Mesh(genfloortxt.DefaultMeshParams)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := mesh.ExportToObj("sample.obj"); err != nil {
+		log.Fatal(err)
+	}
+
+	// Generate a floor plan for the rooms of a great house.
+	b := genfurnishing.BuildingGreatHouse.Generate()
+	var rooms []string
+	for _, r := range b.Rooms {
+		rooms = append(rooms, r.Name)
+	}
+	footprint := []vectors.Vec2{{X: 0, Y: 0}, {X: 40, Y: 0}, {X: 40, Y: 14}, {X: 18, Y: 14}, {X: 18, Y: 30}, {X: 0, Y: 30}}
+	p, err = genfloortxt.Generate(genfloortxt.NewProgram(rooms), footprint, 1234)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, line := range p.Render() {
+		log.Println(line)
+	}
+	for _, r := range p.Model().Rooms {
+		log.Printf("%s: %d cells, %d doors, %d windows", r.Name, r.Area(), len(r.Doors), len(r.Windows))
+	}
 }
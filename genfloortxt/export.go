@@ -0,0 +1,235 @@
+package genfloortxt
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+
+	"github.com/Flokey82/go_gens/gengeometry"
+	"github.com/Flokey82/go_gens/vectors"
+	svg "github.com/ajstarks/svgo"
+)
+
+// Colors used for exporting floor plans.
+var (
+	colorWall   = color.RGBA{60, 60, 60, 255}
+	colorDoor   = color.RGBA{140, 90, 40, 255}
+	colorWindow = color.RGBA{120, 190, 230, 255}
+	colorRooms  = []color.RGBA{
+		{240, 220, 190, 255},
+		{210, 230, 200, 255},
+		{200, 215, 235, 255},
+		{235, 205, 215, 255},
+		{225, 225, 190, 255},
+		{215, 200, 230, 255},
+	}
+)
+
+// roomColor returns the fill color of the given room.
+func roomColor(r *Room) color.RGBA {
+	return colorRooms[r.ID%len(colorRooms)]
+}
+
+// svgColor returns the SVG representation of the given color.
+func svgColor(c color.RGBA) string {
+	return fmt.Sprintf("rgb(%d,%d,%d)", c.R, c.G, c.B)
+}
+
+// ExportSVG exports the floor plan as SVG to the given path, with each cell
+// being cellSize pixels wide. Rooms are labeled with their name and area.
+func (m *Model) ExportSVG(path string, cellSize int) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	sv := svg.New(f)
+	sv.Start(m.Width*cellSize, m.Height*cellSize)
+	for _, r := range m.Rooms {
+		style := "fill:" + svgColor(roomColor(r))
+		for _, c := range r.Cells {
+			sv.Rect(c.X*cellSize, c.Y*cellSize, cellSize, cellSize, style)
+		}
+	}
+	for _, w := range m.Walls {
+		sv.Rect(w.Start.X*cellSize, w.Start.Y*cellSize, (w.End.X-w.Start.X+1)*cellSize, (w.End.Y-w.Start.Y+1)*cellSize, "fill:"+svgColor(colorWall))
+	}
+	for _, d := range m.Doors {
+		sv.Rect(d.Pos.X*cellSize, d.Pos.Y*cellSize, cellSize, cellSize, "fill:"+svgColor(colorDoor))
+	}
+	for _, w := range m.Windows {
+		sv.Rect(w.Pos.X*cellSize, w.Pos.Y*cellSize, cellSize, cellSize, "fill:"+svgColor(colorWindow))
+	}
+	for _, r := range m.Rooms {
+		b := r.Bounds()
+		label := fmt.Sprintf("%d", r.Area())
+		if r.Name != "" {
+			label = fmt.Sprintf("%s (%d)", r.Name, r.Area())
+		}
+		sv.Text((b.Min.X+b.Max.X)*cellSize/2, (b.Min.Y+b.Max.Y)*cellSize/2, label, fmt.Sprintf("text-anchor:middle;font-size:%dpx;fill:black", cellSize))
+	}
+	sv.End()
+	return nil
+}
+
+// Image renders the floor plan to an image, with each cell being cellSize
+// pixels wide.
+func (m *Model) Image(cellSize int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, m.Width*cellSize, m.Height*cellSize))
+	fill := func(x, y int, c color.RGBA) {
+		for py := y * cellSize; py < (y+1)*cellSize; py++ {
+			for px := x * cellSize; px < (x+1)*cellSize; px++ {
+				img.SetRGBA(px, py, c)
+			}
+		}
+	}
+	for y := 0; y < m.Height; y++ {
+		for x := 0; x < m.Width; x++ {
+			fill(x, y, color.RGBA{255, 255, 255, 255})
+		}
+	}
+	for _, r := range m.Rooms {
+		for _, c := range r.Cells {
+			fill(c.X, c.Y, roomColor(r))
+		}
+	}
+	for _, w := range m.Walls {
+		for _, c := range w.Cells() {
+			fill(c.X, c.Y, colorWall)
+		}
+	}
+	for _, d := range m.Doors {
+		fill(d.Pos.X, d.Pos.Y, colorDoor)
+	}
+	for _, w := range m.Windows {
+		fill(w.Pos.X, w.Pos.Y, colorWindow)
+	}
+	return img
+}
+
+// ExportPNG exports the floor plan as PNG to the given path, with each cell
+// being cellSize pixels wide.
+func (m *Model) ExportPNG(path string, cellSize int) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return png.Encode(f, m.Image(cellSize))
+}
+
+// MeshParams are the dimensions used for extruding a floor plan to a mesh.
+type MeshParams struct {
+	CellSize     float64 // Size of a cell.
+	WallHeight   float64 // Height of the walls.
+	DoorHeight   float64 // Height of doors.
+	SillHeight   float64 // Height of the bottom of windows.
+	WindowHeight float64 // Height of windows.
+}
+
+// DefaultMeshParams are the default dimensions (in meters) for extruding a
+// floor plan to a mesh.
+var DefaultMeshParams = MeshParams{
+	CellSize:     1,
+	WallHeight:   3,
+	DoorHeight:   2.1,
+	SillHeight:   0.9,
+	WindowHeight: 1.2,
+}
+
+// Names of the mesh groups of an extruded floor plan.
+const (
+	GroupWall  = "wall"
+	GroupFloor = "floor"
+)
+
+// Mesh extrudes the floor plan to a 3D mesh, with openings in the walls for
+// doors and windows and a floor for each room. The Y axis is flipped, so the
+// plan is seen from above.
+func (m *Model) Mesh(params MeshParams) (*gengeometry.Mesh, error) {
+	cs := params.CellSize
+	pos := func(x, y int) vectors.Vec2 {
+		return vectors.NewVec2(float64(x)*cs, float64(m.Height-y)*cs)
+	}
+	openings := make(map[image.Point]byte)
+	for _, d := range m.Doors {
+		openings[d.Pos] = CellDoor
+	}
+	for _, w := range m.Windows {
+		openings[w.Pos] = CellWindow
+	}
+
+	walls := &gengeometry.Mesh{}
+	addBox := func(x0, y0, x1, y1 int, z0, z1 float64) error {
+		if z1 <= z0 {
+			return nil
+		}
+		a, b := pos(x0, y1), pos(x1, y0)
+		box, err := gengeometry.ExtrudePath([]vectors.Vec2{a, {X: b.X, Y: a.Y}, b, {X: a.X, Y: b.Y}}, z1-z0)
+		if err != nil {
+			return err
+		}
+		walls.AddMesh(box, vectors.NewVec3(0, 0, z0))
+		return nil
+	}
+
+	// Split each wall into runs of solid cells, doors and windows.
+	for _, w := range m.Walls {
+		cells := w.Cells()
+		for i := 0; i < len(cells); {
+			kind := openings[cells[i]]
+			j := i + 1
+			for j < len(cells) && openings[cells[j]] == kind {
+				j++
+			}
+			start, end := cells[i], cells[j-1]
+			var err error
+			switch kind {
+			case CellDoor:
+				err = addBox(start.X, start.Y, end.X+1, end.Y+1, params.DoorHeight, params.WallHeight)
+			case CellWindow:
+				if err = addBox(start.X, start.Y, end.X+1, end.Y+1, 0, params.SillHeight); err == nil {
+					err = addBox(start.X, start.Y, end.X+1, end.Y+1, params.SillHeight+params.WindowHeight, params.WallHeight)
+				}
+			default:
+				err = addBox(start.X, start.Y, end.X+1, end.Y+1, 0, params.WallHeight)
+			}
+			if err != nil {
+				return nil, err
+			}
+			i = j
+		}
+	}
+	walls.SetGroup(GroupWall, &gengeometry.Material{Name: GroupWall, Diffuse: vectors.NewVec3(0.85, 0.85, 0.8)})
+
+	// Add the floors of the rooms and below doors.
+	floors := &gengeometry.Mesh{}
+	addQuad := func(x, y int) {
+		a, b := pos(x, y+1), pos(x+1, y)
+		i := len(floors.Vertices)
+		floors.Vertices = append(floors.Vertices,
+			vectors.NewVec3(a.X, a.Y, 0),
+			vectors.NewVec3(b.X, a.Y, 0),
+			vectors.NewVec3(b.X, b.Y, 0),
+			vectors.NewVec3(a.X, b.Y, 0))
+		floors.Triangles = append(floors.Triangles, i, i+1, i+2, i, i+2, i+3)
+	}
+	for _, r := range m.Rooms {
+		for _, c := range r.Cells {
+			addQuad(c.X, c.Y)
+		}
+	}
+	for _, d := range m.Doors {
+		addQuad(d.Pos.X, d.Pos.Y)
+	}
+	floors.SetGroup(GroupFloor, &gengeometry.Material{Name: GroupFloor, Diffuse: vectors.NewVec3(0.55, 0.45, 0.35)})
+
+	mesh := &gengeometry.Mesh{}
+	mesh.AddMesh(walls, vectors.Vec3{})
+	mesh.AddMesh(floors, vectors.Vec3{})
+	mesh.ComputeFlatNormals()
+	return mesh, nil
+}
@@ -0,0 +1,170 @@
+package genfloortxt
+
+import (
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Flokey82/go_gens/gengeometry"
+)
+
+func TestMesh(t *testing.T) {
+	params := DefaultMeshParams
+	for _, tc := range []struct {
+		name string
+		plan *Plan
+	}{
+		{"sample", readSamplePlan(t)},
+		{"two rooms", ReadPlan(strings.NewReader(twoRooms))},
+	} {
+		m := tc.plan.Model()
+		mesh, err := m.Mesh(params)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if len(mesh.Vertices) == 0 || mesh.NumTriangles() == 0 {
+			t.Fatalf("%s: got an empty mesh", tc.name)
+		}
+
+		// Each room cell and each door has a floor quad.
+		groups := make(map[string]int)
+		for _, g := range mesh.TriangleGroups {
+			groups[mesh.Groups[g].Name]++
+		}
+		floorCells := len(m.Doors)
+		for _, r := range m.Rooms {
+			floorCells += r.Area()
+		}
+		if groups[GroupFloor] != 2*floorCells || groups[GroupWall] == 0 {
+			t.Errorf("%s: got %d floor and %d wall triangles, want %d floor and some wall triangles", tc.name, groups[GroupFloor], groups[GroupWall], 2*floorCells)
+		}
+
+		// A line through the center of each door and window crosses the wall
+		// without hitting it.
+		for _, d := range m.Doors {
+			if wallBlocks(mesh, m.Height, d.Pos.X, d.Pos.Y, params.DoorHeight/2, params.CellSize) {
+				t.Errorf("%s: door at %v is blocked", tc.name, d.Pos)
+			}
+		}
+		for _, w := range m.Windows {
+			if wallBlocks(mesh, m.Height, w.Pos.X, w.Pos.Y, params.SillHeight+params.WindowHeight/2, params.CellSize) {
+				t.Errorf("%s: window at %v is blocked", tc.name, w.Pos)
+			}
+		}
+		var maxZ float64
+		for _, v := range mesh.Vertices {
+			maxZ = math.Max(maxZ, v.Z)
+		}
+		if maxZ != params.WallHeight {
+			t.Errorf("%s: got wall height %f, want %f", tc.name, maxZ, params.WallHeight)
+		}
+	}
+}
+
+func TestExportSVG(t *testing.T) {
+	m := ReadPlan(strings.NewReader(twoRooms)).Model()
+	path := filepath.Join(t.TempDir(), "plan.svg")
+	if err := m.ExportSVG(path, 8); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	svg := string(data)
+
+	// A rectangle for each room cell, wall, door and window.
+	if got, want := strings.Count(svg, "<rect"), 9+9+5+2+1; got != want {
+		t.Errorf("got %d rectangles, want %d", got, want)
+	}
+	if got := strings.Count(svg, ">9</text>"); got != 2 {
+		t.Errorf("got %d area labels, want 2", got)
+	}
+	if !strings.Contains(svg, `width="72" height="40"`) {
+		t.Errorf("missing size in %q", svg)
+	}
+}
+
+func TestExportPNG(t *testing.T) {
+	m := ReadPlan(strings.NewReader(twoRooms)).Model()
+	path := filepath.Join(t.TempDir(), "plan.png")
+	if err := m.ExportPNG(path, 4); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != 36 || b.Dy() != 20 {
+		t.Fatalf("got size %v, want 36x20", b.Size())
+	}
+	for _, tc := range []struct {
+		name string
+		x, y int
+		want [3]uint8
+	}{
+		{"wall", 0, 0, [3]uint8{colorWall.R, colorWall.G, colorWall.B}},
+		{"door", 4, 2, [3]uint8{colorDoor.R, colorDoor.G, colorDoor.B}},
+		{"window", 8, 2, [3]uint8{colorWindow.R, colorWindow.G, colorWindow.B}},
+		{"room", 2, 2, [3]uint8{colorRooms[0].R, colorRooms[0].G, colorRooms[0].B}},
+		{"second room", 6, 2, [3]uint8{colorRooms[1].R, colorRooms[1].G, colorRooms[1].B}},
+	} {
+		r, g, b, _ := img.At(tc.x*4+1, tc.y*4+1).RGBA()
+		if got := [3]uint8{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8)}; got != tc.want {
+			t.Errorf("%s: got color %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+// wallBlocks returns true if the walls of the mesh block both horizontal
+// lines through the center of the given cell at height z.
+func wallBlocks(mesh *gengeometry.Mesh, height, x, y int, z, cellSize float64) bool {
+	cx, cy := (float64(x)+0.5)*cellSize, (float64(height-y)-0.5)*cellSize
+	for _, alongX := range []bool{true, false} {
+		var hit bool
+		for i := 0; i < len(mesh.Triangles) && !hit; i += 3 {
+			if mesh.Groups[mesh.TriangleGroups[i/3]].Name != GroupWall {
+				continue
+			}
+			// Project the triangle onto the plane perpendicular to the line
+			// and check if it contains the line and is close to the cell.
+			var u, v, w [3]float64
+			var lo, hi float64
+			for j := 0; j < 3; j++ {
+				p := mesh.Vertices[mesh.Triangles[i+j]]
+				pos, other := p.Y, p.X
+				if alongX {
+					pos, other = p.X, p.Y
+				}
+				u[j], v[j], w[j] = other, p.Z, pos
+			}
+			lo, hi = math.Min(w[0], math.Min(w[1], w[2])), math.Max(w[0], math.Max(w[1], w[2]))
+			pu, center := cx, cy
+			if alongX {
+				pu, center = cy, cx
+			}
+			if hi < center-cellSize || lo > center+cellSize {
+				continue
+			}
+			var pos, neg bool
+			for j := 0; j < 3; j++ {
+				k := (j + 1) % 3
+				c := (u[k]-u[j])*(z-v[j]) - (v[k]-v[j])*(pu-u[j])
+				pos, neg = pos || c > 1e-9, neg || c < -1e-9
+			}
+			hit = (pos || neg) && !(pos && neg)
+		}
+		if !hit {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,658 @@
+package genfloortxt
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/Flokey82/go_gens/gengeometry"
+	"github.com/Flokey82/go_gens/vectors"
+)
+
+// RoomSpec describes a room of a room program.
+type RoomSpec struct {
+	Name     string   // Name (type) of the room.
+	Area     int      // Desired area in cells (relative to the other rooms).
+	Windows  bool     // The room should have windows.
+	Adjacent []string // Rooms this room should be connected to by a door.
+}
+
+// Program is a room program, which describes the rooms of a floor plan.
+type Program struct {
+	Rooms    []RoomSpec
+	Entrance string // Room with the entrance (defaults to the first room).
+}
+
+// NewProgram returns a room program for the given room types (like the names
+// of the rooms of a genfurnishing.Building) using the default size, windows
+// and adjacency rules of each room type. The entrance is the first hallway,
+// great hall or atrium, if there is one.
+func NewProgram(rooms []string) *Program {
+	p := &Program{}
+	for _, name := range rooms {
+		spec, ok := defaultRooms[name]
+		if !ok {
+			spec = RoomSpec{Area: 20, Windows: true}
+		}
+		spec.Name = name
+		p.Rooms = append(p.Rooms, spec)
+		if p.Entrance == "" && isHub(name) {
+			p.Entrance = name
+		}
+	}
+	return p
+}
+
+// Room types with default rules (see genfurnishing).
+const (
+	RoomArmory     = "armory"
+	RoomAtrium     = "atrium"
+	RoomBallroom   = "ballroom"
+	RoomBathhouse  = "bathhouse"
+	RoomBedroom    = "bedroom"
+	RoomChamber    = "chamber"
+	RoomDining     = "dining"
+	RoomDormitory  = "dormitory"
+	RoomGreatHall  = "greatHall"
+	RoomHallway    = "hallway"
+	RoomKitchen    = "kitchen"
+	RoomLaboratory = "laboratory"
+	RoomLibrary    = "library"
+	RoomPantry     = "pantry"
+	RoomParlour    = "parlour"
+	RoomPrison     = "prison"
+	RoomShrine     = "shrine"
+	RoomSmithy     = "smithy"
+	RoomStorage    = "storage"
+	RoomStudy      = "study"
+	RoomThrone     = "throne"
+	RoomTorture    = "torture"
+	RoomTreasury   = "treasury"
+)
+
+// defaultRooms are the default rules for the known room types.
+var defaultRooms = map[string]RoomSpec{
+	RoomArmory:     {Area: 20, Adjacent: []string{RoomHallway, RoomGreatHall}},
+	RoomAtrium:     {Area: 30, Windows: true, Adjacent: []string{RoomHallway, RoomGreatHall}},
+	RoomBallroom:   {Area: 50, Windows: true, Adjacent: []string{RoomGreatHall, RoomAtrium}},
+	RoomBathhouse:  {Area: 20, Windows: true, Adjacent: []string{RoomBedroom, RoomHallway}},
+	RoomBedroom:    {Area: 20, Windows: true, Adjacent: []string{RoomHallway, RoomChamber, RoomBathhouse}},
+	RoomChamber:    {Area: 16, Windows: true, Adjacent: []string{RoomBedroom, RoomHallway}},
+	RoomDining:     {Area: 30, Windows: true, Adjacent: []string{RoomKitchen, RoomGreatHall}},
+	RoomDormitory:  {Area: 35, Windows: true, Adjacent: []string{RoomHallway, RoomBathhouse}},
+	RoomGreatHall:  {Area: 60, Windows: true, Adjacent: []string{RoomHallway, RoomDining, RoomThrone}},
+	RoomHallway:    {Area: 20},
+	RoomKitchen:    {Area: 25, Windows: true, Adjacent: []string{RoomPantry, RoomDining, RoomGreatHall}},
+	RoomLaboratory: {Area: 20, Windows: true, Adjacent: []string{RoomLibrary, RoomStudy}},
+	RoomLibrary:    {Area: 30, Windows: true, Adjacent: []string{RoomHallway, RoomStudy, RoomParlour}},
+	RoomPantry:     {Area: 10, Adjacent: []string{RoomKitchen}},
+	RoomParlour:    {Area: 25, Windows: true, Adjacent: []string{RoomHallway, RoomGreatHall}},
+	RoomPrison:     {Area: 20, Adjacent: []string{RoomTorture, RoomArmory}},
+	RoomShrine:     {Area: 15, Windows: true, Adjacent: []string{RoomHallway}},
+	RoomSmithy:     {Area: 25, Windows: true, Adjacent: []string{RoomStorage, RoomArmory}},
+	RoomStorage:    {Area: 12, Adjacent: []string{RoomHallway, RoomKitchen}},
+	RoomStudy:      {Area: 15, Windows: true, Adjacent: []string{RoomLibrary, RoomBedroom}},
+	RoomThrone:     {Area: 40, Windows: true, Adjacent: []string{RoomGreatHall, RoomTreasury}},
+	RoomTorture:    {Area: 15, Adjacent: []string{RoomPrison}},
+	RoomTreasury:   {Area: 10, Adjacent: []string{RoomThrone, RoomChamber}},
+}
+
+// isHub returns true if the room type connects other rooms.
+func isHub(name string) bool {
+	return name == RoomHallway || name == RoomGreatHall || name == RoomAtrium
+}
+
+// Values of the side of a wall (see layout.side).
+const (
+	sideWall    = -1
+	sideOutside = -2
+)
+
+// minRoomWidth is the minimum width of a room in cells (including a wall).
+const minRoomWidth = 3
+
+// layout is the state of the floor plan generator.
+type layout struct {
+	prog          *Program
+	rng           *rand.Rand
+	width, height int
+	mask          [][]bool // Cells within the footprint.
+	labels        [][]int  // Room of each cell (-1 = outside).
+	cells         [][]byte // Resulting plan cells.
+}
+
+// rect is a rectangle of cells (max exclusive).
+type rect struct {
+	x0, y0, x1, y1 int
+}
+
+// Generate lays out the rooms of the program within the given footprint (in
+// cells) and returns the resulting floor plan. Rooms that should be adjacent
+// are placed next to each other where possible and are connected by doors,
+// all rooms are reachable from the entrance, and rooms that want windows get
+// them on their exterior walls.
+func Generate(prog *Program, footprint []vectors.Vec2, seed int64) (*Plan, error) {
+	if len(prog.Rooms) == 0 {
+		return nil, errors.New("no rooms in program")
+	}
+	if len(footprint) < 3 {
+		return nil, errors.New("invalid footprint")
+	}
+	l := &layout{
+		prog: prog,
+		rng:  rand.New(rand.NewSource(seed)),
+	}
+	l.rasterize(footprint)
+
+	// Split the footprint into rooms, keeping adjacent rooms close together.
+	order := l.order()
+	l.partition(rect{0, 0, l.width, l.height}, order)
+	l.mergeFragments()
+
+	// Add the walls and check if all rooms fit.
+	l.buildWalls()
+	for i, r := range prog.Rooms {
+		if !l.hasInterior(i) {
+			return nil, fmt.Errorf("room %q does not fit into the footprint", r.Name)
+		}
+	}
+
+	// Add the doors and windows.
+	if err := l.placeEntrance(order); err != nil {
+		return nil, err
+	}
+	if err := l.placeDoors(order); err != nil {
+		return nil, err
+	}
+	l.placeWindows()
+	return &Plan{
+		cells:  l.cells,
+		names:  l.roomNames(),
+		Height: l.height,
+		Width:  l.width,
+	}, nil
+}
+
+// roomNames returns the names of the rooms by one of their interior cells.
+func (l *layout) roomNames() map[image.Point]string {
+	names := make(map[image.Point]string)
+	named := make([]bool, len(l.prog.Rooms))
+	for y := range l.cells {
+		for x := range l.cells[y] {
+			if room := l.side(x, y); room >= 0 && !named[room] {
+				names[image.Pt(x, y)] = l.prog.Rooms[room].Name
+				named[room] = true
+			}
+		}
+	}
+	return names
+}
+
+// rasterize sets up the grid with the cells whose center is within the
+// footprint.
+func (l *layout) rasterize(footprint []vectors.Vec2) {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, p := range footprint {
+		minX, maxX = math.Min(minX, p.X), math.Max(maxX, p.X)
+		minY, maxY = math.Min(minY, p.Y), math.Max(maxY, p.Y)
+	}
+	l.width = int(math.Ceil(maxX - minX))
+	l.height = int(math.Ceil(maxY - minY))
+	l.mask = make([][]bool, l.height)
+	l.labels = make([][]int, l.height)
+	l.cells = make([][]byte, l.height)
+	for y := range l.mask {
+		l.mask[y] = make([]bool, l.width)
+		l.labels[y] = make([]int, l.width)
+		l.cells[y] = make([]byte, l.width)
+		for x := range l.mask[y] {
+			p := vectors.NewVec2(minX+float64(x)+0.5, minY+float64(y)+0.5)
+			l.mask[y][x] = gengeometry.PointInPolygon(p, footprint)
+			l.labels[y][x] = -1
+			l.cells[y][x] = ' '
+		}
+	}
+}
+
+// adjacent returns true if the rooms with the given indices should be
+// adjacent.
+func (l *layout) adjacent(i, j int) bool {
+	a, b := l.prog.Rooms[i], l.prog.Rooms[j]
+	for _, n := range a.Adjacent {
+		if n == b.Name {
+			return true
+		}
+	}
+	for _, n := range b.Adjacent {
+		if n == a.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// entrance returns the index of the entrance room.
+func (l *layout) entrance() int {
+	for i, r := range l.prog.Rooms {
+		if r.Name == l.prog.Entrance {
+			return i
+		}
+	}
+	return 0
+}
+
+// order returns the room indices in breadth-first order of the adjacency
+// graph starting at the entrance, so that adjacent rooms end up close to
+// each other when partitioning the footprint.
+func (l *layout) order() []int {
+	n := len(l.prog.Rooms)
+	visited := make([]bool, n)
+	var order []int
+	starts := append([]int{l.entrance()}, l.rng.Perm(n)...)
+	for _, s := range starts {
+		if visited[s] {
+			continue
+		}
+		visited[s] = true
+		queue := []int{s}
+		for len(queue) > 0 {
+			i := queue[0]
+			queue = queue[1:]
+			order = append(order, i)
+			for _, j := range l.rng.Perm(n) {
+				if !visited[j] && l.adjacent(i, j) {
+					visited[j] = true
+					queue = append(queue, j)
+				}
+			}
+		}
+	}
+	return order
+}
+
+// roomArea returns the desired area of the room with the given index.
+func (l *layout) roomArea(i int) float64 {
+	if a := l.prog.Rooms[i].Area; a > 0 {
+		return float64(a)
+	}
+	return 1
+}
+
+// partition recursively splits the footprint cells within the rectangle
+// between the given rooms according to their desired areas.
+func (l *layout) partition(r rect, rooms []int) {
+	if len(rooms) == 1 {
+		for y := r.y0; y < r.y1; y++ {
+			for x := r.x0; x < r.x1; x++ {
+				if l.mask[y][x] {
+					l.labels[y][x] = rooms[0]
+				}
+			}
+		}
+		return
+	}
+
+	// Split the rooms into two groups of similar area.
+	var total float64
+	for _, i := range rooms {
+		total += l.roomArea(i)
+	}
+	k, best := 1, math.Inf(1)
+	var sum float64
+	for i := 0; i < len(rooms)-1; i++ {
+		sum += l.roomArea(rooms[i])
+		if d := math.Abs(sum - total/2); d < best {
+			k, best = i+1, d
+		}
+	}
+	var ratio float64
+	for _, i := range rooms[:k] {
+		ratio += l.roomArea(i)
+	}
+	ratio = ratio / total * (0.95 + 0.1*l.rng.Float64())
+
+	// Split the longer side of the rectangle first.
+	vertical := r.x1-r.x0 >= r.y1-r.y0
+	for attempt := 0; attempt < 2; attempt++ {
+		if a, b, ok := l.splitRect(r, vertical, ratio); ok {
+			l.partition(a, rooms[:k])
+			l.partition(b, rooms[k:])
+			return
+		}
+		vertical = !vertical
+	}
+
+	// The rectangle is too small, so the remaining rooms are dropped.
+	l.partition(r, rooms[:1])
+}
+
+// splitRect splits the rectangle vertically or horizontally, so that the
+// first part contains roughly the given ratio of the footprint cells.
+func (l *layout) splitRect(r rect, vertical bool, ratio float64) (rect, rect, bool) {
+	lo, hi := r.y0, r.y1
+	if vertical {
+		lo, hi = r.x0, r.x1
+	}
+
+	// Count the footprint cells in each column or row.
+	counts := make([]int, hi-lo)
+	var total int
+	for y := r.y0; y < r.y1; y++ {
+		for x := r.x0; x < r.x1; x++ {
+			if l.mask[y][x] {
+				if vertical {
+					counts[x-lo]++
+				} else {
+					counts[y-lo]++
+				}
+				total++
+			}
+		}
+	}
+
+	// Find the split closest to the ratio leaving enough space on both sides.
+	split, best := -1, math.Inf(1)
+	var sum int
+	for s := lo + 1; s < hi; s++ {
+		sum += counts[s-1-lo]
+		if s-lo < minRoomWidth || hi-s < minRoomWidth || sum == 0 || sum == total {
+			continue
+		}
+		if d := math.Abs(float64(sum)/float64(total) - ratio); d < best {
+			split, best = s, d
+		}
+	}
+	if split < 0 {
+		return r, r, false
+	}
+	a, b := r, r
+	if vertical {
+		a.x1, b.x0 = split, split
+	} else {
+		a.y1, b.y0 = split, split
+	}
+	return a, b, true
+}
+
+// mergeFragments merges disconnected fragments of rooms (e.g. caused by a
+// concave footprint) into neighboring rooms.
+func (l *layout) mergeFragments() {
+	for changed := true; changed; {
+		changed = false
+		for i := range l.prog.Rooms {
+			comps := l.components(i)
+			if len(comps) < 2 {
+				continue
+			}
+			sort.Slice(comps, func(a, b int) bool { return len(comps[a]) > len(comps[b]) })
+			for _, comp := range comps[1:] {
+				if n := l.mostCommonNeighbor(comp, i); n >= 0 {
+					for _, p := range comp {
+						l.labels[p[1]][p[0]] = n
+					}
+					changed = true
+				}
+			}
+		}
+	}
+}
+
+// components returns the connected components of the cells of a room.
+func (l *layout) components(room int) [][][2]int {
+	seen := make(map[[2]int]bool)
+	var comps [][][2]int
+	for y := range l.labels {
+		for x := range l.labels[y] {
+			if l.labels[y][x] != room || seen[[2]int{x, y}] {
+				continue
+			}
+			var comp [][2]int
+			stack := [][2]int{{x, y}}
+			seen[[2]int{x, y}] = true
+			for len(stack) > 0 {
+				p := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				comp = append(comp, p)
+				for _, d := range neighbors4 {
+					q := [2]int{p[0] + d[0], p[1] + d[1]}
+					if l.label(q[0], q[1]) == room && !seen[q] {
+						seen[q] = true
+						stack = append(stack, q)
+					}
+				}
+			}
+			comps = append(comps, comp)
+		}
+	}
+	return comps
+}
+
+// mostCommonNeighbor returns the most common room next to the given cells
+// (other than the given room), or -1 if there is none.
+func (l *layout) mostCommonNeighbor(cells [][2]int, room int) int {
+	counts := make(map[int]int)
+	for _, p := range cells {
+		for _, d := range neighbors4 {
+			if n := l.label(p[0]+d[0], p[1]+d[1]); n >= 0 && n != room {
+				counts[n]++
+			}
+		}
+	}
+	best, bestCount := -1, 0
+	for n, c := range counts {
+		if c > bestCount || (c == bestCount && n < best) {
+			best, bestCount = n, c
+		}
+	}
+	return best
+}
+
+var neighbors4 = [4][2]int{{1, 0}, {0, 1}, {-1, 0}, {0, -1}}
+
+// label returns the room of the given cell (-1 if outside).
+func (l *layout) label(x, y int) int {
+	if x < 0 || y < 0 || x >= l.width || y >= l.height {
+		return -1
+	}
+	return l.labels[y][x]
+}
+
+// buildWalls turns the cells along the footprint and between rooms into
+// walls. Walls between rooms are placed on the east and south side of rooms.
+func (l *layout) buildWalls() {
+	for y := range l.labels {
+		for x, room := range l.labels[y] {
+			if room < 0 {
+				continue
+			}
+			wall := l.label(x+1, y) != room || l.label(x, y+1) != room || l.label(x+1, y+1) != room
+			for dy := -1; dy <= 1 && !wall; dy++ {
+				for dx := -1; dx <= 1; dx++ {
+					if l.label(x+dx, y+dy) < 0 {
+						wall = true
+					}
+				}
+			}
+			if wall {
+				l.cells[y][x] = CellWall
+			}
+		}
+	}
+}
+
+// side returns the room of the given cell if it is part of the interior of
+// a room, sideWall for walls, doors and windows, and sideOutside for cells
+// outside the footprint.
+func (l *layout) side(x, y int) int {
+	room := l.label(x, y)
+	switch {
+	case room < 0:
+		return sideOutside
+	case l.cells[y][x] != ' ':
+		return sideWall
+	}
+	return room
+}
+
+// hasInterior returns true if the room has cells that are not walls.
+func (l *layout) hasInterior(room int) bool {
+	for y := range l.labels {
+		for x := range l.labels[y] {
+			if l.side(x, y) == room {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// openings returns the straight wall cells that separate the interior of
+// room a from room b (or the outside if b is sideOutside).
+func (l *layout) openings(a, b int) [][2]int {
+	var res [][2]int
+	for y := range l.cells {
+		for x, c := range l.cells[y] {
+			if c != CellWall {
+				continue
+			}
+			for _, d := range neighbors4[:2] {
+				p, q := l.side(x-d[0], y-d[1]), l.side(x+d[0], y+d[1])
+				if !(p == a && q == b) && !(p == b && q == a) {
+					continue
+				}
+				// The wall should continue on both sides of the opening.
+				if l.side(x+d[1], y+d[0]) == sideWall && l.side(x-d[1], y-d[0]) == sideWall {
+					res = append(res, [2]int{x, y})
+				}
+			}
+		}
+	}
+	return res
+}
+
+// nearOpening returns true if there is a door or window within the given
+// distance of the cell.
+func (l *layout) nearOpening(p [2]int, dist int) bool {
+	for y := p[1] - dist; y <= p[1]+dist; y++ {
+		for x := p[0] - dist; x <= p[0]+dist; x++ {
+			if x >= 0 && y >= 0 && x < l.width && y < l.height && (l.cells[y][x] == CellDoor || l.cells[y][x] == CellWindow) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// addDoor adds a door between the rooms in the middle of their shared wall
+// and returns true if there is a suitable place.
+func (l *layout) addDoor(a, b int) bool {
+	var cands [][2]int
+	for _, p := range l.openings(a, b) {
+		if !l.nearOpening(p, 1) {
+			cands = append(cands, p)
+		}
+	}
+	if len(cands) == 0 {
+		return false
+	}
+	p := cands[len(cands)/2]
+	l.cells[p[1]][p[0]] = CellDoor
+	return true
+}
+
+// placeEntrance adds a door to the outside to the entrance room (or the
+// first room in the given order that has an exterior wall).
+func (l *layout) placeEntrance(order []int) error {
+	rooms := append([]int{l.entrance()}, order...)
+	for _, room := range rooms {
+		if cands := l.openings(room, sideOutside); len(cands) > 0 {
+			p := cands[l.rng.Intn(len(cands))]
+			l.cells[p[1]][p[0]] = CellDoor
+			return nil
+		}
+	}
+	return errors.New("no room has an exterior wall for the entrance")
+}
+
+// placeDoors adds doors between rooms that should be adjacent, and between
+// further rooms until all rooms are reachable from the entrance.
+func (l *layout) placeDoors(order []int) error {
+	n := len(l.prog.Rooms)
+	connected := make([][]bool, n)
+	for i := range connected {
+		connected[i] = make([]bool, n)
+	}
+	connect := func(a, b int) bool {
+		if connected[a][b] || !l.addDoor(a, b) {
+			return false
+		}
+		connected[a][b], connected[b][a] = true, true
+		return true
+	}
+	for _, a := range order {
+		for _, b := range order {
+			if a < b && l.adjacent(a, b) {
+				connect(a, b)
+			}
+		}
+	}
+
+	// Connect the remaining rooms to the reachable ones.
+	for {
+		reached := make([]bool, n)
+		queue := []int{l.entrance()}
+		reached[queue[0]] = true
+		for len(queue) > 0 {
+			a := queue[0]
+			queue = queue[1:]
+			for b := 0; b < n; b++ {
+				if connected[a][b] && !reached[b] {
+					reached[b] = true
+					queue = append(queue, b)
+				}
+			}
+		}
+		var unreached []int
+		for _, a := range order {
+			if !reached[a] {
+				unreached = append(unreached, a)
+			}
+		}
+		if len(unreached) == 0 {
+			return nil
+		}
+		var progress bool
+		for _, a := range unreached {
+			for _, b := range order {
+				if reached[b] && connect(a, b) {
+					progress = true
+					break
+				}
+			}
+			if progress {
+				break
+			}
+		}
+		if !progress {
+			return fmt.Errorf("room %q is not reachable", l.prog.Rooms[unreached[0]].Name)
+		}
+	}
+}
+
+// placeWindows adds windows to the exterior walls of rooms that want them.
+func (l *layout) placeWindows() {
+	for i, r := range l.prog.Rooms {
+		if !r.Windows {
+			continue
+		}
+		for _, p := range l.openings(i, sideOutside) {
+			if !l.nearOpening(p, 2) {
+				l.cells[p[1]][p[0]] = CellWindow
+			}
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package genfloortxt
+
+import (
+	"testing"
+
+	"github.com/Flokey82/go_gens/vectors"
+)
+
+func TestGenerate(t *testing.T) {
+	rect := []vectors.Vec2{{X: 0, Y: 0}, {X: 30, Y: 0}, {X: 30, Y: 20}, {X: 0, Y: 20}}
+	lShape := []vectors.Vec2{{X: 0, Y: 0}, {X: 40, Y: 0}, {X: 40, Y: 14}, {X: 18, Y: 14}, {X: 18, Y: 30}, {X: 0, Y: 30}}
+	for _, tc := range []struct {
+		name      string
+		rooms     []string
+		footprint []vectors.Vec2
+	}{
+		{"cottage", []string{RoomKitchen, RoomBedroom, RoomStorage}, rect},
+		{"house", []string{RoomHallway, RoomKitchen, RoomPantry, RoomDining, RoomBedroom, RoomBedroom, RoomStudy}, rect},
+		{"great house", []string{RoomGreatHall, RoomHallway, RoomDining, RoomKitchen, RoomPantry, RoomLibrary, RoomStudy, RoomBedroom, RoomBedroom, RoomChamber, RoomBathhouse}, lShape},
+	} {
+		for seed := int64(0); seed < 20; seed++ {
+			p, err := Generate(NewProgram(tc.rooms), tc.footprint, seed)
+			if err != nil {
+				t.Errorf("%s (seed %d): %v", tc.name, seed, err)
+				continue
+			}
+			if len(p.names) != len(tc.rooms) {
+				t.Errorf("%s (seed %d): got %d rooms, want %d", tc.name, seed, len(p.names), len(tc.rooms))
+			}
+			regions, outside := planRegions(p)
+
+			// Each room is a separate region.
+			seen := make(map[int]bool)
+			for pt, name := range p.names {
+				r := regions[pt.Y][pt.X]
+				if outside[r] || seen[r] {
+					t.Errorf("%s (seed %d): room %q is not a separate region", tc.name, seed, name)
+				}
+				seen[r] = true
+			}
+
+			// Collect the regions connected by each door and check that the
+			// windows are on exterior walls.
+			var entrances int
+			links := make(map[int][]int)
+			for y := range p.cells {
+				for x, c := range p.cells[y] {
+					var sides []int
+					for _, d := range neighbors4 {
+						if r := regionAt(regions, x+d[0], y+d[1]); r != -1 {
+							sides = append(sides, r)
+						}
+					}
+					switch c {
+					case CellDoor:
+						if len(sides) != 2 {
+							t.Errorf("%s (seed %d): door at %d,%d connects %d regions", tc.name, seed, x, y, len(sides))
+							continue
+						}
+						if outside[sides[0]] || outside[sides[1]] {
+							entrances++
+						}
+						links[sides[0]] = append(links[sides[0]], sides[1])
+						links[sides[1]] = append(links[sides[1]], sides[0])
+					case CellWindow:
+						var exterior bool
+						for _, r := range sides {
+							exterior = exterior || outside[r]
+						}
+						if !exterior {
+							t.Errorf("%s (seed %d): window at %d,%d is not on an exterior wall", tc.name, seed, x, y)
+						}
+					}
+				}
+			}
+			if entrances != 1 {
+				t.Errorf("%s (seed %d): got %d entrances, want 1", tc.name, seed, entrances)
+			}
+
+			// All rooms are reachable from the outside.
+			reached := make(map[int]bool)
+			var queue []int
+			for r := range outside {
+				reached[r] = true
+				queue = append(queue, r)
+			}
+			for len(queue) > 0 {
+				r := queue[0]
+				queue = queue[1:]
+				for _, n := range links[r] {
+					if !reached[n] {
+						reached[n] = true
+						queue = append(queue, n)
+					}
+				}
+			}
+			for pt, name := range p.names {
+				if !reached[regions[pt.Y][pt.X]] {
+					t.Errorf("%s (seed %d): room %q is not reachable", tc.name, seed, name)
+				}
+			}
+		}
+	}
+}
+
+// offPlan is the region of the cells beyond the border of a plan.
+const offPlan = -2
+
+// planRegions returns the connected regions of empty cells of the plan (-1
+// for walls, doors and windows) and the regions outside of the footprint,
+// which touch the border of the plan.
+func planRegions(p *Plan) ([][]int, map[int]bool) {
+	regions := make([][]int, p.Height)
+	for y := range regions {
+		regions[y] = make([]int, p.Width)
+		for x := range regions[y] {
+			regions[y][x] = -1
+		}
+	}
+	outside := map[int]bool{offPlan: true}
+	var n int
+	for y := range p.cells {
+		for x, c := range p.cells[y] {
+			if c != ' ' || regions[y][x] >= 0 {
+				continue
+			}
+			stack := [][2]int{{x, y}}
+			regions[y][x] = n
+			for len(stack) > 0 {
+				q := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				if q[0] == 0 || q[1] == 0 || q[0] == p.Width-1 || q[1] == p.Height-1 {
+					outside[n] = true
+				}
+				for _, d := range neighbors4 {
+					nx, ny := q[0]+d[0], q[1]+d[1]
+					if nx >= 0 && ny >= 0 && nx < p.Width && ny < p.Height && p.cells[ny][nx] == ' ' && regions[ny][nx] < 0 {
+						regions[ny][nx] = n
+						stack = append(stack, [2]int{nx, ny})
+					}
+				}
+			}
+			n++
+		}
+	}
+	return regions, outside
+}
+
+// regionAt returns the region of the given cell (-1 for walls, doors and
+// windows).
+func regionAt(regions [][]int, x, y int) int {
+	if y < 0 || y >= len(regions) || x < 0 || x >= len(regions[y]) {
+		return offPlan
+	}
+	return regions[y][x]
+}
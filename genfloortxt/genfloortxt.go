@@ -1,9 +1,10 @@
 // Package genfloortxt renders a primitive floor plan read from an ASCII file to the console.
-// NOTE: In future I plan to add some procedural generation bits to it.
+// Floor plans can also be generated procedurally from a room program (see Generate).
 package genfloortxt
 
 import (
 	"bufio"
+	"image"
 	"io"
 	"strings"
 )
@@ -20,6 +21,7 @@ const (
 // Plan represents a parsed floorplan.
 type Plan struct {
 	cells  [][]byte
+	names  map[image.Point]string // Names of rooms by one of their cells (for generated plans).
 	Height int
 	Width  int
 }
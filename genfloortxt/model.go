@@ -0,0 +1,271 @@
+package genfloortxt
+
+import (
+	"image"
+)
+
+// Model is a semantic model of a floor plan. All coordinates are in cells.
+type Model struct {
+	Width   int
+	Height  int
+	Walls   []*Wall
+	Rooms   []*Room
+	Doors   []*Door
+	Windows []*Window
+}
+
+// Wall is a straight run of wall cells (including doors and windows).
+type Wall struct {
+	Start    image.Point // First cell of the wall.
+	End      image.Point // Last cell of the wall.
+	Exterior bool        // The wall borders the outside.
+}
+
+// Length returns the length of the wall in cells.
+func (w *Wall) Length() int {
+	return w.End.X - w.Start.X + w.End.Y - w.Start.Y + 1
+}
+
+// Cells returns the cells of the wall.
+func (w *Wall) Cells() []image.Point {
+	var res []image.Point
+	for y := w.Start.Y; y <= w.End.Y; y++ {
+		for x := w.Start.X; x <= w.End.X; x++ {
+			res = append(res, image.Pt(x, y))
+		}
+	}
+	return res
+}
+
+// Room is an enclosed region of a floor plan.
+type Room struct {
+	ID      int
+	Name    string        // Name of the room (if known, e.g. for generated plans).
+	Cells   []image.Point // Cells of the room.
+	Doors   []*Door       // Doors of the room.
+	Windows []*Window     // Windows of the room.
+}
+
+// Area returns the area of the room in cells.
+func (r *Room) Area() int {
+	return len(r.Cells)
+}
+
+// Bounds returns the bounding rectangle of the room (max exclusive).
+func (r *Room) Bounds() image.Rectangle {
+	var b image.Rectangle
+	for i, c := range r.Cells {
+		cr := image.Rect(c.X, c.Y, c.X+1, c.Y+1)
+		if i == 0 {
+			b = cr
+		} else {
+			b = b.Union(cr)
+		}
+	}
+	return b
+}
+
+// Door is a door linking rooms (or a room and the outside).
+type Door struct {
+	Pos      image.Point
+	Rooms    []*Room // Rooms linked by the door.
+	Exterior bool    // The door leads outside.
+}
+
+// Window is a window of a room.
+type Window struct {
+	Pos      image.Point
+	Room     *Room // Room the window belongs to (nil if unknown).
+	Exterior bool  // The window is on an outer wall.
+}
+
+// isWallCell returns true if the cell is part of a wall.
+func isWallCell(c byte) bool {
+	return c == CellWall || c == CellDoor || c == CellWindow
+}
+
+// Cell returns the cell at the given coordinates (0 if out of bounds).
+func (p *Plan) Cell(x, y int) byte {
+	if x < 0 || y < 0 || y >= p.Height || x >= p.Width {
+		return 0
+	}
+	return p.cells[y][x]
+}
+
+// Model parses the floor plan into a semantic model. Rooms are the enclosed
+// regions of the plan, while regions touching the border of the plan are
+// considered to be outside.
+func (p *Plan) Model() *Model {
+	m := &Model{
+		Width:  p.Width,
+		Height: p.Height,
+	}
+
+	// Find the connected regions of non-wall cells.
+	regions := make([][]int, p.Height)
+	for y := range regions {
+		regions[y] = make([]int, p.Width)
+		for x := range regions[y] {
+			regions[y][x] = -1
+		}
+	}
+	var outside []bool
+	var regionCells [][]image.Point
+	for y := 0; y < p.Height; y++ {
+		for x := 0; x < p.Width; x++ {
+			if regions[y][x] >= 0 || isWallCell(p.cells[y][x]) {
+				continue
+			}
+			id := len(regionCells)
+			var cells []image.Point
+			var touchesBorder bool
+			stack := []image.Point{image.Pt(x, y)}
+			regions[y][x] = id
+			for len(stack) > 0 {
+				c := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				cells = append(cells, c)
+				for _, d := range neighbors4 {
+					n := image.Pt(c.X+d[0], c.Y+d[1])
+					if n.X < 0 || n.Y < 0 || n.X >= p.Width || n.Y >= p.Height {
+						touchesBorder = true
+						continue
+					}
+					if regions[n.Y][n.X] < 0 && !isWallCell(p.cells[n.Y][n.X]) {
+						regions[n.Y][n.X] = id
+						stack = append(stack, n)
+					}
+				}
+			}
+			regionCells = append(regionCells, cells)
+			outside = append(outside, touchesBorder)
+		}
+	}
+
+	// The regions that are not outside are rooms.
+	rooms := make([]*Room, len(regionCells))
+	for i, cells := range regionCells {
+		if outside[i] {
+			continue
+		}
+		r := &Room{
+			ID:    len(m.Rooms),
+			Cells: cells,
+		}
+		for _, c := range cells {
+			if name, ok := p.names[c]; ok {
+				r.Name = name
+				break
+			}
+		}
+		rooms[i] = r
+		m.Rooms = append(m.Rooms, r)
+	}
+
+	// region returns the region of the given cell (-1 for walls, -2 for
+	// cells out of bounds, which are outside).
+	region := func(x, y int) int {
+		if x < 0 || y < 0 || x >= p.Width || y >= p.Height {
+			return -2
+		}
+		return regions[y][x]
+	}
+	isOutside := func(r int) bool {
+		return r == -2 || (r >= 0 && outside[r])
+	}
+
+	// Link doors and windows to the regions on both sides.
+	for y := 0; y < p.Height; y++ {
+		for x := 0; x < p.Width; x++ {
+			c := p.cells[y][x]
+			if c != CellDoor && c != CellWindow {
+				continue
+			}
+			var linked []*Room
+			var exterior bool
+			for _, d := range neighbors4[:2] {
+				a, b := region(x-d[0], y-d[1]), region(x+d[0], y+d[1])
+				if a == -1 || b == -1 {
+					continue
+				}
+				for _, r := range []int{a, b} {
+					if isOutside(r) {
+						exterior = true
+					} else {
+						linked = append(linked, rooms[r])
+					}
+				}
+				break
+			}
+			if c == CellDoor {
+				door := &Door{Pos: image.Pt(x, y), Rooms: linked, Exterior: exterior}
+				for _, r := range linked {
+					r.Doors = append(r.Doors, door)
+				}
+				m.Doors = append(m.Doors, door)
+			} else {
+				window := &Window{Pos: image.Pt(x, y), Exterior: exterior}
+				if len(linked) > 0 {
+					window.Room = linked[0]
+					linked[0].Windows = append(linked[0].Windows, window)
+				}
+				m.Windows = append(m.Windows, window)
+			}
+		}
+	}
+
+	// Find the walls. Horizontal runs are found first, and vertical runs
+	// only consist of the remaining cells, so walls don't overlap.
+	isWall := func(x, y int) bool {
+		return isWallCell(p.Cell(x, y))
+	}
+	used := make(map[image.Point]bool)
+	exterior := func(start, end image.Point) bool {
+		for y := start.Y; y <= end.Y; y++ {
+			for x := start.X; x <= end.X; x++ {
+				for _, d := range neighbors4 {
+					if isOutside(region(x+d[0], y+d[1])) {
+						return true
+					}
+				}
+			}
+		}
+		return false
+	}
+	addWall := func(start, end image.Point) {
+		m.Walls = append(m.Walls, &Wall{Start: start, End: end, Exterior: exterior(start, end)})
+		for y := start.Y; y <= end.Y; y++ {
+			for x := start.X; x <= end.X; x++ {
+				used[image.Pt(x, y)] = true
+			}
+		}
+	}
+	for y := 0; y < p.Height; y++ {
+		for x := 0; x < p.Width; x++ {
+			if !isWall(x, y) || isWall(x-1, y) || !isWall(x+1, y) {
+				continue
+			}
+			end := x
+			for isWall(end+1, y) {
+				end++
+			}
+			addWall(image.Pt(x, y), image.Pt(end, y))
+		}
+	}
+	free := func(x, y int) bool {
+		return isWall(x, y) && !used[image.Pt(x, y)]
+	}
+	for x := 0; x < p.Width; x++ {
+		for y := 0; y < p.Height; y++ {
+			if !free(x, y) || free(x, y-1) {
+				continue
+			}
+			end := y
+			for free(x, end+1) {
+				end++
+			}
+			addWall(image.Pt(x, y), image.Pt(x, end))
+		}
+	}
+	return m
+}
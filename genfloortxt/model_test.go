@@ -0,0 +1,129 @@
+package genfloortxt
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// twoRooms is a plan with two rooms linked by a door, an entrance and a
+// window.
+const twoRooms = `#########
+#   #   #
+#   D   W
+#   #   #
+###D#####`
+
+// readSamplePlan reads the sample plan of the runner.
+func readSamplePlan(t *testing.T) *Plan {
+	f, err := os.Open("cmd/sample.plan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	return ReadPlan(f)
+}
+
+func TestModel(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		plan        *Plan
+		wantAreas   []int   // Area of each room.
+		wantDoors   [][]int // Rooms linked by each door.
+		wantExtDoor []bool  // Whether each door leads outside.
+		wantWindows []int   // Room of each window.
+		wantWalls   int     // Number of walls.
+	}{{
+		// The rooms of the sample are connected by a gap in the wall, so
+		// they are a single region.
+		name:        "sample",
+		plan:        readSamplePlan(t),
+		wantAreas:   []int{15*12 + 1 + 23*5},
+		wantDoors:   [][]int{{0}},
+		wantExtDoor: []bool{true},
+		wantWindows: []int{0, 0, 0},
+		wantWalls:   10,
+	}, {
+		name:        "two rooms",
+		plan:        ReadPlan(strings.NewReader(twoRooms)),
+		wantAreas:   []int{9, 9},
+		wantDoors:   [][]int{{0, 1}, {0}},
+		wantExtDoor: []bool{false, true},
+		wantWindows: []int{1},
+		wantWalls:   5,
+	}} {
+		m := tc.plan.Model()
+		if len(m.Rooms) != len(tc.wantAreas) {
+			t.Fatalf("%s: got %d rooms, want %d", tc.name, len(m.Rooms), len(tc.wantAreas))
+		}
+		for i, r := range m.Rooms {
+			if r.ID != i || r.Area() != tc.wantAreas[i] {
+				t.Errorf("%s: got room %d with area %d, want room %d with area %d", tc.name, r.ID, r.Area(), i, tc.wantAreas[i])
+			}
+		}
+
+		if len(m.Doors) != len(tc.wantDoors) {
+			t.Fatalf("%s: got %d doors, want %d", tc.name, len(m.Doors), len(tc.wantDoors))
+		}
+		for i, d := range m.Doors {
+			var ids []int
+			for _, r := range d.Rooms {
+				ids = append(ids, r.ID)
+
+				// Rooms link back to their doors.
+				var found bool
+				for _, rd := range r.Doors {
+					found = found || rd == d
+				}
+				if !found {
+					t.Errorf("%s: room %d does not link to door %d", tc.name, r.ID, i)
+				}
+			}
+			if !equalInts(ids, tc.wantDoors[i]) || d.Exterior != tc.wantExtDoor[i] {
+				t.Errorf("%s: door %d: got rooms %v (exterior %t), want %v (exterior %t)", tc.name, i, ids, d.Exterior, tc.wantDoors[i], tc.wantExtDoor[i])
+			}
+		}
+
+		if len(m.Windows) != len(tc.wantWindows) {
+			t.Fatalf("%s: got %d windows, want %d", tc.name, len(m.Windows), len(tc.wantWindows))
+		}
+		for i, w := range m.Windows {
+			if w.Room == nil || w.Room.ID != tc.wantWindows[i] || !w.Exterior {
+				t.Errorf("%s: window %d: got room %v (exterior %t), want room %d on an exterior wall", tc.name, i, w.Room, w.Exterior, tc.wantWindows[i])
+			}
+		}
+
+		// Walls don't overlap and cover all wall cells.
+		if len(m.Walls) != tc.wantWalls {
+			t.Errorf("%s: got %d walls, want %d", tc.name, len(m.Walls), tc.wantWalls)
+		}
+		var wallCells int
+		for _, w := range m.Walls {
+			wallCells += w.Length()
+		}
+		var want int
+		for y := 0; y < tc.plan.Height; y++ {
+			for x := 0; x < tc.plan.Width; x++ {
+				if isWallCell(tc.plan.Cell(x, y)) {
+					want++
+				}
+			}
+		}
+		if wallCells != want {
+			t.Errorf("%s: got %d wall cells, want %d", tc.name, wallCells, want)
+		}
+	}
+}
+
+// equalInts returns true if both slices contain the same values.
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -34,19 +34,27 @@ func NewBuildingConfig(name string, required, possible []string, minSize, maxSiz
 
 // Generate a building from the config.
 func (bc *BuildingConfig) Generate() *Building {
+	return bc.generate(roomTypeToConfig, furnishingIndex)
+}
+
+// generate a building from the config using the given rooms and items (by ID).
+func (bc *BuildingConfig) generate(rooms map[string]*RoomConfig, items map[string]*ItemBase) *Building {
 	building := &Building{
 		Name: bc.Name,
-		Size: bc.Size[0] + rand.Intn(bc.Size[1]-bc.Size[0]),
+		Size: bc.Size[0],
+	}
+	if bc.Size[1] > bc.Size[0] {
+		building.Size += rand.Intn(bc.Size[1] - bc.Size[0])
 	}
 
 	// Generate all required rooms.
 	for _, room := range bc.Required {
-		building.Rooms = append(building.Rooms, roomTypeToConfig[room].Generate())
+		building.Rooms = append(building.Rooms, rooms[room].generate(items))
 	}
 
 	// Generate random rooms.
 	for _, i := range rand.Perm(len(bc.Possible)) {
-		room := roomTypeToConfig[bc.Possible[i]].Generate()
+		room := rooms[bc.Possible[i]].generate(items)
 		building.Rooms = append(building.Rooms, room)
 		if len(building.Rooms) >= building.Size {
 			break
@@ -0,0 +1,513 @@
+package genfurnishing
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Catalogue is a collection of items, item sets, rooms and buildings (all
+// by ID), which can be extended by loading JSON files and modified by
+// culture or era variants.
+type Catalogue struct {
+	Items     map[string]*ItemBase
+	Sets      map[string]*ItemSet
+	Rooms     map[string]*RoomConfig
+	Buildings map[string]*BuildingConfig
+	variants  map[string]*variantDef
+}
+
+// defaultSets are the built-in item sets.
+var defaultSets = []*ItemSet{ClothingSet, StationarySet}
+
+func init() {
+	for _, s := range defaultSets {
+		for _, it := range s.Items {
+			it.ID = it.Name
+		}
+	}
+}
+
+// DefaultCatalogue returns a catalogue with the built-in items, rooms and
+// buildings.
+func DefaultCatalogue() *Catalogue {
+	c := &Catalogue{
+		Items:     make(map[string]*ItemBase),
+		Sets:      make(map[string]*ItemSet),
+		Rooms:     make(map[string]*RoomConfig),
+		Buildings: make(map[string]*BuildingConfig),
+		variants:  make(map[string]*variantDef),
+	}
+	for id, it := range furnishingIndex {
+		c.Items[id] = it
+	}
+	for _, s := range defaultSets {
+		c.Sets[s.Name] = s
+		for _, it := range s.Items {
+			c.Items[it.ID] = it
+		}
+	}
+	for id, rc := range roomTypeToConfig {
+		c.Rooms[id] = rc
+	}
+	for _, bc := range []*BuildingConfig{BuildingKeep, BuildingGreatHouse} {
+		c.Buildings[bc.Name] = bc
+	}
+	return c
+}
+
+// clone returns a copy of the catalogue, which can be modified without
+// affecting the original. Item sets are copied, items and configs are
+// replaced (not modified) when changed.
+func (c *Catalogue) clone() *Catalogue {
+	res := &Catalogue{
+		Items:     make(map[string]*ItemBase, len(c.Items)),
+		Sets:      make(map[string]*ItemSet, len(c.Sets)),
+		Rooms:     make(map[string]*RoomConfig, len(c.Rooms)),
+		Buildings: make(map[string]*BuildingConfig, len(c.Buildings)),
+		variants:  make(map[string]*variantDef, len(c.variants)),
+	}
+	for id, it := range c.Items {
+		res.Items[id] = it
+	}
+	for id, s := range c.Sets {
+		res.Sets[id] = &ItemSet{Name: s.Name, Items: append([]*ItemBase(nil), s.Items...)}
+	}
+	for id, rc := range c.Rooms {
+		res.Rooms[id] = rc
+	}
+	for id, bc := range c.Buildings {
+		res.Buildings[id] = bc
+	}
+	for id, v := range c.variants {
+		res.variants[id] = v
+	}
+	res.link()
+	return res
+}
+
+// link makes sure that items refer to the item sets of the catalogue and
+// item sets to the items of the catalogue.
+func (c *Catalogue) link() {
+	for id, it := range c.Items {
+		var changed bool
+		sets := make([]*ItemSet, len(it.Sets))
+		for i, s := range it.Sets {
+			sets[i] = s
+			if cs, ok := c.Sets[s.Name]; ok && cs != s {
+				sets[i] = cs
+				changed = true
+			}
+		}
+		if changed {
+			cp := *it
+			cp.Sets = sets
+			c.Items[id] = &cp
+		}
+	}
+	for _, s := range c.Sets {
+		for i, it := range s.Items {
+			if ci, ok := c.Items[it.ID]; ok {
+				s.Items[i] = ci
+			}
+		}
+	}
+}
+
+// Variants returns the names of the culture and era variants of the catalogue.
+func (c *Catalogue) Variants() []string {
+	var res []string
+	for name := range c.variants {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// Load loads the catalogue file with the given name and adds its
+// definitions to the catalogue. Definitions with an existing ID replace the
+// existing ones. The resulting catalogue is validated.
+func (c *Catalogue) Load(filename string) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	if err := c.Parse(data); err != nil {
+		return fmt.Errorf("%s: %w", filename, err)
+	}
+	return nil
+}
+
+// Parse adds the definitions of the given JSON catalogue to the catalogue.
+// The catalogue is not modified if the definitions are invalid.
+func (c *Catalogue) Parse(data []byte) error {
+	var f catalogueFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return err
+	}
+	res := c.clone()
+	applyErr := res.apply(&f)
+	for name, v := range f.Variants {
+		res.variants[name] = v
+	}
+	if err := joinErrors(applyErr, res.Validate()); err != nil {
+		return err
+	}
+	// Make sure all variants can be applied.
+	for _, name := range res.Variants() {
+		if _, err := res.WithVariant(name); err != nil {
+			return err
+		}
+	}
+	*c = *res
+	return nil
+}
+
+// WithVariant returns a copy of the catalogue with the culture or era
+// variant of the given name applied.
+func (c *Catalogue) WithVariant(name string) (*Catalogue, error) {
+	v, ok := c.variants[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown variant %q", name)
+	}
+	res := c.clone()
+	if err := res.apply(&v.catalogueFile); err != nil {
+		return nil, fmt.Errorf("variant %q: %w", name, err)
+	}
+
+	// Override the variants (e.g. materials) of items.
+	for id, variants := range v.ItemVariants {
+		it, ok := res.Items[id]
+		if !ok {
+			return nil, fmt.Errorf("variant %q: unknown item %q", name, id)
+		}
+		cp := *it
+		cp.Variants = variants
+		res.Items[id] = &cp
+	}
+	res.link()
+
+	// Replace (or remove) items in rooms and in the items other items are
+	// placed next to.
+	for from, to := range v.Replace {
+		if _, ok := res.Items[from]; !ok {
+			return nil, fmt.Errorf("variant %q: unknown item %q", name, from)
+		}
+		if _, ok := res.Items[to]; !ok && to != "" {
+			return nil, fmt.Errorf("variant %q: unknown item %q", name, to)
+		}
+	}
+	if len(v.Replace) > 0 {
+		replace := func(ids []string) []string {
+			var res []string
+			for _, id := range ids {
+				if to, ok := v.Replace[id]; ok {
+					id = to
+				}
+				if id != "" {
+					res = append(res, id)
+				}
+			}
+			return res
+		}
+		for id, rc := range res.Rooms {
+			res.Rooms[id] = NewRoomConfig(rc.Name, replace(rc.Required), replace(rc.Possible))
+		}
+		for id, it := range res.Items {
+			if it.Placement == nil || len(it.Placement.Near) == 0 {
+				continue
+			}
+			rule := *it.Placement
+			rule.Near = nil
+			for _, near := range replace(it.Placement.Near) {
+				if !containsString(rule.Near, near) {
+					rule.Near = append(rule.Near, near)
+				}
+			}
+			cp := *it
+			cp.Placement = &rule
+			res.Items[id] = &cp
+		}
+		res.link()
+	}
+	if err := res.Validate(); err != nil {
+		return nil, fmt.Errorf("variant %q: %w", name, err)
+	}
+	return res, nil
+}
+
+// apply adds the definitions of the given file to the catalogue.
+func (c *Catalogue) apply(f *catalogueFile) error {
+	var errs []error
+	added := make(map[string]*ItemBase)
+	for _, id := range sortedKeys(f.Items) {
+		it, err := f.Items[id].itemBase(id)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("item %q: %w", id, err))
+			continue
+		}
+		c.Items[id] = it
+		added[id] = it
+	}
+	for _, name := range sortedKeys(f.Sets) {
+		s := &ItemSet{Name: name}
+		for _, id := range f.Sets[name] {
+			it, ok := c.Items[id]
+			if !ok {
+				errs = append(errs, fmt.Errorf("set %q: unknown item %q", name, id))
+				continue
+			}
+			s.Items = append(s.Items, it)
+		}
+		c.Sets[name] = s
+	}
+
+	// Resolve the sets of the new items now that all sets are known.
+	for _, id := range sortedKeys(added) {
+		it := added[id]
+		for _, name := range f.Items[id].Sets {
+			s, ok := c.Sets[name]
+			if !ok {
+				errs = append(errs, fmt.Errorf("item %q: unknown set %q", id, name))
+				continue
+			}
+			it.Sets = append(it.Sets, s)
+		}
+	}
+	for id, r := range f.Rooms {
+		c.Rooms[id] = NewRoomConfig(id, r.Required, r.Possible)
+	}
+	for id, b := range f.Buildings {
+		c.Buildings[id] = NewBuildingConfig(id, b.Required, b.Possible, b.Size[0], b.Size[1])
+	}
+	c.link()
+	return joinErrors(errs...)
+}
+
+// Validate returns an error if the catalogue refers to unknown items, sets or
+// rooms or contains invalid definitions.
+func (c *Catalogue) Validate() error {
+	var errs []error
+	for _, id := range sortedKeys(c.Items) {
+		it := c.Items[id]
+		if _, ok := sizeFootprints[it.Size]; !ok {
+			errs = append(errs, fmt.Errorf("item %q: unknown size %q", id, it.Size))
+		}
+		if it.Rarity == nil {
+			errs = append(errs, fmt.Errorf("item %q: no rarity", id))
+		}
+		for _, s := range it.Sets {
+			if c.Sets[s.Name] != s {
+				errs = append(errs, fmt.Errorf("item %q: unknown set %q", id, s.Name))
+			}
+		}
+		if it.Placement != nil {
+			for _, near := range it.Placement.Near {
+				if _, ok := c.Items[near]; !ok {
+					errs = append(errs, fmt.Errorf("item %q: unknown item %q to place near", id, near))
+				}
+			}
+		}
+	}
+	for _, name := range sortedKeys(c.Sets) {
+		for _, it := range c.Sets[name].Items {
+			if c.Items[it.ID] != it {
+				errs = append(errs, fmt.Errorf("set %q: unknown item %q", name, it.ID))
+			}
+		}
+	}
+	for _, id := range sortedKeys(c.Rooms) {
+		rc := c.Rooms[id]
+		for _, item := range append(append([]string(nil), rc.Required...), rc.Possible...) {
+			if _, ok := c.Items[item]; !ok {
+				errs = append(errs, fmt.Errorf("room %q: unknown item %q", id, item))
+			}
+		}
+	}
+	for _, id := range sortedKeys(c.Buildings) {
+		bc := c.Buildings[id]
+		for _, room := range append(append([]string(nil), bc.Required...), bc.Possible...) {
+			if _, ok := c.Rooms[room]; !ok {
+				errs = append(errs, fmt.Errorf("building %q: unknown room %q", id, room))
+			}
+		}
+		if bc.Size[0] < 0 || bc.Size[1] < bc.Size[0] {
+			errs = append(errs, fmt.Errorf("building %q: invalid size %v", id, bc.Size))
+		}
+	}
+	return joinErrors(errs...)
+}
+
+// joinErrors returns an error with the messages of the given errors on
+// separate lines (nil if all errors are nil).
+func joinErrors(errs ...error) error {
+	var msgs []string
+	for _, err := range errs {
+		if err != nil {
+			msgs = append(msgs, err.Error())
+		}
+	}
+	if len(msgs) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(msgs, "\n"))
+}
+
+// GenerateRoom generates a room of the given type.
+func (c *Catalogue) GenerateRoom(name string) (*Room, error) {
+	rc, ok := c.Rooms[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown room %q", name)
+	}
+	return rc.generate(c.Items), nil
+}
+
+// GenerateBuilding generates a building of the given type.
+func (c *Catalogue) GenerateBuilding(name string) (*Building, error) {
+	bc, ok := c.Buildings[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown building %q", name)
+	}
+	return bc.generate(c.Rooms, c.Items), nil
+}
+
+// catalogueFile is the JSON representation of a catalogue.
+type catalogueFile struct {
+	Items     map[string]*itemDef     `json:"items,omitempty"`
+	Sets      map[string][]string     `json:"sets,omitempty"` // Item IDs by set name.
+	Rooms     map[string]*roomDef     `json:"rooms,omitempty"`
+	Buildings map[string]*buildingDef `json:"buildings,omitempty"`
+	Variants  map[string]*variantDef  `json:"variants,omitempty"`
+}
+
+// variantDef is the JSON representation of a culture or era variant, which
+// adds or replaces definitions, replaces items in rooms and overrides the
+// variants of items.
+type variantDef struct {
+	catalogueFile
+	Replace      map[string]string   `json:"replace,omitempty"`       // Item IDs to replace in rooms ("" to remove).
+	ItemVariants map[string][]string `json:"item_variants,omitempty"` // Variants by item ID.
+}
+
+type itemDef struct {
+	Name      string        `json:"name"`
+	Type      string        `json:"type,omitempty"`
+	Rarity    string        `json:"rarity,omitempty"`
+	Size      Size          `json:"size,omitempty"`
+	Capacity  int           `json:"capacity,omitempty"`
+	Variants  []string      `json:"variants,omitempty"`
+	Sets      []string      `json:"sets,omitempty"`
+	Placement *placementDef `json:"placement,omitempty"`
+}
+
+type placementDef struct {
+	Width     int      `json:"width"`
+	Depth     int      `json:"depth"`
+	Placement string   `json:"placement"`
+	Near      []string `json:"near,omitempty"`
+	Tall      bool     `json:"tall,omitempty"`
+	Glyph     string   `json:"glyph,omitempty"`
+}
+
+type roomDef struct {
+	Required []string `json:"required,omitempty"`
+	Possible []string `json:"possible,omitempty"`
+}
+
+type buildingDef struct {
+	Required []string `json:"required,omitempty"`
+	Possible []string `json:"possible,omitempty"`
+	Size     [2]int   `json:"size"` // Minimum/Maximum number of rooms.
+}
+
+// rarities are the rarities by name.
+var rarities = map[string]*Rarity{
+	RarityAbundant.Name:  RarityAbundant,
+	RarityCommon.Name:    RarityCommon,
+	RarityAverage.Name:   RarityAverage,
+	RarityUncommon.Name:  RarityUncommon,
+	RarityRare.Name:      RarityRare,
+	RarityExotic.Name:    RarityExotic,
+	RarityLegendary.Name: RarityLegendary,
+}
+
+// placementNames are the placements by name.
+var placementNames = map[string]Placement{
+	"anywhere":     PlaceAnywhere,
+	"wall":         PlaceWall,
+	"center":       PlaceCenter,
+	"near":         PlaceNear,
+	"wall_mounted": PlaceWallMounted,
+	"floor":        PlaceFloor,
+}
+
+// itemBase returns the item base for the definition (without sets).
+func (d *itemDef) itemBase(id string) (*ItemBase, error) {
+	name, iType := d.Name, d.Type
+	if name == "" {
+		name = id
+	}
+	if iType == "" {
+		iType = ItemTypeFurnishing
+	}
+	it := NewItemBase(name, iType, nil)
+	it.ID = id
+	it.Capacity = d.Capacity
+	it.Variants = d.Variants
+	if d.Size != "" {
+		it.Size = d.Size
+	}
+	if d.Rarity != "" {
+		r, ok := rarities[d.Rarity]
+		if !ok {
+			return nil, fmt.Errorf("unknown rarity %q", d.Rarity)
+		}
+		it.Rarity = r
+	}
+	if p := d.Placement; p != nil {
+		placement, ok := placementNames[p.Placement]
+		if !ok {
+			return nil, fmt.Errorf("unknown placement %q", p.Placement)
+		}
+		if p.Width < 1 || p.Depth < 1 {
+			return nil, fmt.Errorf("invalid footprint %dx%d", p.Width, p.Depth)
+		}
+		glyph := '?'
+		for _, r := range p.Glyph {
+			glyph = r
+			break
+		}
+		it.Placement = &PlacementRule{
+			Width:     p.Width,
+			Depth:     p.Depth,
+			Placement: placement,
+			Near:      p.Near,
+			Tall:      p.Tall,
+			Glyph:     glyph,
+		}
+	}
+	return it, nil
+}
+
+// sortedKeys returns the keys of the map in sorted order.
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// containsString returns true if the slice contains the string.
+func containsString(s []string, str string) bool {
+	for _, v := range s {
+		if v == str {
+			return true
+		}
+	}
+	return false
+}
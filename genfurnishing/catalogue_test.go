@@ -0,0 +1,174 @@
+package genfurnishing
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCatalogueParse(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		data    string
+		wantErr []string // Parts of the error message (nil if valid).
+	}{{
+		name: "valid",
+		data: `{
+			"items": {"keg": {"name": "Keg", "rarity": "common", "size": "large", "sets": ["drinks"]}, "mug": {"name": "Mug", "size": "tiny"}},
+			"sets": {"drinks": ["mug"]},
+			"rooms": {"tavern": {"required": ["keg", "tableLg"], "possible": ["chair"]}},
+			"buildings": {"Inn": {"required": ["tavern", "bedroom"], "size": [2, 4]}}
+		}`,
+	}, {
+		name:    "invalid JSON",
+		data:    `{"items": [}`,
+		wantErr: []string{"invalid character"},
+	}, {
+		name:    "unknown references",
+		data:    `{"rooms": {"tavern": {"required": ["keg"]}}, "buildings": {"Inn": {"required": ["cellar"], "size": [1, 2]}}}`,
+		wantErr: []string{`room "tavern": unknown item "keg"`, `building "Inn": unknown room "cellar"`},
+	}, {
+		name:    "unknown set",
+		data:    `{"items": {"keg": {"size": "large", "sets": ["drinks"]}}}`,
+		wantErr: []string{`item "keg": unknown set "drinks"`},
+	}, {
+		name:    "invalid item",
+		data:    `{"items": {"keg": {"rarity": "divine", "size": "huge"}, "mug": {"placement": {"width": 0, "depth": 1, "placement": "wall"}}}}`,
+		wantErr: []string{`item "keg": unknown rarity "divine"`, `item "mug": invalid footprint 0x1`},
+	}, {
+		name:    "invalid variant",
+		data:    `{"variants": {"elven": {"replace": {"bed": "leafBed"}}}}`,
+		wantErr: []string{`variant "elven": unknown item "leafBed"`},
+	}} {
+		c := DefaultCatalogue()
+		before := DefaultCatalogue()
+		err := c.Parse([]byte(tc.data))
+		if tc.wantErr == nil {
+			if err != nil {
+				t.Errorf("%s: %v", tc.name, err)
+				continue
+			}
+			if _, err := c.GenerateBuilding("Inn"); err != nil {
+				t.Errorf("%s: %v", tc.name, err)
+			}
+			if keg := c.Items["keg"]; len(keg.Sets) != 1 || keg.Sets[0] != c.Sets["drinks"] || c.Sets["drinks"].Items[0] != c.Items["mug"] {
+				t.Errorf("%s: sets are not linked", tc.name)
+			}
+			continue
+		}
+		if err == nil {
+			t.Errorf("%s: got no error", tc.name)
+			continue
+		}
+		for _, want := range tc.wantErr {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("%s: got error %q, want %q", tc.name, err, want)
+			}
+		}
+
+		// The catalogue is unchanged on error.
+		if !reflect.DeepEqual(c, before) {
+			t.Errorf("%s: catalogue was modified", tc.name)
+		}
+	}
+
+	// Parsing doesn't modify the built-in definitions.
+	if _, ok := DefaultCatalogue().Items["keg"]; ok {
+		t.Errorf("default catalogue was modified")
+	}
+}
+
+func TestCatalogueValidate(t *testing.T) {
+	if err := DefaultCatalogue().Validate(); err != nil {
+		t.Fatalf("default catalogue: %v", err)
+	}
+	for _, tc := range []struct {
+		name    string
+		modify  func(c *Catalogue)
+		wantErr string
+	}{
+		{"unknown room item", func(c *Catalogue) { c.Rooms["cell"] = NewRoomConfig("cell", []string{"bunk"}, nil) }, `room "cell": unknown item "bunk"`},
+		{"unknown building room", func(c *Catalogue) { c.Buildings["Jail"] = NewBuildingConfig("Jail", []string{"cell"}, nil, 1, 1) }, `building "Jail": unknown room "cell"`},
+		{"invalid building size", func(c *Catalogue) { c.Buildings["Jail"] = NewBuildingConfig("Jail", []string{"prison"}, nil, 3, 1) }, `building "Jail": invalid size`},
+		{"unknown item to place near", func(c *Catalogue) {
+			it := *c.Items[RoomFurnishingChair]
+			it.Placement = &PlacementRule{Width: 1, Depth: 1, Placement: PlaceNear, Near: []string{"altar"}}
+			c.Items[RoomFurnishingChair] = &it
+		}, `item "chair": unknown item "altar" to place near`},
+		{"unknown size", func(c *Catalogue) {
+			it := *c.Items[RoomFurnishingChair]
+			it.Size = "huge"
+			c.Items[RoomFurnishingChair] = &it
+		}, `item "chair": unknown size "huge"`},
+	} {
+		c := DefaultCatalogue()
+		tc.modify(c)
+		if err := c.Validate(); err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+			t.Errorf("%s: got error %v, want %q", tc.name, err, tc.wantErr)
+		}
+	}
+}
+
+func TestCatalogueWithVariant(t *testing.T) {
+	c := DefaultCatalogue()
+	err := c.Parse([]byte(`{
+		"items": {"stoneTable": {"name": "Stone table", "size": "large", "placement": {"width": 3, "depth": 2, "placement": "center"}}},
+		"variants": {
+			"dwarven": {
+				"replace": {"tableLg": "stoneTable", "carpet": "", "lamp": "torch"},
+				"item_variants": {"chair": ["stone", "iron"]}
+			}
+		}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Variants(); !reflect.DeepEqual(got, []string{"dwarven"}) {
+		t.Errorf("got variants %v, want [dwarven]", got)
+	}
+	before := DefaultCatalogue()
+	if err := before.Parse([]byte(`{"items": {"stoneTable": {"name": "Stone table", "size": "large"}}}`)); err != nil {
+		t.Fatal(err)
+	}
+	chair := c.Items[RoomFurnishingChair]
+
+	v, err := c.WithVariant("dwarven")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Items are replaced or removed in all rooms.
+	for id, rc := range v.Rooms {
+		for _, item := range append(append([]string(nil), rc.Required...), rc.Possible...) {
+			if item == RoomFurnishingTableLg || item == RoomFurnishingCarpet || item == RoomFurnishingLamp {
+				t.Errorf("room %q: got replaced item %q", id, item)
+			}
+		}
+	}
+	if !containsString(v.Rooms[RoomTypeDining].Required, "stoneTable") && !containsString(v.Rooms[RoomTypeDining].Possible, "stoneTable") {
+		t.Errorf("dining room: missing stoneTable")
+	}
+
+	// Chairs are placed next to the replacement table.
+	vc := v.Items[RoomFurnishingChair]
+	if vc.Placement.Placement != PlaceNear || len(vc.Placement.Near) == 0 || vc.Placement.Near[0] != "stoneTable" {
+		t.Errorf("got chair placement %+v, want near stoneTable", vc.Placement)
+	}
+	if !reflect.DeepEqual(vc.Variants, []string{"stone", "iron"}) {
+		t.Errorf("got chair variants %v, want [stone iron]", vc.Variants)
+	}
+
+	// The original catalogue is unchanged.
+	if c.Items[RoomFurnishingChair] != chair || chair.Placement.Near[0] != RoomFurnishingTableLg {
+		t.Errorf("original chair was modified")
+	}
+	for id, rc := range c.Rooms {
+		if !reflect.DeepEqual(rc, before.Rooms[id]) {
+			t.Errorf("room %q was modified", id)
+		}
+	}
+
+	if _, err := c.WithVariant("elven"); err == nil {
+		t.Errorf("unknown variant: got no error")
+	}
+}
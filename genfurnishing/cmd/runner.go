@@ -1,7 +1,12 @@
 package main
 
 import (
+	"image"
+	"log"
+
+	"github.com/Flokey82/go_gens/genfloortxt"
 	"github.com/Flokey82/go_gens/genfurnishing"
+	"github.com/Flokey82/go_gens/vectors"
 )
 
 func main() {
@@ -9,7 +14,63 @@ func main() {
 	room := genfurnishing.RoomBedroom.Generate()
 	room.Log()
 
+	// Place the furniture of the room in a 6x5 room with a door.
+	grid := genfurnishing.NewRoomGrid(6, 5)
+	grid.Doors = []image.Point{{X: 2, Y: 5}}
+	grid.Windows = []image.Point{{X: -1, Y: 2}}
+	layout := room.Place(grid, 1234)
+	for _, it := range layout.Items {
+		log.Printf("%s at %v (%dx%d), facing %d", it.Name(), it.Pos, it.Width, it.Height, it.Rotation)
+	}
+	for _, it := range layout.Unplaced {
+		log.Printf("%s does not fit", it.Name())
+	}
+
 	// Generate a new building.
 	building := genfurnishing.BuildingKeep.Generate()
 	building.Log()
+
+	// Load a catalogue with a dwarven hall and generate it in dwarven style.
+	catalogue := genfurnishing.DefaultCatalogue()
+	if err := catalogue.Load("../catalogues/dwarven.json"); err != nil {
+		log.Fatal(err)
+	}
+	dwarven, err := catalogue.WithVariant("dwarven")
+	if err != nil {
+		log.Fatal(err)
+	}
+	hall, err := dwarven.GenerateBuilding("Dwarven Hall")
+	if err != nil {
+		log.Fatal(err)
+	}
+	hall.Log()
+
+	// Generate a floor plan for a great house and furnish its rooms.
+	house := genfurnishing.BuildingGreatHouse.Generate()
+	var names []string
+	for _, r := range house.Rooms {
+		names = append(names, r.Name)
+	}
+	footprint := []vectors.Vec2{{X: 0, Y: 0}, {X: 40, Y: 0}, {X: 40, Y: 14}, {X: 18, Y: 14}, {X: 18, Y: 30}, {X: 0, Y: 30}}
+	plan, err := genfloortxt.Generate(genfloortxt.NewProgram(names), footprint, 1234)
+	if err != nil {
+		log.Fatal(err)
+	}
+	// Each room of the plan is furnished with the items of a different room
+	// of the house, so rooms with the same name (e.g. two bedrooms) don't get
+	// the same furniture.
+	var placed []*genfurnishing.PlacedItem
+	used := make(map[*genfurnishing.Room]bool)
+	for _, pr := range plan.Model().Rooms {
+		for _, r := range house.Rooms {
+			if r.Name == pr.Name && !used[r] {
+				used[r] = true
+				placed = append(placed, r.Place(genfurnishing.NewRoomGridFromPlan(pr), 1234).Items...)
+				break
+			}
+		}
+	}
+	for _, line := range genfurnishing.OverlayItems(plan.Render(), placed) {
+		log.Println(line)
+	}
 }
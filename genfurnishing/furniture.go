@@ -58,11 +58,11 @@ var furnishingIndex = map[string]*ItemBase{
 	RoomFurnishingDesk: NewItemBase("Desk", ItemTypeFurnishing, func(ib *ItemBase) {
 		ib.Capacity = CapacityMedium
 		ib.Variants = []string{"wood", "stone", "metal"}
-		ib.Set = StationarySet
+		ib.Sets = []*ItemSet{StationarySet}
 	}),
 	RoomFurnishingDresser: NewItemBase("Dresser", ItemTypeFurnishing, func(ib *ItemBase) {
 		ib.Capacity = CapacityMedium
-		ib.Set = ClothingSet
+		ib.Sets = []*ItemSet{ClothingSet}
 	}),
 	RoomFurnishingFirePit:   NewItemBase("Fire pit", ItemTypeFurnishing, nil),
 	RoomFurnishingFireplace: NewItemBase("Fireplace", ItemTypeFurnishing, nil),
@@ -4,13 +4,15 @@ import "math/rand"
 
 // ItemBase represents a base item prototype.
 type ItemBase struct {
-	Name     string
-	Rarity   *Rarity
-	Size     Size
-	Capacity int
-	Type     string
-	Variants []string
-	Set      *ItemSet // TODO: Allow multiple sets.
+	ID        string // Furnishing ID (e.g. RoomFurnishingBed).
+	Name      string
+	Rarity    *Rarity
+	Size      Size
+	Capacity  int
+	Type      string
+	Variants  []string
+	Sets      []*ItemSet     // Sets the contained items are picked from.
+	Placement *PlacementRule // How the item is placed in a room (nil: based on size).
 }
 
 // NewItemBase returns a new item base.
@@ -37,8 +39,12 @@ func (it *ItemBase) Generate() *Item {
 
 	// Generate a number of items from the set up to the capacity.
 	var contains []*Item
-	if it.Capacity > 0 && it.Set != nil {
-		contains = it.Set.GenerateN(rand.Intn(it.Capacity))
+	if it.Capacity > 0 && len(it.Sets) > 0 {
+		for i, n := 0, rand.Intn(it.Capacity); i < n; i++ {
+			if item := it.Sets[rand.Intn(len(it.Sets))].Generate(); item != nil {
+				contains = append(contains, item)
+			}
+		}
 	}
 
 	return &Item{
@@ -0,0 +1,593 @@
+package genfurnishing
+
+import (
+	"image"
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/Flokey82/go_gens/genfloortxt"
+	"github.com/Flokey82/go_gens/gengeometry"
+	"github.com/Flokey82/go_gens/vectors"
+)
+
+// Placement determines where an item is placed in a room.
+type Placement int
+
+// The supported placements.
+const (
+	PlaceAnywhere    Placement = iota // Anywhere in the room.
+	PlaceWall                         // With the back against a wall.
+	PlaceCenter                       // As close to the center of the room as possible.
+	PlaceNear                         // Next to one of the items given by PlacementRule.Near, facing it.
+	PlaceWallMounted                  // Mounted on a wall (does not block the floor).
+	PlaceFloor                        // Covering the floor (e.g. carpets, does not block the floor).
+)
+
+// PlacementRule describes how an item is placed in a room.
+type PlacementRule struct {
+	Width     int // Width of the footprint in cells (along the wall for wall items).
+	Depth     int // Depth of the footprint in cells.
+	Placement Placement
+	Near      []string // IDs of the items to place this item next to (in order of preference).
+	Tall      bool     // The item must not block windows.
+	Glyph     rune     // Glyph used for rendering the item on a floor plan.
+}
+
+// defaultPlacements are the placement rules for the furnishings.
+var defaultPlacements = map[string]*PlacementRule{
+	RoomFurnishingAlchemy:   {Width: 2, Depth: 1, Placement: PlaceWall, Glyph: 'A'},
+	RoomFurnishingAnvil:     {Width: 1, Depth: 1, Placement: PlaceNear, Near: []string{RoomFurnishingForge}, Glyph: 'a'},
+	RoomFurnishingBed:       {Width: 2, Depth: 3, Placement: PlaceWall, Glyph: 'b'},
+	RoomFurnishingBench:     {Width: 2, Depth: 1, Placement: PlaceWall, Glyph: 'n'},
+	RoomFurnishingBookcase:  {Width: 2, Depth: 1, Placement: PlaceWall, Tall: true, Glyph: 'B'},
+	RoomFurnishingCabinet:   {Width: 1, Depth: 1, Placement: PlaceWall, Tall: true, Glyph: 'c'},
+	RoomFurnishingCarpet:    {Width: 3, Depth: 2, Placement: PlaceFloor, Glyph: '~'},
+	RoomFurnishingChair:     {Width: 1, Depth: 1, Placement: PlaceNear, Near: []string{RoomFurnishingTableLg, RoomFurnishingTableSm, RoomFurnishingDesk, RoomFurnishingFireplace, RoomFurnishingFirePit}, Glyph: 'h'},
+	RoomFurnishingCupboard:  {Width: 2, Depth: 1, Placement: PlaceWall, Tall: true, Glyph: 'C'},
+	RoomFurnishingDesk:      {Width: 2, Depth: 1, Placement: PlaceWall, Glyph: 'd'},
+	RoomFurnishingDresser:   {Width: 2, Depth: 1, Placement: PlaceWall, Glyph: 'D'},
+	RoomFurnishingFirePit:   {Width: 2, Depth: 2, Placement: PlaceCenter, Glyph: '*'},
+	RoomFurnishingFireplace: {Width: 2, Depth: 1, Placement: PlaceWall, Glyph: 'F'},
+	RoomFurnishingForge:     {Width: 2, Depth: 2, Placement: PlaceWall, Glyph: 'f'},
+	RoomFurnishingLamp:      {Width: 1, Depth: 1, Placement: PlaceAnywhere, Glyph: 'l'},
+	RoomFurnishingMirror:    {Width: 1, Depth: 1, Placement: PlaceWallMounted, Glyph: 'm'},
+	RoomFurnishingPainting:  {Width: 1, Depth: 1, Placement: PlaceWallMounted, Glyph: 'p'},
+	RoomFurnishingPillar:    {Width: 1, Depth: 1, Placement: PlaceAnywhere, Glyph: 'o'},
+	RoomFurnishingRack:      {Width: 2, Depth: 1, Placement: PlaceWall, Tall: true, Glyph: 'r'},
+	RoomFurnishingShelf:     {Width: 1, Depth: 1, Placement: PlaceWall, Glyph: 's'},
+	RoomFurnishingShrine:    {Width: 1, Depth: 1, Placement: PlaceWall, Glyph: 'S'},
+	RoomFurnishingSpit:      {Width: 1, Depth: 1, Placement: PlaceNear, Near: []string{RoomFurnishingFireplace, RoomFurnishingFirePit}, Glyph: 'x'},
+	RoomFurnishingTableLg:   {Width: 3, Depth: 2, Placement: PlaceCenter, Glyph: 'T'},
+	RoomFurnishingTableSm:   {Width: 1, Depth: 1, Placement: PlaceAnywhere, Glyph: 't'},
+	RoomFurnishingTapestry:  {Width: 2, Depth: 1, Placement: PlaceWallMounted, Glyph: 'y'},
+	RoomFurnishingThrone:    {Width: 1, Depth: 1, Placement: PlaceWall, Glyph: 'K'},
+	RoomFurnishingTorch:     {Width: 1, Depth: 1, Placement: PlaceWallMounted, Glyph: 'i'},
+	RoomFurnishingWardrobe:  {Width: 2, Depth: 1, Placement: PlaceWall, Tall: true, Glyph: 'w'},
+	RoomFurnishingWorkbench: {Width: 2, Depth: 1, Placement: PlaceWall, Glyph: 'W'},
+}
+
+func init() {
+	for id, it := range furnishingIndex {
+		it.ID = id
+		it.Placement = defaultPlacements[id]
+	}
+}
+
+// sizeFootprints are the footprints of items without a placement rule.
+var sizeFootprints = map[Size][2]int{
+	SizeTiny:    {1, 1},
+	SizeSmall:   {1, 1},
+	SizeMedium:  {1, 1},
+	SizeLarge:   {2, 2},
+	SizeMassive: {3, 3},
+}
+
+// placementRule returns the placement rule of the item.
+func (it *Item) placementRule() *PlacementRule {
+	if it.Placement != nil {
+		return it.Placement
+	}
+	fp, ok := sizeFootprints[it.Size]
+	if !ok {
+		fp = [2]int{1, 1}
+	}
+	return &PlacementRule{Width: fp[0], Depth: fp[1], Placement: PlaceAnywhere, Glyph: '?'}
+}
+
+// RoomGrid is the floor of a room as a grid of cells, which is used for
+// placing furniture.
+type RoomGrid struct {
+	Origin  image.Point   // Position of the top left cell (e.g. on a floor plan).
+	Width   int           // Width of the grid in cells.
+	Height  int           // Height of the grid in cells.
+	Floor   [][]bool      // Cells that belong to the room.
+	Doors   []image.Point // Doors next to the room (in grid coordinates).
+	Windows []image.Point // Windows next to the room (in grid coordinates).
+}
+
+// NewRoomGrid returns a rectangular room grid without doors and windows.
+func NewRoomGrid(width, height int) *RoomGrid {
+	g := &RoomGrid{
+		Width:  width,
+		Height: height,
+		Floor:  make([][]bool, height),
+	}
+	for y := range g.Floor {
+		g.Floor[y] = make([]bool, width)
+		for x := range g.Floor[y] {
+			g.Floor[y][x] = true
+		}
+	}
+	return g
+}
+
+// NewRoomGridFromPolygon returns a room grid with the cells whose center is
+// within the given polygon (in cells). If the polygon has less than three
+// points, the grid is empty.
+func NewRoomGridFromPolygon(polygon []vectors.Vec2) *RoomGrid {
+	if len(polygon) < 3 {
+		return NewRoomGrid(0, 0)
+	}
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, p := range polygon {
+		minX, maxX = math.Min(minX, p.X), math.Max(maxX, p.X)
+		minY, maxY = math.Min(minY, p.Y), math.Max(maxY, p.Y)
+	}
+	// Align the grid with whole cells, so the cells match their position
+	// given by the origin.
+	minX, minY = math.Floor(minX), math.Floor(minY)
+	g := NewRoomGrid(int(math.Ceil(maxX-minX)), int(math.Ceil(maxY-minY)))
+	g.Origin = image.Pt(int(minX), int(minY))
+	for y := range g.Floor {
+		for x := range g.Floor[y] {
+			g.Floor[y][x] = gengeometry.PointInPolygon(vectors.NewVec2(minX+float64(x)+0.5, minY+float64(y)+0.5), polygon)
+		}
+	}
+	return g
+}
+
+// NewRoomGridFromPlan returns the room grid of the given room of a floor
+// plan, including its doors and windows.
+func NewRoomGridFromPlan(r *genfloortxt.Room) *RoomGrid {
+	b := r.Bounds()
+	g := NewRoomGrid(b.Dx(), b.Dy())
+	g.Origin = b.Min
+	for y := range g.Floor {
+		for x := range g.Floor[y] {
+			g.Floor[y][x] = false
+		}
+	}
+	for _, c := range r.Cells {
+		g.Floor[c.Y-b.Min.Y][c.X-b.Min.X] = true
+	}
+	for _, d := range r.Doors {
+		g.Doors = append(g.Doors, d.Pos.Sub(b.Min))
+	}
+	for _, w := range r.Windows {
+		g.Windows = append(g.Windows, w.Pos.Sub(b.Min))
+	}
+	return g
+}
+
+// isFloor returns true if the cell belongs to the room.
+func (g *RoomGrid) isFloor(x, y int) bool {
+	return x >= 0 && y >= 0 && x < g.Width && y < g.Height && g.Floor[y][x]
+}
+
+// PlacedItem is an item placed in a room.
+type PlacedItem struct {
+	*Item
+	Pos      image.Point // Top left cell of the footprint (including the origin of the grid).
+	Width    int         // Width of the footprint in cells (after rotation).
+	Height   int         // Height of the footprint in cells (after rotation).
+	Rotation int         // Direction the item faces in degrees clockwise from north (up).
+}
+
+// Cells returns the cells covered by the item.
+func (p *PlacedItem) Cells() []image.Point {
+	var res []image.Point
+	for y := p.Pos.Y; y < p.Pos.Y+p.Height; y++ {
+		for x := p.Pos.X; x < p.Pos.X+p.Width; x++ {
+			res = append(res, image.Pt(x, y))
+		}
+	}
+	return res
+}
+
+// Glyph returns the glyph used for rendering the item.
+func (p *PlacedItem) Glyph() rune {
+	return p.placementRule().Glyph
+}
+
+// RoomLayout is the result of placing the items of a room.
+type RoomLayout struct {
+	Items    []*PlacedItem // Items placed in the room.
+	Unplaced []*Item       // Items that did not fit into the room.
+}
+
+// Place places the items of the room in the given grid.
+func (r *Room) Place(grid *RoomGrid, seed int64) *RoomLayout {
+	return PlaceItems(grid, r.Items, seed)
+}
+
+// facings are the directions items can face (north, east, south, west).
+var facings = [4]image.Point{{0, -1}, {1, 0}, {0, 1}, {-1, 0}}
+
+// placer is the state of the furniture placement solver.
+type placer struct {
+	grid      *RoomGrid
+	rng       *rand.Rand
+	occupied  [][]bool // Cells blocked by furniture.
+	clearance [][]bool // Cells that need to be kept free in front of doors.
+	carpets   [][]bool // Cells covered by carpets.
+	mounted   [][]bool // Cells with wall mounted items.
+	placed    []*PlacedItem
+}
+
+// candidate is a possible placement of an item.
+type candidate struct {
+	x, y, w, h int
+	facing     int
+	score      float64
+}
+
+// PlaceItems places the given items in the room grid. Large and central
+// items are placed first, wall items are placed with their back against a
+// wall, items like chairs are placed next to the items they belong to, and
+// the space in front of doors as well as a path between all free cells is
+// kept clear. Items that don't fit (or have a footprint smaller than one
+// cell) are returned as unplaced.
+func PlaceItems(grid *RoomGrid, items []*Item, seed int64) *RoomLayout {
+	p := &placer{
+		grid:      grid,
+		rng:       rand.New(rand.NewSource(seed)),
+		occupied:  newBoolGrid(grid.Width, grid.Height),
+		clearance: newBoolGrid(grid.Width, grid.Height),
+		carpets:   newBoolGrid(grid.Width, grid.Height),
+		mounted:   newBoolGrid(grid.Width, grid.Height),
+	}
+
+	// Keep the two cells in front of each door free.
+	for _, d := range grid.Doors {
+		for _, f := range facings {
+			if x, y := d.X+f.X, d.Y+f.Y; grid.isFloor(x, y) {
+				p.clearance[y][x] = true
+				if grid.isFloor(x+f.X, y+f.Y) {
+					p.clearance[y+f.Y][x+f.X] = true
+				}
+			}
+		}
+	}
+
+	// Place the items in order of their placement and size.
+	order := make([]*Item, len(items))
+	copy(order, items)
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := order[i].placementRule(), order[j].placementRule()
+		if pa, pb := placementPriority[a.Placement], placementPriority[b.Placement]; pa != pb {
+			return pa < pb
+		}
+		return a.Width*a.Depth > b.Width*b.Depth
+	})
+	layout := &RoomLayout{}
+	for _, it := range order {
+		if pi := p.place(it); pi != nil {
+			layout.Items = append(layout.Items, pi)
+		} else {
+			layout.Unplaced = append(layout.Unplaced, it)
+		}
+	}
+	return layout
+}
+
+// placementPriority is the order in which items are placed by placement.
+var placementPriority = map[Placement]int{
+	PlaceFloor:       0,
+	PlaceCenter:      1,
+	PlaceWall:        2,
+	PlaceNear:        3,
+	PlaceAnywhere:    4,
+	PlaceWallMounted: 5,
+}
+
+func newBoolGrid(width, height int) [][]bool {
+	g := make([][]bool, height)
+	for y := range g {
+		g[y] = make([]bool, width)
+	}
+	return g
+}
+
+// place finds the best placement for the item and returns it (nil if the
+// item does not fit or has an invalid footprint).
+func (p *placer) place(it *Item) *PlacedItem {
+	rule := it.placementRule()
+	if rule.Width < 1 || rule.Depth < 1 {
+		return nil
+	}
+	placement := rule.Placement
+	if placement == PlaceNear && len(p.targets(rule.Near)) == 0 {
+		// Without any of the items to place this item next to, we place it
+		// against a wall instead.
+		placement = PlaceWall
+	}
+
+	var cands []candidate
+	for facing := range facings {
+		w, h := rule.Width, rule.Depth
+		if facing%2 == 1 {
+			w, h = h, w
+		}
+		for y := 0; y+h <= p.grid.Height; y++ {
+			for x := 0; x+w <= p.grid.Width; x++ {
+				c := candidate{x: x, y: y, w: w, h: h, facing: facing}
+				if score, ok := p.score(c, rule, placement); ok {
+					c.score = score + p.rng.Float64()*0.5
+					cands = append(cands, c)
+				}
+			}
+		}
+	}
+	sort.Slice(cands, func(i, j int) bool { return cands[i].score > cands[j].score })
+	blocking := placement != PlaceFloor && placement != PlaceWallMounted
+	for _, c := range cands {
+		if blocking && !p.keepsPath(c) {
+			continue
+		}
+		p.mark(c, placement)
+		pi := &PlacedItem{
+			Item:     it,
+			Pos:      p.grid.Origin.Add(image.Pt(c.x, c.y)),
+			Width:    c.w,
+			Height:   c.h,
+			Rotation: c.facing * 90,
+		}
+		p.placed = append(p.placed, pi)
+		return pi
+	}
+	return nil
+}
+
+// targets returns the placed items with the given IDs (in order of the IDs).
+func (p *placer) targets(ids []string) []*PlacedItem {
+	var res []*PlacedItem
+	for _, id := range ids {
+		for _, pi := range p.placed {
+			if pi.ID == id {
+				res = append(res, pi)
+			}
+		}
+		if len(res) > 0 {
+			break
+		}
+	}
+	return res
+}
+
+// score returns the score of the candidate (the higher the better) and false
+// if the candidate is not valid.
+func (p *placer) score(c candidate, rule *PlacementRule, placement Placement) (float64, bool) {
+	f := facings[c.facing]
+	for y := c.y; y < c.y+c.h; y++ {
+		for x := c.x; x < c.x+c.w; x++ {
+			if !p.grid.isFloor(x, y) {
+				return 0, false
+			}
+			switch placement {
+			case PlaceFloor:
+				if p.carpets[y][x] {
+					return 0, false
+				}
+			case PlaceWallMounted:
+				if p.mounted[y][x] {
+					return 0, false
+				}
+			default:
+				if p.occupied[y][x] || p.clearance[y][x] {
+					return 0, false
+				}
+			}
+		}
+	}
+
+	// The cells behind the item.
+	var back []image.Point
+	switch c.facing {
+	case 0:
+		for x := c.x; x < c.x+c.w; x++ {
+			back = append(back, image.Pt(x, c.y+c.h))
+		}
+	case 1:
+		for y := c.y; y < c.y+c.h; y++ {
+			back = append(back, image.Pt(c.x-1, y))
+		}
+	case 2:
+		for x := c.x; x < c.x+c.w; x++ {
+			back = append(back, image.Pt(x, c.y-1))
+		}
+	case 3:
+		for y := c.y; y < c.y+c.h; y++ {
+			back = append(back, image.Pt(c.x+c.w, y))
+		}
+	}
+	againstWall := true
+	for _, b := range back {
+		if p.grid.isFloor(b.X, b.Y) || containsPoint(p.grid.Doors, b) || (rule.Tall && containsPoint(p.grid.Windows, b)) {
+			againstWall = false
+			break
+		}
+	}
+
+	// Distance of the center of the item to the center of the room and to
+	// the closest door.
+	center := vectors.NewVec2(float64(c.x)+float64(c.w)/2, float64(c.y)+float64(c.h)/2)
+	doorDist := math.Inf(1)
+	for _, d := range p.grid.Doors {
+		doorDist = math.Min(doorDist, vectors.Dist2(center, vectors.NewVec2(float64(d.X)+0.5, float64(d.Y)+0.5)))
+	}
+	doorDist = math.Min(doorDist, 8)
+
+	switch placement {
+	case PlaceWall, PlaceWallMounted:
+		if !againstWall {
+			return 0, false
+		}
+		if placement == PlaceWallMounted {
+			for _, b := range back {
+				if containsPoint(p.grid.Windows, b) {
+					return 0, false
+				}
+			}
+		}
+		return doorDist, true
+	case PlaceCenter, PlaceFloor:
+		// Only consider the facings along the longer side of the room.
+		if (c.facing%2 == 1) != (p.grid.Height > p.grid.Width) {
+			return 0, false
+		}
+		return -vectors.Dist2(center, p.roomCenter()), true
+	case PlaceNear:
+		// The item must face one of the targets directly.
+		for _, t := range p.targets(rule.Near) {
+			if overlapsCells(t, p.grid.Origin, c.x+f.X, c.y+f.Y, c.w, c.h) {
+				return doorDist, true
+			}
+		}
+		return 0, false
+	}
+
+	// Items placed anywhere prefer not to block the middle of the room.
+	if againstWall {
+		return doorDist + 1, true
+	}
+	return doorDist, true
+}
+
+// roomCenter returns the centroid of the floor cells.
+func (p *placer) roomCenter() vectors.Vec2 {
+	var sum vectors.Vec2
+	var n float64
+	for y := range p.grid.Floor {
+		for x, ok := range p.grid.Floor[y] {
+			if ok {
+				sum = sum.Add(vectors.NewVec2(float64(x)+0.5, float64(y)+0.5))
+				n++
+			}
+		}
+	}
+	if n == 0 {
+		return sum
+	}
+	return sum.Mul(1 / n)
+}
+
+// overlapsCells returns true if the placed item overlaps the given rectangle
+// of grid cells.
+func overlapsCells(pi *PlacedItem, origin image.Point, x, y, w, h int) bool {
+	r := image.Rect(x, y, x+w, y+h).Add(origin)
+	return r.Overlaps(image.Rect(pi.Pos.X, pi.Pos.Y, pi.Pos.X+pi.Width, pi.Pos.Y+pi.Height))
+}
+
+func containsPoint(points []image.Point, p image.Point) bool {
+	for _, q := range points {
+		if q == p {
+			return true
+		}
+	}
+	return false
+}
+
+// mark marks the cells of the candidate as used.
+func (p *placer) mark(c candidate, placement Placement) {
+	for y := c.y; y < c.y+c.h; y++ {
+		for x := c.x; x < c.x+c.w; x++ {
+			switch placement {
+			case PlaceFloor:
+				p.carpets[y][x] = true
+			case PlaceWallMounted:
+				p.mounted[y][x] = true
+			default:
+				p.occupied[y][x] = true
+			}
+		}
+	}
+}
+
+// keepsPath returns true if all free floor cells are still connected after
+// placing the candidate, so every part of the room remains reachable.
+func (p *placer) keepsPath(c candidate) bool {
+	blocked := func(x, y int) bool {
+		return !p.grid.isFloor(x, y) || p.occupied[y][x] || (x >= c.x && x < c.x+c.w && y >= c.y && y < c.y+c.h)
+	}
+	var start *image.Point
+	var free int
+	for y := 0; y < p.grid.Height; y++ {
+		for x := 0; x < p.grid.Width; x++ {
+			if !blocked(x, y) {
+				free++
+				if start == nil {
+					start = &image.Point{X: x, Y: y}
+				}
+			}
+		}
+	}
+	if start == nil {
+		return false
+	}
+	seen := newBoolGrid(p.grid.Width, p.grid.Height)
+	seen[start.Y][start.X] = true
+	stack := []image.Point{*start}
+	var reached int
+	for len(stack) > 0 {
+		q := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		reached++
+		for _, f := range facings {
+			n := q.Add(f)
+			if !blocked(n.X, n.Y) && !seen[n.Y][n.X] {
+				seen[n.Y][n.X] = true
+				stack = append(stack, n)
+			}
+		}
+	}
+	return reached == free
+}
+
+// OverlayItems draws the placed items onto the rendered lines of a floor
+// plan (see genfloortxt.Plan.Render) or any other rune grid. Carpets are
+// drawn below furniture and wall mounted items only on free cells.
+func OverlayItems(lines []string, items []*PlacedItem) []string {
+	grid := make([][]rune, len(lines))
+	for i, l := range lines {
+		grid[i] = []rune(l)
+	}
+	layers := []func(Placement) bool{
+		func(pl Placement) bool { return pl == PlaceFloor },
+		func(pl Placement) bool { return pl != PlaceFloor && pl != PlaceWallMounted },
+		func(pl Placement) bool { return pl == PlaceWallMounted },
+	}
+	drawn := make(map[image.Point]bool)
+	for i, inLayer := range layers {
+		for _, pi := range items {
+			if !inLayer(pi.placementRule().Placement) {
+				continue
+			}
+			for _, c := range pi.Cells() {
+				if c.Y < 0 || c.Y >= len(grid) || c.X < 0 || c.X >= len(grid[c.Y]) || (i == 2 && drawn[c]) {
+					continue
+				}
+				grid[c.Y][c.X] = pi.Glyph()
+				if i > 0 {
+					drawn[c] = true
+				}
+			}
+		}
+	}
+	res := make([]string, len(grid))
+	for i, l := range grid {
+		res[i] = string(l)
+	}
+	return res
+}
@@ -0,0 +1,212 @@
+package genfurnishing
+
+import (
+	"image"
+	"testing"
+
+	"github.com/Flokey82/go_gens/vectors"
+)
+
+// testItems returns the items with the given IDs.
+func testItems(ids ...string) []*Item {
+	var items []*Item
+	for _, id := range ids {
+		items = append(items, &Item{ItemBase: furnishingIndex[id]})
+	}
+	return items
+}
+
+// backCells returns the cells behind the placed item (in grid coordinates).
+func backCells(pi *PlacedItem, origin image.Point) []image.Point {
+	pos := pi.Pos.Sub(origin)
+	var res []image.Point
+	switch pi.Rotation {
+	case 0:
+		for x := pos.X; x < pos.X+pi.Width; x++ {
+			res = append(res, image.Pt(x, pos.Y+pi.Height))
+		}
+	case 90:
+		for y := pos.Y; y < pos.Y+pi.Height; y++ {
+			res = append(res, image.Pt(pos.X-1, y))
+		}
+	case 180:
+		for x := pos.X; x < pos.X+pi.Width; x++ {
+			res = append(res, image.Pt(x, pos.Y-1))
+		}
+	case 270:
+		for y := pos.Y; y < pos.Y+pi.Height; y++ {
+			res = append(res, image.Pt(pos.X+pi.Width, y))
+		}
+	}
+	return res
+}
+
+func TestPlaceItems(t *testing.T) {
+	bedroom := func() *RoomGrid {
+		g := NewRoomGrid(6, 5)
+		g.Doors = []image.Point{{X: 2, Y: 5}}
+		g.Windows = []image.Point{{X: -1, Y: 2}, {X: 3, Y: -1}}
+		return g
+	}
+	// A narrow room with windows at every other cell of the walls, so wide
+	// wall items easily end up in front of a window.
+	gallery := func() *RoomGrid {
+		g := NewRoomGrid(7, 3)
+		g.Doors = []image.Point{{X: -1, Y: 1}}
+		for x := 1; x < 7; x += 2 {
+			g.Windows = append(g.Windows, image.Pt(x, -1), image.Pt(x, 3))
+		}
+		return g
+	}
+	lShape := func() *RoomGrid {
+		g := NewRoomGridFromPolygon([]vectors.Vec2{{X: 10, Y: 20}, {X: 18, Y: 20}, {X: 18, Y: 24}, {X: 14, Y: 24}, {X: 14, Y: 28}, {X: 10, Y: 28}})
+		g.Doors = []image.Point{{X: 8, Y: 1}}
+		return g
+	}
+	for _, tc := range []struct {
+		name  string
+		grid  func() *RoomGrid
+		items []*Item
+	}{
+		{"bedroom", bedroom, testItems(RoomFurnishingBed, RoomFurnishingWardrobe, RoomFurnishingDesk, RoomFurnishingChair, RoomFurnishingCarpet, RoomFurnishingPainting, RoomFurnishingMirror)},
+		{"gallery", gallery, testItems(RoomFurnishingTapestry, RoomFurnishingTapestry, RoomFurnishingBookcase, RoomFurnishingPainting, RoomFurnishingTorch)},
+		{"dining hall", lShape, testItems(RoomFurnishingTableLg, RoomFurnishingChair, RoomFurnishingChair, RoomFurnishingChair, RoomFurnishingFireplace, RoomFurnishingSpit, RoomFurnishingCupboard, RoomFurnishingLamp)},
+	} {
+		for seed := int64(0); seed < 50; seed++ {
+			g := tc.grid()
+			layout := PlaceItems(g, tc.items, seed)
+			if len(layout.Items)+len(layout.Unplaced) != len(tc.items) {
+				t.Fatalf("%s (seed %d): got %d placed and %d unplaced items, want %d", tc.name, seed, len(layout.Items), len(layout.Unplaced), len(tc.items))
+			}
+			if len(layout.Items) == 0 {
+				t.Errorf("%s (seed %d): no items placed", tc.name, seed)
+			}
+
+			occupied := make(map[image.Point]bool)
+			for _, pi := range layout.Items {
+				rule := pi.placementRule()
+				blocking := rule.Placement != PlaceFloor && rule.Placement != PlaceWallMounted
+				for _, c := range pi.Cells() {
+					c = c.Sub(g.Origin)
+					if !g.isFloor(c.X, c.Y) {
+						t.Errorf("%s (seed %d): %s is outside the room at %v", tc.name, seed, pi.Name(), c)
+					}
+					if !blocking {
+						continue
+					}
+					if occupied[c] {
+						t.Errorf("%s (seed %d): %s overlaps another item at %v", tc.name, seed, pi.Name(), c)
+					}
+					occupied[c] = true
+					for _, d := range g.Doors {
+						if c.Sub(d) == image.Pt(0, 1) || c.Sub(d) == image.Pt(0, -1) || c.Sub(d) == image.Pt(1, 0) || c.Sub(d) == image.Pt(-1, 0) {
+							t.Errorf("%s (seed %d): %s blocks the door at %v", tc.name, seed, pi.Name(), d)
+						}
+					}
+				}
+
+				// Wall items are against a wall, and tall and wall mounted
+				// items don't cover windows.
+				if rule.Placement != PlaceWall && rule.Placement != PlaceWallMounted {
+					continue
+				}
+				for _, b := range backCells(pi, g.Origin) {
+					if g.isFloor(b.X, b.Y) || containsPoint(g.Doors, b) {
+						t.Errorf("%s (seed %d): %s is not against a wall", tc.name, seed, pi.Name())
+					}
+					if (rule.Tall || rule.Placement == PlaceWallMounted) && containsPoint(g.Windows, b) {
+						t.Errorf("%s (seed %d): %s covers the window at %v", tc.name, seed, pi.Name(), b)
+					}
+				}
+			}
+
+			// All free cells are connected.
+			var free []image.Point
+			for y := 0; y < g.Height; y++ {
+				for x := 0; x < g.Width; x++ {
+					if g.isFloor(x, y) && !occupied[image.Pt(x, y)] {
+						free = append(free, image.Pt(x, y))
+					}
+				}
+			}
+			seen := map[image.Point]bool{free[0]: true}
+			stack := []image.Point{free[0]}
+			for len(stack) > 0 {
+				q := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				for _, f := range facings {
+					n := q.Add(f)
+					if g.isFloor(n.X, n.Y) && !occupied[n] && !seen[n] {
+						seen[n] = true
+						stack = append(stack, n)
+					}
+				}
+			}
+			if len(seen) != len(free) {
+				t.Errorf("%s (seed %d): %d of %d free cells are reachable", tc.name, seed, len(seen), len(free))
+			}
+		}
+	}
+}
+
+func TestPlaceItemsNear(t *testing.T) {
+	g := NewRoomGrid(8, 6)
+	for seed := int64(0); seed < 20; seed++ {
+		layout := PlaceItems(g, testItems(RoomFurnishingChair, RoomFurnishingTableLg, RoomFurnishingChair), seed)
+		if len(layout.Unplaced) > 0 {
+			t.Fatalf("seed %d: got %d unplaced items", seed, len(layout.Unplaced))
+		}
+		var table *PlacedItem
+		for _, pi := range layout.Items {
+			if pi.ID == RoomFurnishingTableLg {
+				table = pi
+			}
+		}
+		for _, pi := range layout.Items {
+			if pi.ID != RoomFurnishingChair {
+				continue
+			}
+			// The chairs face the table.
+			f := facings[pi.Rotation/90]
+			if !overlapsCells(table, image.Point{}, pi.Pos.X+f.X, pi.Pos.Y+f.Y, pi.Width, pi.Height) {
+				t.Errorf("seed %d: chair at %v facing %d is not at the table at %v", seed, pi.Pos, pi.Rotation, table.Pos)
+			}
+		}
+	}
+}
+
+func TestNewRoomGridFromPolygon(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		polygon    []vectors.Vec2
+		wantOrigin image.Point
+		wantWidth  int
+		wantHeight int
+		wantFloor  int
+	}{
+		{"nil", nil, image.Point{}, 0, 0, 0},
+		{"line", []vectors.Vec2{{X: 0, Y: 0}, {X: 2, Y: 0}}, image.Point{}, 0, 0, 0},
+		{"square", []vectors.Vec2{{X: 2, Y: 3}, {X: 5, Y: 3}, {X: 5, Y: 6}, {X: 2, Y: 6}}, image.Pt(2, 3), 3, 3, 9},
+		{"triangle", []vectors.Vec2{{X: 0, Y: 0}, {X: 4, Y: 0}, {X: 0, Y: 4}}, image.Point{}, 4, 4, 6},
+	} {
+		g := NewRoomGridFromPolygon(tc.polygon)
+		var floor int
+		for y := range g.Floor {
+			for x := range g.Floor[y] {
+				if g.Floor[y][x] {
+					floor++
+				}
+			}
+		}
+		if g.Origin != tc.wantOrigin || g.Width != tc.wantWidth || g.Height != tc.wantHeight || floor != tc.wantFloor {
+			t.Errorf("%s: got %dx%d grid at %v with %d floor cells, want %dx%d at %v with %d", tc.name, g.Width, g.Height, g.Origin, floor, tc.wantWidth, tc.wantHeight, tc.wantOrigin, tc.wantFloor)
+		}
+
+		// Placing items in an empty grid doesn't fail.
+		if tc.wantFloor == 0 {
+			if layout := PlaceItems(g, testItems(RoomFurnishingBed), 1); len(layout.Unplaced) != 1 {
+				t.Errorf("%s: got %d unplaced items, want 1", tc.name, len(layout.Unplaced))
+			}
+		}
+	}
+}
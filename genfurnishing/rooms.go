@@ -23,16 +23,21 @@ func NewRoomConfig(name string, required, possible []string) *RoomConfig {
 
 // Generate a room from the config.
 func (rc *RoomConfig) Generate() *Room {
+	return rc.generate(furnishingIndex)
+}
+
+// generate a room from the config using the given items (by ID).
+func (rc *RoomConfig) generate(index map[string]*ItemBase) *Room {
 	var items []*Item
 
 	// Generate all required items.
 	for _, item := range rc.Required {
-		items = append(items, furnishingIndex[item].Generate())
+		items = append(items, index[item].Generate())
 	}
 
 	// Generate random items.
 	for _, item := range rc.Possible {
-		if itemProto := furnishingIndex[item]; itemProto.Rarity.Roll() {
+		if itemProto := index[item]; itemProto.Rarity.Roll() {
 			items = append(items, itemProto.Generate())
 		}
 	}
@@ -0,0 +1,432 @@
+package gengeometry
+
+import (
+	"math"
+	"sort"
+
+	"github.com/Flokey82/go_gens/vectors"
+)
+
+// BoolOp is a boolean operation on polygons.
+type BoolOp int
+
+// The supported boolean operations.
+const (
+	OpUnion        BoolOp = iota // Area covered by A or B.
+	OpIntersection               // Area covered by A and B.
+	OpDifference                 // Area covered by A but not by B.
+	OpXor                        // Area covered by either A or B, but not both.
+)
+
+// eval returns true if a point with the given coverage is part of the result.
+func (op BoolOp) eval(inA, inB bool) bool {
+	switch op {
+	case OpUnion:
+		return inA || inB
+	case OpIntersection:
+		return inA && inB
+	case OpDifference:
+		return inA && !inB
+	case OpXor:
+		return inA != inB
+	}
+	return false
+}
+
+// Union returns the union of the polygons in a and b.
+func Union(a, b []Polygon) []Polygon {
+	return Clip(a, b, OpUnion)
+}
+
+// Intersection returns the intersection of the polygons in a and b.
+func Intersection(a, b []Polygon) []Polygon {
+	return Clip(a, b, OpIntersection)
+}
+
+// Difference returns the polygons in a minus the polygons in b.
+func Difference(a, b []Polygon) []Polygon {
+	return Clip(a, b, OpDifference)
+}
+
+// Xor returns the area covered by exactly one of a and b.
+func Xor(a, b []Polygon) []Polygon {
+	return Clip(a, b, OpXor)
+}
+
+// Clip applies the given boolean operation to the polygons in a and b and
+// returns the resulting polygons (with holes).
+//
+// Each set of polygons may contain overlapping and self-intersecting
+// polygons, their union is used. The orientation of the input rings doesn't
+// matter. The outer rings of the result are counter-clockwise, the holes are
+// clockwise.
+//
+// The algorithm splits all edges at their intersections and keeps the edges
+// that separate the result from the rest, which handles shared edges and
+// vertices gracefully.
+func Clip(a, b []Polygon, op BoolOp) []Polygon {
+	return assemblePolygons(clipRings(polygonRings(a), polygonRings(b), op))
+}
+
+// polygonRings returns the rings of the given polygons, with the outer rings
+// in counter-clockwise and the holes in clockwise order.
+func polygonRings(polys []Polygon) [][]vectors.Vec2 {
+	var rings [][]vectors.Vec2
+	add := func(r []vectors.Vec2, ccw bool) {
+		if len(r) < 3 {
+			return
+		}
+		area := polygonOrder(r)
+		if area == 0 {
+			return
+		}
+		if (area > 0) != ccw {
+			r = reversedPath(r)
+		}
+		rings = append(rings, r)
+	}
+	for _, p := range polys {
+		add(p.Points, true)
+		for _, h := range p.Holes {
+			add(h, false)
+		}
+	}
+	return rings
+}
+
+// reversedPath returns a reversed copy of the given path.
+func reversedPath(path []vectors.Vec2) []vectors.Vec2 {
+	res := make([]vectors.Vec2, len(path))
+	for i, p := range path {
+		res[len(path)-1-i] = p
+	}
+	return res
+}
+
+// windingNumber returns the winding number of the given rings around the
+// given point.
+func windingNumber(rings [][]vectors.Vec2, p vectors.Vec2) int {
+	var wn int
+	for _, r := range rings {
+		for i := range r {
+			a, b := r[i], r[(i+1)%len(r)]
+			side := (b.X-a.X)*(p.Y-a.Y) - (p.X-a.X)*(b.Y-a.Y)
+			if a.Y <= p.Y {
+				if b.Y > p.Y && side > 0 {
+					wn++
+				}
+			} else if b.Y <= p.Y && side < 0 {
+				wn--
+			}
+		}
+	}
+	return wn
+}
+
+// pointSnapper merges points that are closer than the tolerance.
+type pointSnapper struct {
+	tol    float64
+	points []vectors.Vec2
+	grid   map[[2]int64][]int
+}
+
+func newPointSnapper(tol float64) *pointSnapper {
+	return &pointSnapper{
+		tol:  tol,
+		grid: make(map[[2]int64][]int),
+	}
+}
+
+// snap returns the index of the given point (or a previous point close to it).
+func (s *pointSnapper) snap(p vectors.Vec2) int {
+	cx, cy := int64(math.Floor(p.X/s.tol)), int64(math.Floor(p.Y/s.tol))
+	for dx := int64(-1); dx <= 1; dx++ {
+		for dy := int64(-1); dy <= 1; dy++ {
+			for _, idx := range s.grid[[2]int64{cx + dx, cy + dy}] {
+				if q := s.points[idx]; math.Abs(q.X-p.X) <= s.tol && math.Abs(q.Y-p.Y) <= s.tol {
+					return idx
+				}
+			}
+		}
+	}
+	s.points = append(s.points, p)
+	s.grid[[2]int64{cx, cy}] = append(s.grid[[2]int64{cx, cy}], len(s.points)-1)
+	return len(s.points) - 1
+}
+
+// ringsTolerance returns the tolerance used for snapping points of the given
+// rings (relative to their extent).
+func ringsTolerance(rings ...[][]vectors.Vec2) float64 {
+	extent := 1.0
+	for _, rs := range rings {
+		for _, r := range rs {
+			for _, p := range r {
+				extent = math.Max(extent, math.Max(math.Abs(p.X), math.Abs(p.Y)))
+			}
+		}
+	}
+	return extent * 1e-9
+}
+
+// clipRings applies the boolean operation to the given rings. A point is
+// considered inside a set of rings if their winding number is positive.
+// The resulting rings are counter-clockwise for outer rings and clockwise
+// for holes.
+func clipRings(a, b [][]vectors.Vec2, op BoolOp) [][]vectors.Vec2 {
+	tol := ringsTolerance(a, b)
+
+	// Collect all edges.
+	var segs []vectors.Segment
+	for _, rs := range [][][]vectors.Vec2{a, b} {
+		for _, r := range rs {
+			for i := range r {
+				s := vectors.Segment{Start: r[i], End: r[(i+1)%len(r)]}
+				if s.Len() > tol {
+					segs = append(segs, s)
+				}
+			}
+		}
+	}
+
+	// Split the edges at all intersections with other edges.
+	splits := make([][]float64, len(segs))
+	for i := range segs {
+		for j := i + 1; j < len(segs); j++ {
+			ti, tj := segmentIntersections(segs[i], segs[j], tol)
+			splits[i] = append(splits[i], ti...)
+			splits[j] = append(splits[j], tj...)
+		}
+	}
+
+	// Build the list of unique (undirected) sub-edges.
+	snapper := newPointSnapper(tol)
+	type edgeKey struct{ a, b int }
+	seen := make(map[edgeKey]bool)
+	var edges [][2]int
+	for i, s := range segs {
+		ts := append([]float64{0, 1}, splits[i]...)
+		sort.Float64s(ts)
+		prev := snapper.snap(s.Start)
+		for _, t := range ts[1:] {
+			var idx int
+			if t >= 1 {
+				idx = snapper.snap(s.End)
+			} else {
+				idx = snapper.snap(vectors.Vec2{
+					X: s.Start.X + (s.End.X-s.Start.X)*t,
+					Y: s.Start.Y + (s.End.Y-s.Start.Y)*t,
+				})
+			}
+			if idx == prev {
+				continue
+			}
+			k := edgeKey{prev, idx}
+			if k.a > k.b {
+				k.a, k.b = k.b, k.a
+			}
+			if !seen[k] {
+				seen[k] = true
+				edges = append(edges, [2]int{prev, idx})
+			}
+			prev = idx
+		}
+	}
+
+	// Keep the edges that separate the result from the rest, oriented so
+	// that the result is on the left.
+	pts := snapper.points
+	inside := func(p vectors.Vec2) bool {
+		return op.eval(windingNumber(a, p) > 0, windingNumber(b, p) > 0)
+	}
+	var kept [][2]int
+	for _, e := range edges {
+		p0, p1 := pts[e[0]], pts[e[1]]
+		d := vectors.Sub2(p1, p0)
+		l := d.Len()
+		mid := vectors.Vec2{X: (p0.X + p1.X) / 2, Y: (p0.Y + p1.Y) / 2}
+
+		// Sample the coverage slightly left and right of the edge.
+		off := math.Max(l*1e-5, tol*10)
+		n := vectors.Vec2{X: -d.Y / l * off, Y: d.X / l * off}
+		left, right := inside(mid.Add(n)), inside(mid.Sub(n))
+		if left == right {
+			continue
+		}
+		if left {
+			kept = append(kept, e)
+		} else {
+			kept = append(kept, [2]int{e[1], e[0]})
+		}
+	}
+	return linkEdges(pts, kept, tol)
+}
+
+// segmentIntersections returns the parameters (0-1) at which the segments
+// a and b need to be split because they intersect or overlap.
+func segmentIntersections(a, b vectors.Segment, tol float64) (ta, tb []float64) {
+	da := vectors.Sub2(a.End, a.Start)
+	db := vectors.Sub2(b.End, b.Start)
+	la, lb := da.Len(), db.Len()
+	den := vectors.Cross2(da, db)
+
+	// param returns the parameter of the projection of p onto s and its
+	// distance to the line through s.
+	param := func(s vectors.Segment, d vectors.Vec2, l float64, p vectors.Vec2) (float64, float64) {
+		v := vectors.Sub2(p, s.Start)
+		return vectors.Dot2(v, d) / (l * l), math.Abs(vectors.Cross2(d, v)) / l
+	}
+	inner := func(t, l float64) bool {
+		return t*l > tol && (1-t)*l > tol
+	}
+
+	if math.Abs(den) <= tol*(la+lb) {
+		// Parallel, split at the endpoints of the other segment if collinear.
+		for _, p := range []vectors.Vec2{b.Start, b.End} {
+			if t, dist := param(a, da, la, p); dist <= tol && inner(t, la) {
+				ta = append(ta, t)
+			}
+		}
+		for _, p := range []vectors.Vec2{a.Start, a.End} {
+			if t, dist := param(b, db, lb, p); dist <= tol && inner(t, lb) {
+				tb = append(tb, t)
+			}
+		}
+		return ta, tb
+	}
+
+	// Proper intersection (or touching).
+	ab := vectors.Sub2(b.Start, a.Start)
+	t := vectors.Cross2(ab, db) / den
+	u := vectors.Cross2(ab, da) / den
+	if t*la < -tol || (1-t)*la < -tol || u*lb < -tol || (1-u)*lb < -tol {
+		return nil, nil
+	}
+	if inner(t, la) {
+		ta = append(ta, t)
+	}
+	if inner(u, lb) {
+		tb = append(tb, u)
+	}
+	return ta, tb
+}
+
+// linkEdges links the given directed edges into closed rings. At vertices
+// with multiple outgoing edges, the edge with the sharpest turn to the
+// right is taken, which keeps touching rings separate.
+func linkEdges(pts []vectors.Vec2, edges [][2]int, tol float64) [][]vectors.Vec2 {
+	outgoing := make(map[int][]int)
+	for i, e := range edges {
+		outgoing[e[0]] = append(outgoing[e[0]], i)
+	}
+	used := make([]bool, len(edges))
+
+	var rings [][]vectors.Vec2
+	for start := range edges {
+		if used[start] {
+			continue
+		}
+		var ring []int
+		cur := start
+		closed := false
+		for !used[cur] {
+			used[cur] = true
+			e := edges[cur]
+			ring = append(ring, e[0])
+			if e[1] == edges[start][0] {
+				closed = true
+				break
+			}
+
+			// Pick the next edge with the smallest clockwise angle from
+			// the reverse direction of the current edge.
+			back := vectors.Sub2(pts[e[0]], pts[e[1]])
+			next, best := -1, math.Inf(1)
+			for _, c := range outgoing[e[1]] {
+				if used[c] {
+					continue
+				}
+				dir := vectors.Sub2(pts[edges[c][1]], pts[e[1]])
+				a := math.Atan2(vectors.Cross2(dir, back), vectors.Dot2(dir, back))
+				if a <= 0 {
+					a += 2 * math.Pi
+				}
+				if a < best {
+					next, best = c, a
+				}
+			}
+			if next < 0 {
+				break
+			}
+			cur = next
+		}
+		if !closed {
+			continue
+		}
+		r := make([]vectors.Vec2, len(ring))
+		for i, idx := range ring {
+			r[i] = pts[idx]
+		}
+		if r = removeCollinear(r, tol); len(r) >= 3 && math.Abs(polygonOrder(r)) > tol {
+			rings = append(rings, r)
+		}
+	}
+	return rings
+}
+
+// removeCollinear removes points of a ring that lie on the line between
+// their neighbors.
+func removeCollinear(r []vectors.Vec2, tol float64) []vectors.Vec2 {
+	for changed := true; changed && len(r) >= 3; {
+		changed = false
+		for i := 0; i < len(r); i++ {
+			p0, p1, p2 := r[(i+len(r)-1)%len(r)], r[i], r[(i+1)%len(r)]
+			d := vectors.Sub2(p2, p0)
+			l := d.Len()
+			if l <= tol || math.Abs(vectors.Cross2(d, vectors.Sub2(p1, p0)))/l <= tol {
+				if vectors.Dot2(vectors.Sub2(p1, p0), vectors.Sub2(p2, p1)) >= 0 || l <= tol {
+					r = append(r[:i], r[i+1:]...)
+					changed = true
+					i--
+				}
+			}
+		}
+	}
+	return r
+}
+
+// assemblePolygons assigns the holes (clockwise rings) to the smallest outer
+// ring (counter-clockwise) containing them.
+func assemblePolygons(rings [][]vectors.Vec2) []Polygon {
+	var polys []Polygon
+	var areas []float64
+	var holes [][]vectors.Vec2
+	for _, r := range rings {
+		if a := polygonOrder(r); a > 0 {
+			polys = append(polys, Polygon{Points: r})
+			areas = append(areas, a)
+		} else {
+			holes = append(holes, r)
+		}
+	}
+	for _, h := range holes {
+		// Use a point right next to the first edge of the hole, which is
+		// inside the surrounding polygon (but not on its boundary).
+		d := vectors.Sub2(h[1], h[0])
+		l := d.Len()
+		off := l * 1e-5
+		p := vectors.Vec2{
+			X: (h[0].X+h[1].X)/2 - d.Y/l*off,
+			Y: (h[0].Y+h[1].Y)/2 + d.X/l*off,
+		}
+		best := -1
+		for i, poly := range polys {
+			if windingNumber([][]vectors.Vec2{poly.Points}, p) > 0 && (best < 0 || areas[i] < areas[best]) {
+				best = i
+			}
+		}
+		if best >= 0 {
+			polys[best].Holes = append(polys[best].Holes, h)
+		}
+	}
+	return polys
+}
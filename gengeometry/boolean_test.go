@@ -0,0 +1,57 @@
+package gengeometry
+
+import (
+	"math"
+	"testing"
+
+	"github.com/Flokey82/go_gens/vectors"
+)
+
+// square returns a counter-clockwise square polygon with the given corner
+// and size.
+func square(x, y, size float64) Polygon {
+	return Polygon{Points: []vectors.Vec2{
+		{X: x, Y: y},
+		{X: x + size, Y: y},
+		{X: x + size, Y: y + size},
+		{X: x, Y: y + size},
+	}}
+}
+
+// totalArea returns the summed area of the given polygons.
+func totalArea(polys []Polygon) float64 {
+	var area float64
+	for _, p := range polys {
+		area += p.Area()
+	}
+	return area
+}
+
+func TestClip(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		a, b      []Polygon
+		op        BoolOp
+		wantPolys int
+		wantHoles int
+		wantArea  float64
+	}{
+		{"union of overlapping squares", []Polygon{square(0, 0, 2)}, []Polygon{square(1, 1, 2)}, OpUnion, 1, 0, 7},
+		{"union of disjoint squares", []Polygon{square(0, 0, 1)}, []Polygon{square(2, 0, 1)}, OpUnion, 2, 0, 2},
+		{"intersection of overlapping squares", []Polygon{square(0, 0, 2)}, []Polygon{square(1, 1, 2)}, OpIntersection, 1, 0, 1},
+		{"difference with a hole", []Polygon{square(0, 0, 3)}, []Polygon{square(1, 1, 1)}, OpDifference, 1, 1, 8},
+		{"xor of overlapping squares", []Polygon{square(0, 0, 2)}, []Polygon{square(1, 1, 2)}, OpXor, 2, 0, 6},
+	} {
+		res := Clip(tc.a, tc.b, tc.op)
+		var holes int
+		for _, p := range res {
+			holes += len(p.Holes)
+		}
+		if len(res) != tc.wantPolys || holes != tc.wantHoles {
+			t.Errorf("%s: got %d polygons with %d holes, want %d with %d holes", tc.name, len(res), holes, tc.wantPolys, tc.wantHoles)
+		}
+		if got := totalArea(res); math.Abs(got-tc.wantArea) > 1e-9 {
+			t.Errorf("%s: got area %f, want %f", tc.name, got, tc.wantArea)
+		}
+	}
+}
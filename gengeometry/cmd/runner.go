@@ -5,6 +5,7 @@ import (
 	"image/color"
 	"image/png"
 	"log"
+	"math"
 	"os"
 
 	"github.com/Flokey82/go_gens/gengeometry"
@@ -99,7 +100,11 @@ func main() {
 	if err != nil {
 		log.Fatal(err)
 	}
-	roofMesh, err := gengeometry.TaperPath(path, 0.2)
+	roofMesh, err := gengeometry.GenerateRoof(path, gengeometry.RoofParams{
+		Type:     gengeometry.RoofGable,
+		Pitch:    math.Pi / 5,
+		Overhang: 0.03,
+	})
 	if err != nil {
 		log.Println(err)
 	} else {
@@ -129,7 +134,9 @@ func main() {
 	mesh.AddMesh(wingMesh, vectors.NewVec3(1, 0, 0.0))
 
 	// Save the mesh to a file.
-	mesh.ExportToObj("test.obj")
+	if err := mesh.ExportToObj("test.obj"); err != nil {
+		log.Fatal(err)
+	}
 
 	// Generate a squircle.
 	squirclePath := gengeometry.SquircleShape{
@@ -0,0 +1,326 @@
+package gengeometry
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Flokey82/go_gens/vectors"
+)
+
+// ExportToObj exports the mesh to a .obj file.
+//
+// If any of the groups has a material, the materials are written to a .mtl
+// file with the same name.
+// NOTE: Vertex colors are written as additional values of the vertices,
+// which is an extension that is supported by most importers.
+// NOTE: I switched Y and Z since importing into Blender would have the Y axis
+// as the up axis.
+func (m *Mesh) ExportToObj(filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	wr := bufio.NewWriter(f)
+
+	// Write the materials (if any).
+	if mats := m.materials(); len(mats) > 0 {
+		mtlName := strings.TrimSuffix(filename, filepath.Ext(filename)) + ".mtl"
+		if err := exportMTL(mtlName, mats); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(wr, "mtllib %s\n", filepath.Base(mtlName)); err != nil {
+			return err
+		}
+	}
+
+	// Write the vertices.
+	hasColors := m.HasColors()
+	for i, v := range m.Vertices {
+		if hasColors {
+			c := m.Colors[i]
+			_, err = fmt.Fprintf(wr, "v %f %f %f %f %f %f\n", v.X, v.Z, v.Y, c.X, c.Y, c.Z)
+		} else {
+			_, err = fmt.Fprintf(wr, "v %f %f %f\n", v.X, v.Z, v.Y)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	// Write the texture coordinates.
+	hasUVs := m.HasUVs()
+	if hasUVs {
+		for _, uv := range m.UVs {
+			if _, err := fmt.Fprintf(wr, "vt %f %f\n", uv.X, uv.Y); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Write the normals.
+	hasNormals := m.HasNormals()
+	if hasNormals {
+		for _, n := range m.Normals {
+			if _, err := fmt.Fprintf(wr, "vn %f %f %f\n", n.X, n.Z, n.Y); err != nil {
+				return err
+			}
+		}
+	}
+
+	// vertex returns the face vertex for the given index.
+	vertex := func(idx int) string {
+		idx++
+		switch {
+		case hasUVs && hasNormals:
+			return fmt.Sprintf("%d/%d/%d", idx, idx, idx)
+		case hasUVs:
+			return fmt.Sprintf("%d/%d", idx, idx)
+		case hasNormals:
+			return fmt.Sprintf("%d//%d", idx, idx)
+		}
+		return fmt.Sprintf("%d", idx)
+	}
+
+	// Write the triangles grouped by group.
+	for _, g := range m.trianglesByGroup() {
+		if g.group != nil {
+			if _, err := fmt.Fprintf(wr, "g %s\n", objName(g.group.Name)); err != nil {
+				return err
+			}
+			if g.group.Material != nil {
+				if _, err := fmt.Fprintf(wr, "usemtl %s\n", objName(g.group.Material.Name)); err != nil {
+					return err
+				}
+			}
+		}
+		for _, t := range g.triangles {
+			// NOTE: Since Y and Z are switched, the winding order needs to be reversed.
+			a, b, c := m.Triangles[t*3], m.Triangles[t*3+1], m.Triangles[t*3+2]
+			if _, err := fmt.Fprintf(wr, "f %s %s %s\n", vertex(a), vertex(c), vertex(b)); err != nil {
+				return err
+			}
+		}
+	}
+	return wr.Flush()
+}
+
+// objName replaces whitespace in names, which is not allowed in OBJ and MTL files.
+func objName(name string) string {
+	if name == "" {
+		return "default"
+	}
+	return strings.Join(strings.Fields(name), "_")
+}
+
+// exportMTL writes the given materials to a .mtl file.
+func exportMTL(filename string, mats []*Material) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	wr := bufio.NewWriter(f)
+	for _, mat := range mats {
+		c := mat.Diffuse
+		if _, err := fmt.Fprintf(wr, "newmtl %s\nKd %f %f %f\n", objName(mat.Name), c.X, c.Y, c.Z); err != nil {
+			return err
+		}
+		if mat.Texture != "" {
+			if _, err := fmt.Fprintf(wr, "map_Kd %s\n", mat.Texture); err != nil {
+				return err
+			}
+		}
+	}
+	return wr.Flush()
+}
+
+// materials returns the distinct materials used by the groups of the mesh.
+// NOTE: Materials are identified by their (OBJ) name, so if different
+// materials share the same name, only the first one is used.
+func (m *Mesh) materials() []*Material {
+	var mats []*Material
+	seen := make(map[string]bool)
+	for _, g := range m.Groups {
+		if g.Material == nil {
+			continue
+		}
+		if name := objName(g.Material.Name); !seen[name] {
+			seen[name] = true
+			mats = append(mats, g.Material)
+		}
+	}
+	return mats
+}
+
+// triangleGroup is a list of triangles belonging to the same group.
+type triangleGroup struct {
+	group     *Group // nil for triangles without group.
+	triangles []int
+}
+
+// trianglesByGroup returns the (non empty) lists of triangles of each group,
+// starting with the triangles without group.
+func (m *Mesh) trianglesByGroup() []triangleGroup {
+	groups := make([]triangleGroup, len(m.Groups)+1)
+	for i := range m.Groups {
+		groups[i+1].group = &m.Groups[i]
+	}
+	for t := 0; t < m.NumTriangles(); t++ {
+		g := m.TriangleGroup(t) + 1
+		groups[g].triangles = append(groups[g].triangles, t)
+	}
+	var res []triangleGroup
+	for _, g := range groups {
+		if len(g.triangles) > 0 {
+			res = append(res, g)
+		}
+	}
+	return res
+}
+
+// ExportToStl exports the mesh to a binary .stl file.
+// NOTE: STL files only contain triangles and their normals, so all other
+// attributes are lost. Unlike the OBJ export, the Z axis is kept as up axis
+// since this is the convention for STL files.
+func (m *Mesh) ExportToStl(filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	wr := bufio.NewWriter(f)
+
+	// Write the header (80 bytes) and the number of triangles.
+	var header [80]byte
+	copy(header[:], "gengeometry")
+	if _, err := wr.Write(header[:]); err != nil {
+		return err
+	}
+	if err := binary.Write(wr, binary.LittleEndian, uint32(m.NumTriangles())); err != nil {
+		return err
+	}
+
+	// Each triangle consists of the normal, the three vertices and an
+	// attribute byte count.
+	var buf [50]byte
+	putVec3 := func(off int, v vectors.Vec3) {
+		binary.LittleEndian.PutUint32(buf[off:], math.Float32bits(float32(v.X)))
+		binary.LittleEndian.PutUint32(buf[off+4:], math.Float32bits(float32(v.Y)))
+		binary.LittleEndian.PutUint32(buf[off+8:], math.Float32bits(float32(v.Z)))
+	}
+	for t := 0; t < m.NumTriangles(); t++ {
+		n := m.triangleNormal(t)
+		if n.Len() > 0 {
+			n = n.Normalize()
+		}
+		putVec3(0, n)
+		for i := 0; i < 3; i++ {
+			putVec3(12+i*12, m.Vertices[m.Triangles[t*3+i]])
+		}
+		if _, err := wr.Write(buf[:]); err != nil {
+			return err
+		}
+	}
+	return wr.Flush()
+}
+
+// ExportToPly exports the mesh to a binary .ply file including the normals,
+// texture coordinates and colors (if present).
+// NOTE: The Z axis is kept as up axis.
+func (m *Mesh) ExportToPly(filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	wr := bufio.NewWriter(f)
+	hasNormals, hasUVs, hasColors := m.HasNormals(), m.HasUVs(), m.HasColors()
+
+	// Write the header.
+	header := []string{
+		"ply",
+		"format binary_little_endian 1.0",
+		"comment generated by gengeometry",
+		fmt.Sprintf("element vertex %d", len(m.Vertices)),
+		"property float x",
+		"property float y",
+		"property float z",
+	}
+	if hasNormals {
+		header = append(header, "property float nx", "property float ny", "property float nz")
+	}
+	if hasUVs {
+		header = append(header, "property float s", "property float t")
+	}
+	if hasColors {
+		header = append(header, "property uchar red", "property uchar green", "property uchar blue")
+	}
+	header = append(header,
+		fmt.Sprintf("element face %d", m.NumTriangles()),
+		"property list uchar int vertex_indices",
+		"end_header",
+	)
+	if _, err := wr.WriteString(strings.Join(header, "\n") + "\n"); err != nil {
+		return err
+	}
+
+	// Write the vertices.
+	var buf []byte
+	putFloats := func(vals ...float64) {
+		for _, v := range vals {
+			buf = appendUint32(buf, math.Float32bits(float32(v)))
+		}
+	}
+	for i, v := range m.Vertices {
+		buf = buf[:0]
+		putFloats(v.X, v.Y, v.Z)
+		if hasNormals {
+			n := m.Normals[i]
+			putFloats(n.X, n.Y, n.Z)
+		}
+		if hasUVs {
+			putFloats(m.UVs[i].X, m.UVs[i].Y)
+		}
+		if hasColors {
+			c := m.Colors[i]
+			buf = append(buf, colorByte(c.X), colorByte(c.Y), colorByte(c.Z))
+		}
+		if _, err := wr.Write(buf); err != nil {
+			return err
+		}
+	}
+
+	// Write the faces.
+	for t := 0; t < m.NumTriangles(); t++ {
+		buf = append(buf[:0], 3)
+		for _, idx := range m.Triangles[t*3 : t*3+3] {
+			buf = appendUint32(buf, uint32(idx))
+		}
+		if _, err := wr.Write(buf); err != nil {
+			return err
+		}
+	}
+	return wr.Flush()
+}
+
+// appendUint32 appends the given value in little endian byte order to buf.
+func appendUint32(buf []byte, v uint32) []byte {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], v)
+	return append(buf, b[:]...)
+}
+
+// colorByte converts a color value from 0.0-1.0 to 0-255.
+func colorByte(v float64) byte {
+	return byte(math.Round(math.Max(0, math.Min(1, v)) * 255))
+}
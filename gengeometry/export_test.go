@@ -0,0 +1,176 @@
+package gengeometry
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Flokey82/go_gens/vectors"
+)
+
+// testQuad returns a unit quad in the XY plane (facing up) with normals,
+// UVs and colors, where each triangle is in a separate group. Both groups
+// use different materials with the same name.
+func testQuad() *Mesh {
+	m := &Mesh{
+		Vertices:  []vectors.Vec3{{X: 0, Y: 0, Z: 0}, {X: 1, Y: 0, Z: 0}, {X: 1, Y: 1, Z: 0}, {X: 0, Y: 1, Z: 0}},
+		Triangles: []int{0, 1, 2, 0, 2, 3},
+		Normals:   []vectors.Vec3{{Z: 1}, {Z: 1}, {Z: 1}, {Z: 1}},
+		UVs:       []vectors.Vec2{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 1, Y: 1}, {X: 0, Y: 1}},
+		Colors:    []vectors.Vec3{{X: 1}, {Y: 1}, {Z: 1}, {X: 1, Y: 1, Z: 1}},
+		Groups: []Group{
+			{Name: "wall", Material: &Material{Name: "brick", Diffuse: vectors.Vec3{X: 0.5}}},
+			{Name: "trim", Material: &Material{Name: "brick", Diffuse: vectors.Vec3{X: 0.5}}},
+		},
+		TriangleGroups: []int{0, 1},
+	}
+	return m
+}
+
+// countPrefix returns the number of lines starting with the given prefix.
+func countPrefix(lines []string, prefix string) int {
+	var n int
+	for _, l := range lines {
+		if strings.HasPrefix(l, prefix) {
+			n++
+		}
+	}
+	return n
+}
+
+func TestExportToObj(t *testing.T) {
+	dir := t.TempDir()
+	if err := testQuad().ExportToObj(filepath.Join(dir, "quad.obj")); err != nil {
+		t.Fatal(err)
+	}
+	obj, err := os.ReadFile(filepath.Join(dir, "quad.obj"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(string(obj), "\n")
+	for _, tc := range []struct {
+		prefix string
+		want   int
+	}{
+		{"mtllib quad.mtl", 1},
+		{"v ", 4},
+		{"vt ", 4},
+		{"vn ", 4},
+		{"g ", 2},
+		{"usemtl brick", 2},
+		{"f ", 2},
+	} {
+		if got := countPrefix(lines, tc.prefix); got != tc.want {
+			t.Errorf("%q: got %d lines, want %d", tc.prefix, got, tc.want)
+		}
+	}
+
+	// Y and Z are switched, so the winding order is reversed.
+	for _, want := range []string{
+		"v 1.000000 0.000000 1.000000 0.000000 0.000000 1.000000",
+		"vn 0.000000 1.000000 0.000000",
+		"f 1/1/1 3/3/3 2/2/2",
+		"f 1/1/1 4/4/4 3/3/3",
+	} {
+		if countPrefix(lines, want) == 0 {
+			t.Errorf("missing line %q", want)
+		}
+	}
+
+	// Materials with the same name are only written once.
+	mtl, err := os.ReadFile(filepath.Join(dir, "quad.mtl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines = strings.Split(string(mtl), "\n")
+	if got := countPrefix(lines, "newmtl "); got != 1 {
+		t.Errorf("newmtl: got %d lines, want 1", got)
+	}
+	if countPrefix(lines, "Kd 0.500000 0.000000 0.000000") != 1 {
+		t.Errorf("missing diffuse color in %q", mtl)
+	}
+}
+
+// readFloat32 returns the little endian float32 at the given offset.
+func readFloat32(data []byte, off int) float64 {
+	return float64(math.Float32frombits(binary.LittleEndian.Uint32(data[off:])))
+}
+
+func TestExportToStl(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quad.stl")
+	if err := testQuad().ExportToStl(path); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 84 + 2*50; len(data) != want {
+		t.Fatalf("got %d bytes, want %d", len(data), want)
+	}
+	if got := binary.LittleEndian.Uint32(data[80:]); got != 2 {
+		t.Errorf("got %d triangles, want 2", got)
+	}
+
+	// Normal and vertices of the second triangle (Z is kept as up axis).
+	off := 84 + 50
+	var got []float64
+	for i := 0; i < 12; i++ {
+		got = append(got, readFloat32(data, off+i*4))
+	}
+	want := []float64{0, 0, 1, 0, 0, 0, 1, 1, 0, 0, 1, 0}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("got %v, want %v", got, want)
+			break
+		}
+	}
+}
+
+func TestExportToPly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quad.ply")
+	if err := testQuad().ExportToPly(path); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx := bytes.Index(data, []byte("end_header\n"))
+	if idx < 0 {
+		t.Fatalf("missing end of header in %q", data)
+	}
+	header := strings.Split(string(data[:idx]), "\n")
+	for _, want := range []string{
+		"format binary_little_endian 1.0",
+		"element vertex 4",
+		"property float nz",
+		"property float t",
+		"property uchar blue",
+		"element face 2",
+		"property list uchar int vertex_indices",
+	} {
+		if countPrefix(header, want) != 1 {
+			t.Errorf("missing header line %q", want)
+		}
+	}
+
+	// Each vertex has a position, normal and UV (8 floats) and a color (3
+	// bytes), each face has a count (1 byte) and three indices.
+	body := data[idx+len("end_header\n"):]
+	const vertexSize = 8*4 + 3
+	if want := 4*vertexSize + 2*(1+3*4); len(body) != want {
+		t.Fatalf("got %d bytes, want %d", len(body), want)
+	}
+	if x, c := readFloat32(body, vertexSize), body[2*vertexSize-2]; x != 1 || c != 255 {
+		t.Errorf("second vertex: got x %f and green %d, want 1 and 255", x, c)
+	}
+	face := body[4*vertexSize+13:]
+	if face[0] != 3 || binary.LittleEndian.Uint32(face[1:]) != 0 || binary.LittleEndian.Uint32(face[5:]) != 2 || binary.LittleEndian.Uint32(face[9:]) != 3 {
+		t.Errorf("got second face %v, want 3 indices 0, 2, 3", face)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"image"
 	"image/color"
 	"log"
+	"math"
 	"sort"
 
 	"github.com/Flokey82/go_gens/vectors"
@@ -55,9 +56,19 @@ func Abs(x float64) float64 {
 // Polygon is a polygon with multiple points.
 type Polygon struct {
 	Points []vectors.Vec2
+	Holes  [][]vectors.Vec2 // Optional holes in the polygon.
 	// SubAreas []Polygon TODO: Populate when splitting a polygon.
 }
 
+// Area returns the area of the polygon (minus the area of the holes).
+func (p Polygon) Area() float64 {
+	area := math.Abs(polygonOrder(p.Points)) / 2
+	for _, h := range p.Holes {
+		area -= math.Abs(polygonOrder(h)) / 2
+	}
+	return area
+}
+
 // DrawToImage draws the polygon to an image.
 func (p Polygon) DrawToImage(img *image.RGBA, color color.RGBA, scale float64) {
 	if len(p.Points) == 0 {
@@ -0,0 +1,322 @@
+package gengeometry
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math"
+	"os"
+
+	"github.com/Flokey82/go_gens/vectors"
+)
+
+// glTF constants.
+const (
+	gltfMagic          = 0x46546C67 // "glTF"
+	gltfChunkJSON      = 0x4E4F534A // "JSON"
+	gltfChunkBIN       = 0x004E4942 // "BIN\x00"
+	gltfFloat          = 5126
+	gltfUnsignedInt    = 5125
+	gltfArrayBuffer    = 34962
+	gltfElementBuffer  = 34963
+	gltfModeTriangles  = 4
+	gltfAccessorVec2   = "VEC2"
+	gltfAccessorVec3   = "VEC3"
+	gltfAccessorScalar = "SCALAR"
+)
+
+type gltfDocument struct {
+	Asset       gltfAsset        `json:"asset"`
+	Scene       int              `json:"scene"`
+	Scenes      []gltfScene      `json:"scenes"`
+	Nodes       []gltfNode       `json:"nodes"`
+	Meshes      []gltfMesh       `json:"meshes"`
+	Materials   []gltfMaterial   `json:"materials,omitempty"`
+	Textures    []gltfTexture    `json:"textures,omitempty"`
+	Images      []gltfImage      `json:"images,omitempty"`
+	Accessors   []gltfAccessor   `json:"accessors"`
+	BufferViews []gltfBufferView `json:"bufferViews"`
+	Buffers     []gltfBuffer     `json:"buffers"`
+}
+
+type gltfAsset struct {
+	Version   string `json:"version"`
+	Generator string `json:"generator,omitempty"`
+}
+
+type gltfScene struct {
+	Nodes []int `json:"nodes"`
+}
+
+type gltfNode struct {
+	Mesh int `json:"mesh"`
+}
+
+type gltfMesh struct {
+	Primitives []gltfPrimitive `json:"primitives"`
+}
+
+type gltfPrimitive struct {
+	Attributes map[string]int `json:"attributes"`
+	Indices    int            `json:"indices"`
+	Material   *int           `json:"material,omitempty"`
+	Mode       int            `json:"mode"`
+}
+
+type gltfMaterial struct {
+	Name                 string  `json:"name,omitempty"`
+	PbrMetallicRoughness gltfPbr `json:"pbrMetallicRoughness"`
+}
+
+type gltfPbr struct {
+	BaseColorFactor  [4]float64        `json:"baseColorFactor"`
+	BaseColorTexture *gltfTextureIndex `json:"baseColorTexture,omitempty"`
+	MetallicFactor   float64           `json:"metallicFactor"`
+	RoughnessFactor  float64           `json:"roughnessFactor"`
+}
+
+type gltfTextureIndex struct {
+	Index int `json:"index"`
+}
+
+type gltfTexture struct {
+	Source int `json:"source"`
+}
+
+type gltfImage struct {
+	URI string `json:"uri"`
+}
+
+type gltfAccessor struct {
+	BufferView    int       `json:"bufferView"`
+	ComponentType int       `json:"componentType"`
+	Count         int       `json:"count"`
+	Type          string    `json:"type"`
+	Min           []float64 `json:"min,omitempty"`
+	Max           []float64 `json:"max,omitempty"`
+}
+
+type gltfBufferView struct {
+	Buffer     int `json:"buffer"`
+	ByteOffset int `json:"byteOffset"`
+	ByteLength int `json:"byteLength"`
+	Target     int `json:"target,omitempty"`
+}
+
+type gltfBuffer struct {
+	ByteLength int `json:"byteLength"`
+}
+
+// gltfBuilder assembles the JSON document and the binary buffer of a glTF file.
+type gltfBuilder struct {
+	doc gltfDocument
+	bin bytes.Buffer
+}
+
+// addView appends the given data to the binary buffer and returns the index
+// of the new buffer view.
+func (b *gltfBuilder) addView(data []byte, target int) int {
+	// Buffer views need to be aligned to 4 bytes.
+	for b.bin.Len()%4 != 0 {
+		b.bin.WriteByte(0)
+	}
+	b.doc.BufferViews = append(b.doc.BufferViews, gltfBufferView{
+		ByteOffset: b.bin.Len(),
+		ByteLength: len(data),
+		Target:     target,
+	})
+	b.bin.Write(data)
+	return len(b.doc.BufferViews) - 1
+}
+
+// addFloats adds an accessor for the given float values and returns its index.
+func (b *gltfBuilder) addFloats(vals []float64, typ string, count int, minMax bool) int {
+	data := make([]byte, 0, len(vals)*4)
+	for _, v := range vals {
+		data = appendUint32(data, math.Float32bits(float32(v)))
+	}
+	acc := gltfAccessor{
+		BufferView:    b.addView(data, gltfArrayBuffer),
+		ComponentType: gltfFloat,
+		Count:         count,
+		Type:          typ,
+	}
+	if minMax && count > 0 {
+		// The bounds are required for positions.
+		n := len(vals) / count
+		acc.Min = make([]float64, n)
+		acc.Max = make([]float64, n)
+		for c := 0; c < n; c++ {
+			acc.Min[c], acc.Max[c] = math.Inf(1), math.Inf(-1)
+			for i := c; i < len(vals); i += n {
+				v := float64(float32(vals[i]))
+				acc.Min[c] = math.Min(acc.Min[c], v)
+				acc.Max[c] = math.Max(acc.Max[c], v)
+			}
+		}
+	}
+	b.doc.Accessors = append(b.doc.Accessors, acc)
+	return len(b.doc.Accessors) - 1
+}
+
+// addIndices adds an accessor for the given vertex indices and returns its index.
+func (b *gltfBuilder) addIndices(indices []int) int {
+	data := make([]byte, 0, len(indices)*4)
+	for _, idx := range indices {
+		data = appendUint32(data, uint32(idx))
+	}
+	b.doc.Accessors = append(b.doc.Accessors, gltfAccessor{
+		BufferView:    b.addView(data, gltfElementBuffer),
+		ComponentType: gltfUnsignedInt,
+		Count:         len(indices),
+		Type:          gltfAccessorScalar,
+	})
+	return len(b.doc.Accessors) - 1
+}
+
+// addMaterial adds the given material and returns its index.
+func (b *gltfBuilder) addMaterial(mat *Material) int {
+	c := mat.Diffuse
+	gm := gltfMaterial{
+		Name: mat.Name,
+		PbrMetallicRoughness: gltfPbr{
+			BaseColorFactor: [4]float64{c.X, c.Y, c.Z, 1},
+			RoughnessFactor: 1,
+		},
+	}
+	if mat.Texture != "" {
+		// The base color is multiplied with the texture, so we use white
+		// to show the texture as is.
+		gm.PbrMetallicRoughness.BaseColorFactor = [4]float64{1, 1, 1, 1}
+		b.doc.Images = append(b.doc.Images, gltfImage{URI: mat.Texture})
+		b.doc.Textures = append(b.doc.Textures, gltfTexture{Source: len(b.doc.Images) - 1})
+		gm.PbrMetallicRoughness.BaseColorTexture = &gltfTextureIndex{Index: len(b.doc.Textures) - 1}
+	}
+	b.doc.Materials = append(b.doc.Materials, gm)
+	return len(b.doc.Materials) - 1
+}
+
+// toGltf converts a vector from mesh space (Z up) to glTF space (Y up).
+func toGltf(v vectors.Vec3) [3]float64 {
+	return [3]float64{v.X, v.Z, -v.Y}
+}
+
+// ExportToGlb exports the mesh to a binary glTF 2.0 (.glb) file.
+//
+// Each group is written as a separate primitive with its material. Textures
+// are referenced by their path and not embedded.
+// NOTE: Since glTF uses Y as up axis, the mesh is rotated accordingly.
+func (m *Mesh) ExportToGlb(filename string) error {
+	if m.NumTriangles() == 0 {
+		return errors.New("mesh has no triangles")
+	}
+	b := &gltfBuilder{
+		doc: gltfDocument{
+			Asset:  gltfAsset{Version: "2.0", Generator: "gengeometry"},
+			Scenes: []gltfScene{{Nodes: []int{0}}},
+			Nodes:  []gltfNode{{Mesh: 0}},
+		},
+	}
+
+	// Add the vertex attributes.
+	n := len(m.Vertices)
+	attrs := make(map[string]int)
+	vals := make([]float64, 0, n*3)
+	for _, v := range m.Vertices {
+		p := toGltf(v)
+		vals = append(vals, p[:]...)
+	}
+	attrs["POSITION"] = b.addFloats(vals, gltfAccessorVec3, n, true)
+	if m.HasNormals() {
+		vals = vals[:0]
+		for _, v := range m.Normals {
+			p := toGltf(v)
+			vals = append(vals, p[:]...)
+		}
+		attrs["NORMAL"] = b.addFloats(vals, gltfAccessorVec3, n, false)
+	}
+	if m.HasUVs() {
+		// NOTE: glTF has the origin of the texture in the top left corner.
+		vals = vals[:0]
+		for _, uv := range m.UVs {
+			vals = append(vals, uv.X, 1-uv.Y)
+		}
+		attrs["TEXCOORD_0"] = b.addFloats(vals, gltfAccessorVec2, n, false)
+	}
+	if m.HasColors() {
+		vals = vals[:0]
+		for _, c := range m.Colors {
+			vals = append(vals, c.X, c.Y, c.Z)
+		}
+		attrs["COLOR_0"] = b.addFloats(vals, gltfAccessorVec3, n, false)
+	}
+
+	// Add a primitive for each group.
+	// NOTE: Like in the OBJ export, materials are identified by their name.
+	matIdx := make(map[string]int)
+	var prims []gltfPrimitive
+	for _, g := range m.trianglesByGroup() {
+		indices := make([]int, 0, len(g.triangles)*3)
+		for _, t := range g.triangles {
+			indices = append(indices, m.Triangles[t*3:t*3+3]...)
+		}
+		prim := gltfPrimitive{
+			Attributes: attrs,
+			Indices:    b.addIndices(indices),
+			Mode:       gltfModeTriangles,
+		}
+		if g.group != nil && g.group.Material != nil {
+			name := objName(g.group.Material.Name)
+			idx, ok := matIdx[name]
+			if !ok {
+				idx = b.addMaterial(g.group.Material)
+				matIdx[name] = idx
+			}
+			prim.Material = &idx
+		}
+		prims = append(prims, prim)
+	}
+	b.doc.Meshes = []gltfMesh{{Primitives: prims}}
+
+	// Pad the binary buffer to 4 bytes.
+	for b.bin.Len()%4 != 0 {
+		b.bin.WriteByte(0)
+	}
+	b.doc.Buffers = []gltfBuffer{{ByteLength: b.bin.Len()}}
+
+	// Encode the JSON chunk and pad it with spaces to 4 bytes.
+	js, err := json.Marshal(b.doc)
+	if err != nil {
+		return err
+	}
+	for len(js)%4 != 0 {
+		js = append(js, ' ')
+	}
+
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	wr := bufio.NewWriter(f)
+	header := []uint32{
+		gltfMagic, 2, uint32(12 + 8 + len(js) + 8 + b.bin.Len()),
+		uint32(len(js)), gltfChunkJSON,
+	}
+	if err := binary.Write(wr, binary.LittleEndian, header); err != nil {
+		return err
+	}
+	if _, err := wr.Write(js); err != nil {
+		return err
+	}
+	if err := binary.Write(wr, binary.LittleEndian, []uint32{uint32(b.bin.Len()), gltfChunkBIN}); err != nil {
+		return err
+	}
+	if _, err := wr.Write(b.bin.Bytes()); err != nil {
+		return err
+	}
+	return wr.Flush()
+}
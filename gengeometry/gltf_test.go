@@ -0,0 +1,87 @@
+package gengeometry
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExportToGlb(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quad.glb")
+	if err := testQuad().ExportToGlb(path); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Check the header and the chunks.
+	le := binary.LittleEndian
+	if le.Uint32(data) != gltfMagic || le.Uint32(data[4:]) != 2 {
+		t.Fatalf("invalid header %v", data[:8])
+	}
+	if got := int(le.Uint32(data[8:])); got != len(data) {
+		t.Errorf("got length %d, want %d", got, len(data))
+	}
+	jsLen := int(le.Uint32(data[12:]))
+	if le.Uint32(data[16:]) != gltfChunkJSON || jsLen%4 != 0 {
+		t.Fatalf("invalid JSON chunk (length %d)", jsLen)
+	}
+	bin := data[20+jsLen:]
+	binLen := int(le.Uint32(bin))
+	if le.Uint32(bin[4:]) != gltfChunkBIN || binLen != len(bin)-8 {
+		t.Fatalf("invalid BIN chunk (length %d of %d)", binLen, len(bin)-8)
+	}
+
+	var doc gltfDocument
+	if err := json.Unmarshal(data[20:20+jsLen], &doc); err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Buffers) != 1 || doc.Buffers[0].ByteLength != binLen {
+		t.Errorf("got buffers %v, want one with length %d", doc.Buffers, binLen)
+	}
+
+	// Each group is a primitive, but materials with the same name are shared.
+	prims := doc.Meshes[0].Primitives
+	if len(prims) != 2 {
+		t.Fatalf("got %d primitives, want 2", len(prims))
+	}
+	if len(doc.Materials) != 1 {
+		t.Errorf("got %d materials, want 1", len(doc.Materials))
+	}
+	for i, p := range prims {
+		if p.Material == nil || *p.Material != 0 {
+			t.Errorf("primitive %d: got material %v, want 0", i, p.Material)
+		}
+		if got := doc.Accessors[p.Indices].Count; got != 3 {
+			t.Errorf("primitive %d: got %d indices, want 3", i, got)
+		}
+		for _, attr := range []string{"POSITION", "NORMAL", "TEXCOORD_0", "COLOR_0"} {
+			if _, ok := p.Attributes[attr]; !ok {
+				t.Errorf("primitive %d: missing attribute %s", i, attr)
+			}
+		}
+	}
+
+	// The positions are rotated so that Y is up.
+	pos := doc.Accessors[prims[0].Attributes["POSITION"]]
+	wantMin, wantMax := []float64{0, 0, -1}, []float64{1, 0, 0}
+	for c := 0; c < 3; c++ {
+		if pos.Min[c] != wantMin[c] || pos.Max[c] != wantMax[c] {
+			t.Errorf("got bounds %v - %v, want %v - %v", pos.Min, pos.Max, wantMin, wantMax)
+			break
+		}
+	}
+	view := doc.BufferViews[pos.BufferView]
+	if view.ByteLength != 4*3*4 {
+		t.Errorf("got %d position bytes, want %d", view.ByteLength, 4*3*4)
+	}
+	// The third vertex (1, 1, 0) becomes (1, 0, -1).
+	p := bin[8+view.ByteOffset+2*12:]
+	if x, y, z := readFloat32(p, 0), readFloat32(p, 4), readFloat32(p, 8); x != 1 || y != 0 || z != -1 {
+		t.Errorf("got third vertex %f %f %f, want 1 0 -1", x, y, z)
+	}
+}
@@ -2,45 +2,96 @@ package gengeometry
 
 import (
 	"errors"
-	"fmt"
-	"log"
-	"os"
+	"math"
 
 	"github.com/Flokey82/go_gens/vectors"
 )
 
-// Mesh represents a 3d mesh that can be exported to a .obj file.
+// Mesh represents a 3d mesh that can be exported to various file formats.
+//
+// All per-vertex attributes (normals, UVs, colors) are optional. They are
+// only considered valid if they have the same length as the vertices.
 type Mesh struct {
-	Vertices  []vectors.Vec3 // Contains the vertices of the mesh.
-	Triangles []int          // Contains the indices of the triangle vertices.
+	Vertices       []vectors.Vec3 // Contains the vertices of the mesh.
+	Triangles      []int          // Contains the indices of the triangle vertices.
+	Normals        []vectors.Vec3 // Contains the (optional) normal of each vertex.
+	UVs            []vectors.Vec2 // Contains the (optional) texture coordinates of each vertex.
+	Colors         []vectors.Vec3 // Contains the (optional) RGB color (0.0-1.0) of each vertex.
+	Groups         []Group        // Contains the (optional) groups of triangles.
+	TriangleGroups []int          // Contains the group of each triangle (-1 if none).
 }
 
-// ExportToObj exports the mesh to a .obj file.
-func (m *Mesh) ExportToObj(filename string) {
-	f, err := os.Create(filename)
-	if err != nil {
-		log.Fatal(err)
-	}
+// Group is a named group of triangles sharing the same material.
+type Group struct {
+	Name     string
+	Material *Material // Material of the group (nil for the default material).
+}
 
-	// Write the vertices.
-	for _, v := range m.Vertices {
-		fmt.Fprintf(f, "v %f %f %f\n", v.X, v.Z, v.Y)
-	}
+// Material describes the appearance of a group of triangles.
+type Material struct {
+	Name    string
+	Diffuse vectors.Vec3 // RGB color (0.0-1.0).
+	Texture string       // Path of the (optional) diffuse texture.
+}
 
-	fmt.Fprintln(f, "")
-	// Write the triangles.
-	for i := 0; i < len(m.Triangles); i += 3 {
-		fmt.Fprintf(f, "f %d %d %d\n", m.Triangles[i]+1, m.Triangles[i+1]+1, m.Triangles[i+2]+1)
+// NumTriangles returns the number of triangles of the mesh.
+func (m *Mesh) NumTriangles() int {
+	return len(m.Triangles) / 3
+}
+
+// HasNormals returns true if the mesh has a normal for each vertex.
+func (m *Mesh) HasNormals() bool {
+	return len(m.Normals) > 0 && len(m.Normals) == len(m.Vertices)
+}
+
+// HasUVs returns true if the mesh has texture coordinates for each vertex.
+func (m *Mesh) HasUVs() bool {
+	return len(m.UVs) > 0 && len(m.UVs) == len(m.Vertices)
+}
+
+// HasColors returns true if the mesh has a color for each vertex.
+func (m *Mesh) HasColors() bool {
+	return len(m.Colors) > 0 && len(m.Colors) == len(m.Vertices)
+}
+
+// HasGroups returns true if the triangles of the mesh are assigned to groups.
+func (m *Mesh) HasGroups() bool {
+	return len(m.Groups) > 0 && len(m.TriangleGroups) == m.NumTriangles()
+}
+
+// TriangleGroup returns the group index of the given triangle (-1 if none).
+func (m *Mesh) TriangleGroup(t int) int {
+	if !m.HasGroups() {
+		return -1
 	}
+	return m.TriangleGroups[t]
+}
 
-	fmt.Fprintln(f, "")
+// SetGroup assigns all triangles of the mesh to a single group with the
+// given name and material.
+func (m *Mesh) SetGroup(name string, mat *Material) {
+	m.Groups = []Group{{Name: name, Material: mat}}
+	m.TriangleGroups = make([]int, m.NumTriangles())
+}
 
-	f.Close()
+// groupIndex returns the index of the given group, adding it if necessary.
+func (m *Mesh) groupIndex(g Group) int {
+	for i, mg := range m.Groups {
+		if mg == g {
+			return i
+		}
+	}
+	m.Groups = append(m.Groups, g)
+	return len(m.Groups) - 1
 }
 
-// AddMesh adds a mesh to the current mesh (at a given vertical offset).
+// AddMesh adds a mesh to the current mesh (at a given offset).
+// NOTE: Normals, UVs and colors are only kept if both meshes have them.
+// Groups are merged, so triangles of both meshes keep their group.
 func (m *Mesh) AddMesh(mesh *Mesh, position vectors.Vec3) {
 	lenVerts := len(m.Vertices)
+	lenTris := m.NumTriangles()
+
 	// Add the vertices.
 	for _, v := range mesh.Vertices {
 		m.Vertices = append(m.Vertices, vectors.Vec3{
@@ -50,6 +101,44 @@ func (m *Mesh) AddMesh(mesh *Mesh, position vectors.Vec3) {
 		})
 	}
 
+	// Add the normals, UVs and colors.
+	// NOTE: An empty mesh can take on the attributes of the added mesh.
+	if len(m.Normals) == lenVerts && len(mesh.Normals) == len(mesh.Vertices) {
+		m.Normals = append(m.Normals, mesh.Normals...)
+	} else {
+		m.Normals = nil
+	}
+	if len(m.UVs) == lenVerts && len(mesh.UVs) == len(mesh.Vertices) {
+		m.UVs = append(m.UVs, mesh.UVs...)
+	} else {
+		m.UVs = nil
+	}
+	if len(m.Colors) == lenVerts && len(mesh.Colors) == len(mesh.Vertices) {
+		m.Colors = append(m.Colors, mesh.Colors...)
+	} else {
+		m.Colors = nil
+	}
+
+	// Merge the groups.
+	if m.HasGroups() || mesh.HasGroups() {
+		groups := make([]int, lenTris, lenTris+mesh.NumTriangles())
+		for t := range groups {
+			groups[t] = m.TriangleGroup(t)
+		}
+		remap := make([]int, len(mesh.Groups))
+		for i, g := range mesh.Groups {
+			remap[i] = m.groupIndex(g)
+		}
+		for t := 0; t < mesh.NumTriangles(); t++ {
+			g := mesh.TriangleGroup(t)
+			if g >= 0 {
+				g = remap[g]
+			}
+			groups = append(groups, g)
+		}
+		m.TriangleGroups = groups
+	}
+
 	// Add the triangles.
 	for _, t := range mesh.Triangles {
 		m.Triangles = append(m.Triangles, t+lenVerts)
@@ -64,6 +153,288 @@ func (m *Mesh) Translate(v vectors.Vec2) {
 	}
 }
 
+// Translate3 translates the mesh by a given vector.
+func (m *Mesh) Translate3(v vectors.Vec3) {
+	for i := range m.Vertices {
+		m.Vertices[i].AddToThis(v)
+	}
+}
+
+// Scale scales the mesh by the given factors along each axis (relative to
+// the origin).
+// NOTE: If the scale mirrors the mesh (an odd number of negative factors),
+// the winding order of the triangles is reversed to keep them facing out.
+func (m *Mesh) Scale(s vectors.Vec3) {
+	for i, v := range m.Vertices {
+		m.Vertices[i] = vectors.NewVec3(v.X*s.X, v.Y*s.Y, v.Z*s.Z)
+	}
+
+	// Normals need to be scaled by the inverse scale.
+	for i, n := range m.Normals {
+		m.Normals[i] = vectors.NewVec3(n.X*s.Y*s.Z, n.Y*s.X*s.Z, n.Z*s.X*s.Y).Normalize()
+	}
+	if s.X*s.Y*s.Z < 0 {
+		m.FlipWinding()
+	}
+}
+
+// Rotate rotates the mesh around the given axis (through the origin) by the
+// given angle (in radians).
+func (m *Mesh) Rotate(axis vectors.Vec3, angle float64) {
+	for i, v := range m.Vertices {
+		m.Vertices[i] = v.Rotate(axis, angle)
+	}
+	for i, n := range m.Normals {
+		m.Normals[i] = n.Rotate(axis, angle)
+	}
+}
+
+// RotateAroundPoint rotates the mesh around the given axis through the given
+// point by the given angle (in radians).
+func (m *Mesh) RotateAroundPoint(point, axis vectors.Vec3, angle float64) {
+	m.Translate3(point.Mul(-1))
+	m.Rotate(axis, angle)
+	m.Translate3(point)
+}
+
+// FlipWinding reverses the winding order of all triangles (and the normals),
+// which turns the mesh inside out.
+func (m *Mesh) FlipWinding() {
+	for i := 0; i+2 < len(m.Triangles); i += 3 {
+		m.Triangles[i+1], m.Triangles[i+2] = m.Triangles[i+2], m.Triangles[i+1]
+	}
+	for i, n := range m.Normals {
+		m.Normals[i] = n.Mul(-1)
+	}
+}
+
+// triangleNormal returns the (non normalized) normal of the given triangle,
+// which has the length of twice the area of the triangle.
+func (m *Mesh) triangleNormal(t int) vectors.Vec3 {
+	a := m.Vertices[m.Triangles[t*3]]
+	b := m.Vertices[m.Triangles[t*3+1]]
+	c := m.Vertices[m.Triangles[t*3+2]]
+	return vectors.Cross3(vectors.Sub3(b, a), vectors.Sub3(c, a))
+}
+
+// ComputeNormals computes smooth vertex normals by averaging the normals of
+// all triangles sharing a vertex (weighted by their area).
+// NOTE: Only triangles sharing the same vertex index are averaged, so you
+// might want to call Weld first.
+func (m *Mesh) ComputeNormals() {
+	m.Normals = make([]vectors.Vec3, len(m.Vertices))
+	for t := 0; t < m.NumTriangles(); t++ {
+		n := m.triangleNormal(t)
+		for _, idx := range m.Triangles[t*3 : t*3+3] {
+			m.Normals[idx].AddToThis(n)
+		}
+	}
+	for i, n := range m.Normals {
+		if n.Len() > 0 {
+			m.Normals[i] = n.Normalize()
+		}
+	}
+}
+
+// ComputeFlatNormals gives each triangle its own vertices with the normal
+// of the triangle, which results in flat shading.
+func (m *Mesh) ComputeFlatNormals() {
+	m.Unweld()
+	m.Normals = make([]vectors.Vec3, len(m.Vertices))
+	for t := 0; t < m.NumTriangles(); t++ {
+		n := m.triangleNormal(t)
+		if n.Len() > 0 {
+			n = n.Normalize()
+		}
+		for _, idx := range m.Triangles[t*3 : t*3+3] {
+			m.Normals[idx] = n
+		}
+	}
+}
+
+// Unweld duplicates the vertices (and their attributes), so that no two
+// triangles share a vertex.
+func (m *Mesh) Unweld() {
+	hasNormals, hasUVs, hasColors := m.HasNormals(), m.HasUVs(), m.HasColors()
+	verts := make([]vectors.Vec3, len(m.Triangles))
+	var normals, colors []vectors.Vec3
+	var uvs []vectors.Vec2
+	for i, idx := range m.Triangles {
+		verts[i] = m.Vertices[idx]
+		if hasNormals {
+			normals = append(normals, m.Normals[idx])
+		}
+		if hasUVs {
+			uvs = append(uvs, m.UVs[idx])
+		}
+		if hasColors {
+			colors = append(colors, m.Colors[idx])
+		}
+		m.Triangles[i] = i
+	}
+	m.Vertices, m.Normals, m.UVs, m.Colors = verts, normals, uvs, colors
+}
+
+// Weld merges vertices that are closer than the given distance and have the
+// same normals, UVs and colors (within the same tolerance). Triangles that
+// become degenerate are removed.
+func (m *Mesh) Weld(epsilon float64) {
+	if epsilon <= 0 {
+		epsilon = 1e-9
+	}
+	hasNormals, hasUVs, hasColors := m.HasNormals(), m.HasUVs(), m.HasColors()
+	near := func(a, b vectors.Vec3) bool {
+		return math.Abs(a.X-b.X) <= epsilon && math.Abs(a.Y-b.Y) <= epsilon && math.Abs(a.Z-b.Z) <= epsilon
+	}
+	same := func(i, j int) bool {
+		if !near(m.Vertices[i], m.Vertices[j]) {
+			return false
+		}
+		if hasNormals && !near(m.Normals[i], m.Normals[j]) {
+			return false
+		}
+		if hasUVs && (math.Abs(m.UVs[i].X-m.UVs[j].X) > epsilon || math.Abs(m.UVs[i].Y-m.UVs[j].Y) > epsilon) {
+			return false
+		}
+		return !hasColors || near(m.Colors[i], m.Colors[j])
+	}
+
+	// Sort the vertices into a grid with the cell size of epsilon, so we only
+	// need to compare vertices in neighboring cells.
+	cell := func(v vectors.Vec3) [3]int64 {
+		return [3]int64{
+			int64(math.Floor(v.X / epsilon)),
+			int64(math.Floor(v.Y / epsilon)),
+			int64(math.Floor(v.Z / epsilon)),
+		}
+	}
+	grid := make(map[[3]int64][]int)
+	remap := make([]int, len(m.Vertices))
+	var kept []int
+	for i, v := range m.Vertices {
+		c := cell(v)
+		remap[i] = -1
+	search:
+		for dx := int64(-1); dx <= 1; dx++ {
+			for dy := int64(-1); dy <= 1; dy++ {
+				for dz := int64(-1); dz <= 1; dz++ {
+					for _, j := range grid[[3]int64{c[0] + dx, c[1] + dy, c[2] + dz}] {
+						if same(i, j) {
+							remap[i] = remap[j]
+							break search
+						}
+					}
+				}
+			}
+		}
+		if remap[i] < 0 {
+			remap[i] = len(kept)
+			kept = append(kept, i)
+			grid[c] = append(grid[c], i)
+		}
+	}
+
+	// Compact the vertex attributes.
+	verts := make([]vectors.Vec3, len(kept))
+	var normals, colors []vectors.Vec3
+	var uvs []vectors.Vec2
+	for i, idx := range kept {
+		verts[i] = m.Vertices[idx]
+		if hasNormals {
+			normals = append(normals, m.Normals[idx])
+		}
+		if hasUVs {
+			uvs = append(uvs, m.UVs[idx])
+		}
+		if hasColors {
+			colors = append(colors, m.Colors[idx])
+		}
+	}
+	m.Vertices, m.Normals, m.UVs, m.Colors = verts, normals, uvs, colors
+
+	// Remap the triangles and drop the degenerate ones.
+	hasGroups := m.HasGroups()
+	var tris, groups []int
+	for t := 0; t < len(m.Triangles)/3; t++ {
+		a, b, c := remap[m.Triangles[t*3]], remap[m.Triangles[t*3+1]], remap[m.Triangles[t*3+2]]
+		if a == b || b == c || a == c {
+			continue
+		}
+		tris = append(tris, a, b, c)
+		if hasGroups {
+			groups = append(groups, m.TriangleGroups[t])
+		}
+	}
+	m.Triangles = tris
+	if hasGroups {
+		m.TriangleGroups = groups
+	}
+}
+
+// ProjectUVs generates texture coordinates using a box projection, where
+// each triangle is projected onto the plane of the axis its normal is most
+// aligned with. The scale is the number of texture repetitions per unit.
+// NOTE: Vertices shared by triangles projected onto different planes are
+// duplicated.
+func (m *Mesh) ProjectUVs(scale float64) {
+	hasNormals, hasColors := m.HasNormals(), m.HasColors()
+	type key struct{ idx, axis int }
+	seen := make(map[key]int)
+	var verts, normals, colors []vectors.Vec3
+	var uvs []vectors.Vec2
+	for t := 0; t < m.NumTriangles(); t++ {
+		n := m.triangleNormal(t)
+		axis := 2
+		if ax, ay, az := math.Abs(n.X), math.Abs(n.Y), math.Abs(n.Z); ax >= ay && ax >= az {
+			axis = 0
+		} else if ay >= az {
+			axis = 1
+		}
+		for i := t * 3; i < t*3+3; i++ {
+			idx := m.Triangles[i]
+			k := key{idx, axis}
+			if ni, ok := seen[k]; ok {
+				m.Triangles[i] = ni
+				continue
+			}
+			v := m.Vertices[idx]
+			var uv vectors.Vec2
+			switch axis {
+			case 0:
+				uv = vectors.NewVec2(v.Y, v.Z)
+			case 1:
+				uv = vectors.NewVec2(v.X, v.Z)
+			default:
+				uv = vectors.NewVec2(v.X, v.Y)
+			}
+			seen[k] = len(verts)
+			m.Triangles[i] = len(verts)
+			verts = append(verts, v)
+			uvs = append(uvs, uv.Mul(scale))
+			if hasNormals {
+				normals = append(normals, m.Normals[idx])
+			}
+			if hasColors {
+				colors = append(colors, m.Colors[idx])
+			}
+		}
+	}
+	m.Vertices, m.Normals, m.UVs, m.Colors = verts, normals, uvs, colors
+}
+
+// Bounds returns the minimum and maximum coordinates of the mesh.
+func (m *Mesh) Bounds() (min, max vectors.Vec3) {
+	if len(m.Vertices) == 0 {
+		return
+	}
+	min, max = m.Vertices[0], m.Vertices[0]
+	for _, v := range m.Vertices[1:] {
+		min = vectors.NewVec3(math.Min(min.X, v.X), math.Min(min.Y, v.Y), math.Min(min.Z, v.Z))
+		max = vectors.NewVec3(math.Max(max.X, v.X), math.Max(max.Y, v.Y), math.Max(max.Z, v.Z))
+	}
+	return min, max
+}
+
 // ExtrudePath extrudes a path to a 3D shape.
 func ExtrudePath(path []vectors.Vec2, height float64) (*Mesh, error) {
 	// For every point in the path, create two vertices.
@@ -80,17 +451,7 @@ func ExtrudePath(path []vectors.Vec2, height float64) (*Mesh, error) {
 	}
 
 	// Create the triangles.
-	triangles := make([]int, len(path)*6)
-	for i := 0; i < len(path); i++ {
-		// Create two triangles for every point in the path.
-		triangles[i*6] = i
-		triangles[i*6+1] = i + len(path)
-		triangles[i*6+2] = (i + 1) % len(path)
-
-		triangles[i*6+3] = (i + 1) % len(path)
-		triangles[i*6+4] = i + len(path)
-		triangles[i*6+5] = (i+1)%len(path) + len(path)
-	}
+	triangles := sideTriangles(path)
 
 	// Add triangles for the bottom and top.
 	bottom, err := Triangulate(path)
@@ -99,10 +460,7 @@ func ExtrudePath(path []vectors.Vec2, height float64) (*Mesh, error) {
 	}
 
 	// Add the offset to the top indices.
-	triBottomTop := bottom
-	for _, t := range bottom {
-		triBottomTop = append(triBottomTop, t+len(path))
-	}
+	triBottomTop := append(bottom, topTriangles(bottom, len(path))...)
 
 	return &Mesh{
 		Vertices:  vertices,
@@ -110,60 +468,75 @@ func ExtrudePath(path []vectors.Vec2, height float64) (*Mesh, error) {
 	}, nil
 }
 
-// TaperPath tapers a path to a 3D shape to generate a roof.
-// NOTE: This is WIP since it doesn't get the angle right.
+// TaperPath tapers a path to a 3D shape with the given height to generate a
+// (hip) roof. See GenerateRoof for other roof types.
 func TaperPath(path []vectors.Vec2, height float64) (*Mesh, error) {
-	// For every point in the path, create two vertices.
-	// The first vertex is the point itself, the second vertex is the point
-	// with the height added and translated towards the shrunk center.
-	// The triangles are created by connecting the vertices in the order
-	// they are stored in the indices array.
+	s, err := ComputeStraightSkeleton(path)
+	if err != nil {
+		return nil, err
+	}
 
-	// Create the vertices.
-	vertices := make([]vectors.Vec3, len(path)*2)
-	shrunk := StraightSkeleton(path, height, -height*0.8)
-	for i, point := range path {
-		vertices[i] = vectors.Vec3{X: point.X, Y: point.Y, Z: 0}
-		vertices[i+len(path)] = vectors.Vec3{X: shrunk[i].X, Y: shrunk[i].Y, Z: height}
+	// Calculate the pitch from the depth of the skeleton, so the ridge is
+	// at the given height.
+	var depth float64
+	for _, n := range s.Nodes {
+		depth = math.Max(depth, n.Z)
+	}
+	m, err := GenerateRoof(path, RoofParams{
+		Type:  RoofHip,
+		Pitch: math.Atan2(height, depth),
+	})
+	if err != nil {
+		return nil, err
 	}
+	m.Groups, m.TriangleGroups = nil, nil
+	return m, nil
+}
 
-	// Create the triangles.
-	triangles := make([]int, len(path)*6)
-	for i := 0; i < len(path); i++ {
+// sideTriangles returns the triangles connecting the bottom vertices (0 to
+// len(path)-1) with the top vertices (len(path) to 2*len(path)-1) of an
+// extruded path, facing outwards.
+func sideTriangles(path []vectors.Vec2) []int {
+	n := len(path)
+	triangles := make([]int, n*6)
+	for i := 0; i < n; i++ {
 		// Create two triangles for every point in the path.
 		triangles[i*6] = i
-		triangles[i*6+1] = i + len(path)
-		triangles[i*6+2] = (i + 1) % len(path)
+		triangles[i*6+1] = i + n
+		triangles[i*6+2] = (i + 1) % n
 
-		triangles[i*6+3] = (i + 1) % len(path)
-		triangles[i*6+4] = i + len(path)
-		triangles[i*6+5] = (i+1)%len(path) + len(path)
+		triangles[i*6+3] = (i + 1) % n
+		triangles[i*6+4] = i + n
+		triangles[i*6+5] = (i+1)%n + n
 	}
 
-	// Add triangles for the bottom and top.
-	bottom, err := Triangulate(path)
-	if err != nil {
-		return nil, err
-	}
-	top, err := Triangulate(shrunk)
-	if err != nil {
-		return nil, err
+	// The triangles above face outwards for clockwise paths, so we need to
+	// reverse the winding order for counter-clockwise paths.
+	if !isPolyClockwise(path) {
+		for i := 0; i < len(triangles); i += 3 {
+			triangles[i+1], triangles[i+2] = triangles[i+2], triangles[i+1]
+		}
 	}
+	return triangles
+}
 
-	// Add the offset to the top indices.
-	triBottomTop := bottom
-	for _, t := range top {
-		triBottomTop = append(triBottomTop, t+len(path))
+// topTriangles returns the given triangles of a cap (as returned by
+// Triangulate) offset by the given number of vertices and with reversed
+// winding order, so they face upwards.
+func topTriangles(triangles []int, offset int) []int {
+	top := make([]int, len(triangles))
+	for i := 0; i < len(triangles); i += 3 {
+		top[i] = triangles[i] + offset
+		top[i+1] = triangles[i+2] + offset
+		top[i+2] = triangles[i+1] + offset
 	}
-
-	return &Mesh{
-		Vertices:  vertices,
-		Triangles: append(triangles, triBottomTop...),
-	}, nil
+	return top
 }
 
 // Triangulate triangulates a polygon using the ear clipping algorithm.
 // It returns the indices of each vertex of each triangle in pairs of 3.
+// NOTE: The triangles are always in clockwise order (facing down along the
+// Z axis), regardless of the orientation of the polygon.
 func Triangulate(polygon []vectors.Vec2) ([]int, error) {
 	// Create a copy of the polygon.
 	poly := make([]vectors.Vec2, len(polygon))
@@ -256,7 +629,7 @@ func isEar(polygon []vectors.Vec2, i int) bool {
 	}
 
 	// Check if the midpoint of the side is inside the polygon.
-	if !isInsidePolygon(mid, polygon) {
+	if !PointInPolygon(mid, polygon) {
 		// The midpoint is outside the polygon, so we have to skip this
 		// triangle.
 		return false
@@ -320,30 +693,6 @@ func isInside(polygon []vectors.Vec2, i int) bool {
 	return false
 }
 
-// isInsidePolygon checks if a point is inside a polygon.
-func isInsidePolygon(point vectors.Vec2, polygon []vectors.Vec2) bool {
-	// Create a ray from the point in any direction.
-	ray := vectors.Ray2{
-		Origin: point,
-		Dir:    vectors.Vec2{X: 0.0000001, Y: 0.000001},
-	}
-
-	var intersections int
-	for i := 0; i < len(polygon); i++ {
-		segment := vectors.Segment{
-			Start: polygon[i],
-			End:   polygon[(i+1)%len(polygon)],
-		}
-		if ray.Intersects(segment) {
-			intersections++
-		}
-	}
-
-	// Check if the ray intersects the polygon an odd number of times.
-	// If it does, the point is inside the polygon.
-	return intersections%2 == 1
-}
-
 func isPolyClockwise(polygon []vectors.Vec2) bool {
 	var sum float64
 	for i := 0; i < len(polygon); i++ {
@@ -0,0 +1,73 @@
+package gengeometry
+
+import (
+	"testing"
+
+	"github.com/Flokey82/go_gens/vectors"
+)
+
+func TestWeld(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		mesh      func() *Mesh
+		wantVerts int
+		wantTris  int
+	}{{
+		name: "unwelded quad",
+		mesh: func() *Mesh {
+			m := testQuad()
+			m.Unweld()
+			return m
+		},
+		wantVerts: 4,
+		wantTris:  2,
+	}, {
+		name: "vertices within epsilon",
+		mesh: func() *Mesh {
+			m := testQuad()
+			m.Unweld()
+			m.Vertices[3].X += 1e-7 // Copy of vertex 0.
+			return m
+		},
+		wantVerts: 4,
+		wantTris:  2,
+	}, {
+		name: "different UVs are kept",
+		mesh: func() *Mesh {
+			m := testQuad()
+			m.Unweld()
+			m.UVs[3] = vectors.Vec2{X: 0.5} // Copy of vertex 0.
+			return m
+		},
+		wantVerts: 5,
+		wantTris:  2,
+	}, {
+		name: "degenerate triangle is dropped",
+		mesh: func() *Mesh {
+			m := testQuad()
+			m.Vertices[3] = m.Vertices[2]
+			m.Normals, m.UVs, m.Colors = nil, nil, nil
+			return m
+		},
+		wantVerts: 3,
+		wantTris:  1,
+	}} {
+		m := tc.mesh()
+		m.Weld(1e-6)
+		if len(m.Vertices) != tc.wantVerts || m.NumTriangles() != tc.wantTris {
+			t.Errorf("%s: got %d vertices and %d triangles, want %d and %d", tc.name, len(m.Vertices), m.NumTriangles(), tc.wantVerts, tc.wantTris)
+		}
+		if len(m.TriangleGroups) != m.NumTriangles() {
+			t.Errorf("%s: got %d triangle groups, want %d", tc.name, len(m.TriangleGroups), m.NumTriangles())
+		}
+		for _, idx := range m.Triangles {
+			if idx < 0 || idx >= len(m.Vertices) {
+				t.Errorf("%s: vertex index %d out of range", tc.name, idx)
+				break
+			}
+		}
+		if m.Normals != nil && !m.HasNormals() || m.UVs != nil && !m.HasUVs() || m.Colors != nil && !m.HasColors() {
+			t.Errorf("%s: attributes don't match the vertices", tc.name)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package gengeometry
+
+import (
+	"math"
+
+	"github.com/Flokey82/go_gens/vectors"
+)
+
+// JoinType determines how the corners of an offset polygon are joined.
+type JoinType int
+
+// The supported join types.
+const (
+	JoinMiter JoinType = iota // Sharp corners (beveled beyond the miter limit).
+	JoinRound                 // Rounded corners.
+	JoinBevel                 // Cut off corners.
+)
+
+// offsetMiterLimit is the maximum distance of a mitered corner from the
+// original corner (as a multiple of the offset) before it is beveled.
+const offsetMiterLimit = 2.0
+
+// offsetArcTolerance is the maximum deviation of rounded corners from a
+// true arc (as a fraction of the offset).
+const offsetArcTolerance = 0.0025
+
+// OffsetPolygon grows (positive delta) or shrinks (negative delta) the given
+// polygon (including its holes) by the given distance.
+//
+// Unlike ShrinkPath, this works for concave polygons as well. Shrinking a
+// polygon might split it into multiple polygons (or make it vanish), while
+// growing might merge parts of it or close holes.
+func OffsetPolygon(p Polygon, delta float64, join JoinType) []Polygon {
+	return OffsetPolygons([]Polygon{p}, delta, join)
+}
+
+// OffsetPolygons offsets the given polygons (see OffsetPolygon) and returns
+// the union of the results.
+func OffsetPolygons(polys []Polygon, delta float64, join JoinType) []Polygon {
+	rings := polygonRings(polys)
+	if delta == 0 {
+		return assemblePolygons(clipRings(rings, nil, OpUnion))
+	}
+	raw := make([][]vectors.Vec2, 0, len(rings))
+	for _, r := range rings {
+		if o := offsetRing(r, delta, join); len(o) >= 3 {
+			raw = append(raw, o)
+		}
+	}
+
+	// The raw offset rings contain loops wherever the offset overlaps itself,
+	// which have a winding number of zero or less, so they are removed by
+	// the union.
+	return assemblePolygons(clipRings(raw, nil, OpUnion))
+}
+
+// RemoveSlivers returns the polygons with an area of at least minArea.
+//
+// Shrinking a polygon with beveled (or clipped miter) corners close to the
+// distance at which it collapses can leave small parts where the bevel cuts
+// off less than a rounded corner would. These are correct, but often not
+// wanted, so they can be removed with this function.
+func RemoveSlivers(polys []Polygon, minArea float64) []Polygon {
+	var res []Polygon
+	for _, p := range polys {
+		if p.Area() >= minArea {
+			res = append(res, p)
+		}
+	}
+	return res
+}
+
+// OffsetPath offsets a simple polygon given as path and returns the outer
+// rings of the resulting polygons (see OffsetPolygon).
+func OffsetPath(path []vectors.Vec2, delta float64, join JoinType) [][]vectors.Vec2 {
+	var res [][]vectors.Vec2
+	for _, p := range OffsetPolygon(Polygon{Points: path}, delta, join) {
+		res = append(res, p.Points)
+	}
+	return res
+}
+
+// offsetRing returns the raw offset of the given ring, which is expected to
+// have the inside on the left (counter-clockwise outer rings and clockwise
+// holes). The result might intersect itself.
+func offsetRing(r []vectors.Vec2, delta float64, join JoinType) []vectors.Vec2 {
+	// Remove duplicate points.
+	var pts []vectors.Vec2
+	for i, p := range r {
+		if vectors.Dist2(p, r[(i+1)%len(r)]) > 1e-12 {
+			pts = append(pts, p)
+		}
+	}
+	n := len(pts)
+	if n < 3 {
+		return nil
+	}
+
+	// Calculate the outward (right) normals of all edges.
+	normals := make([]vectors.Vec2, n)
+	for i := range pts {
+		d := vectors.Sub2(pts[(i+1)%n], pts[i]).Normalize()
+		normals[i] = vectors.Vec2{X: d.Y, Y: -d.X}
+	}
+
+	// Number of steps for a full circle for rounded corners.
+	steps := math.Pi / math.Acos(1-offsetArcTolerance)
+
+	var res []vectors.Vec2
+	for i, p := range pts {
+		n0 := normals[(i+n-1)%n]
+		n1 := normals[i]
+		cross := vectors.Cross2(n0, n1)
+		dot := vectors.Dot2(n0, n1)
+		if math.Abs(cross) < 1e-9 && dot > 0 {
+			// Collinear edges.
+			res = append(res, p.Add(n0.Mul(delta)))
+			continue
+		}
+		if cross*delta < 0 {
+			// The offset edges overlap, so we connect them via the corner,
+			// which creates a small loop that is removed later.
+			res = append(res, p.Add(n0.Mul(delta)), p, p.Add(n1.Mul(delta)))
+			continue
+		}
+		switch join {
+		case JoinMiter:
+			// The distance of the miter point is delta / cos(angle/2).
+			if 1+dot > 2/(offsetMiterLimit*offsetMiterLimit) {
+				res = append(res, p.Add(n0.Add(n1).Mul(delta/(1+dot))))
+				continue
+			}
+			res = append(res, p.Add(n0.Mul(delta)), p.Add(n1.Mul(delta)))
+		case JoinRound:
+			a0 := math.Atan2(n0.Y, n0.X)
+			if delta < 0 {
+				a0 += math.Pi
+			}
+			angle := math.Atan2(cross, dot)
+			k := int(math.Ceil(math.Abs(angle) / (2 * math.Pi) * steps))
+			for j := 0; j <= k; j++ {
+				a := a0 + angle*float64(j)/float64(k)
+				res = append(res, p.Add(vectors.Vec2{X: math.Cos(a), Y: math.Sin(a)}.Mul(math.Abs(delta))))
+			}
+		default:
+			res = append(res, p.Add(n0.Mul(delta)), p.Add(n1.Mul(delta)))
+		}
+	}
+	return res
+}
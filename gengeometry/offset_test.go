@@ -0,0 +1,60 @@
+package gengeometry
+
+import (
+	"math"
+	"testing"
+
+	"github.com/Flokey82/go_gens/vectors"
+)
+
+func TestOffsetPolygon(t *testing.T) {
+	lShape := Polygon{Points: []vectors.Vec2{
+		{X: 0, Y: 0}, {X: 2, Y: 0}, {X: 2, Y: 1}, {X: 1, Y: 1}, {X: 1, Y: 2}, {X: 0, Y: 2},
+	}}
+	for _, tc := range []struct {
+		name      string
+		p         Polygon
+		delta     float64
+		join      JoinType
+		wantPolys int
+		wantArea  float64
+	}{
+		{"grow square", square(0, 0, 2), 1, JoinMiter, 1, 16},
+		{"shrink square", square(0, 0, 2), -0.5, JoinMiter, 1, 1},
+		{"collapse square by half its width", square(0, 0, 2), -1, JoinMiter, 0, 0},
+		{"collapse square by half its width (round)", square(0, 0, 2), -1, JoinRound, 0, 0},
+		{"collapse square by half its width (bevel)", square(0, 0, 2), -1, JoinBevel, 0, 0},
+		{"shrink L-shape", lShape, -0.25, JoinMiter, 1, 1.25},
+		{"collapse L-shape (bevel)", lShape, -0.6, JoinBevel, 1, 0.02}, // The bevel at the inner corner leaves a triangle.
+		{"collapse L-shape (round)", lShape, -0.6, JoinRound, 0, 0},
+		{"close hole", Polygon{Points: square(0, 0, 3).Points, Holes: [][]vectors.Vec2{reversedPath(square(1, 1, 1).Points)}}, 0.5, JoinMiter, 1, 16},
+	} {
+		res := OffsetPolygon(tc.p, tc.delta, tc.join)
+		if len(res) != tc.wantPolys {
+			t.Errorf("%s: got %d polygons, want %d", tc.name, len(res), tc.wantPolys)
+		}
+		if got := totalArea(res); math.Abs(got-tc.wantArea) > 1e-9 {
+			t.Errorf("%s: got area %f, want %f", tc.name, got, tc.wantArea)
+		}
+	}
+}
+
+func TestRemoveSlivers(t *testing.T) {
+	lShape := Polygon{Points: []vectors.Vec2{
+		{X: 0, Y: 0}, {X: 2, Y: 0}, {X: 2, Y: 1}, {X: 1, Y: 1}, {X: 1, Y: 2}, {X: 0, Y: 2},
+	}}
+	for _, tc := range []struct {
+		name      string
+		polys     []Polygon
+		minArea   float64
+		wantPolys int
+	}{
+		{"bevel sliver", OffsetPolygon(lShape, -0.6, JoinBevel), 0.1, 0},
+		{"small but large enough", []Polygon{square(0, 0, 0.5)}, 0.25, 1},
+		{"keep large, drop small", []Polygon{square(0, 0, 2), square(5, 5, 0.1)}, 0.1, 1},
+	} {
+		if got := RemoveSlivers(tc.polys, tc.minArea); len(got) != tc.wantPolys {
+			t.Errorf("%s: got %d polygons, want %d", tc.name, len(got), tc.wantPolys)
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 )
 
 // ShrinkPath shrinks a polygon by a given factor around its center.
+// NOTE: For shrinking a polygon by a given distance, use OffsetPolygon.
 func ShrinkPath(points []vectors.Vec2, shrink float64) []vectors.Vec2 {
 	// Calculate the center of the polygon and shrink the polygon
 	// around the center.
@@ -29,7 +30,8 @@ func ShrinkPath(points []vectors.Vec2, shrink float64) []vectors.Vec2 {
 // See: https://github.com/feldhaus/coding-2d-cookbook/blob/main/js/geometry/polygon-straight-skeleton.js
 // NOTE: This is a very naive implementation that only works for simple polygons.
 // There are some issues wit NaN values and the added epsilon values make it not
-// precise.
+// precise. Use OffsetPolygon for insetting and ComputeStraightSkeleton for the
+// actual skeleton instead.
 func StraightSkeleton(points []vectors.Vec2, shrink, spacing float64) []vectors.Vec2 {
 	order := polygonOrder(points)
 	spacing *= order / math.Abs(order)
@@ -176,6 +178,26 @@ func CenterOfPath(points []vectors.Vec2) vectors.Vec2 {
 	return center
 }
 
+// PointInPolygon returns true if the point is inside the given polygon
+// (using the even-odd rule).
+func PointInPolygon(point vectors.Vec2, polygon []vectors.Vec2) bool {
+	// Cast a horizontal ray from the point and count the edges it crosses.
+	// NOTE: Each edge includes its lower end point but not its upper one, so
+	// a ray passing through a vertex is only counted once.
+	var inside bool
+	for i, j := 0, len(polygon)-1; i < len(polygon); j, i = i, i+1 {
+		a, b := polygon[i], polygon[j]
+		if (a.Y > point.Y) != (b.Y > point.Y) &&
+			point.X < (b.X-a.X)*(point.Y-a.Y)/(b.Y-a.Y)+a.X {
+			inside = !inside
+		}
+	}
+
+	// If the ray crosses the polygon an odd number of times, the point is
+	// inside the polygon.
+	return inside
+}
+
 // TranslatePath translates a path by the given offset.
 func TranslatePath(path []vectors.Vec2, offset vectors.Vec2) []vectors.Vec2 {
 	newPath := make([]vectors.Vec2, len(path))
@@ -0,0 +1,25 @@
+package gengeometry
+
+import (
+	"testing"
+
+	"github.com/Flokey82/go_gens/vectors"
+)
+
+func TestPointInPolygon(t *testing.T) {
+	// A diamond, so that rays through the center pass through a vertex.
+	diamond := []vectors.Vec2{{X: 0, Y: -1}, {X: 1, Y: 0}, {X: 0, Y: 1}, {X: -1, Y: 0}}
+	for _, tc := range []struct {
+		p    vectors.Vec2
+		want bool
+	}{
+		{vectors.Vec2{X: 0, Y: 0}, true},
+		{vectors.Vec2{X: -0.5, Y: 0}, true},
+		{vectors.Vec2{X: -2, Y: 0}, false},
+		{vectors.Vec2{X: 0.9, Y: 0.9}, false},
+	} {
+		if got := PointInPolygon(tc.p, diamond); got != tc.want {
+			t.Errorf("PointInPolygon(%v) = %t, want %t", tc.p, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,477 @@
+package gengeometry
+
+import (
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/Flokey82/go_gens/vectors"
+)
+
+// RoofType is the shape of a roof.
+type RoofType int
+
+// The supported roof types.
+const (
+	RoofHip     RoofType = iota // All sides slope down to the eaves.
+	RoofGable                   // Like hip, but narrow ends are vertical gables.
+	RoofMansard                 // Hip roof with a steep lower and a shallow upper part.
+	RoofFlat                    // Flat roof surrounded by a parapet.
+	RoofShed                    // A single sloped plane.
+)
+
+// RoofParams are the parameters for generating a roof.
+type RoofParams struct {
+	Type     RoofType
+	Pitch    float64 // Slope of the roof in radians (the upper part for mansard roofs).
+	Overhang float64 // Horizontal distance the roof extends beyond the footprint.
+
+	MansardPitch float64 // Slope of the lower part of mansard roofs in radians.
+	MansardBreak float64 // Fraction (0-1) of the roof depth covered by the lower part (default 0.5).
+
+	ParapetHeight float64 // Height of the parapet of flat roofs.
+	ParapetWidth  float64 // Width of the parapet of flat roofs.
+	DeckThickness float64 // Thickness of the deck of flat roofs (default 0.2).
+
+	ShedEdge int // Index of the footprint edge the shed roof slopes down to.
+
+	RoofMaterial *Material // Material of the roof surfaces (optional).
+	WallMaterial *Material // Material of gables, parapets and sides (optional).
+}
+
+// Names of the groups of the generated roof meshes.
+const (
+	RoofGroupRoof = "roof"
+	RoofGroupWall = "roof_wall"
+)
+
+// GenerateRoof generates a roof mesh for the given footprint (any simple
+// polygon). The roof sits on top of walls with the top at Z = 0, so it needs
+// to be translated to the height of the walls.
+//
+// The triangles are assigned to the groups RoofGroupRoof (roof surfaces and
+// the underside) and RoofGroupWall (gables, parapets and the sides of shed
+// roofs) with the materials given in the parameters.
+// NOTE: The overhang extends the roof along the slope, so the eaves are below
+// the top of the walls. For gable roofs, the gables are moved outwards by the
+// overhang as well, and for flat roofs the parapet follows the overhang.
+func GenerateRoof(footprint []vectors.Vec2, p RoofParams) (*Mesh, error) {
+	if len(footprint) < 3 {
+		return nil, errors.New("footprint needs at least 3 points")
+	}
+
+	// Make sure the footprint is counter-clockwise.
+	if polygonOrder(footprint) < 0 {
+		footprint = reversedPath(footprint)
+		if p.Type == RoofShed {
+			p.ShedEdge = len(footprint) - 2 - p.ShedEdge
+		}
+	}
+
+	// Extend the outline by the overhang.
+	outline := footprint
+	if p.Overhang > 0 {
+		var maxArea float64
+		for _, poly := range OffsetPolygon(Polygon{Points: footprint}, p.Overhang, JoinMiter) {
+			if a := poly.Area(); a > maxArea {
+				outline, maxArea = poly.Points, a
+			}
+		}
+	}
+
+	b := newRoofBuilder(p)
+	var err error
+	switch p.Type {
+	case RoofHip, RoofGable:
+		err = b.hipRoof(outline, p)
+	case RoofMansard:
+		err = b.mansardRoof(outline, p)
+	case RoofFlat:
+		err = b.flatRoof(outline, p)
+	case RoofShed:
+		err = b.shedRoof(footprint, outline, p)
+	default:
+		err = errors.New("unknown roof type")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return b.m, nil
+}
+
+// roofBuilder assembles the roof mesh.
+type roofBuilder struct {
+	m *Mesh
+}
+
+// The group indices of the roof mesh.
+const (
+	roofGroupRoof = iota
+	roofGroupWall
+)
+
+func newRoofBuilder(p RoofParams) *roofBuilder {
+	return &roofBuilder{
+		m: &Mesh{
+			Groups: []Group{
+				{Name: RoofGroupRoof, Material: p.RoofMaterial},
+				{Name: RoofGroupWall, Material: p.WallMaterial},
+			},
+		},
+	}
+}
+
+// addTriangle adds a triangle facing in the given direction. Degenerate
+// triangles are skipped.
+func (b *roofBuilder) addTriangle(p0, p1, p2, facing vectors.Vec3, group int) {
+	n := vectors.Cross3(vectors.Sub3(p1, p0), vectors.Sub3(p2, p0))
+	if n.Len() < 1e-12 {
+		return
+	}
+	if vectors.Dot3(n, facing) < 0 {
+		p1, p2 = p2, p1
+	}
+	idx := len(b.m.Vertices)
+	b.m.Vertices = append(b.m.Vertices, p0, p1, p2)
+	b.m.Triangles = append(b.m.Triangles, idx, idx+1, idx+2)
+	b.m.TriangleGroups = append(b.m.TriangleGroups, group)
+}
+
+// addCap triangulates the given polygon (as seen from above) and adds it
+// facing up or down.
+func (b *roofBuilder) addCap(poly []vectors.Vec3, up bool, group int) error {
+	pts := make([]vectors.Vec2, len(poly))
+	for i, p := range poly {
+		pts[i] = vectors.NewVec2(p.X, p.Y)
+	}
+	tris, err := Triangulate(pts)
+	if err != nil {
+		return err
+	}
+	facing := vectors.NewVec3(0, 0, -1)
+	if up {
+		facing.Z = 1
+	}
+	for i := 0; i < len(tris); i += 3 {
+		b.addTriangle(poly[tris[i]], poly[tris[i+1]], poly[tris[i+2]], facing, group)
+	}
+	return nil
+}
+
+// addWall adds a vertical quad from a to b (on the ground plane) between the
+// given bottom and top heights, facing in the given direction.
+func (b *roofBuilder) addWall(a, c vectors.Vec2, bottomA, bottomC, topA, topC float64, facing vectors.Vec2, group int) {
+	f := vectors.NewVec3(facing.X, facing.Y, 0)
+	a0, a1 := vectors.NewVec3(a.X, a.Y, bottomA), vectors.NewVec3(a.X, a.Y, topA)
+	c0, c1 := vectors.NewVec3(c.X, c.Y, bottomC), vectors.NewVec3(c.X, c.Y, topC)
+	b.addTriangle(a0, c0, a1, f, group)
+	b.addTriangle(a1, c0, c1, f, group)
+}
+
+// addBottom closes the roof at the given height.
+func (b *roofBuilder) addBottom(outline []vectors.Vec2, z float64) error {
+	poly := make([]vectors.Vec3, len(outline))
+	for i, p := range outline {
+		poly[i] = vectors.NewVec3(p.X, p.Y, z)
+	}
+	return b.addCap(poly, false, roofGroupRoof)
+}
+
+// addTop adds the given polygon at the given height facing up.
+func (b *roofBuilder) addTop(path []vectors.Vec2, z float64, group int) error {
+	poly := make([]vectors.Vec3, len(path))
+	for i, p := range path {
+		poly[i] = vectors.NewVec3(p.X, p.Y, z)
+	}
+	return b.addCap(poly, true, group)
+}
+
+// outwardNormal returns the outward normal of the given edge of a
+// counter-clockwise polygon.
+func outwardNormal(path []vectors.Vec2, i int) vectors.Vec2 {
+	d := vectors.Sub2(path[(i+1)%len(path)], path[i]).Normalize()
+	return vectors.NewVec2(d.Y, -d.X)
+}
+
+// hipRoof adds a hip (or gable) roof based on the straight skeleton of the
+// outline.
+func (b *roofBuilder) hipRoof(outline []vectors.Vec2, p RoofParams) error {
+	s, err := ComputeStraightSkeleton(outline)
+	if err != nil {
+		return err
+	}
+	slope := math.Tan(p.Pitch)
+	base := -p.Overhang * slope
+	nodes := make([]vectors.Vec3, len(s.Nodes))
+	for i, n := range s.Nodes {
+		nodes[i] = vectors.NewVec3(n.X, n.Y, base+n.Z*slope)
+	}
+
+	// For gable roofs, the apex of each triangular face (at the end of a
+	// ridge) is moved onto the edge, which turns the face into a vertical
+	// gable. We only do this if the apex is shared by three faces, since
+	// otherwise it is the tip of a pyramid.
+	gable := make([]bool, len(s.Faces))
+	if p.Type == RoofGable {
+		faceCount := make(map[int]int)
+		for _, f := range s.Faces {
+			for _, idx := range f[2:] {
+				faceCount[idx]++
+			}
+		}
+		moved := make(map[int]bool)
+		for i, f := range s.Faces {
+			if len(f) != 3 || faceCount[f[2]] != 3 || moved[f[2]] {
+				continue
+			}
+			a, c := outline[i], outline[(i+1)%len(outline)]
+			apex := nodes[f[2]]
+			d := vectors.Sub2(c, a).Normalize()
+			q := a.Add(d.Mul(vectors.Dot2(vectors.Sub2(vectors.NewVec2(apex.X, apex.Y), a), d)))
+			nodes[f[2]] = vectors.NewVec3(q.X, q.Y, apex.Z)
+			moved[f[2]] = true
+			gable[i] = true
+		}
+	}
+
+	for i, f := range s.Faces {
+		poly := make([]vectors.Vec3, len(f))
+		for j, idx := range f {
+			poly[j] = nodes[idx]
+		}
+		if gable[i] {
+			n := outwardNormal(outline, i)
+			b.addTriangle(poly[0], poly[1], poly[2], vectors.NewVec3(n.X, n.Y, 0), roofGroupWall)
+			continue
+		}
+		if err := b.addCap(poly, true, roofGroupRoof); err != nil {
+			return err
+		}
+	}
+	return b.addBottom(outline, base)
+}
+
+// skeletonFaceParts splits the faces of the straight skeleton at the given
+// distance from the outline and returns the parts of each face closer to
+// the outline (lower) and further away (upper).
+func skeletonFaceParts(s *Skeleton, outline []vectors.Vec2, dist float64) (lower, upper [][][]vectors.Vec2) {
+	var extent float64
+	for _, p := range outline {
+		extent = math.Max(extent, math.Max(math.Abs(p.X), math.Abs(p.Y)))
+	}
+	l := 4*extent + 1
+	n := len(outline)
+	lower = make([][][]vectors.Vec2, n)
+	upper = make([][][]vectors.Vec2, n)
+	for i := range s.Faces {
+		face := s.Face(i)
+		pts := make([]vectors.Vec2, len(face))
+		for j, f := range face {
+			pts[j] = vectors.NewVec2(f.X, f.Y)
+		}
+		a := outline[i]
+		d := vectors.Sub2(outline[(i+1)%n], a).Normalize()
+		in := vectors.NewVec2(-d.Y, d.X)
+		halfPlane := func(from, to float64) []Polygon {
+			return []Polygon{{Points: []vectors.Vec2{
+				a.Add(d.Mul(-l)).Add(in.Mul(from)),
+				a.Add(d.Mul(l)).Add(in.Mul(from)),
+				a.Add(d.Mul(l)).Add(in.Mul(to)),
+				a.Add(d.Mul(-l)).Add(in.Mul(to)),
+			}}}
+		}
+		for _, part := range Intersection([]Polygon{{Points: pts}}, halfPlane(-l, dist)) {
+			lower[i] = append(lower[i], part.Points)
+		}
+		for _, part := range Intersection([]Polygon{{Points: pts}}, halfPlane(dist, l)) {
+			upper[i] = append(upper[i], part.Points)
+		}
+	}
+
+	// The clipping removes collinear points, which might be corners of
+	// neighboring parts, so we need to add them again to avoid T-junctions.
+	var points []vectors.Vec2
+	for _, parts := range append(lower, upper...) {
+		for _, part := range parts {
+			points = append(points, part...)
+		}
+	}
+	for _, n := range s.Nodes {
+		points = append(points, vectors.NewVec2(n.X, n.Y))
+	}
+	tol := extent * 1e-9
+	for _, parts := range append(lower, upper...) {
+		for j, part := range parts {
+			parts[j] = insertPointsOnEdges(part, points, tol)
+		}
+	}
+	return lower, upper
+}
+
+// insertPointsOnEdges returns the given path with all points that lie within
+// an edge of the path inserted in order.
+func insertPointsOnEdges(path, points []vectors.Vec2, tol float64) []vectors.Vec2 {
+	var res []vectors.Vec2
+	for i, a := range path {
+		c := path[(i+1)%len(path)]
+		res = append(res, a)
+		seg := vectors.Sub2(c, a)
+		l := seg.Len()
+		if l <= tol {
+			continue
+		}
+		dir := seg.Mul(1 / l)
+		var onEdge []float64
+		for _, q := range points {
+			t := vectors.Dot2(vectors.Sub2(q, a), dir)
+			if t <= tol || t >= l-tol || math.Abs(vectors.Cross2(dir, vectors.Sub2(q, a))) > tol {
+				continue
+			}
+			onEdge = append(onEdge, t)
+		}
+		sort.Float64s(onEdge)
+		for k, t := range onEdge {
+			if k > 0 && t-onEdge[k-1] <= tol {
+				continue
+			}
+			res = append(res, a.Add(dir.Mul(t)))
+		}
+	}
+	return res
+}
+
+// edgeDistance returns the distance of p from the line through the given
+// edge of a counter-clockwise polygon (positive on the inside).
+func edgeDistance(path []vectors.Vec2, i int, p vectors.Vec2) float64 {
+	return -vectors.Dot2(vectors.Sub2(p, path[i]), outwardNormal(path, i))
+}
+
+// mansardRoof adds a mansard roof, which is a hip roof with a steep lower
+// and a shallow upper part.
+func (b *roofBuilder) mansardRoof(outline []vectors.Vec2, p RoofParams) error {
+	s, err := ComputeStraightSkeleton(outline)
+	if err != nil {
+		return err
+	}
+	var depth float64
+	for _, n := range s.Nodes {
+		depth = math.Max(depth, n.Z)
+	}
+	brk := p.MansardBreak
+	if brk <= 0 || brk >= 1 {
+		brk = 0.5
+	}
+	brk *= depth
+
+	lowerSlope, upperSlope := math.Tan(p.MansardPitch), math.Tan(p.Pitch)
+	base := -p.Overhang * lowerSlope
+	height := func(t float64) float64 {
+		if t <= brk {
+			return base + t*lowerSlope
+		}
+		return base + brk*lowerSlope + (t-brk)*upperSlope
+	}
+
+	lower, upper := skeletonFaceParts(s, outline, brk)
+	for i := range s.Faces {
+		for _, part := range append(lower[i], upper[i]...) {
+			poly := make([]vectors.Vec3, len(part))
+			for j, q := range part {
+				poly[j] = vectors.NewVec3(q.X, q.Y, height(edgeDistance(outline, i, q)))
+			}
+			if err := b.addCap(poly, true, roofGroupRoof); err != nil {
+				return err
+			}
+		}
+	}
+	return b.addBottom(outline, base)
+}
+
+// flatRoof adds a flat roof covering the outline with a parapet along its
+// edges. The deck is lifted by its thickness, so the roof is a closed solid
+// from the bottom at Z = 0 up to the deck (and the top of the parapet).
+func (b *roofBuilder) flatRoof(outline []vectors.Vec2, p RoofParams) error {
+	deck := p.DeckThickness
+	if deck <= 0 {
+		deck = 0.2
+	}
+	if err := b.addBottom(outline, 0); err != nil {
+		return err
+	}
+	if p.ParapetHeight <= 0 || p.ParapetWidth <= 0 {
+		for i, q := range outline {
+			b.addWall(q, outline[(i+1)%len(outline)], 0, 0, deck, deck, outwardNormal(outline, i), roofGroupRoof)
+		}
+		return b.addTop(outline, deck, roofGroupRoof)
+	}
+
+	// Keep the deck below the top of the parapet.
+	h := p.ParapetHeight
+	deck = math.Min(deck, h/2)
+
+	// Add the outer side of the parapet.
+	for i, q := range outline {
+		b.addWall(q, outline[(i+1)%len(outline)], 0, 0, h, h, outwardNormal(outline, i), roofGroupWall)
+	}
+
+	// The top of the parapet consists of the parts of the skeleton faces
+	// within the width of the parapet, the inner side follows the edges
+	// of these parts at the width of the parapet down to the deck, which
+	// covers the remaining parts.
+	s, err := ComputeStraightSkeleton(outline)
+	if err != nil {
+		return err
+	}
+	lower, upper := skeletonFaceParts(s, outline, p.ParapetWidth)
+	for i, parts := range lower {
+		in := outwardNormal(outline, i).Mul(-1)
+		for _, part := range parts {
+			if err := b.addTop(part, h, roofGroupWall); err != nil {
+				return err
+			}
+			const eps = 1e-9
+			for j, q := range part {
+				r := part[(j+1)%len(part)]
+				if math.Abs(edgeDistance(outline, i, q)-p.ParapetWidth) < eps*math.Max(1, p.ParapetWidth) &&
+					math.Abs(edgeDistance(outline, i, r)-p.ParapetWidth) < eps*math.Max(1, p.ParapetWidth) {
+					b.addWall(q, r, deck, deck, h, h, in, roofGroupWall)
+				}
+			}
+		}
+	}
+	for _, parts := range upper {
+		for _, part := range parts {
+			if err := b.addTop(part, deck, roofGroupRoof); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// shedRoof adds a single sloped roof, which slopes down to the given edge of
+// the footprint.
+func (b *roofBuilder) shedRoof(footprint, outline []vectors.Vec2, p RoofParams) error {
+	n := len(footprint)
+	edge := ((p.ShedEdge % n) + n) % n
+	slope := math.Tan(p.Pitch)
+	height := func(q vectors.Vec2) float64 {
+		return edgeDistance(footprint, edge, q) * slope
+	}
+
+	// Add the roof surface and the sides down to the lowest point.
+	top := make([]vectors.Vec3, len(outline))
+	base := math.Inf(1)
+	for i, q := range outline {
+		top[i] = vectors.NewVec3(q.X, q.Y, height(q))
+		base = math.Min(base, top[i].Z)
+	}
+	if err := b.addCap(top, true, roofGroupRoof); err != nil {
+		return err
+	}
+	for i, q := range outline {
+		j := (i + 1) % len(outline)
+		b.addWall(q, outline[j], base, base, top[i].Z, top[j].Z, outwardNormal(outline, i), roofGroupWall)
+	}
+	return b.addBottom(outline, base)
+}
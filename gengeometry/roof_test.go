@@ -0,0 +1,86 @@
+package gengeometry
+
+import (
+	"math"
+	"testing"
+
+	"github.com/Flokey82/go_gens/vectors"
+)
+
+// openEdges returns the number of edges of the mesh that are not matched by
+// an edge in the opposite direction (which is 0 for closed meshes).
+func openEdges(m *Mesh) int {
+	key := func(v vectors.Vec3) [3]int64 {
+		return [3]int64{int64(math.Round(v.X * 1e6)), int64(math.Round(v.Y * 1e6)), int64(math.Round(v.Z * 1e6))}
+	}
+	edges := make(map[[2][3]int64]int)
+	for i := 0; i < len(m.Triangles); i += 3 {
+		for j := 0; j < 3; j++ {
+			a := key(m.Vertices[m.Triangles[i+j]])
+			b := key(m.Vertices[m.Triangles[i+(j+1)%3]])
+			if n := edges[[2][3]int64{b, a}]; n > 0 {
+				edges[[2][3]int64{b, a}] = n - 1
+			} else {
+				edges[[2][3]int64{a, b}]++
+			}
+		}
+	}
+	var open int
+	for _, n := range edges {
+		open += n
+	}
+	return open
+}
+
+// signedVolume returns the volume enclosed by the mesh, which is positive if
+// the triangles of a closed mesh face outwards.
+func signedVolume(m *Mesh) float64 {
+	var vol float64
+	for i := 0; i < len(m.Triangles); i += 3 {
+		a, b, c := m.Vertices[m.Triangles[i]], m.Vertices[m.Triangles[i+1]], m.Vertices[m.Triangles[i+2]]
+		vol += vectors.Dot3(a, vectors.Cross3(b, c)) / 6
+	}
+	return vol
+}
+
+func TestRoofClosed(t *testing.T) {
+	rect := []vectors.Vec2{{X: 0, Y: 0}, {X: 4, Y: 0}, {X: 4, Y: 3}, {X: 0, Y: 3}}
+	lShape := []vectors.Vec2{{X: 0, Y: 0}, {X: 6, Y: 0}, {X: 6, Y: 3}, {X: 3, Y: 3}, {X: 3, Y: 6}, {X: 0, Y: 6}}
+	uShape := []vectors.Vec2{{X: 0, Y: 0}, {X: 9, Y: 0}, {X: 9, Y: 7}, {X: 6, Y: 7}, {X: 6, Y: 3}, {X: 3, Y: 3}, {X: 3, Y: 7}, {X: 0, Y: 7}}
+	pitch := math.Pi / 6
+	for _, tc := range []struct {
+		name      string
+		footprint []vectors.Vec2
+		params    RoofParams
+	}{
+		{"flat rectangle", rect, RoofParams{Type: RoofFlat}},
+		{"flat rectangle with parapet", rect, RoofParams{Type: RoofFlat, ParapetHeight: 0.6, ParapetWidth: 0.3}},
+		{"flat L-shape with parapet", lShape, RoofParams{Type: RoofFlat, ParapetHeight: 0.6, ParapetWidth: 0.3}},
+		{"flat L-shape with parapet and overhang", lShape, RoofParams{Type: RoofFlat, ParapetHeight: 0.6, ParapetWidth: 0.3, Overhang: 0.2}},
+		{"hip rectangle", rect, RoofParams{Type: RoofHip, Pitch: pitch}},
+		{"hip L-shape with overhang", lShape, RoofParams{Type: RoofHip, Pitch: pitch, Overhang: 0.3}},
+		{"hip U-shape (clockwise)", reversedPath(uShape), RoofParams{Type: RoofHip, Pitch: pitch}},
+		{"gable rectangle", rect, RoofParams{Type: RoofGable, Pitch: pitch}},
+		{"gable L-shape with overhang", lShape, RoofParams{Type: RoofGable, Pitch: pitch, Overhang: 0.3}},
+		{"gable U-shape", uShape, RoofParams{Type: RoofGable, Pitch: pitch}},
+		{"mansard rectangle", rect, RoofParams{Type: RoofMansard, Pitch: pitch, MansardPitch: math.Pi / 3}},
+		{"mansard L-shape with overhang", lShape, RoofParams{Type: RoofMansard, Pitch: pitch, MansardPitch: math.Pi / 3, Overhang: 0.3}},
+		{"mansard U-shape", uShape, RoofParams{Type: RoofMansard, Pitch: pitch, MansardPitch: math.Pi / 3, MansardBreak: 0.3}},
+		{"shed rectangle", rect, RoofParams{Type: RoofShed, Pitch: pitch}},
+		{"shed L-shape with overhang", lShape, RoofParams{Type: RoofShed, Pitch: pitch, Overhang: 0.3, ShedEdge: 2}},
+		{"shed rectangle (clockwise)", reversedPath(rect), RoofParams{Type: RoofShed, Pitch: pitch, ShedEdge: 1}},
+	} {
+		m, err := GenerateRoof(tc.footprint, tc.params)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if n := openEdges(m); n != 0 {
+			t.Errorf("%s: roof mesh has %d open edges, want 0", tc.name, n)
+		}
+		// Since the mesh is closed, a positive volume means that all
+		// triangles face outwards.
+		if vol := signedVolume(m); vol <= 0 {
+			t.Errorf("%s: got volume %f, want > 0", tc.name, vol)
+		}
+	}
+}
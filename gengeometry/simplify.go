@@ -11,7 +11,7 @@ func getSqSegDist(p vectors.Vec2, p1, p2 vectors.Vec2) float64 {
 	dy := p2.Y - y
 
 	if dx != 0 || dy != 0 {
-		t := (p.X-x)*dx + (p.Y-y)*dy/(dx*dx+dy*dy)
+		t := ((p.X-x)*dx + (p.Y-y)*dy) / (dx*dx + dy*dy)
 		if t > 1 {
 			x = p2.X
 			y = p2.Y
@@ -0,0 +1,25 @@
+package gengeometry
+
+import (
+	"math"
+	"testing"
+
+	"github.com/Flokey82/go_gens/vectors"
+)
+
+func TestGetSqSegDist(t *testing.T) {
+	a, b := vectors.Vec2{X: 0, Y: 0}, vectors.Vec2{X: 2, Y: 0}
+	for _, tc := range []struct {
+		p    vectors.Vec2
+		want float64
+	}{
+		{vectors.Vec2{X: 1, Y: 1}, 1},   // Above the middle of the segment.
+		{vectors.Vec2{X: 0.5, Y: 2}, 4}, // Above the segment, close to the start.
+		{vectors.Vec2{X: -1, Y: 0}, 1},  // Before the start.
+		{vectors.Vec2{X: 3, Y: 1}, 2},   // Beyond the end.
+	} {
+		if got := getSqSegDist(tc.p, a, b); math.Abs(got-tc.want) > 1e-9 {
+			t.Errorf("getSqSegDist(%v, %v, %v) = %f, want %f", tc.p, a, b, got, tc.want)
+		}
+	}
+}
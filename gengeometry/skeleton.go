@@ -0,0 +1,505 @@
+package gengeometry
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/Flokey82/go_gens/vectors"
+)
+
+// Skeleton is the straight skeleton of a simple polygon, which is the path
+// traced by the vertices while the edges of the polygon move inwards at the
+// same speed. It is useful for generating roofs, since the faces of the
+// skeleton (with the Z coordinate set to the time the wavefront reached the
+// point) form a hip roof with a 45° pitch.
+type Skeleton struct {
+	Nodes []vectors.Vec3 // Positions (X, Y) and distance from the outline (Z).
+	Arcs  []SkeletonArc  // Edges of the skeleton.
+	Faces [][]int        // Node indices of the face of each polygon edge.
+}
+
+// SkeletonArc is an edge of the straight skeleton.
+type SkeletonArc struct {
+	From, To int    // Node indices.
+	Faces    [2]int // Indices of the polygon edges on either side of the arc.
+}
+
+// Face returns the points of the face of the polygon edge with the given
+// index (the edge from point i to point i+1). The first two points are the
+// start and end of the edge, so the face has the same orientation as the
+// polygon.
+func (s *Skeleton) Face(i int) []vectors.Vec3 {
+	face := make([]vectors.Vec3, len(s.Faces[i]))
+	for j, idx := range s.Faces[i] {
+		face[j] = s.Nodes[idx]
+	}
+	return face
+}
+
+// skelVertex is a vertex of the wavefront.
+type skelVertex struct {
+	node       int          // Node where the vertex started.
+	pos        vectors.Vec2 // Position at time t0.
+	t0         float64      // Time when the vertex started.
+	vel        vectors.Vec2 // Velocity of the vertex.
+	degenerate bool         // In and out edge are antiparallel.
+	in, out    int          // Indices of the incoming and outgoing edge.
+	prev, next *skelVertex
+	active     bool
+}
+
+// at returns the position of the vertex at time t.
+func (v *skelVertex) at(t float64) vectors.Vec2 {
+	return v.pos.Add(v.vel.Mul(t - v.t0))
+}
+
+// skelEdge is an edge of the original polygon.
+type skelEdge struct {
+	dir    vectors.Vec2 // Normalized direction.
+	normal vectors.Vec2 // Inward normal.
+	offset float64      // Dot product of the normal with any point of the edge.
+}
+
+// skeletonBuilder computes the straight skeleton by simulating the
+// wavefront event by event.
+type skeletonBuilder struct {
+	s     *Skeleton
+	edges []skelEdge
+	verts []*skelVertex
+	eps   float64
+	t     float64 // Current time.
+}
+
+// ComputeStraightSkeleton computes the straight skeleton of the given simple
+// polygon (which can be concave, but must not intersect itself).
+// The faces of the skeleton are returned in the order of the polygon edges
+// and have the same orientation as the polygon.
+func ComputeStraightSkeleton(points []vectors.Vec2) (*Skeleton, error) {
+	n := len(points)
+	if n < 3 {
+		return nil, errors.New("polygon needs at least 3 points")
+	}
+
+	// Make sure the polygon is counter-clockwise, so the inside is on the left.
+	ccw := polygonOrder(points) > 0
+	pts := points
+	if !ccw {
+		pts = reversedPath(points)
+	}
+
+	extent := 0.0
+	for _, p := range pts {
+		extent = math.Max(extent, math.Max(math.Abs(p.X), math.Abs(p.Y)))
+	}
+	b := &skeletonBuilder{
+		s:   &Skeleton{},
+		eps: math.Max(extent, 1) * 1e-9,
+	}
+	for i, p := range pts {
+		d := vectors.Sub2(pts[(i+1)%n], p)
+		if d.Len() <= b.eps {
+			return nil, fmt.Errorf("polygon has a zero length edge at point %d", i)
+		}
+		d = d.Normalize()
+		nrm := vectors.Vec2{X: -d.Y, Y: d.X}
+		b.edges = append(b.edges, skelEdge{dir: d, normal: nrm, offset: vectors.Dot2(nrm, p)})
+		b.s.Nodes = append(b.s.Nodes, vectors.NewVec3(p.X, p.Y, 0))
+	}
+
+	// Set up the initial wavefront.
+	var prev *skelVertex
+	for i, p := range pts {
+		v := b.newVertex(i, p, (i+n-1)%n, i)
+		if prev != nil {
+			prev.next, v.prev = v, prev
+		}
+		prev = v
+	}
+	prev.next, b.verts[0].prev = b.verts[0], prev
+	b.cleanup(b.verts[0])
+
+	// Process the events until the wavefront has vanished.
+	for iter := 0; ; iter++ {
+		if iter > 10*n*n+100 {
+			return nil, errors.New("straight skeleton did not converge")
+		}
+		ev, ok := b.nextEvent()
+		if !ok {
+			break
+		}
+		b.t = math.Max(b.t, ev.t)
+		if ev.split == nil {
+			b.edgeEvent(ev.v, ev.p)
+		} else {
+			b.splitEvent(ev.v, ev.split, ev.p)
+		}
+	}
+	for _, v := range b.verts {
+		if v.active {
+			return nil, errors.New("straight skeleton did not converge")
+		}
+	}
+
+	if err := b.buildFaces(); err != nil {
+		return nil, err
+	}
+
+	// Map the faces back to the original edge order.
+	if !ccw {
+		faces := make([][]int, n)
+		for i := range faces {
+			faces[i] = b.s.Faces[(2*n-2-i)%n]
+		}
+		b.s.Faces = faces
+
+		// The node indices of the original points are reversed as well.
+		remap := func(idx int) int {
+			if idx < n {
+				return n - 1 - idx
+			}
+			return idx
+		}
+		for i := 0; i < n/2; i++ {
+			b.s.Nodes[i], b.s.Nodes[n-1-i] = b.s.Nodes[n-1-i], b.s.Nodes[i]
+		}
+		for i := range b.s.Arcs {
+			a := &b.s.Arcs[i]
+			a.From, a.To = remap(a.From), remap(a.To)
+			a.Faces = [2]int{(2*n - 2 - a.Faces[0]) % n, (2*n - 2 - a.Faces[1]) % n}
+		}
+		for i, f := range b.s.Faces {
+			// Reverse the face, so it starts with the start of the edge.
+			rf := []int{remap(f[1]), remap(f[0])}
+			for j := len(f) - 1; j >= 2; j-- {
+				rf = append(rf, remap(f[j]))
+			}
+			b.s.Faces[i] = rf
+		}
+	}
+	return b.s, nil
+}
+
+// newVertex adds a new wavefront vertex between the given edges.
+func (b *skeletonBuilder) newVertex(node int, pos vectors.Vec2, in, out int) *skelVertex {
+	v := &skelVertex{
+		node:   node,
+		pos:    pos,
+		t0:     b.t,
+		in:     in,
+		out:    out,
+		active: true,
+	}
+
+	// The vertex moves so that it stays on both edges, which move inwards
+	// at unit speed: vel·n_in = 1 and vel·n_out = 1.
+	nIn, nOut := b.edges[in].normal, b.edges[out].normal
+	dot := vectors.Dot2(nIn, nOut)
+	if 1+dot < 1e-9 {
+		v.degenerate = true
+	} else {
+		v.vel = nIn.Add(nOut).Mul(1 / (1 + dot))
+	}
+	b.verts = append(b.verts, v)
+	return v
+}
+
+// addNode adds a skeleton node at the given position and the current time.
+func (b *skeletonBuilder) addNode(p vectors.Vec2) int {
+	b.s.Nodes = append(b.s.Nodes, vectors.NewVec3(p.X, p.Y, b.t))
+	return len(b.s.Nodes) - 1
+}
+
+// finish ends the given vertex at the given node.
+func (b *skeletonBuilder) finish(v *skelVertex, node int) {
+	v.active = false
+	if v.node != node {
+		b.s.Arcs = append(b.s.Arcs, SkeletonArc{From: v.node, To: node, Faces: [2]int{v.in, v.out}})
+	}
+}
+
+// replace replaces the vertices from first to last (following next) with the
+// given vertex.
+func (b *skeletonBuilder) replace(first, last, v *skelVertex) {
+	if first.prev == last {
+		// The whole loop is replaced.
+		v.prev, v.next = v, v
+		return
+	}
+	v.prev, v.next = first.prev, last.next
+	first.prev.next = v
+	last.next.prev = v
+}
+
+// skelEvent is an event of the wavefront.
+type skelEvent struct {
+	t     float64
+	p     vectors.Vec2
+	v     *skelVertex // Vertex starting the edge that collapses, or the reflex vertex.
+	split *skelVertex // Vertex starting the edge that is split (nil for edge events).
+}
+
+// nextEvent returns the earliest event of the wavefront.
+func (b *skeletonBuilder) nextEvent() (skelEvent, bool) {
+	best := skelEvent{t: math.Inf(1)}
+	for _, v := range b.verts {
+		if !v.active {
+			continue
+		}
+
+		// Edge event: the edge from v to v.next collapses.
+		w := v.next
+		e := b.edges[v.out]
+		rate := vectors.Dot2(vectors.Sub2(w.vel, v.vel), e.dir)
+		if rate < -1e-12 {
+			l := vectors.Dot2(vectors.Sub2(w.at(b.t), v.at(b.t)), e.dir)
+			if t := b.t - l/rate; t < best.t {
+				t = math.Max(t, b.t)
+				best = skelEvent{t: t, p: v.at(t).Add(w.at(t)).Mul(0.5), v: v}
+			}
+		}
+
+		// Split event: the reflex vertex v hits an edge of the wavefront.
+		if vectors.Cross2(b.edges[v.in].dir, e.dir) >= -1e-12 {
+			continue
+		}
+		for u := v.next.next; u != v.prev; u = u.next {
+			ue := b.edges[u.out]
+			approach := 1 - vectors.Dot2(ue.normal, v.vel)
+			if approach <= 1e-12 {
+				continue
+			}
+			// Solve n·(pos + vel*(t-t0)) = offset + t for t.
+			t := (vectors.Dot2(ue.normal, v.pos) - vectors.Dot2(ue.normal, v.vel)*v.t0 - ue.offset) / approach
+			if t < b.t-b.eps || t >= best.t {
+				continue
+			}
+			t = math.Max(t, b.t)
+			p := v.at(t)
+
+			// The point needs to be within the edge at that time.
+			if vectors.Dot2(vectors.Sub2(p, u.at(t)), ue.dir) < -b.eps ||
+				vectors.Dot2(vectors.Sub2(u.next.at(t), p), ue.dir) < -b.eps {
+				continue
+			}
+			best = skelEvent{t: t, p: p, v: v, split: u}
+		}
+	}
+	return best, !math.IsInf(best.t, 1)
+}
+
+// edgeEvent handles the collapse of the edge starting at vertex v.
+func (b *skeletonBuilder) edgeEvent(v *skelVertex, p vectors.Vec2) {
+	w := v.next
+	node := b.addNode(p)
+	b.finish(v, node)
+	b.finish(w, node)
+	nv := b.newVertex(node, p, v.in, w.out)
+	b.replace(v, w, nv)
+	b.cleanup(nv)
+}
+
+// splitEvent handles the reflex vertex v hitting the edge starting at u,
+// which splits the wavefront into two loops.
+func (b *skeletonBuilder) splitEvent(v, u *skelVertex, p vectors.Vec2) {
+	node := b.addNode(p)
+	b.finish(v, node)
+
+	// Loop 1: v1 -> u.next -> ... -> v.prev -> v1
+	// Loop 2: v2 -> v.next -> ... -> u -> v2
+	v1 := b.newVertex(node, p, v.in, u.out)
+	v2 := b.newVertex(node, p, u.out, v.out)
+	un := u.next
+	v1.prev, v1.next = v.prev, un
+	v.prev.next, un.prev = v1, v1
+	v2.prev, v2.next = u, v.next
+	u.next, v.next.prev = v2, v2
+	b.cleanup(v1)
+	b.cleanup(v2)
+}
+
+// cleanup resolves degenerate situations in the loop containing v, which
+// occur when multiple events happen at the same time and place: vertices
+// at the same position are merged, and vertices between antiparallel edges
+// (which means that part of the wavefront has collapsed to a line) are
+// moved along that line to their neighbor.
+func (b *skeletonBuilder) cleanup(v *skelVertex) {
+	for {
+		// Count the vertices of the loop.
+		var loop []*skelVertex
+		for x := v; ; x = x.next {
+			loop = append(loop, x)
+			if x.next == v || len(loop) > len(b.verts) {
+				break
+			}
+		}
+		if len(loop) <= 2 {
+			// The loop has collapsed to a point or a line.
+			if len(loop) == 2 && loop[0].at(b.t).DistanceTo(loop[1].at(b.t)) > b.eps*10 {
+				a0, a1 := b.addNode(loop[0].at(b.t)), b.addNode(loop[1].at(b.t))
+				b.finish(loop[0], a0)
+				b.finish(loop[1], a1)
+				b.s.Arcs = append(b.s.Arcs, SkeletonArc{From: a0, To: a1, Faces: [2]int{loop[0].in, loop[0].out}})
+			} else {
+				node := b.addNode(loop[0].at(b.t))
+				for _, x := range loop {
+					b.finish(x, node)
+				}
+			}
+			return
+		}
+
+		changed := false
+		for _, x := range loop {
+			y := x.next
+			if x.at(b.t).DistanceTo(y.at(b.t)) <= b.eps*10 {
+				// Merge the coincident vertices.
+				p := x.at(b.t)
+				node := b.addNode(p)
+				b.finish(x, node)
+				b.finish(y, node)
+				nv := b.newVertex(node, p, x.in, y.out)
+				b.replace(x, y, nv)
+				v = nv
+				changed = true
+				break
+			}
+			if x.degenerate {
+				// Move the vertex to the closer neighbor on the line.
+				p := x.at(b.t)
+				prev, next := x.prev, x.next
+				if p.DistanceTo(next.at(b.t)) <= p.DistanceTo(prev.at(b.t)) {
+					q := next.at(b.t)
+					node := b.addNode(q)
+					b.finish(x, node)
+					b.finish(next, node)
+					nv := b.newVertex(node, q, x.in, next.out)
+					b.replace(x, next, nv)
+					v = nv
+				} else {
+					q := prev.at(b.t)
+					node := b.addNode(q)
+					b.finish(prev, node)
+					b.finish(x, node)
+					nv := b.newVertex(node, q, prev.in, x.out)
+					b.replace(prev, x, nv)
+					v = nv
+				}
+				changed = true
+				break
+			}
+		}
+		if !changed {
+			return
+		}
+	}
+}
+
+// buildFaces traces the face of each polygon edge through the arcs.
+func (b *skeletonBuilder) buildFaces() error {
+	n := len(b.edges)
+
+	// Merge nodes at the same position, since events at the same place
+	// might have created multiple nodes.
+	remap := make([]int, len(b.s.Nodes))
+	for i := range remap {
+		remap[i] = i
+	}
+	for i := n; i < len(b.s.Nodes); i++ {
+		for j := 0; j < i; j++ {
+			if remap[j] == j && vectors.Dist3(b.s.Nodes[i], b.s.Nodes[j]) <= b.eps*100 {
+				remap[i] = j
+				break
+			}
+		}
+	}
+	var arcs []SkeletonArc
+	for _, a := range b.s.Arcs {
+		a.From, a.To = remap[a.From], remap[a.To]
+		if a.From != a.To {
+			arcs = append(arcs, a)
+		}
+	}
+	b.s.Arcs = arcs
+
+	// Collect the arcs of each face.
+	faceArcs := make([][]int, n)
+	for i, a := range b.s.Arcs {
+		faceArcs[a.Faces[0]] = append(faceArcs[a.Faces[0]], i)
+		if a.Faces[1] != a.Faces[0] {
+			faceArcs[a.Faces[1]] = append(faceArcs[a.Faces[1]], i)
+		}
+	}
+
+	b.s.Faces = make([][]int, n)
+	for e := 0; e < n; e++ {
+		start, end := e, (e+1)%n
+		face := []int{start, end}
+		used := make(map[int]bool)
+		cur := end
+		for cur != start {
+			// Follow the next unused arc of this face, preferring arcs
+			// leading back to the start.
+			next := -1
+			for _, ai := range faceArcs[e] {
+				if used[ai] {
+					continue
+				}
+				a := b.s.Arcs[ai]
+				var other int
+				switch cur {
+				case a.From:
+					other = a.To
+				case a.To:
+					other = a.From
+				default:
+					continue
+				}
+				if next < 0 || other == start {
+					next = ai
+				}
+			}
+			if next < 0 {
+				return fmt.Errorf("straight skeleton: face %d is not closed", e)
+			}
+			used[next] = true
+			a := b.s.Arcs[next]
+			if a.From == cur {
+				cur = a.To
+			} else {
+				cur = a.From
+			}
+			if cur != start {
+				face = append(face, cur)
+			}
+		}
+		b.s.Faces[e] = face
+	}
+
+	// Remove unused nodes, keeping the original points at the start.
+	used := make([]bool, len(b.s.Nodes))
+	for i := 0; i < n; i++ {
+		used[i] = true
+	}
+	for _, a := range b.s.Arcs {
+		used[a.From], used[a.To] = true, true
+	}
+	newIdx := make([]int, len(b.s.Nodes))
+	var nodes []vectors.Vec3
+	for i, p := range b.s.Nodes {
+		if used[i] {
+			newIdx[i] = len(nodes)
+			nodes = append(nodes, p)
+		}
+	}
+	b.s.Nodes = nodes
+	for i := range b.s.Arcs {
+		b.s.Arcs[i].From, b.s.Arcs[i].To = newIdx[b.s.Arcs[i].From], newIdx[b.s.Arcs[i].To]
+	}
+	for _, f := range b.s.Faces {
+		for j := range f {
+			f[j] = newIdx[f[j]]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package gengeometry
+
+import (
+	"math"
+	"testing"
+
+	"github.com/Flokey82/go_gens/vectors"
+)
+
+func TestComputeStraightSkeleton(t *testing.T) {
+	rect := []vectors.Vec2{{X: 0, Y: 0}, {X: 4, Y: 0}, {X: 4, Y: 2}, {X: 0, Y: 2}}
+	for _, tc := range []struct {
+		name    string
+		points  []vectors.Vec2
+		wantMax float64 // Maximum distance of a node from the outline.
+	}{
+		{"square", square(0, 0, 2).Points, 1},
+		{"rectangle", rect, 1},
+		{"rectangle (clockwise)", reversedPath(rect), 1},
+		{"triangle", []vectors.Vec2{{X: 0, Y: 0}, {X: 4, Y: 0}, {X: 0, Y: 3}}, 1}, // Inradius of the 3-4-5 triangle.
+		{"L-shape", []vectors.Vec2{{X: 0, Y: 0}, {X: 6, Y: 0}, {X: 6, Y: 2}, {X: 2, Y: 2}, {X: 2, Y: 6}, {X: 0, Y: 6}}, 1},
+		{"U-shape", []vectors.Vec2{{X: 0, Y: 0}, {X: 6, Y: 0}, {X: 6, Y: 5}, {X: 4, Y: 5}, {X: 4, Y: 2}, {X: 2, Y: 2}, {X: 2, Y: 5}, {X: 0, Y: 5}}, 1},
+	} {
+		s, err := ComputeStraightSkeleton(tc.points)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		n := len(tc.points)
+		if len(s.Faces) != n {
+			t.Fatalf("%s: got %d faces, want %d", tc.name, len(s.Faces), n)
+		}
+		var maxZ, area float64
+		for i := range s.Faces {
+			face := s.Face(i)
+			a, b := tc.points[i], tc.points[(i+1)%n]
+
+			// Each face starts with its polygon edge.
+			if face[0].X != a.X || face[0].Y != a.Y || face[1].X != b.X || face[1].Y != b.Y {
+				t.Errorf("%s: face %d starts with %v, %v, want %v, %v", tc.name, i, face[0], face[1], a, b)
+			}
+
+			// The distance from the outline of all nodes of a face is the
+			// distance from the line of its edge.
+			d := vectors.Sub2(b, a).Normalize()
+			for _, p := range face {
+				dist := math.Abs(vectors.Cross2(d, vectors.Sub2(vectors.Vec2{X: p.X, Y: p.Y}, a)))
+				if math.Abs(dist-p.Z) > 1e-6 {
+					t.Errorf("%s: face %d: node %v has distance %f from its edge", tc.name, i, p, dist)
+				}
+				maxZ = math.Max(maxZ, p.Z)
+			}
+
+			// The faces have the same orientation as the polygon.
+			path := make([]vectors.Vec2, len(face))
+			for j, p := range face {
+				path[j] = vectors.Vec2{X: p.X, Y: p.Y}
+			}
+			if polygonOrder(path)*polygonOrder(tc.points) <= 0 {
+				t.Errorf("%s: face %d has the wrong orientation", tc.name, i)
+			}
+			area += math.Abs(polygonOrder(path)) / 2
+		}
+
+		// The faces cover the polygon.
+		if want := math.Abs(polygonOrder(tc.points)) / 2; math.Abs(area-want) > 1e-6 {
+			t.Errorf("%s: got face area %f, want %f", tc.name, area, want)
+		}
+		if math.Abs(maxZ-tc.wantMax) > 1e-6 {
+			t.Errorf("%s: got max distance %f, want %f", tc.name, maxZ, tc.wantMax)
+		}
+	}
+}
+
+func TestComputeStraightSkeletonInvalid(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		points []vectors.Vec2
+	}{
+		{"too few points", []vectors.Vec2{{X: 0, Y: 0}, {X: 1, Y: 0}}},
+		{"zero length edge", []vectors.Vec2{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 1, Y: 0}, {X: 0, Y: 1}}},
+	} {
+		if _, err := ComputeStraightSkeleton(tc.points); err == nil {
+			t.Errorf("%s: got no error", tc.name)
+		}
+	}
+}
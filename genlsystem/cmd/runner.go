@@ -1,15 +1,47 @@
 package main
 
 import (
+	"log"
+
 	"github.com/Flokey82/go_gens/genlsystem"
 )
 
 func main() {
-	genlsystem.ExportToPNG("bintree.png", genlsystem.BinTree(8))
-	genlsystem.ExportToPNG("plant.png", genlsystem.Plant(7))
-	genlsystem.ExportToPNG("tree.png", genlsystem.Tree(9))
-	genlsystem.ExportToPNG("hilbert.png", genlsystem.Hilbert(5))
-	genlsystem.Hilbert3d("out.obj", 3)
-	genlsystem.Plant3d("plant.obj", 4)
-	genlsystem.Pyramid3d("pyramid.obj", 5)
+	export("bintree", genlsystem.BinTreeDrawing(8))
+	export("plant", genlsystem.PlantDrawing(7))
+	export("tree", genlsystem.TreeDrawing(9))
+	export("hilbert", genlsystem.HilbertDrawing(5))
+	if err := genlsystem.Hilbert3d("out.obj", 3); err != nil {
+		log.Fatal(err)
+	}
+	if err := genlsystem.Plant3d("plant.obj", 4); err != nil {
+		log.Fatal(err)
+	}
+	if err := genlsystem.Pyramid3d("pyramid.obj", 5); err != nil {
+		log.Fatal(err)
+	}
+
+	// Render the plants defined in grammar files.
+	for _, name := range []string{"plant", "tree", "signal"} {
+		g, err := genlsystem.LoadGrammar("../grammars/" + name + ".txt")
+		if err != nil {
+			log.Fatal(err)
+		}
+		d, err := g.RenderDrawing(-1)
+		if err != nil {
+			log.Fatal(err)
+		}
+		export("grammar_"+name, d)
+	}
+}
+
+// export writes the given drawing as PNG and SVG.
+func export(name string, d *genlsystem.Drawing) {
+	if err := genlsystem.ExportToPNG(name+".png", d.Image(1)); err != nil {
+		log.Fatal(err)
+	}
+	if err := genlsystem.ExportToSVG(name+".svg", d); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Wrote %s.png and %s.svg", name, name)
 }
@@ -0,0 +1,133 @@
+package genlsystem
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	svgo "github.com/ajstarks/svgo"
+	"github.com/llgcode/draw2d/draw2dimg"
+)
+
+// drawingBorder is the margin around the lines of a drawing (in turtle units).
+const drawingBorder = 5.0
+
+// Drawing is the resolution independent result of a turtle run, which can be
+// rasterized at any scale (see Image) or written as SVG (see WriteSVG).
+type Drawing struct {
+	lines  []line
+	bounds Bounds
+}
+
+// Size returns the size of the drawing including the border (in turtle units).
+func (d *Drawing) Size() (width, height float64) {
+	w, h := d.bounds.Size()
+	return w + drawingBorder*2, h + drawingBorder*2
+}
+
+// offset returns the offset that moves the drawing (including the border) to
+// the origin.
+func (d *Drawing) offset() (x, y float64) {
+	return d.bounds.minX - drawingBorder, d.bounds.minY - drawingBorder
+}
+
+// Image rasterizes the drawing, where scale is the number of pixels per
+// turtle unit. Line widths are scaled as well.
+func (d *Drawing) Image(scale float64) image.Image {
+	w, h := d.Size()
+	offx, offy := d.offset()
+
+	img := image.NewRGBA(image.Rect(0, 0, int(w*scale), int(h*scale)))
+	gc := draw2dimg.NewGraphicContext(img)
+
+	for _, line := range d.lines {
+		gc.SetLineWidth(line.width * scale)
+		gc.SetStrokeColor(line.color)
+		gc.MoveTo((line.x1-offx)*scale, (line.y1-offy)*scale)
+		gc.LineTo((line.x2-offx)*scale, (line.y2-offy)*scale)
+		gc.Stroke()
+	}
+	return img
+}
+
+// WriteSVG writes the drawing as SVG to the given writer.
+//
+// The coordinates are written in turtle units, so the SVG has the same
+// size as the image returned by Image(1), but can be scaled without loss.
+// Consecutive connected lines with the same color and width are merged
+// into a single path.
+func (d *Drawing) WriteSVG(w io.Writer) error {
+	width, height := d.Size()
+	offx, offy := d.offset()
+
+	ew := &errWriter{w: w}
+	svg := svgo.New(ew)
+	svg.Start(int(math.Ceil(width)), int(math.Ceil(height)),
+		fmt.Sprintf(`viewBox="0 0 %s %s"`, svgNum(width), svgNum(height)))
+	svg.Gstyle("fill:none;stroke-linecap:round;stroke-linejoin:round")
+
+	var path strings.Builder
+	var cur *line
+	flush := func() {
+		if cur != nil {
+			svg.Path(path.String(), svgStroke(cur.color, cur.width))
+		}
+		path.Reset()
+	}
+	for i := range d.lines {
+		l := &d.lines[i]
+		x1, y1 := svgNum(l.x1-offx), svgNum(l.y1-offy)
+		x2, y2 := svgNum(l.x2-offx), svgNum(l.y2-offy)
+		if cur == nil || !sameStroke(cur, l) || cur.x2 != l.x1 || cur.y2 != l.y1 {
+			flush()
+			fmt.Fprintf(&path, "M%s %s", x1, y1)
+		}
+		fmt.Fprintf(&path, " L%s %s", x2, y2)
+		cur = l
+	}
+	flush()
+
+	svg.Gend()
+	svg.End()
+	return ew.err
+}
+
+// sameStroke returns true if both lines are drawn with the same color and width.
+func sameStroke(a, b *line) bool {
+	return a.width == b.width && color.NRGBAModel.Convert(a.color) == color.NRGBAModel.Convert(b.color)
+}
+
+// svgStroke returns the style of a stroke with the given color and width.
+func svgStroke(c color.Color, width float64) string {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	s := fmt.Sprintf("stroke:rgb(%d,%d,%d);stroke-width:%s", n.R, n.G, n.B, svgNum(width))
+	if n.A != 0xFF {
+		s += fmt.Sprintf(";stroke-opacity:%s", svgNum(float64(n.A)/0xFF))
+	}
+	return s
+}
+
+// svgNum formats the given number with a precision sufficient for SVG output.
+func svgNum(v float64) string {
+	return strconv.FormatFloat(math.Round(v*1000)/1000, 'f', -1, 64)
+}
+
+// errWriter remembers the first error of the underlying writer, since the
+// SVG library does not return any errors.
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (e *errWriter) Write(p []byte) (int, error) {
+	if e.err != nil {
+		return 0, e.err
+	}
+	n, err := e.w.Write(p)
+	e.err = err
+	return n, err
+}
@@ -2,31 +2,39 @@ package genlsystem
 
 import (
 	"bufio"
-	"fmt"
 	"image"
 	"image/png"
-	"log"
 	"os"
 )
 
-func ExportToPNG(filePath string, m image.Image) {
+// ExportToPNG writes the given image as PNG to the given path.
+func ExportToPNG(filePath string, m image.Image) error {
 	f, err := os.Create(filePath)
 	if err != nil {
-		log.Println(err)
-		os.Exit(1)
+		return err
 	}
 	defer f.Close()
 
 	b := bufio.NewWriter(f)
 	if err := png.Encode(b, m); err != nil {
-		log.Println(err)
-		os.Exit(1)
+		return err
 	}
 
-	if err := b.Flush(); err != nil {
-		log.Println(err)
-		os.Exit(1)
+	return b.Flush()
+}
+
+// ExportToSVG writes the given drawing as SVG to the given path.
+func ExportToSVG(filePath string, d *Drawing) error {
+	f, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	b := bufio.NewWriter(f)
+	if err := d.WriteSVG(b); err != nil {
+		return err
 	}
 
-	fmt.Printf("Wrote %s\n", filePath)
+	return b.Flush()
 }
@@ -0,0 +1,334 @@
+package genlsystem
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// expr is a compiled arithmetic expression, which is evaluated using the
+// given variables. Boolean results are represented as 1 (true) and 0 (false).
+type expr func(vars map[string]float64) (float64, error)
+
+// exprFuncs are the functions that can be used in expressions.
+var exprFuncs = map[string]func(args []float64) (float64, error){
+	"sin":   exprFunc1(math.Sin),
+	"cos":   exprFunc1(math.Cos),
+	"tan":   exprFunc1(math.Tan),
+	"sqrt":  exprFunc1(math.Sqrt),
+	"abs":   exprFunc1(math.Abs),
+	"floor": exprFunc1(math.Floor),
+	"ceil":  exprFunc1(math.Ceil),
+	"min":   exprFunc2(math.Min),
+	"max":   exprFunc2(math.Max),
+	"pow":   exprFunc2(math.Pow),
+}
+
+func exprFunc1(f func(float64) float64) func(args []float64) (float64, error) {
+	return func(args []float64) (float64, error) {
+		if len(args) != 1 {
+			return 0, fmt.Errorf("expected 1 argument, got %d", len(args))
+		}
+		return f(args[0]), nil
+	}
+}
+
+func exprFunc2(f func(float64, float64) float64) func(args []float64) (float64, error) {
+	return func(args []float64) (float64, error) {
+		if len(args) != 2 {
+			return 0, fmt.Errorf("expected 2 arguments, got %d", len(args))
+		}
+		return f(args[0], args[1]), nil
+	}
+}
+
+// parseExpr compiles the given expression.
+//
+// Supported are numbers, variables, the functions in exprFuncs, parentheses,
+// the arithmetic operators + - * / % ^, the comparison operators
+// < <= > >= == != and the logical operators && || !.
+func parseExpr(s string) (expr, error) {
+	toks, err := tokenizeExpr(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{toks: toks}
+	e, err := p.parseOr()
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression %q: %v", s, err)
+	}
+	if p.pos < len(p.toks) {
+		return nil, fmt.Errorf("invalid expression %q: unexpected %q", s, p.toks[p.pos])
+	}
+	return e, nil
+}
+
+// tokenizeExpr splits the given expression into tokens.
+func tokenizeExpr(s string) ([]string, error) {
+	var toks []string
+	rs := []rune(s)
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsDigit(r) || r == '.':
+			j := i
+			for j < len(rs) && (unicode.IsDigit(rs[j]) || rs[j] == '.') {
+				j++
+			}
+			// Exponent (e.g. 1e-3).
+			if j < len(rs) && (rs[j] == 'e' || rs[j] == 'E') {
+				k := j + 1
+				if k < len(rs) && (rs[k] == '+' || rs[k] == '-') {
+					k++
+				}
+				if k < len(rs) && unicode.IsDigit(rs[k]) {
+					j = k
+					for j < len(rs) && unicode.IsDigit(rs[j]) {
+						j++
+					}
+				}
+			}
+			toks = append(toks, string(rs[i:j]))
+			i = j
+		case unicode.IsLetter(r) || r == '_':
+			j := i
+			for j < len(rs) && (unicode.IsLetter(rs[j]) || unicode.IsDigit(rs[j]) || rs[j] == '_') {
+				j++
+			}
+			toks = append(toks, string(rs[i:j]))
+			i = j
+		case strings.ContainsRune("+-*/%^(),", r):
+			toks = append(toks, string(r))
+			i++
+		case strings.ContainsRune("<>=!&|", r):
+			if i+1 < len(rs) {
+				if op := string(rs[i : i+2]); op == "<=" || op == ">=" || op == "==" || op == "!=" || op == "&&" || op == "||" {
+					toks = append(toks, op)
+					i += 2
+					continue
+				}
+			}
+			if r == '<' || r == '>' || r == '!' {
+				toks = append(toks, string(r))
+				i++
+				continue
+			}
+			return nil, fmt.Errorf("invalid operator %q in %q", r, s)
+		default:
+			return nil, fmt.Errorf("invalid character %q in %q", r, s)
+		}
+	}
+	return toks, nil
+}
+
+// exprParser is a recursive descent parser for expressions.
+type exprParser struct {
+	toks []string
+	pos  int
+}
+
+// peek returns the current token (or an empty string at the end).
+func (p *exprParser) peek() string {
+	if p.pos < len(p.toks) {
+		return p.toks[p.pos]
+	}
+	return ""
+}
+
+// parseBinary parses a chain of binary operators of the same precedence.
+func (p *exprParser) parseBinary(next func() (expr, error), ops map[string]func(a, b float64) float64) (expr, error) {
+	left, err := next()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := ops[p.peek()]
+		if !ok {
+			return left, nil
+		}
+		p.pos++
+		right, err := next()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryExpr(left, right, op)
+	}
+}
+
+func binaryExpr(a, b expr, op func(a, b float64) float64) expr {
+	return func(vars map[string]float64) (float64, error) {
+		va, err := a(vars)
+		if err != nil {
+			return 0, err
+		}
+		vb, err := b(vars)
+		if err != nil {
+			return 0, err
+		}
+		return op(va, vb), nil
+	}
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func (p *exprParser) parseOr() (expr, error) {
+	return p.parseBinary(p.parseAnd, map[string]func(a, b float64) float64{
+		"||": func(a, b float64) float64 { return boolToFloat(a != 0 || b != 0) },
+	})
+}
+
+func (p *exprParser) parseAnd() (expr, error) {
+	return p.parseBinary(p.parseCompare, map[string]func(a, b float64) float64{
+		"&&": func(a, b float64) float64 { return boolToFloat(a != 0 && b != 0) },
+	})
+}
+
+func (p *exprParser) parseCompare() (expr, error) {
+	return p.parseBinary(p.parseSum, map[string]func(a, b float64) float64{
+		"<":  func(a, b float64) float64 { return boolToFloat(a < b) },
+		"<=": func(a, b float64) float64 { return boolToFloat(a <= b) },
+		">":  func(a, b float64) float64 { return boolToFloat(a > b) },
+		">=": func(a, b float64) float64 { return boolToFloat(a >= b) },
+		"==": func(a, b float64) float64 { return boolToFloat(a == b) },
+		"!=": func(a, b float64) float64 { return boolToFloat(a != b) },
+	})
+}
+
+func (p *exprParser) parseSum() (expr, error) {
+	return p.parseBinary(p.parseProduct, map[string]func(a, b float64) float64{
+		"+": func(a, b float64) float64 { return a + b },
+		"-": func(a, b float64) float64 { return a - b },
+	})
+}
+
+func (p *exprParser) parseProduct() (expr, error) {
+	return p.parseBinary(p.parseUnary, map[string]func(a, b float64) float64{
+		"*": func(a, b float64) float64 { return a * b },
+		"/": func(a, b float64) float64 { return a / b },
+		"%": math.Mod,
+	})
+}
+
+func (p *exprParser) parseUnary() (expr, error) {
+	switch p.peek() {
+	case "-", "!":
+		op := p.peek()
+		p.pos++
+		e, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return func(vars map[string]float64) (float64, error) {
+			v, err := e(vars)
+			if op == "-" {
+				return -v, err
+			}
+			return boolToFloat(v == 0), err
+		}, nil
+	case "+":
+		p.pos++
+		return p.parseUnary()
+	}
+	return p.parsePower()
+}
+
+func (p *exprParser) parsePower() (expr, error) {
+	base, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek() != "^" {
+		return base, nil
+	}
+	p.pos++
+	exp, err := p.parseUnary() // Right associative.
+	if err != nil {
+		return nil, err
+	}
+	return binaryExpr(base, exp, math.Pow), nil
+}
+
+func (p *exprParser) parsePrimary() (expr, error) {
+	tok := p.peek()
+	if tok == "" {
+		return nil, fmt.Errorf("unexpected end")
+	}
+	p.pos++
+	if tok == "(" {
+		e, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, fmt.Errorf("missing ')'")
+		}
+		p.pos++
+		return e, nil
+	}
+	if r := rune(tok[0]); unicode.IsDigit(r) || r == '.' {
+		v, err := strconv.ParseFloat(tok, 64)
+		if err != nil {
+			return nil, err
+		}
+		return func(map[string]float64) (float64, error) { return v, nil }, nil
+	}
+	if r := rune(tok[0]); !unicode.IsLetter(r) && r != '_' {
+		return nil, fmt.Errorf("unexpected %q", tok)
+	}
+
+	// Function call.
+	if p.peek() == "(" {
+		f, ok := exprFuncs[tok]
+		if !ok {
+			return nil, fmt.Errorf("unknown function %q", tok)
+		}
+		p.pos++
+		var args []expr
+		for p.peek() != ")" {
+			a, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, a)
+			if p.peek() == "," {
+				p.pos++
+			} else if p.peek() != ")" {
+				return nil, fmt.Errorf("expected ',' or ')' in call of %q", tok)
+			}
+		}
+		p.pos++
+		return func(vars map[string]float64) (float64, error) {
+			vals := make([]float64, len(args))
+			for i, a := range args {
+				v, err := a(vars)
+				if err != nil {
+					return 0, err
+				}
+				vals[i] = v
+			}
+			v, err := f(vals)
+			if err != nil {
+				return 0, fmt.Errorf("%s: %v", tok, err)
+			}
+			return v, nil
+		}, nil
+	}
+
+	// Variable.
+	return func(vars map[string]float64) (float64, error) {
+		v, ok := vars[tok]
+		if !ok {
+			return 0, fmt.Errorf("unknown variable %q", tok)
+		}
+		return v, nil
+	}, nil
+}
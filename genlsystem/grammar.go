@@ -0,0 +1,571 @@
+package genlsystem
+
+import (
+	"bufio"
+	"fmt"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Symbol is a (parametric) symbol of an L-system string.
+type Symbol struct {
+	Name   string
+	Params []float64
+}
+
+// String returns the symbol in the notation used by grammar files (e.g. "F(1,0.5)").
+func (s Symbol) String() string {
+	if len(s.Params) == 0 {
+		return s.Name
+	}
+	params := make([]string, len(s.Params))
+	for i, p := range s.Params {
+		params[i] = strconv.FormatFloat(p, 'g', -1, 64)
+	}
+	return s.Name + "(" + strings.Join(params, ",") + ")"
+}
+
+// SymbolsToString returns the given symbols as a string.
+func SymbolsToString(syms []Symbol) string {
+	var sb strings.Builder
+	for _, s := range syms {
+		sb.WriteString(s.String())
+	}
+	return sb.String()
+}
+
+// SymbolNames returns the names of the given symbols, which allows using the
+// generated symbols with the turtle rules of Turtle.Go and Turtle3d.Go.
+func SymbolNames(syms []Symbol) []string {
+	names := make([]string, len(syms))
+	for i, s := range syms {
+		names[i] = s.Name
+	}
+	return names
+}
+
+// symbolPattern is a symbol with formal parameters, which is used to match
+// symbols in productions.
+type symbolPattern struct {
+	name   string
+	params []string
+}
+
+// matches returns true if the given symbol matches the pattern and sets the
+// formal parameters to the actual values.
+func (p symbolPattern) matches(s Symbol, vars map[string]float64) bool {
+	if s.Name != p.name || len(s.Params) != len(p.params) {
+		return false
+	}
+	for i, name := range p.params {
+		vars[name] = s.Params[i]
+	}
+	return true
+}
+
+// symbolTemplate is a symbol with parameter expressions, which is used to
+// generate symbols in productions.
+type symbolTemplate struct {
+	name string
+	args []expr
+}
+
+// eval returns the symbol with the parameters evaluated using the given variables.
+func (t symbolTemplate) eval(vars map[string]float64) (Symbol, error) {
+	s := Symbol{Name: t.name}
+	for _, a := range t.args {
+		v, err := a(vars)
+		if err != nil {
+			return s, err
+		}
+		s.Params = append(s.Params, v)
+	}
+	return s, nil
+}
+
+// production is a single rewriting rule of a Grammar.
+type production struct {
+	left      []symbolPattern // Left context (optional).
+	pred      symbolPattern   // Predecessor.
+	right     []symbolPattern // Right context (optional).
+	condition expr            // Condition (optional).
+	successor []symbolTemplate
+	weight    float64 // Weight for stochastic selection.
+}
+
+// specificity returns how specific the production is, which is the number of
+// context symbols plus one if it has a condition. If multiple productions
+// match a symbol, only the most specific ones are considered.
+func (p *production) specificity() int {
+	n := len(p.left) + len(p.right)
+	if p.condition != nil {
+		n++
+	}
+	return n
+}
+
+// Grammar is an L-system grammar supporting stochastic, parametric and
+// context-sensitive productions, as well as turtle bindings for drawing the
+// generated symbols.
+//
+// Grammars can be loaded from text files, where each line starts with a
+// keyword (everything after a '#' is a comment):
+//
+//	axiom F(1,0.1)X         # Start symbols.
+//	iterations 5            # Default number of iterations.
+//	define angle 25         # Constant that can be used in expressions.
+//	ignore + -              # Symbols skipped when matching contexts.
+//	rule X -> F[+X][-X]     # Production (see AddRule).
+//	start turn(-90)         # Turtle commands run before drawing.
+//	bind F(l,w) = width(w) draw(l)
+//	bind + = turn(angle)
+//
+// Symbols are single characters, optionally followed by parameters in
+// parentheses. The available turtle commands are draw(length), move(length),
+// turn(degrees) (or yaw in 3D), width(w), color(r,g,b[,a]) (0-255), push and
+// pop, as well as pitch(degrees), roll(degrees), leaf(length,width) and
+// flower(petals,length,width), which are only supported in 3D.
+type Grammar struct {
+	Axiom      []Symbol           // Start symbols.
+	Iterations int                // Default number of iterations (if set in the grammar file).
+	Constants  map[string]float64 // Constants that can be used in expressions.
+	ignore     map[string]bool
+	rules      map[string][]*production
+	start      []turtleCommand
+	bindings   map[string]*turtleBinding
+}
+
+// NewGrammar returns a new, empty grammar.
+func NewGrammar() *Grammar {
+	return &Grammar{
+		Constants: make(map[string]float64),
+		ignore:    make(map[string]bool),
+		rules:     make(map[string][]*production),
+		bindings:  make(map[string]*turtleBinding),
+	}
+}
+
+// LoadGrammar loads a grammar from the given file.
+func LoadGrammar(filename string) (*Grammar, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		lines = append(lines, sc.Text())
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	g, err := ParseGrammar(strings.Join(lines, "\n"))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	return g, nil
+}
+
+// ParseGrammar parses a grammar from the given source (see Grammar for the format).
+func ParseGrammar(src string) (*Grammar, error) {
+	g := NewGrammar()
+	var axiom string
+	var axiomLine int
+	for i, line := range strings.Split(src, "\n") {
+		if idx := strings.Index(line, "#"); idx >= 0 {
+			line = line[:idx]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		keyword, rest := line, ""
+		if idx := strings.IndexFunc(line, unicode.IsSpace); idx >= 0 {
+			keyword, rest = line[:idx], strings.TrimSpace(line[idx:])
+		}
+		var err error
+		switch keyword {
+		case "axiom":
+			axiom, axiomLine = rest, i+1
+		case "iterations":
+			g.Iterations, err = strconv.Atoi(rest)
+		case "define":
+			err = g.parseDefine(rest)
+		case "ignore":
+			for _, f := range strings.Fields(rest) {
+				for _, r := range f {
+					g.ignore[string(r)] = true
+				}
+			}
+		case "rule":
+			err = g.AddRule(rest)
+		case "start":
+			g.start, err = parseTurtleCommands(rest)
+		case "bind":
+			err = g.Bind(rest)
+		default:
+			err = fmt.Errorf("unknown keyword %q", keyword)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", i+1, err)
+		}
+	}
+	if axiom != "" {
+		if err := g.SetAxiom(axiom); err != nil {
+			return nil, fmt.Errorf("line %d: %v", axiomLine, err)
+		}
+	}
+	return g, nil
+}
+
+// parseDefine parses a constant definition ("name expression").
+func (g *Grammar) parseDefine(s string) error {
+	fields := strings.Fields(s)
+	if len(fields) < 2 {
+		return fmt.Errorf("invalid define %q", s)
+	}
+	e, err := parseExpr(strings.Join(fields[1:], " "))
+	if err != nil {
+		return err
+	}
+	v, err := e(g.Constants)
+	if err != nil {
+		return err
+	}
+	g.Constants[fields[0]] = v
+	return nil
+}
+
+// SetAxiom sets the start symbols (parameters may use constants).
+func (g *Grammar) SetAxiom(s string) error {
+	tpls, err := parseSymbolTemplates(s)
+	if err != nil {
+		return err
+	}
+	g.Axiom = nil
+	for _, t := range tpls {
+		sym, err := t.eval(g.Constants)
+		if err != nil {
+			return err
+		}
+		g.Axiom = append(g.Axiom, sym)
+	}
+	return nil
+}
+
+// AddRule adds a production to the grammar using the notation
+//
+//	[left <] predecessor [> right] [: condition] -> successor [: weight]
+//
+// for example "A < B(x) > C : x > 1 -> B(x-1)C : 0.5".
+//
+// If multiple productions match a symbol, the ones with the most context
+// symbols (plus one for a condition) take precedence, and one of those is
+// chosen randomly based on their weights (default 1). This way, context
+// sensitive or conditional productions override context free ones. Symbols
+// without matching productions are copied unchanged.
+//
+// Context matching skips symbols that are ignored (see the 'ignore' keyword)
+// and branches, so in "A[+B]C" the right context of A is C.
+// NOTE: The symbols '<' and '>' can't be used in the predecessor or contexts.
+func (g *Grammar) AddRule(s string) error {
+	idx := strings.Index(s, "->")
+	if idx < 0 {
+		return fmt.Errorf("invalid rule %q: missing '->'", s)
+	}
+	lhs, rhs := strings.TrimSpace(s[:idx]), strings.TrimSpace(s[idx+2:])
+	p := &production{weight: 1}
+
+	// Condition.
+	if idx := strings.Index(lhs, ":"); idx >= 0 {
+		cond, err := parseExpr(lhs[idx+1:])
+		if err != nil {
+			return err
+		}
+		p.condition = cond
+		lhs = strings.TrimSpace(lhs[:idx])
+	}
+
+	// Contexts.
+	var err error
+	if idx := strings.Index(lhs, "<"); idx >= 0 {
+		if p.left, err = parseSymbolPatterns(lhs[:idx]); err != nil {
+			return err
+		}
+		lhs = lhs[idx+1:]
+	}
+	if idx := strings.Index(lhs, ">"); idx >= 0 {
+		if p.right, err = parseSymbolPatterns(lhs[idx+1:]); err != nil {
+			return err
+		}
+		lhs = lhs[:idx]
+	}
+	pred, err := parseSymbolPatterns(lhs)
+	if err != nil {
+		return err
+	}
+	if len(pred) != 1 {
+		return fmt.Errorf("invalid rule %q: expected a single predecessor symbol", s)
+	}
+	p.pred = pred[0]
+
+	// Weight.
+	if idx := strings.LastIndex(rhs, ":"); idx >= 0 {
+		if p.weight, err = strconv.ParseFloat(strings.TrimSpace(rhs[idx+1:]), 64); err != nil {
+			return fmt.Errorf("invalid weight in rule %q: %v", s, err)
+		}
+		if p.weight < 0 {
+			return fmt.Errorf("invalid rule %q: negative weight", s)
+		}
+		rhs = rhs[:idx]
+	}
+	if p.successor, err = parseSymbolTemplates(rhs); err != nil {
+		return err
+	}
+	g.rules[p.pred.name] = append(g.rules[p.pred.name], p)
+	return nil
+}
+
+// Generate applies the productions n times to the axiom and returns the
+// resulting symbols. If 'r' is nil, the global random source is used for
+// stochastic productions.
+func (g *Grammar) Generate(n int, r *rand.Rand) ([]Symbol, error) {
+	syms := g.Axiom
+	for i := 0; i < n; i++ {
+		var err error
+		if syms, err = g.Rewrite(syms, r); err != nil {
+			return nil, err
+		}
+	}
+	return syms, nil
+}
+
+// Rewrite applies the productions once to the given symbols.
+func (g *Grammar) Rewrite(syms []Symbol, r *rand.Rand) ([]Symbol, error) {
+	var res []Symbol
+	for i, s := range syms {
+		var matched []*production
+		var matchedVars []map[string]float64
+		var total float64
+		best := -1
+		for _, p := range g.rules[s.Name] {
+			spec := p.specificity()
+			if spec < best {
+				continue // We already have a more specific match.
+			}
+			vars := make(map[string]float64, len(g.Constants))
+			for k, v := range g.Constants {
+				vars[k] = v
+			}
+			if !p.pred.matches(s, vars) || !g.matchLeft(syms, i, p.left, vars) || !g.matchRight(syms, i, p.right, vars) {
+				continue
+			}
+			if p.condition != nil {
+				v, err := p.condition(vars)
+				if err != nil {
+					return nil, fmt.Errorf("condition for %s: %v", s, err)
+				}
+				if v == 0 {
+					continue
+				}
+			}
+			if spec > best {
+				// Discard all less specific matches.
+				best = spec
+				matched, matchedVars, total = nil, nil, 0
+			}
+			matched = append(matched, p)
+			matchedVars = append(matchedVars, vars)
+			total += p.weight
+		}
+		if len(matched) == 0 {
+			res = append(res, s)
+			continue
+		}
+
+		// Pick a production based on the weights.
+		choice := len(matched) - 1
+		if len(matched) > 1 {
+			var f float64
+			if r != nil {
+				f = r.Float64()
+			} else {
+				f = rand.Float64()
+			}
+			f *= total
+			for j, p := range matched {
+				if f < p.weight {
+					choice = j
+					break
+				}
+				f -= p.weight
+			}
+		}
+		for _, t := range matched[choice].successor {
+			sym, err := t.eval(matchedVars[choice])
+			if err != nil {
+				return nil, fmt.Errorf("successor of %s: %v", s, err)
+			}
+			res = append(res, sym)
+		}
+	}
+	return res, nil
+}
+
+// matchLeft returns true if the left context matches the symbols before the
+// symbol at index i.
+func (g *Grammar) matchLeft(syms []Symbol, i int, ctx []symbolPattern, vars map[string]float64) bool {
+	j := i - 1
+	for k := len(ctx) - 1; k >= 0; k-- {
+		for ; j >= 0; j-- {
+			name := syms[j].Name
+			if name == "]" {
+				// Skip the entire branch.
+				depth := 0
+				for ; j >= 0; j-- {
+					if syms[j].Name == "]" {
+						depth++
+					} else if syms[j].Name == "[" {
+						if depth--; depth == 0 {
+							break
+						}
+					}
+				}
+				continue
+			}
+			if name != "[" && !g.ignore[name] {
+				break
+			}
+		}
+		if j < 0 || !ctx[k].matches(syms[j], vars) {
+			return false
+		}
+		j--
+	}
+	return true
+}
+
+// matchRight returns true if the right context matches the symbols after
+// the symbol at index i.
+func (g *Grammar) matchRight(syms []Symbol, i int, ctx []symbolPattern, vars map[string]float64) bool {
+	j := i + 1
+	for k := range ctx {
+		for ; j < len(syms); j++ {
+			name := syms[j].Name
+			if name == "]" {
+				return false // End of the branch.
+			}
+			if name == "[" {
+				// Skip the entire branch.
+				depth := 0
+				for ; j < len(syms); j++ {
+					if syms[j].Name == "[" {
+						depth++
+					} else if syms[j].Name == "]" {
+						if depth--; depth == 0 {
+							break
+						}
+					}
+				}
+				continue
+			}
+			if !g.ignore[name] {
+				break
+			}
+		}
+		if j >= len(syms) || !ctx[k].matches(syms[j], vars) {
+			return false
+		}
+		j++
+	}
+	return true
+}
+
+// splitSymbols splits the given string into symbol names and their
+// (unparsed) parameters.
+func splitSymbols(s string) ([]string, [][]string, error) {
+	var names []string
+	var params [][]string
+	rs := []rune(s)
+	for i := 0; i < len(rs); i++ {
+		if unicode.IsSpace(rs[i]) {
+			continue
+		}
+		names = append(names, string(rs[i]))
+		var args []string
+		if i+1 < len(rs) && rs[i+1] == '(' {
+			depth := 0
+			start := i + 2
+			for j := i + 1; j < len(rs); j++ {
+				switch rs[j] {
+				case '(':
+					depth++
+				case ')':
+					depth--
+				case ',':
+					if depth == 1 {
+						args = append(args, string(rs[start:j]))
+						start = j + 1
+					}
+				}
+				if depth == 0 {
+					args = append(args, string(rs[start:j]))
+					i = j
+					break
+				}
+			}
+			if depth != 0 {
+				return nil, nil, fmt.Errorf("missing ')' in %q", s)
+			}
+		}
+		params = append(params, args)
+	}
+	return names, params, nil
+}
+
+// parseSymbolPatterns parses symbols with formal parameters (e.g. "A(x,y)B").
+func parseSymbolPatterns(s string) ([]symbolPattern, error) {
+	names, params, err := splitSymbols(s)
+	if err != nil {
+		return nil, err
+	}
+	pats := make([]symbolPattern, len(names))
+	for i, name := range names {
+		pats[i].name = name
+		for _, p := range params[i] {
+			p = strings.TrimSpace(p)
+			if p == "" || strings.IndexFunc(p, func(r rune) bool {
+				return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+			}) >= 0 {
+				return nil, fmt.Errorf("invalid parameter name %q in %q", p, s)
+			}
+			pats[i].params = append(pats[i].params, p)
+		}
+	}
+	return pats, nil
+}
+
+// parseSymbolTemplates parses symbols with parameter expressions (e.g. "A(x*2)B").
+func parseSymbolTemplates(s string) ([]symbolTemplate, error) {
+	names, params, err := splitSymbols(s)
+	if err != nil {
+		return nil, err
+	}
+	tpls := make([]symbolTemplate, len(names))
+	for i, name := range names {
+		tpls[i].name = name
+		for _, p := range params[i] {
+			e, err := parseExpr(p)
+			if err != nil {
+				return nil, err
+			}
+			tpls[i].args = append(tpls[i].args, e)
+		}
+	}
+	return tpls, nil
+}
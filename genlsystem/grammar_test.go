@@ -0,0 +1,64 @@
+package genlsystem
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestRewritePrecedence(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		src   string
+		axiom string
+		want  map[string]bool // All possible results.
+	}{{
+		name:  "context before context free",
+		src:   "rule B -> X\nrule A < B -> Y",
+		axiom: "AB",
+		want:  map[string]bool{"AY": true},
+	}, {
+		name:  "context free without context",
+		src:   "rule B -> X\nrule A < B -> Y",
+		axiom: "CB",
+		want:  map[string]bool{"CX": true},
+	}, {
+		name:  "condition before context free",
+		src:   "rule B(x) -> X\nrule B(x) : x > 1 -> Y",
+		axiom: "B(2)B(0)",
+		want:  map[string]bool{"YX": true},
+	}, {
+		name:  "longer context first",
+		src:   "rule A < B -> X\nrule A < B > C -> Y",
+		axiom: "ABC",
+		want:  map[string]bool{"AYC": true},
+	}, {
+		name:  "stochastic among equally specific",
+		src:   "rule B -> Z\nrule A < B -> X : 1\nrule A < B -> Y : 1",
+		axiom: "AB",
+		want:  map[string]bool{"AX": true, "AY": true},
+	}} {
+		g, err := ParseGrammar(tc.src + "\naxiom " + tc.axiom)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		got := make(map[string]bool)
+		r := rand.New(rand.NewSource(1))
+		for i := 0; i < 100; i++ {
+			syms, err := g.Rewrite(g.Axiom, r)
+			if err != nil {
+				t.Fatalf("%s: %v", tc.name, err)
+			}
+			got[SymbolsToString(syms)] = true
+		}
+		for s := range got {
+			if !tc.want[s] {
+				t.Errorf("%s: got %q, want one of %v", tc.name, s, tc.want)
+			}
+		}
+		for s := range tc.want {
+			if !got[s] {
+				t.Errorf("%s: never got %q", tc.name, s)
+			}
+		}
+	}
+}
@@ -0,0 +1,302 @@
+package genlsystem
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"strings"
+	"unicode"
+)
+
+// turtleCommand is a single turtle command of a binding.
+type turtleCommand struct {
+	name string
+	args []expr
+}
+
+// turtleBinding is the list of turtle commands bound to a symbol.
+type turtleBinding struct {
+	params []string
+	cmds   []turtleCommand
+}
+
+// turtleCommandArgs contains the valid number of arguments of each turtle command.
+var turtleCommandArgs = map[string][]int{
+	"draw":   {1},
+	"move":   {1},
+	"turn":   {1},
+	"yaw":    {1},
+	"pitch":  {1},
+	"roll":   {1},
+	"width":  {1},
+	"color":  {3, 4},
+	"leaf":   {2},
+	"flower": {3},
+	"push":   {0},
+	"pop":    {0},
+}
+
+// Bind binds turtle commands to a symbol using the notation
+//
+//	symbol = command command ...
+//
+// for example "F(l,w) = width(w) draw(l)". The parameters of the symbol
+// (and the constants of the grammar) can be used in the command arguments.
+func (g *Grammar) Bind(s string) error {
+	idx := strings.Index(s, "=")
+	if idx < 0 {
+		return fmt.Errorf("invalid binding %q: missing '='", s)
+	}
+	pats, err := parseSymbolPatterns(s[:idx])
+	if err != nil {
+		return err
+	}
+	if len(pats) != 1 {
+		return fmt.Errorf("invalid binding %q: expected a single symbol", s)
+	}
+	cmds, err := parseTurtleCommands(s[idx+1:])
+	if err != nil {
+		return err
+	}
+	g.bindings[pats[0].name] = &turtleBinding{
+		params: pats[0].params,
+		cmds:   cmds,
+	}
+	return nil
+}
+
+// parseTurtleCommands parses a list of turtle commands (e.g. "width(2) draw(l)").
+func parseTurtleCommands(s string) ([]turtleCommand, error) {
+	var cmds []turtleCommand
+	rs := []rune(strings.TrimSpace(s))
+	for i := 0; i < len(rs); {
+		if unicode.IsSpace(rs[i]) {
+			i++
+			continue
+		}
+		j := i
+		for j < len(rs) && unicode.IsLetter(rs[j]) {
+			j++
+		}
+		cmd := turtleCommand{name: string(rs[i:j])}
+		valid, ok := turtleCommandArgs[cmd.name]
+		if !ok {
+			return nil, fmt.Errorf("unknown turtle command %q in %q", cmd.name, s)
+		}
+		if j < len(rs) && rs[j] == '(' {
+			// Parse the arguments using the symbol parser.
+			depth := 0
+			k := j
+			for ; k < len(rs); k++ {
+				if rs[k] == '(' {
+					depth++
+				} else if rs[k] == ')' {
+					if depth--; depth == 0 {
+						break
+					}
+				}
+			}
+			if depth != 0 {
+				return nil, fmt.Errorf("missing ')' in %q", s)
+			}
+			tpls, err := parseSymbolTemplates("_" + string(rs[j:k+1]))
+			if err != nil {
+				return nil, err
+			}
+			cmd.args = tpls[0].args
+			j = k + 1
+		}
+		var argsOK bool
+		for _, n := range valid {
+			argsOK = argsOK || len(cmd.args) == n
+		}
+		if !argsOK {
+			return nil, fmt.Errorf("invalid number of arguments for %q in %q", cmd.name, s)
+		}
+		cmds = append(cmds, cmd)
+		i = j
+	}
+	return cmds, nil
+}
+
+// turtleCommands returns the commands bound to the given symbol with the
+// variables to evaluate them.
+func (g *Grammar) turtleCommands(s Symbol) ([]turtleCommand, map[string]float64) {
+	b, ok := g.bindings[s.Name]
+	if !ok {
+		return nil, nil
+	}
+	vars := make(map[string]float64, len(g.Constants)+len(b.params))
+	for k, v := range g.Constants {
+		vars[k] = v
+	}
+	for i, name := range b.params {
+		if i < len(s.Params) {
+			vars[name] = s.Params[i]
+		}
+	}
+	return b.cmds, vars
+}
+
+// evalArgs evaluates the arguments of the given command.
+func (c turtleCommand) evalArgs(vars map[string]float64) ([]float64, error) {
+	args := make([]float64, len(c.args))
+	for i, a := range c.args {
+		v, err := a(vars)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", c.name, err)
+		}
+		args[i] = v
+	}
+	return args, nil
+}
+
+// toColor converts the given color arguments (0-255) to a color.
+func toColor(args []float64) color.Color {
+	clamp := func(v float64) uint8 {
+		if v < 0 {
+			return 0
+		}
+		if v > 255 {
+			return 255
+		}
+		return uint8(v)
+	}
+	c := color.RGBA{clamp(args[0]), clamp(args[1]), clamp(args[2]), 0xFF}
+	if len(args) == 4 {
+		c.A = clamp(args[3])
+	}
+	return c
+}
+
+// DrawTurtle runs the start commands and the commands bound to the given
+// symbols on the given turtle. Symbols without binding are ignored.
+func (g *Grammar) DrawTurtle(t *Turtle, syms []Symbol) error {
+	run := func(cmds []turtleCommand, vars map[string]float64) error {
+		for _, c := range cmds {
+			args, err := c.evalArgs(vars)
+			if err != nil {
+				return err
+			}
+			switch c.name {
+			case "draw":
+				t.Draw(args[0], 0)
+			case "move":
+				t.Move(args[0], 0)
+			case "turn", "yaw":
+				t.Turn(args[0])
+			case "width":
+				t.SetWidth(args[0])
+			case "color":
+				t.SetColor(toColor(args))
+			case "push":
+				t.Save()
+			case "pop":
+				t.Restore()
+			default:
+				return fmt.Errorf("turtle command %q is not supported in 2D", c.name)
+			}
+		}
+		return nil
+	}
+	if err := run(g.start, g.Constants); err != nil {
+		return err
+	}
+	for _, s := range syms {
+		if err := run(g.turtleCommands(s)); err != nil {
+			return fmt.Errorf("%s: %v", s, err)
+		}
+	}
+	return nil
+}
+
+// DrawTurtle3d runs the start commands and the commands bound to the given
+// symbols on the given 3d turtle. Symbols without binding are ignored.
+func (g *Grammar) DrawTurtle3d(t *Turtle3d, syms []Symbol) error {
+	run := func(cmds []turtleCommand, vars map[string]float64) error {
+		for _, c := range cmds {
+			args, err := c.evalArgs(vars)
+			if err != nil {
+				return err
+			}
+			switch c.name {
+			case "draw":
+				t.Draw(args[0])
+			case "move":
+				t.Move(args[0])
+			case "turn", "yaw":
+				t.Rotate(args[0])
+			case "pitch":
+				t.Pitch(args[0])
+			case "roll":
+				t.Roll(args[0])
+			case "leaf":
+				t.Leaf(args[0], args[1])
+			case "flower":
+				t.Flower(int(args[0]), args[1], args[2])
+			case "width":
+				t.SetWidth(args[0])
+			case "color":
+				t.SetColor(toColor(args))
+			case "push":
+				t.Save()
+			case "pop":
+				t.Restore()
+			}
+		}
+		return nil
+	}
+	if err := run(g.start, g.Constants); err != nil {
+		return err
+	}
+	for _, s := range syms {
+		if err := run(g.turtleCommands(s)); err != nil {
+			return fmt.Errorf("%s: %v", s, err)
+		}
+	}
+	return nil
+}
+
+// Render generates the symbols using the given number of iterations (or the
+// default number of iterations of the grammar if n < 0) and draws them using
+// a new turtle.
+func (g *Grammar) Render(n int) (image.Image, error) {
+	d, err := g.RenderDrawing(n)
+	if err != nil {
+		return nil, err
+	}
+	return d.Image(1), nil
+}
+
+// RenderDrawing is like Render, but returns a resolution independent drawing.
+func (g *Grammar) RenderDrawing(n int) (*Drawing, error) {
+	if n < 0 {
+		n = g.Iterations
+	}
+	syms, err := g.Generate(n, nil)
+	if err != nil {
+		return nil, err
+	}
+	t := NewTurtle(nil)
+	if err := g.DrawTurtle(t, syms); err != nil {
+		return nil, err
+	}
+	return t.GoDrawing(nil), nil
+}
+
+// Render3d is like Render, but draws the symbols using a new 3d turtle and
+// writes the resulting mesh (see Turtle3d.Mesh) to the given OBJ file.
+func (g *Grammar) Render3d(fname string, n int) error {
+	if n < 0 {
+		n = g.Iterations
+	}
+	syms, err := g.Generate(n, nil)
+	if err != nil {
+		return err
+	}
+	t := NewTurtle3d(nil)
+	if err := g.DrawTurtle3d(t, syms); err != nil {
+		return err
+	}
+	return t.Mesh().ExportToObj(fname)
+}
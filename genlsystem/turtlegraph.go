@@ -4,8 +4,6 @@ import (
 	"image"
 	"image/color"
 	"math"
-
-	"github.com/llgcode/draw2d/draw2dimg"
 )
 
 type Bounds struct {
@@ -161,31 +159,26 @@ func (t *Turtle) Width() float64 {
 
 // UNLEASH THE TURTLE!
 func (t *Turtle) Go(path []string) image.Image {
+	return t.GoDrawing(path).Image(1)
+}
+
+// GoDrawing runs the turtle on the given path and returns the resulting
+// drawing, which can be rasterized at any scale or exported as SVG.
+func (t *Turtle) GoDrawing(path []string) *Drawing {
 	// draw lines
 	for _, c := range path {
 		if f, ok := t.rules[c]; ok {
 			f(t)
 		}
 	}
-
-	border := 5.0
-	w, h := t.boundary.Size()
-	offx, offy := t.boundary.minX-border, t.boundary.minY-border
-
-	img := image.NewRGBA(image.Rect(0, 0, int(w+border*2), int(h+border*2)))
-	gc := draw2dimg.NewGraphicContext(img)
-
-	for _, line := range t.lines {
-		gc.SetLineWidth(line.width)
-		gc.SetStrokeColor(line.color)
-		gc.MoveTo(line.x1-offx, line.y1-offy)
-		gc.LineTo(line.x2-offx, line.y2-offy)
-		gc.Stroke()
+	d := &Drawing{
+		lines:  t.lines,
+		bounds: *t.boundary,
 	}
 
 	// cleanup
 	t.Cleanup()
-	return img
+	return d
 }
 
 func (t *Turtle) Cleanup() {
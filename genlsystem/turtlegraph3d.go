@@ -46,6 +46,7 @@ type stack3d struct {
 	width   float64
 	forward vectors.Vec3
 	up      vectors.Vec3
+	prevSeg int // Index of the segment ending at the current position (-1 if none).
 	prev    *stack3d
 }
 
@@ -61,21 +62,26 @@ type line3d struct {
 // and:
 // https://github.com/recp/cglm/blob/master/include/cglm/vec3.h
 type Turtle3d struct {
+	Sides    int // Number of sides of the cylinders generated by GoMesh.
 	rules    map[string]func(*Turtle3d)
 	cur      *stack3d
 	lines    []line3d
+	segments []segment3d
+	leaves   []leaf3d
 	boundary *Bounds3d
 }
 
 // NewTurtle3d returns a new Turtle3d struct.
 func NewTurtle3d(rules map[string]func(*Turtle3d)) *Turtle3d {
 	return &Turtle3d{
+		Sides: 8,
 		rules: rules,
 		cur: &stack3d{
 			color:   color.RGBA{0x00, 0x00, 0x00, 0xFF},
 			width:   1,
 			forward: vectors.NewVec3(1, 0, 0),
 			up:      vectors.NewVec3(0, 1, 0),
+			prevSeg: -1,
 		},
 		lines:    []line3d{},
 		boundary: &Bounds3d{},
@@ -93,6 +99,7 @@ func (t *Turtle3d) Save() {
 		prev:    t.cur, // stackception
 		up:      t.cur.up,
 		forward: t.cur.forward,
+		prevSeg: t.cur.prevSeg,
 	}
 }
 
@@ -114,6 +121,7 @@ func (t *Turtle3d) Move(f float64) {
 	t.cur.x += x
 	t.cur.y += y
 	t.cur.z += z
+	t.cur.prevSeg = -1 // Start a new chain of segments.
 	t.boundary.AddPoint(t.cur.x, t.cur.y, t.cur.z)
 }
 
@@ -145,6 +153,56 @@ func (t *Turtle3d) Draw(f float64) {
 		color: t.cur.color,
 		width: t.cur.width,
 	})
+
+	// Record the segment for mesh generation.
+	t.segments = append(t.segments, segment3d{
+		start:   vectors.NewVec3(sx, sy, sz),
+		end:     vectors.NewVec3(t.cur.x, t.cur.y, t.cur.z),
+		forward: t.cur.forward,
+		up:      t.cur.up,
+		color:   t.cur.color,
+		width:   t.cur.width,
+		prev:    t.cur.prevSeg,
+	})
+	t.cur.prevSeg = len(t.segments) - 1
+}
+
+// Leaf places a leaf (a diamond shaped quad) with the given length and width
+// at the current position, pointing forward and facing up.
+// NOTE: Leaves are only part of the mesh generated by GoMesh.
+func (t *Turtle3d) Leaf(length, width float64) {
+	t.leaves = append(t.leaves, t.newLeaf(length, width))
+}
+
+// Flower places a flower with the given number of petals (leaves with the
+// given length and width) at the current position, arranged around the
+// forward axis.
+// NOTE: Flowers are only part of the mesh generated by GoMesh.
+func (t *Turtle3d) Flower(petals int, length, width float64) {
+	for i := 0; i < petals; i++ {
+		l := t.newLeaf(length, width)
+
+		// Tilt the petal outwards and rotate it around the forward axis.
+		right := vectors.Cross3(t.cur.forward, t.cur.up)
+		glm_vec3_rotate(&l.forward, degToRadians(60), right)
+		glm_vec3_rotate(&l.up, degToRadians(60), right)
+		angle := 2 * math.Pi * float64(i) / float64(petals)
+		glm_vec3_rotate(&l.forward, angle, t.cur.forward)
+		glm_vec3_rotate(&l.up, angle, t.cur.forward)
+		t.leaves = append(t.leaves, l)
+	}
+}
+
+// newLeaf returns a new leaf at the current position and orientation.
+func (t *Turtle3d) newLeaf(length, width float64) leaf3d {
+	return leaf3d{
+		pos:     vectors.NewVec3(t.cur.x, t.cur.y, t.cur.z),
+		forward: t.cur.forward,
+		up:      t.cur.up,
+		length:  length,
+		width:   width,
+		color:   t.cur.color,
+	}
 }
 
 // GetPosition gets the current position.
@@ -236,7 +294,11 @@ func (t *Turtle3d) Go(fname string, path []string) error {
 		stop := [3]float64{line.x2 - offx, line.y2 - offy, line.z2 - offz}
 		wr.WriteString(fmt.Sprintf("l %d %d \n", vtxIdx[start]+1, vtxIdx[stop]+1))
 	}
-	wr.Flush()
+	// NOTE: The bufio writer keeps the first write error, so we only need
+	// to check the error returned by Flush.
+	if err := wr.Flush(); err != nil {
+		return err
+	}
 
 	// cleanup
 	t.Cleanup()
@@ -249,8 +311,11 @@ func (t *Turtle3d) Cleanup() {
 		width:   1,
 		forward: vectors.NewVec3(1, 0, 0),
 		up:      vectors.NewVec3(0, 1, 0),
+		prevSeg: -1,
 	}
 	t.lines = nil
+	t.segments = nil
+	t.leaves = nil
 	t.boundary = new(Bounds3d)
 }
 
@@ -0,0 +1,269 @@
+package genlsystem
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/Flokey82/go_gens/gengeometry"
+	"github.com/Flokey82/go_gens/vectors"
+)
+
+// segment3d is a segment drawn by the 3d turtle.
+type segment3d struct {
+	start, end vectors.Vec3
+	forward    vectors.Vec3 // Heading of the turtle.
+	up         vectors.Vec3 // Up vector of the turtle (used to orient the cylinder).
+	color      color.Color
+	width      float64
+	prev       int // Index of the segment ending at the start of this segment (-1 if none).
+}
+
+// leaf3d is a leaf (or petal) placed by the 3d turtle.
+type leaf3d struct {
+	pos     vectors.Vec3
+	forward vectors.Vec3
+	up      vectors.Vec3
+	length  float64
+	width   float64
+	color   color.Color
+}
+
+// GoMesh runs the turtle on the given path and returns the resulting mesh.
+// See Turtle3d.Mesh for details.
+func (t *Turtle3d) GoMesh(path []string) *gengeometry.Mesh {
+	for _, c := range path {
+		if f, ok := t.rules[c]; ok {
+			f(t)
+		}
+	}
+	m := t.Mesh()
+
+	// cleanup
+	t.Cleanup()
+	return m
+}
+
+// Mesh returns a mesh of everything drawn so far.
+//
+// Each segment is swept as a (tapered) cylinder with the number of sides
+// specified in Turtle3d.Sides. The diameter of a segment changes from the
+// width of the segment it continues from to its own width. Consecutive
+// segments share the vertices at their joint, where the joint is oriented
+// halfway between both segments. If multiple segments start at the same
+// joint (branches), the one heading in the most similar direction continues
+// the previous segment, all others get their own capped start.
+//
+// Leaves and flowers are added as double sided quads.
+//
+// The colors set with SetColor are stored as vertex colors.
+// NOTE: The Y and Z axis of the turtle are swapped, since the turtle's up
+// vector points along Y, while meshes use Z as up axis.
+func (t *Turtle3d) Mesh() *gengeometry.Mesh {
+	b := &meshBuilder3d{
+		m:     &gengeometry.Mesh{},
+		sides: t.Sides,
+	}
+	if b.sides < 3 {
+		b.sides = 3
+	}
+
+	// Find the segment that continues each segment (if any).
+	dirs := make([]vectors.Vec3, len(t.segments))
+	for i, s := range t.segments {
+		dirs[i] = s.dir()
+	}
+	cont := make(map[int]int)
+	for i, s := range t.segments {
+		if s.prev < 0 {
+			continue
+		}
+		d := vectors.Dot3(dirs[s.prev], dirs[i])
+		if d <= 0 {
+			continue // Too sharp, we'd get a pinched joint.
+		}
+		if c, ok := cont[s.prev]; !ok || d > vectors.Dot3(dirs[s.prev], dirs[c]) {
+			cont[s.prev] = i
+		}
+	}
+
+	// Sweep the segments in the order they were drawn, so the end ring of
+	// the previous segment always exists.
+	rings := make([]ring3d, len(t.segments))
+	for i, s := range t.segments {
+		col := colorToVec3(s.color)
+		var start ring3d
+		if s.prev >= 0 && cont[s.prev] == i {
+			start = rings[s.prev]
+		} else {
+			radius := s.width / 2
+			if s.prev >= 0 {
+				radius = t.segments[s.prev].width / 2
+			}
+			start = b.addRing(s.start, dirs[i], s.up, radius, col)
+			b.addCap(start, dirs[i].Mul(-1), col)
+		}
+
+		// Orient the end ring halfway between this segment and the one
+		// continuing it.
+		normal := dirs[i]
+		c, hasCont := cont[i]
+		if hasCont {
+			normal = vectors.Add3(dirs[i], dirs[c]).Normalize()
+		}
+		end := b.addRing(s.end, normal, start.u, s.width/2, col)
+		b.addTube(start, end)
+		if !hasCont {
+			b.addCap(end, dirs[i], col)
+		}
+		rings[i] = end
+	}
+
+	for _, l := range t.leaves {
+		b.addLeaf(l)
+	}
+	return b.finish()
+}
+
+// dir returns the direction of the segment.
+func (s segment3d) dir() vectors.Vec3 {
+	d := vectors.Sub3(s.end, s.start)
+	if d.Len() < 1e-9 {
+		return s.forward.Normalize()
+	}
+	return d.Normalize()
+}
+
+// colorToVec3 converts the given color to RGB values from 0.0 to 1.0.
+func colorToVec3(c color.Color) vectors.Vec3 {
+	r, g, b, _ := c.RGBA()
+	return vectors.NewVec3(float64(r)/0xFFFF, float64(g)/0xFFFF, float64(b)/0xFFFF)
+}
+
+// ring3d is a ring of vertices around a segment.
+type ring3d struct {
+	center vectors.Vec3
+	normal vectors.Vec3
+	u, v   vectors.Vec3 // Orthonormal basis of the ring plane.
+	radius float64
+	first  int // Index of the first vertex.
+}
+
+// meshBuilder3d assembles the mesh of a turtle.
+type meshBuilder3d struct {
+	m     *gengeometry.Mesh
+	sides int
+}
+
+// addVertex adds a vertex and returns its index.
+func (b *meshBuilder3d) addVertex(pos, normal, col vectors.Vec3) int {
+	b.m.Vertices = append(b.m.Vertices, pos)
+	b.m.Normals = append(b.m.Normals, normal)
+	b.m.Colors = append(b.m.Colors, col)
+	return len(b.m.Vertices) - 1
+}
+
+// ringPoint returns the direction from the center to the k-th ring vertex.
+func (b *meshBuilder3d) ringPoint(r ring3d, k int) vectors.Vec3 {
+	a := 2 * math.Pi * float64(k) / float64(b.sides)
+	return vectors.Add3(r.u.Mul(math.Cos(a)), r.v.Mul(math.Sin(a)))
+}
+
+// addRing adds a ring of vertices around the given center in the plane with
+// the given normal. The reference vector determines the orientation of the
+// ring (to avoid twisting).
+func (b *meshBuilder3d) addRing(center, normal, ref vectors.Vec3, radius float64, col vectors.Vec3) ring3d {
+	// Project the reference vector onto the ring plane.
+	u := vectors.Sub3(ref, normal.Mul(vectors.Dot3(ref, normal)))
+	if u.Len() < 1e-6 {
+		// Pick any vector perpendicular to the normal.
+		u = vectors.Cross3(normal, vectors.NewVec3(1, 0, 0))
+		if u.Len() < 1e-6 {
+			u = vectors.Cross3(normal, vectors.NewVec3(0, 1, 0))
+		}
+	}
+	u = u.Normalize()
+	r := ring3d{
+		center: center,
+		normal: normal,
+		u:      u,
+		v:      vectors.Cross3(normal, u),
+		radius: radius,
+		first:  len(b.m.Vertices),
+	}
+	for k := 0; k < b.sides; k++ {
+		dir := b.ringPoint(r, k)
+		b.addVertex(vectors.Add3(center, dir.Mul(radius)), dir, col)
+	}
+	return r
+}
+
+// addTube connects two rings with quads.
+func (b *meshBuilder3d) addTube(a, c ring3d) {
+	for k := 0; k < b.sides; k++ {
+		k1 := (k + 1) % b.sides
+		b.m.Triangles = append(b.m.Triangles,
+			a.first+k, a.first+k1, c.first+k1,
+			a.first+k, c.first+k1, c.first+k,
+		)
+	}
+}
+
+// addCap closes the given ring with a disc facing in the given direction.
+func (b *meshBuilder3d) addCap(r ring3d, facing, col vectors.Vec3) {
+	center := b.addVertex(r.center, facing, col)
+	first := len(b.m.Vertices)
+	for k := 0; k < b.sides; k++ {
+		b.addVertex(vectors.Add3(r.center, b.ringPoint(r, k).Mul(r.radius)), facing, col)
+	}
+	flip := vectors.Dot3(facing, r.normal) < 0
+	for k := 0; k < b.sides; k++ {
+		k1 := (k + 1) % b.sides
+		if flip {
+			b.m.Triangles = append(b.m.Triangles, center, first+k1, first+k)
+		} else {
+			b.m.Triangles = append(b.m.Triangles, center, first+k, first+k1)
+		}
+	}
+}
+
+// addLeaf adds a double sided, diamond shaped quad.
+func (b *meshBuilder3d) addLeaf(l leaf3d) {
+	fwd := l.forward.Normalize()
+	side := vectors.Cross3(fwd, l.up).Normalize()
+	up := vectors.Cross3(side, fwd)
+	mid := vectors.Add3(l.pos, fwd.Mul(l.length/2))
+	corners := [4]vectors.Vec3{
+		l.pos,
+		vectors.Add3(mid, side.Mul(l.width/2)),
+		vectors.Add3(l.pos, fwd.Mul(l.length)),
+		vectors.Sub3(mid, side.Mul(l.width/2)),
+	}
+	col := colorToVec3(l.color)
+	for _, n := range []vectors.Vec3{up, up.Mul(-1)} {
+		var idx [4]int
+		for i, c := range corners {
+			idx[i] = b.addVertex(c, n, col)
+		}
+		if n == up {
+			b.m.Triangles = append(b.m.Triangles, idx[0], idx[1], idx[2], idx[0], idx[2], idx[3])
+		} else {
+			b.m.Triangles = append(b.m.Triangles, idx[0], idx[2], idx[1], idx[0], idx[3], idx[2])
+		}
+	}
+}
+
+// finish converts the mesh from turtle space (Y up) to mesh space (Z up)
+// and returns it.
+func (b *meshBuilder3d) finish() *gengeometry.Mesh {
+	m := b.m
+	for i := range m.Vertices {
+		m.Vertices[i].Y, m.Vertices[i].Z = m.Vertices[i].Z, m.Vertices[i].Y
+		m.Normals[i].Y, m.Normals[i].Z = m.Normals[i].Z, m.Normals[i].Y
+	}
+
+	// Swapping two axes mirrors the mesh, so we need to reverse the winding order.
+	for i := 0; i+2 < len(m.Triangles); i += 3 {
+		m.Triangles[i+1], m.Triangles[i+2] = m.Triangles[i+2], m.Triangles[i+1]
+	}
+	return m
+}
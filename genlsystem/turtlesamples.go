@@ -7,6 +7,11 @@ import (
 )
 
 func Hilbert(n int) image.Image {
+	return HilbertDrawing(n).Image(1)
+}
+
+// HilbertDrawing is like Hilbert, but returns a resolution independent drawing.
+func HilbertDrawing(n int) *Drawing {
 	path := Lindenmayer([]string{"A"}, map[string][]string{
 		"A": {"-", "B", "F", "+", "A", "F", "A", "+", "F", "B", "-"},
 		"B": {"+", "A", "F", "-", "B", "F", "B", "-", "F", "A", "+"},
@@ -24,10 +29,15 @@ func Hilbert(n int) image.Image {
 		},
 	})
 
-	return turtle.Go(path)
+	return turtle.GoDrawing(path)
 }
 
 func Tree(n int) image.Image {
+	return TreeDrawing(n).Image(1)
+}
+
+// TreeDrawing is like Tree, but returns a resolution independent drawing.
+func TreeDrawing(n int) *Drawing {
 	segmentlength := 10.0
 
 	green := color.RGBA{0x60, 0xFF, 0x00, 0xFF}
@@ -92,10 +102,15 @@ func Tree(n int) image.Image {
 	turtle.SetWidth(1)
 	turtle.Turn(-90)
 
-	return turtle.Go(path)
+	return turtle.GoDrawing(path)
 }
 
 func BinTree(n int) image.Image {
+	return BinTreeDrawing(n).Image(1)
+}
+
+// BinTreeDrawing is like BinTree, but returns a resolution independent drawing.
+func BinTreeDrawing(n int) *Drawing {
 	segmentlenght := 1.0
 
 	green := color.RGBA{0x33, 0xFF, 0x33, 0xFF}
@@ -132,10 +147,15 @@ func BinTree(n int) image.Image {
 	})
 
 	turtle.Turn(-90)
-	return turtle.Go(path)
+	return turtle.GoDrawing(path)
 }
 
 func Plant(n int) image.Image {
+	return PlantDrawing(n).Image(1)
+}
+
+// PlantDrawing is like Plant, but returns a resolution independent drawing.
+func PlantDrawing(n int) *Drawing {
 	segmentlength := 4.0
 
 	green := color.RGBA{0x60, 0xFF, 0x00, 0xFF}
@@ -172,5 +192,5 @@ func Plant(n int) image.Image {
 	turtle.SetWidth(1)
 	turtle.Turn(-90)
 
-	return turtle.Go(path)
+	return turtle.GoDrawing(path)
 }
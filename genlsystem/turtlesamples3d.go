@@ -1,5 +1,7 @@
 package genlsystem
 
+import "image/color"
+
 func Hilbert3d(fname string, n int) error {
 	path := Lindenmayer([]string{"X"}, map[string][]string{
 		"X": {"^", "<", "X", "F", "^", "<", "X", "F", "X", "-", "F", "^", ">", ">", "X", "F", "X", "&", "F", "+", ">", ">", "X", "F", "X", "-", "F", ">", "X", "-", ">"},
@@ -32,17 +34,27 @@ func Hilbert3d(fname string, n int) error {
 	return turtle.Go(fname, path)
 }
 
+// Plant3d generates a 3d plant with tapering branches and leaves and exports
+// the mesh to the given OBJ file.
 func Plant3d(fname string, n int) error {
 	path := Lindenmayer([]string{"F"}, map[string][]string{
-		"F": {"F", "F", "-", "[", "-", "F", "+", "F", "+", "F", "]", "+", "[", "+", "F", "-", "F", "-", "F", "]"},
+		"F": {"F", "F", "-", "[", "-", "F", "+", "F", "+", "F", "L", "]", "+", "[", "+", "F", "-", "F", "-", "F", "L", "]"},
 		"-": {"-", ">"},
 		"+": {"+", "<"},
 	}, n)
 
+	brown := color.RGBA{0x66, 0x44, 0x22, 0xFF}
+	green := color.RGBA{0x33, 0xAA, 0x22, 0xFF}
+
 	turtle := NewTurtle3d(map[string]func(*Turtle3d){
 		"F": func(t *Turtle3d) {
 			t.Draw(0.2)
 		},
+		"L": func(t *Turtle3d) { // leaf at the tip of a branch
+			t.SetColor(green)
+			t.Leaf(0.15, 0.08)
+			t.SetColor(brown)
+		},
 		"-": func(t *Turtle3d) {
 			t.Rotate(-23)
 		},
@@ -61,8 +73,9 @@ func Plant3d(fname string, n int) error {
 		"<": func(t *Turtle3d) {
 			t.Roll(-23)
 		},
-		"[": func(t *Turtle3d) { // push position and angle
+		"[": func(t *Turtle3d) { // push position and angle, thinner branch
 			t.Save()
+			t.SetWidth(t.GetWidth() * 0.7)
 		},
 		"]": func(t *Turtle3d) { // pop position and angle
 			t.Restore()
@@ -71,8 +84,10 @@ func Plant3d(fname string, n int) error {
 			t.Rotate(180)
 		},
 	})
+	turtle.SetColor(brown)
+	turtle.SetWidth(0.05)
 
-	return turtle.Go(fname, path)
+	return turtle.GoMesh(path).ExportToObj(fname)
 }
 
 func Pyramid3d(fname string, n int) error {
@@ -33,7 +33,7 @@ func getBorders(render *Terrain) [][]voronoi.Vertex {
 	mergedSegs := mergeSegments(edges)
 	for i := range mergedSegs {
 		// Relax the outlines.
-		mergedSegs[i] = relaxPath(mergedSegs[i])
+		mergedSegs[i] = relaxPath(mergedSegs[i], wrapWidth(h.Mesh))
 	}
 	return mergedSegs
 }
@@ -66,7 +66,7 @@ func getCityBorders(render *Terrain) [][]voronoi.Vertex {
 	mergedSegs := mergeSegments(edges)
 	for i := range mergedSegs {
 		// Relax the outlines.
-		mergedSegs[i] = relaxPath(mergedSegs[i])
+		mergedSegs[i] = relaxPath(mergedSegs[i], wrapWidth(h.Mesh))
 	}
 	return mergedSegs
 }
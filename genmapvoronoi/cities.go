@@ -27,8 +27,11 @@ func cityScore(render *Terrain) *vmesh.Heightmap {
 		// TODO: Consider sediment/fertility of land.
 
 		// Prefer points towards the middle of the map.
-		score.Values[i] += 0.01 / (1e-9 + math.Abs(h.Vertices[i].X) - h.Extent.Width/2)
-		score.Values[i] += 0.01 / (1e-9 + math.Abs(h.Vertices[i].Y) - h.Extent.Height/2)
+		// NOTE: A sphere has no edges, so there is nothing to avoid.
+		if !h.IsSphere() {
+			score.Values[i] += 0.01 / (1e-9 + math.Abs(h.Vertices[i].X) - h.Extent.Width/2)
+			score.Values[i] += 0.01 / (1e-9 + math.Abs(h.Vertices[i].Y) - h.Extent.Height/2)
+		}
 
 		// Penalty for proximity / bonus for higher distance to other cities.
 		for j := 0; j < len(cities); j++ {
@@ -7,7 +7,10 @@ import (
 )
 
 func main() {
-	r := genmapvoronoi.NewTerrain(genmapvoronoi.DefaultParams)
+	r, err := genmapvoronoi.NewTerrain(genmapvoronoi.DefaultParams)
+	if err != nil {
+		log.Fatal(err)
+	}
 	if err := r.ExportSVG("test.svg"); err != nil {
 		log.Fatal(err)
 	}
@@ -3,6 +3,7 @@ package genmapvoronoi
 import (
 	"math"
 
+	"github.com/Flokey82/go_gens/vectors"
 	"github.com/Flokey82/go_gens/vmesh"
 )
 
@@ -43,7 +44,7 @@ func getSteepness(h *vmesh.Heightmap) *vmesh.Heightmap {
 		hDiff := h.Values[r] - h.Values[d]
 
 		// Distance between r and dh[r].
-		dist := distPoints(m.Vertices[r].X, m.Vertices[r].Y, m.Vertices[d].X, m.Vertices[d].Y)
+		dist := m.Distance(r, d)
 
 		// Calculate the the angle (0°-90°) expressed as range from 0.0 to 1.0.
 		steeps.Values[r] = math.Atan(hDiff/dist) * 2 / math.Pi
@@ -106,13 +107,14 @@ func erosionRate2(h *vmesh.Heightmap) *vmesh.Heightmap {
 			// TODO: sharp drops should carve with higher intensity.
 			// toE.Values[r] *= (1 + steeps.Values[r]) / 2
 		}
-		rVertex := m.Vertices[r]
-
-		// If we have a downhill neighbor, get the vertex for
+		// If we have a downhill neighbor, get the offset of its vertex for
 		// calculating the river segment distance of each neighbor.
-		dVertex := rVertex
+		//
+		// NOTE: All positions are relative to (r), so that the distances
+		// are also correct on a sphere.
+		var dVertex vectors.Vec2
 		if rdh := dh[r]; rdh >= 0 {
-			dVertex = m.Vertices[rdh]
+			dVertex = m.LocalOffset(r, rdh)
 		}
 
 		// seen will keep track of all regions whose neighbors doErode()
@@ -134,10 +136,10 @@ func erosionRate2(h *vmesh.Heightmap) *vmesh.Heightmap {
 
 			// Visit all neighbors of reg and calculate the erosion rate for each.
 			for _, nb := range h.Neighbours(reg) {
-				nbVertex := m.Vertices[nb]
+				nbVertex := m.LocalOffset(r, nb)
 
 				// Calculate distance of the neighbor to the river segment (r->rdh).
-				dist := distToSegment(rVertex.X, rVertex.Y, dVertex.X, dVertex.Y, nbVertex.X, nbVertex.Y)
+				dist := distToSegment(0, 0, dVertex.X, dVertex.Y, nbVertex.X, nbVertex.Y)
 				if dist > maxDist {
 					continue // Skip everything that is too far away.
 				}
@@ -31,11 +31,12 @@ func (r *Terrain) ExportSVG(path string) error {
 	svg := svgo.New(f)
 	svg.Start(width, height)
 
+	wrap := wrapWidth(r.mesh)
 	svgVisualizeHeight(svg, r, width, height)
-	svgDrawPaths(svg, r.riverPaths, "stroke=\"blue\" fill=\"none\" stroke-width=\"2\"", width, height)
-	svgDrawPaths(svg, r.coasts, "stroke=\"black\" fill=\"none\" stroke-width=\"3\"", width, height)
-	svgDrawPaths(svg, r.borders, "stroke=\"red\" fill=\"none\" stroke-width=\"2\"", width, height)
-	svgDrawPaths(svg, r.cityBorders, "stroke=\"purple\" fill=\"none\"", width, height)
+	svgDrawPaths(svg, r.riverPaths, "stroke=\"blue\" fill=\"none\" stroke-width=\"2\"", wrap, width, height)
+	svgDrawPaths(svg, r.coasts, "stroke=\"black\" fill=\"none\" stroke-width=\"3\"", wrap, width, height)
+	svgDrawPaths(svg, r.borders, "stroke=\"red\" fill=\"none\" stroke-width=\"2\"", wrap, width, height)
+	svgDrawPaths(svg, r.cityBorders, "stroke=\"purple\" fill=\"none\"", wrap, width, height)
 	svgVisualizeSlopes(svg, r, width, height)
 	svgVisualizeCities(svg, r, width, height)
 	svgVisualizeRidges(svg, r, width, height)
@@ -53,39 +54,45 @@ func svgVisualizeHeight(svg *svgo.SVG, r *Terrain, width, height int) {
 	//h = calcWind(r.bd)
 	log.Println(h.Values)
 	min, max := h.MinMax()
+	wrap := wrapWidth(r.mesh)
 	for i := range h.VertexTris {
 		tmpLine := ""
 		var path []voronoi.Vertex
 		for j := range h.VertexTris[i] {
 			path = append(path, h.VertexTris[i][j].Site)
 		}
+		d := svgGenCellD(path, wrap, h.Extent.Height, width, height)
 		if h.Values[i] <= 0 {
 			rr := int(math.Abs(((min - h.Values[i]) / min)) * 68)
 			rg := int(math.Abs(((min - h.Values[i]) / min)) * 68)
 			rb := int(math.Abs(((min - h.Values[i]) / min)) * 255)
-			svg.Path(svgGenD(path, width, height), fmt.Sprintf("fill: rgb(%d, %d, %d)", rr, rg, rb)+tmpLine)
+			svg.Path(d, fmt.Sprintf("fill: rgb(%d, %d, %d)", rr, rg, rb)+tmpLine)
 		} else {
 			rr := int((h.Values[i] / max) * 255)
 			rg := int((h.Values[i] / max) * 255)
 			if r.sediment.Values[i] > 0 {
 				rg = 255
 			}
-			svg.Path(svgGenD(path, width, height), fmt.Sprintf("fill: rgb(%d, %d, %d)", rr, rg, rr)+tmpLine)
+			svg.Path(d, fmt.Sprintf("fill: rgb(%d, %d, %d)", rr, rg, rr)+tmpLine)
 		}
 	}
 }
 
-func svgDrawPaths(svg *svgo.SVG, paths [][]voronoi.Vertex, attr string, width, height int) {
+func svgDrawPaths(svg *svgo.SVG, paths [][]voronoi.Vertex, attr string, wrap float64, width, height int) {
 	for _, path := range paths {
-		svg.Path(svgGenD(path, width, height), attr)
+		svg.Path(svgGenD(path, wrap, width, height), attr)
 	}
 }
 
-func svgGenD(path []voronoi.Vertex, width, height int) string {
+// svgGenD returns the SVG path data for the given path.
+//
+// If the map wraps around (wrap > 0), a new sub-path is started whenever
+// a segment crosses the seam.
+func svgGenD(path []voronoi.Vertex, wrap float64, width, height int) string {
 	var str string
 
 	for i, p := range path {
-		if i == 0 {
+		if i == 0 || (wrap > 0 && math.Abs(p.X-path[i-1].X) > wrap/2) {
 			str += fmt.Sprintf("M %f,%f", (p.X+0.5)*float64(width), (p.Y+0.5)*float64(height))
 			continue
 		}
@@ -94,6 +101,71 @@ func svgGenD(path []voronoi.Vertex, width, height int) string {
 	return str
 }
 
+// svgGenCellD returns the SVG path data for the given closed polygon.
+//
+// If the map wraps around (wrap > 0), a polygon crossing the seam is
+// unwrapped and drawn on both sides of the map, so that each side shows
+// the part of the polygon that falls within the map. A polygon that
+// encircles a pole is extended to the edge of the map (mapHeight is the
+// height of the map in the same units as the path).
+func svgGenCellD(path []voronoi.Vertex, wrap, mapHeight float64, width, height int) string {
+	if wrap <= 0 || len(path) == 0 {
+		return svgGenD(path, 0, width, height)
+	}
+
+	// Unwrap the polygon, so that no segment crosses the seam.
+	pts := make([]voronoi.Vertex, len(path))
+	pts[0] = path[0]
+	minX, maxX := path[0].X, path[0].X
+	for i := 1; i < len(path); i++ {
+		p := path[i]
+		p.X = unwrapX(p.X, pts[i-1].X, wrap)
+		pts[i] = p
+		minX = math.Min(minX, p.X)
+		maxX = math.Max(maxX, p.X)
+	}
+
+	// If the closing segment still crosses the seam, the polygon goes all
+	// the way around the sphere and contains a pole. In this case we close
+	// it along the top or bottom edge of the map.
+	first, last := pts[0], pts[len(pts)-1]
+	if math.Abs(last.X-first.X) > wrap/2 {
+		var sumY float64
+		for _, p := range pts {
+			sumY += p.Y
+		}
+		poleY := mapHeight / 2
+		if sumY < 0 {
+			poleY = -poleY
+		}
+		// Continue to the copy of the first vertex on the far side, then
+		// go to the pole and back along the edge of the map.
+		farX := first.X + wrap
+		if last.X < first.X {
+			farX = first.X - wrap
+		}
+		minX = math.Min(minX, farX)
+		maxX = math.Max(maxX, farX)
+		pts = append(pts,
+			voronoi.Vertex{X: farX, Y: first.Y},
+			voronoi.Vertex{X: farX, Y: poleY},
+			voronoi.Vertex{X: first.X, Y: poleY})
+	}
+
+	str := svgGenD(pts, 0, width, height) + " Z"
+	for _, offset := range []float64{-wrap, wrap} {
+		if maxX+offset < -wrap/2 || minX+offset > wrap/2 {
+			continue // The copy would be entirely outside the map.
+		}
+		shifted := make([]voronoi.Vertex, len(pts))
+		for i, p := range pts {
+			shifted[i] = voronoi.Vertex{X: p.X + offset, Y: p.Y}
+		}
+		str += " " + svgGenD(shifted, 0, width, height) + " Z"
+	}
+	return str
+}
+
 func svgVisualizeCities(svg *svgo.SVG, render *Terrain, width, height int) {
 	cities := render.cities
 	h := render.h
@@ -132,7 +204,7 @@ func svgVisualizeRidges(svg *svgo.SVG, render *Terrain, width, height int) {
 			ridges = append(ridges, vxxs)
 		}
 	}
-	svgDrawPaths(svg, ridges, "stroke=\"gray\" fill=\"none\" stroke-width=\"0.5\"", width, height)
+	svgDrawPaths(svg, ridges, "stroke=\"gray\" fill=\"none\" stroke-width=\"0.5\"", wrapWidth(render.mesh), width, height)
 }
 
 /*
@@ -247,6 +319,6 @@ func svgVisualizeSlopes(svg *svgo.SVG, render *Terrain, width, height int) {
 			//strokes = append(strokes, []voronoi.Vertex{voronoi.Vertex{x - l, y + l*s}, voronoi.Vertex{x + l, y - l*s}})
 		}
 	}
-	svgDrawPaths(svg, shadeStrokes, "stroke=\"black\" fill=\"none\" stroke-width=\"1\"", width, height)
-	svgDrawPaths(svg, sunStrokes, "stroke=\"gray\" fill=\"none\" stroke-width=\"0.5\"", width, height)
+	svgDrawPaths(svg, shadeStrokes, "stroke=\"black\" fill=\"none\" stroke-width=\"1\"", 0, width, height)
+	svgDrawPaths(svg, sunStrokes, "stroke=\"gray\" fill=\"none\" stroke-width=\"0.5\"", 0, width, height)
 }
@@ -10,11 +10,20 @@ import (
 	"github.com/pzsz/voronoi"
 )
 
-func (r *Terrain) genTerrain() {
-	r.mesh = vmesh.GenerateGoodMesh(r.params.NumPoints, &vmesh.Extent{
+func (r *Terrain) genTerrain() error {
+	extent := &vmesh.Extent{
 		Width:  r.params.Extent.Width,
 		Height: r.params.Extent.Height,
-	})
+	}
+	if r.params.Sphere {
+		m, err := vmesh.GenerateSphereMesh(r.params.NumPoints, 0.5, extent)
+		if err != nil {
+			return err
+		}
+		r.mesh = m
+	} else {
+		r.mesh = vmesh.GenerateGoodMesh(r.params.NumPoints, extent)
+	}
 
 	r.h = vmesh.NewHeightmap(r.mesh)
 	r.h.Add(
@@ -41,6 +50,7 @@ func (r *Terrain) genTerrain() {
 	r.h = HeightSetSeaLevel(r.h, runif(0.2, 0.6))
 	r.h = HeightFillSinks(r.h)
 	r.h = HeightCleanCoast(r.h, 5)
+	return nil
 }
 
 // Mesh-based heightmap generation helpers.
@@ -81,15 +91,12 @@ func MeshRidges(m *vmesh.Mesh, direction vectors.Vec2) *vmesh.Heightmap {
 	drawRidge = func(start, lifespan int, maxHeight float64) {
 		// TODO: With increasing lifespan sine height.
 		var length int
-		end := vectors.Vec2{
-			X: m.Vertices[start].X + direction.X,
-			Y: m.Vertices[start].Y + direction.Y,
-		}
+		distToEnd := ridgeTarget(m, start, direction)
 		for i := start; length < lifespan; length++ {
 
 			newvals.Values[i] = maxHeight * float64(rand.Intn(10)) / 10
 			for _, nb := range newvals.Neighbours(i) {
-				if distPoints(m.Vertices[nb].X, m.Vertices[nb].Y, end.X, end.Y) < distPoints(m.Vertices[i].X, m.Vertices[i].Y, end.X, end.Y) {
+				if distToEnd(nb) < distToEnd(i) {
 					i = nb
 				}
 				if rand.Intn(randomWalkChanceFraction) == 0 {
@@ -99,7 +106,7 @@ func MeshRidges(m *vmesh.Mesh, direction vectors.Vec2) *vmesh.Heightmap {
 					br := i
 					for p := 0; p < childRidgeDist; p++ {
 						for _, nb := range newvals.Neighbours(br) {
-							if distPoints(m.Vertices[br].X, m.Vertices[br].Y, m.Vertices[i].X, m.Vertices[i].Y) < distPoints(m.Vertices[i].X, m.Vertices[i].Y, m.Vertices[nb].X, m.Vertices[nb].Y) {
+							if m.Distance(br, i) < m.Distance(i, nb) {
 								br = nb
 							}
 						}
@@ -113,6 +120,52 @@ func MeshRidges(m *vmesh.Mesh, direction vectors.Vec2) *vmesh.Heightmap {
 	return newvals
 }
 
+// ridgeTarget returns a function that returns the distance of the given
+// vertex to the point reached when moving from the start vertex in the given
+// direction.
+//
+// On a sphere, the direction is interpreted as heading (x = east, y = south)
+// and distance along a great circle (using the same scale as the flat map),
+// so that ridges continue seamlessly across the seam of the projection.
+func ridgeTarget(m *vmesh.Mesh, start int, direction vectors.Vec2) func(i int) float64 {
+	if !m.IsSphere() {
+		end := vectors.Vec2{
+			X: m.Vertices[start].X + direction.X,
+			Y: m.Vertices[start].Y + direction.Y,
+		}
+		return func(i int) float64 {
+			return distPoints(m.Vertices[i].X, m.Vertices[i].Y, end.X, end.Y)
+		}
+	}
+
+	// Set up the local east and north vectors at the start position.
+	p := m.XYZ[start]
+	east := vectors.NewVec3(-p.Y, p.X, 0)
+	if east.Len() < 1e-9 {
+		// We are at a pole, so any direction will do.
+		east = vectors.NewVec3(1, 0, 0)
+	}
+	east = east.Normalize()
+	north := p.Cross(east)
+
+	// Y points south in the projection, so we need to flip it.
+	heading := east.Mul(direction.X).Add(north.Mul(-direction.Y))
+	if heading.Len() == 0 {
+		return func(i int) float64 {
+			return m.Distance(i, start)
+		}
+	}
+	heading = heading.Normalize()
+
+	// Don't go further than a quarter of the circumference, otherwise we
+	// would head back towards the start.
+	angle := math.Min(direction.Len()/m.Extent.Height*math.Pi, math.Pi/2)
+	end := p.Mul(math.Cos(angle)).Add(heading.Mul(math.Sin(angle)))
+	return func(i int) float64 {
+		return math.Acos(math.Max(-1, math.Min(1, m.XYZ[i].Dot(end))))
+	}
+}
+
 func distPoints(x1, y1, x2, y2 float64) float64 {
 	dx := x2 - x1
 	dy := y2 - y1
@@ -257,6 +310,18 @@ func HeightFillSinks(h *vmesh.Heightmap) *vmesh.Heightmap { //, epsilon float64
 			newh.Values[i] = infinity
 		}
 	}
+
+	// A sphere has no edges the water could drain into, so we use the
+	// lowest point as outflow instead.
+	if h.IsSphere() {
+		lowest := 0
+		for i, v := range h.Values {
+			if v < h.Values[lowest] {
+				lowest = i
+			}
+		}
+		newh.Values[lowest] = h.Values[lowest]
+	}
 	for {
 		var changed bool
 		for i := 0; i < h.Len(); i++ {
@@ -10,6 +10,7 @@ import (
 func getRiverPaths(h *vmesh.Heightmap, limit float64) [][]voronoi.Vertex {
 	dh := h.Downhill()
 	flux := getFlux(h)
+	wrap := wrapWidth(h.Mesh)
 
 	var links [][2]voronoi.Vertex
 
@@ -34,7 +35,8 @@ func getRiverPaths(h *vmesh.Heightmap, limit float64) [][]voronoi.Vertex {
 			if h.Values[dh[i]] > 0 {
 				links = append(links, [2]voronoi.Vertex{up, down})
 			} else {
-				links = append(links, [2]voronoi.Vertex{up, {X: (up.X + down.X) / 2, Y: (up.Y + down.Y) / 2}})
+				downX := unwrapX(down.X, up.X, wrap)
+				links = append(links, [2]voronoi.Vertex{up, {X: unwrapX((up.X+downX)/2, 0, wrap), Y: (up.Y + down.Y) / 2}})
 			}
 		}
 	}
@@ -43,7 +45,7 @@ func getRiverPaths(h *vmesh.Heightmap, limit float64) [][]voronoi.Vertex {
 	mergedSegs := mergeSegments(links)
 	for i := range mergedSegs {
 		// Relax the paths a little.
-		mergedSegs[i] = relaxPath(mergedSegs[i])
+		mergedSegs[i] = relaxPath(mergedSegs[i], wrap)
 	}
 	return mergedSegs
 }
@@ -225,11 +227,16 @@ func unshiftPath(path []voronoi.Vertex, p voronoi.Vertex) []voronoi.Vertex {
 
 // relaxPath averages the vertex coordinates in the path with their neighbours (to an extent)
 // and returns a "smoothed"/relaxed path.
-func relaxPath(path []voronoi.Vertex) []voronoi.Vertex {
+//
+// If the map wraps around (wrap > 0), neighbours on the other side of the seam
+// are unwrapped before averaging.
+func relaxPath(path []voronoi.Vertex, wrap float64) []voronoi.Vertex {
 	newpath := []voronoi.Vertex{path[0]}
 	for i := 1; i < len(path)-1; i++ {
+		prevX := unwrapX(path[i-1].X, path[i].X, wrap)
+		nextX := unwrapX(path[i+1].X, path[i].X, wrap)
 		newpt := voronoi.Vertex{
-			X: 0.25*path[i-1].X + 0.5*path[i].X + 0.25*path[i+1].X,
+			X: unwrapX(0.25*prevX+0.5*path[i].X+0.25*nextX, 0, wrap),
 			Y: 0.25*path[i-1].Y + 0.5*path[i].Y + 0.25*path[i+1].Y,
 		}
 		newpath = append(newpath, newpt)
@@ -237,3 +244,27 @@ func relaxPath(path []voronoi.Vertex) []voronoi.Vertex {
 	newpath = append(newpath, path[len(path)-1])
 	return newpath
 }
+
+// wrapWidth returns the width of the map if it wraps around horizontally
+// (which is the case for the projection of a sphere), or 0 if it doesn't.
+func wrapWidth(m *vmesh.Mesh) float64 {
+	if !m.IsSphere() {
+		return 0
+	}
+	return m.Extent.Width
+}
+
+// unwrapX shifts x by multiples of wrap, so that it is as close as possible
+// to ref. If wrap is 0, x is returned unchanged.
+func unwrapX(x, ref, wrap float64) float64 {
+	if wrap <= 0 {
+		return x
+	}
+	for x-ref > wrap/2 {
+		x -= wrap
+	}
+	for ref-x > wrap/2 {
+		x += wrap
+	}
+	return x
+}
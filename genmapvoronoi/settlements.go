@@ -0,0 +1,56 @@
+package genmapvoronoi
+
+import "math"
+
+// minCityScore is the threshold below which cityScore considers a region
+// uninhabitable (water or near the edge of the map).
+const minCityScore = -999990.0
+
+// SettlementTerrain provides the regions of a terrain and their fitness for
+// settlements (see cityScore), for example for placing the settlements of a
+// nation with gendemographics.
+type SettlementTerrain struct {
+	t      *Terrain
+	scores []float64
+}
+
+// SettlementTerrain returns the regions of the terrain with their fitness for
+// settlements normalized to the range (0, 1] (0 for uninhabitable regions).
+func (t *Terrain) SettlementTerrain() *SettlementTerrain {
+	score := cityScore(t)
+	min, max := math.Inf(1), math.Inf(-1)
+	for _, v := range score.Values {
+		if v < minCityScore {
+			continue
+		}
+		min = math.Min(min, v)
+		max = math.Max(max, v)
+	}
+	scores := make([]float64, len(score.Values))
+	for i, v := range score.Values {
+		if v < minCityScore {
+			continue
+		}
+		scores[i] = 1
+		if max > min {
+			scores[i] = 0.01 + 0.99*(v-min)/(max-min)
+		}
+	}
+	return &SettlementTerrain{t: t, scores: scores}
+}
+
+// NumCells returns the number of regions.
+func (s *SettlementTerrain) NumCells() int {
+	return len(s.scores)
+}
+
+// CellPosition returns the position of the region.
+func (s *SettlementTerrain) CellPosition(i int) (x, y float64) {
+	v := s.t.h.Vertices[i]
+	return v.X, v.Y
+}
+
+// CellHabitability returns the fitness of the region for settlements.
+func (s *SettlementTerrain) CellHabitability(i int) float64 {
+	return s.scores[i]
+}
@@ -22,6 +22,7 @@ type Params struct {
 	NumCities      int
 	NumTerritories int
 	RiverThreshold float64
+	Sphere         bool // Generate a seamless planet instead of a flat map
 }
 
 var DefaultParams = &Params{
@@ -32,6 +33,16 @@ var DefaultParams = &Params{
 	RiverThreshold: 0.005,
 }
 
+// DefaultSphereParams generates a planet, projected onto the default extent.
+var DefaultSphereParams = &Params{
+	Extent:         DefaultExtent,
+	NumPoints:      16384,
+	NumCities:      15,
+	NumTerritories: 5,
+	RiverThreshold: 0.005,
+	Sphere:         true,
+}
+
 type Terrain struct {
 	params   *Params
 	mesh     *vmesh.Mesh
@@ -48,15 +59,17 @@ type Terrain struct {
 	cityBorders     [][]voronoi.Vertex
 }
 
-func NewTerrain(params *Params) *Terrain {
+func NewTerrain(params *Params) (*Terrain, error) {
 	r := &Terrain{
 		params: params,
 	}
 
-	r.genTerrain()
+	if err := r.genTerrain(); err != nil {
+		return nil, err
+	}
 	r.regenMapFeatures()
 
-	return r
+	return r, nil
 }
 
 func (r *Terrain) regenMapFeatures() {
@@ -0,0 +1,347 @@
+package genmapvoxel
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	opensimplex "github.com/ojrac/opensimplex-go"
+)
+
+// ChunkSize is the number of voxels along each axis of a chunk.
+const ChunkSize = 16
+
+// ChunkPos is the position of a chunk (in chunks, not voxels).
+type ChunkPos struct {
+	X, Y, Z int64
+}
+
+// Chunk is a cubic section of a ChunkedWorld.
+type Chunk struct {
+	Pos    ChunkPos
+	Voxels [ChunkSize * ChunkSize * ChunkSize]Material // Flattened voxel materials.
+}
+
+// index returns the index of the voxel with the given local coordinates.
+func (c *Chunk) index(x, y, z int64) int64 {
+	return (z*ChunkSize+y)*ChunkSize + x
+}
+
+// Get returns the material of the voxel with the given local coordinates.
+func (c *Chunk) Get(x, y, z int64) Material {
+	return c.Voxels[c.index(x, y, z)]
+}
+
+// Set sets the material of the voxel with the given local coordinates.
+func (c *Chunk) Set(x, y, z int64, m Material) {
+	c.Voxels[c.index(x, y, z)] = m
+}
+
+// isEmpty returns true if the chunk only contains air.
+func (c *Chunk) isEmpty() bool {
+	for _, m := range c.Voxels {
+		if m != MatAir {
+			return false
+		}
+	}
+	return true
+}
+
+// ChunkConfig contains the parameters for generating a ChunkedWorld.
+type ChunkConfig struct {
+	Seed          int64
+	BaseHeight    float64 // Minimum terrain height (in voxels).
+	HeightScale   float64 // Maximum height of the terrain above BaseHeight (in voxels).
+	TerrainScale  float64 // Horizontal scale of the terrain noise (in voxels).
+	Octaves       int     // Number of noise octaves for the terrain.
+	SeaLevel      int64   // Everything below this height that is not solid is water.
+	DirtDepth     int64   // Depth of the dirt layer below the surface.
+	CaveScale     float64 // Scale of the 3D cave noise (in voxels).
+	CaveThreshold float64 // Noise threshold above which caves are carved (>= 1 disables caves).
+	OreScale      float64 // Scale of the 3D ore noise (in voxels).
+	OreThreshold  float64 // Noise threshold above which stone turns into ore (>= 1 disables ore).
+}
+
+// DefaultChunkConfig is the default configuration for a ChunkedWorld.
+var DefaultChunkConfig = &ChunkConfig{
+	Seed:          12345,
+	BaseHeight:    8,
+	HeightScale:   40,
+	TerrainScale:  96,
+	Octaves:       4,
+	SeaLevel:      20,
+	DirtDepth:     3,
+	CaveScale:     24,
+	CaveThreshold: 0.55,
+	OreScale:      6,
+	OreThreshold:  0.7,
+}
+
+// ChunkedWorld is a voxel world that is split into chunks, which are only
+// generated (and kept in memory) on demand.
+type ChunkedWorld struct {
+	*ChunkConfig
+	Chunks map[ChunkPos]*Chunk // Generated chunks (chunks only containing air are omitted).
+	noise  opensimplex.Noise   // Terrain and cave noise.
+	ore    opensimplex.Noise   // Ore vein noise.
+}
+
+// NewChunkedWorld returns a new, empty chunked world using the given config.
+func NewChunkedWorld(cfg *ChunkConfig) *ChunkedWorld {
+	if cfg == nil {
+		cfg = DefaultChunkConfig
+	}
+	return &ChunkedWorld{
+		ChunkConfig: cfg,
+		Chunks:      make(map[ChunkPos]*Chunk),
+		noise:       opensimplex.New(cfg.Seed),
+		ore:         opensimplex.New(cfg.Seed + 1),
+	}
+}
+
+// GenerateRange generates all chunks from min to max (inclusive).
+func (w *ChunkedWorld) GenerateRange(min, max ChunkPos) {
+	for cx := min.X; cx <= max.X; cx++ {
+		for cy := min.Y; cy <= max.Y; cy++ {
+			for cz := min.Z; cz <= max.Z; cz++ {
+				w.GenerateChunk(ChunkPos{X: cx, Y: cy, Z: cz})
+			}
+		}
+	}
+}
+
+// GenerateChunk generates the chunk at the given chunk position (if it has
+// not been generated yet) and returns it.
+// NOTE: Returns nil if the chunk only contains air.
+func (w *ChunkedWorld) GenerateChunk(pos ChunkPos) *Chunk {
+	if c, ok := w.Chunks[pos]; ok {
+		return c
+	}
+	c := &Chunk{Pos: pos}
+	for x := int64(0); x < ChunkSize; x++ {
+		for y := int64(0); y < ChunkSize; y++ {
+			wx := pos.X*ChunkSize + x
+			wy := pos.Y*ChunkSize + y
+			height := w.terrainHeight(wx, wy)
+			for z := int64(0); z < ChunkSize; z++ {
+				c.Set(x, y, z, w.genMaterial(wx, wy, pos.Z*ChunkSize+z, height))
+			}
+		}
+	}
+	if c.isEmpty() {
+		c = nil
+	}
+	w.Chunks[pos] = c
+	return c
+}
+
+// terrainHeight returns the height of the terrain surface at the given position.
+func (w *ChunkedWorld) terrainHeight(x, y int64) int64 {
+	var val, amp, sum float64
+	amp = 1.0
+	freq := 1.0 / w.TerrainScale
+	for i := 0; i < w.Octaves; i++ {
+		val += amp * w.noise.Eval2(float64(x)*freq, float64(y)*freq)
+		sum += amp
+		amp /= 2
+		freq *= 2
+	}
+	val = (val/sum + 1) / 2
+	return int64(w.BaseHeight + val*w.HeightScale)
+}
+
+// genMaterial returns the material at the given position for the given
+// terrain height.
+func (w *ChunkedWorld) genMaterial(x, y, z, height int64) Material {
+	if z < 0 {
+		return MatStone
+	}
+	if z > height {
+		if z <= w.SeaLevel {
+			return MatWater
+		}
+		return MatAir
+	}
+
+	// Carve out caves using 3D noise, but keep the bottom layer
+	// intact and don't carve below the sea floor (which would leave
+	// water hanging in the air).
+	if z > 0 && w.CaveThreshold < 1 && !(height <= w.SeaLevel && z >= height-1) {
+		cs := 1.0 / w.CaveScale
+		if w.noise.Eval3(float64(x)*cs, float64(y)*cs, float64(z)*cs*1.5) > w.CaveThreshold {
+			return MatAir
+		}
+	}
+	if z == height {
+		if z >= w.SeaLevel {
+			return MatGrass
+		}
+		return MatDirt
+	}
+	if z > height-w.DirtDepth {
+		return MatDirt
+	}
+
+	// Place ore veins within the stone layer.
+	if w.OreThreshold < 1 {
+		ors := 1.0 / w.OreScale
+		if w.ore.Eval3(float64(x)*ors, float64(y)*ors, float64(z)*ors) > w.OreThreshold {
+			return MatOre
+		}
+	}
+	return MatStone
+}
+
+// floorDiv returns the floored division of a by b (for negative coordinates).
+func floorDiv(a, b int64) int64 {
+	return int64(math.Floor(float64(a) / float64(b)))
+}
+
+// chunkPosOf returns the chunk position and the local coordinates within
+// the chunk of the given voxel.
+func chunkPosOf(x, y, z int64) (ChunkPos, int64, int64, int64) {
+	pos := ChunkPos{
+		X: floorDiv(x, ChunkSize),
+		Y: floorDiv(y, ChunkSize),
+		Z: floorDiv(z, ChunkSize),
+	}
+	return pos, x - pos.X*ChunkSize, y - pos.Y*ChunkSize, z - pos.Z*ChunkSize
+}
+
+// Get returns the material at the given voxel position.
+// NOTE: Voxels in chunks that have not been generated are considered air.
+func (w *ChunkedWorld) Get(x, y, z int64) Material {
+	pos, lx, ly, lz := chunkPosOf(x, y, z)
+	c := w.Chunks[pos]
+	if c == nil {
+		return MatAir
+	}
+	return c.Get(lx, ly, lz)
+}
+
+// Set sets the material at the given voxel position, allocating the chunk
+// if necessary.
+func (w *ChunkedWorld) Set(x, y, z int64, m Material) {
+	pos, lx, ly, lz := chunkPosOf(x, y, z)
+	c := w.Chunks[pos]
+	if c == nil {
+		if m == MatAir {
+			return
+		}
+		c = &Chunk{Pos: pos}
+		w.Chunks[pos] = c
+	}
+	c.Set(lx, ly, lz, m)
+}
+
+// sortedChunks returns all non-empty chunks sorted by position.
+func (w *ChunkedWorld) sortedChunks() []*Chunk {
+	var chunks []*Chunk
+	for _, c := range w.Chunks {
+		if c != nil {
+			chunks = append(chunks, c)
+		}
+	}
+	sort.Slice(chunks, func(i, j int) bool {
+		a, b := chunks[i].Pos, chunks[j].Pos
+		if a.X != b.X {
+			return a.X < b.X
+		}
+		if a.Y != b.Y {
+			return a.Y < b.Y
+		}
+		return a.Z < b.Z
+	})
+	return chunks
+}
+
+// ExportOBJ exports all generated chunks to an OBJ file with one object per
+// chunk and one group per material. The materials are written to a MTL file
+// with the same name.
+// NOTE: Coplanar faces of the same material are merged (see ChunkMesh).
+func (w *ChunkedWorld) ExportOBJ(filename string) error {
+	mtlName := strings.TrimSuffix(filename, filepath.Ext(filename)) + ".mtl"
+	if err := exportMTL(mtlName); err != nil {
+		return err
+	}
+
+	// Open/create the destination file.
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// Initialize a new bufio writer.
+	wr := bufio.NewWriter(f)
+	if _, err := wr.WriteString(fmt.Sprintf("mtllib %s\n", filepath.Base(mtlName))); err != nil {
+		return err
+	}
+
+	var offset int
+	for _, c := range w.sortedChunks() {
+		if _, err := wr.WriteString(fmt.Sprintf("o chunk_%d_%d_%d\n", c.Pos.X, c.Pos.Y, c.Pos.Z)); err != nil {
+			return err
+		}
+		if offset, err = w.ChunkMesh(c).writeOBJ(wr, offset); err != nil {
+			return err
+		}
+	}
+	return wr.Flush()
+}
+
+// ChunkMesh returns the mesh of the visible voxel faces of the given chunk,
+// where adjacent coplanar faces of the same material are merged into larger
+// quads (greedy meshing).
+func (w *ChunkedWorld) ChunkMesh(c *Chunk) *Mesh {
+	m := newMesh()
+	min := [3]int64{c.Pos.X * ChunkSize, c.Pos.Y * ChunkSize, c.Pos.Z * ChunkSize}
+	greedyMesh(w, min, [3]int64{min[0] + ChunkSize, min[1] + ChunkSize, min[2] + ChunkSize}, m)
+	return m
+}
+
+func (w *ChunkedWorld) faceAt(x, y, z int64, dir int) (Material, float64) {
+	d := faceDirs[dir]
+	if m := w.Get(x, y, z); isFaceVisible(m, w.Get(x+d[0], y+d[1], z+d[2])) {
+		return m, 1.0
+	}
+	return MatAir, 0
+}
+
+// faceDirs contains the direction of the neighbor for each encoded face
+// (west, east, north, south, bottom, top).
+var faceDirs = [6][3]int64{
+	{-1, 0, 0},
+	{1, 0, 0},
+	{0, -1, 0},
+	{0, 1, 0},
+	{0, 0, -1},
+	{0, 0, 1},
+}
+
+// exportMTL writes a MTL file with a material for each voxel material.
+func exportMTL(filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	wr := bufio.NewWriter(f)
+	for m := MatStone; m < MatMax; m++ {
+		c := m.Color()
+		if _, err := wr.WriteString(fmt.Sprintf("newmtl %s\nKd %f %f %f\n", m, c[0], c[1], c[2])); err != nil {
+			return err
+		}
+		if m == MatWater {
+			if _, err := wr.WriteString("d 0.6\n"); err != nil {
+				return err
+			}
+		}
+		wr.WriteString("\n")
+	}
+	return wr.Flush()
+}
@@ -11,4 +11,10 @@ func main() {
 	if err := w.ExportOBJ("tmp.obj", true); err != nil {
 		log.Println(err)
 	}
+
+	cw := genmapvoxel.NewChunkedWorld(genmapvoxel.DefaultChunkConfig)
+	cw.GenerateRange(genmapvoxel.ChunkPos{X: 0, Y: 0, Z: 0}, genmapvoxel.ChunkPos{X: 3, Y: 3, Z: 3})
+	if err := cw.ExportOBJ("chunks.obj"); err != nil {
+		log.Println(err)
+	}
 }
@@ -4,6 +4,8 @@ import (
 	"bufio"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/Flokey82/go_gens/vectors"
 
@@ -78,10 +80,15 @@ func (w *World) initGrid() {
 	}
 }
 
-// ExportOBJ exports the world to an OBJ file.
+// ExportOBJ exports the world to an OBJ file (and a MTL file with the same name).
 // NOTE: If 'smooth' is true, partial voxels (the remainder of the respective
 // noise value at the given coordinates) will be rendered with fractional height.
 func (w *World) ExportOBJ(filename string, smooth bool) error {
+	mtlName := strings.TrimSuffix(filename, filepath.Ext(filename)) + ".mtl"
+	if err := exportMTL(mtlName); err != nil {
+		return err
+	}
+
 	// Open/create the destination file.
 	f, err := os.Create(filename)
 	if err != nil {
@@ -91,63 +98,72 @@ func (w *World) ExportOBJ(filename string, smooth bool) error {
 
 	// Initialize a new bufio writer.
 	wr := bufio.NewWriter(f)
+	if _, err := wr.WriteString(fmt.Sprintf("mtllib %s\n", filepath.Base(mtlName))); err != nil {
+		return err
+	}
+	if _, err := w.Mesh(smooth).writeOBJ(wr, 0); err != nil {
+		return err
+	}
+	return wr.Flush()
+}
 
-	// Make sure to flush the buffer on exit.
-	defer wr.Flush()
-
-	var vertices []vectors.Vec3
-	var faces [][]int
+// Mesh returns the mesh of the visible voxel faces, where adjacent coplanar
+// faces are merged into larger quads (greedy meshing).
+// NOTE: See ExportOBJ for 'smooth'.
+func (w *World) Mesh(smooth bool) *Mesh {
+	m := newMesh()
+	greedyMesh(&worldFaces{World: w, smooth: smooth}, [3]int64{}, [3]int64{w.dimX, w.dimY, w.dimZ}, m)
+	return m
+}
 
-	// Iterate over voxel grid and determine visible faces.
+// naiveMesh returns the mesh with one quad per visible voxel face.
+func (w *World) naiveMesh(smooth bool) *Mesh {
+	m := newMesh()
 	for x := int64(0); x < w.dimX; x++ {
 		for y := int64(0); y < w.dimY; y++ {
 			for z := int64(0); z < w.dimZ; z++ {
 				if !w.Voxels[x][y][z] {
 					continue
 				}
-				// Check which faces are visible and encode them in the index.
-				faceIndex := w.getEncodedIndex(x, y, z)
-
 				// If we should smooth the terrain by using the float values, do so.
 				heightVal := 1.0
 				if smooth {
 					heightVal = w.Values[x][y][z]
 				}
-
-				// Get the faces to render for this voxel.
-				fcs := getFaces(faceIndex, heightVal)
-
-				// Add the vertices to the list.
-				for _, f := range fcs {
-					var faceIndices []int
-					// Translate the faces to the voxel position.
-					for _, v := range f.Translate(float64(x), float64(y), float64(z)) {
-						vertices = append(vertices, v)
-						faceIndices = append(faceIndices, len(vertices))
+				faceIndex := w.getEncodedIndex(x, y, z)
+				for dir := range faceDirs {
+					if faceIndex&(1<<dir) == 0 {
+						continue
 					}
-					// Append the face vertex indices to the list of faces.
-					faces = append(faces, faceIndices)
+					s := getFaces(1<<dir, heightVal)[0].Translate(float64(x), float64(y), float64(z))
+					if (dir%2 == 0) != (dir/2 == 1) {
+						// Make sure the face is wound counter-clockwise
+						// (the base sides are wound for the positive axis,
+						// except for north/south, which is the other way round).
+						s[1], s[3] = s[3], s[1]
+					}
+					m.addQuad(s, dir, MatStone)
 				}
 			}
 		}
 	}
+	return m
+}
 
-	// Write all the vertices to the file.
-	for _, v := range vertices {
-		// NOTE: I switched Y and Z since importing into Blender would have the Y axis as the up axis.
-		if _, err := wr.WriteString(fmt.Sprintf("v %f %f %f \n", v.X, v.Z, v.Y)); err != nil {
-			return err
-		}
-	}
+// worldFaces implements voxelFaces for a World.
+type worldFaces struct {
+	*World
+	smooth bool
+}
 
-	// Write all the faces to the file.
-	for _, f := range faces {
-		if _, err := wr.WriteString(fmt.Sprintf("f %d %d %d %d \n", f[0], f[1], f[2], f[3])); err != nil {
-			return err
-		}
+func (w *worldFaces) faceAt(x, y, z int64, dir int) (Material, float64) {
+	if !w.Voxels[x][y][z] || w.getEncodedIndex(x, y, z)&(1<<dir) == 0 {
+		return MatAir, 0
 	}
-
-	return nil
+	if w.smooth {
+		return MatStone, w.Values[x][y][z]
+	}
+	return MatStone, 1.0
 }
 
 func (w *World) getEncodedIndex(x, y, z int64) byte {
@@ -0,0 +1,63 @@
+package genmapvoxel
+
+import (
+	"math"
+	"testing"
+
+	"github.com/Flokey82/go_gens/vectors"
+)
+
+// meshArea returns the total area of all quads of the mesh.
+func meshArea(m *Mesh) float64 {
+	var area float64
+	for _, q := range m.Quads {
+		a, b, d := m.Vertices[q[0]], m.Vertices[q[1]], m.Vertices[q[3]]
+		area += vectors.Sub3(b, a).Cross(vectors.Sub3(d, a)).Len()
+	}
+	return area
+}
+
+func TestGreedyMesh(t *testing.T) {
+	w := New(32, 32, 32, 12345)
+	for _, smooth := range []bool{false, true} {
+		naive := w.naiveMesh(smooth)
+		greedy := w.Mesh(smooth)
+		if got, want := meshArea(greedy), meshArea(naive); math.Abs(got-want) > 1e-6 {
+			t.Errorf("Mesh(%t) area = %f, want %f", smooth, got, want)
+		}
+		if len(naive.Vertices) >= 4*len(naive.Quads) {
+			t.Errorf("naiveMesh(%t) has %d vertices for %d quads, want shared vertices", smooth, len(naive.Vertices), len(naive.Quads))
+		}
+		if greedy.NumTriangles() >= naive.NumTriangles() {
+			t.Errorf("Mesh(%t) has %d triangles, want less than %d", smooth, greedy.NumTriangles(), naive.NumTriangles())
+		}
+		for i, q := range greedy.Quads {
+			a, b, c := greedy.Vertices[q[0]], greedy.Vertices[q[1]], greedy.Vertices[q[2]]
+			if n := vectors.Sub3(b, a).Cross(vectors.Sub3(c, a)); n.Dot(greedy.Normals[q[0]]) <= 0 {
+				t.Fatalf("Mesh(%t) quad %d is not wound counter-clockwise", smooth, i)
+			}
+		}
+	}
+}
+
+// BenchmarkMesh reports the triangle count of the sample world from cmd
+// with one quad per face (naive) and with merged faces (greedy).
+func BenchmarkMesh(b *testing.B) {
+	w := New(32, 32, 32, 12345)
+	for _, bc := range []struct {
+		name string
+		mesh func(smooth bool) *Mesh
+	}{
+		{"naive", w.naiveMesh},
+		{"greedy", w.Mesh},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			var m *Mesh
+			for i := 0; i < b.N; i++ {
+				m = bc.mesh(true)
+			}
+			b.ReportMetric(float64(m.NumTriangles()), "triangles")
+			b.ReportMetric(float64(len(m.Vertices)), "vertices")
+		})
+	}
+}
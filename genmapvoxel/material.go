@@ -0,0 +1,70 @@
+package genmapvoxel
+
+// Material is the material ID of a voxel.
+type Material byte
+
+// The available voxel materials.
+const (
+	MatAir Material = iota
+	MatStone
+	MatDirt
+	MatGrass
+	MatWater
+	MatOre
+	MatMax // Number of materials (not a valid material).
+)
+
+// String returns the name of the material (used for OBJ material groups).
+func (m Material) String() string {
+	switch m {
+	case MatAir:
+		return "air"
+	case MatStone:
+		return "stone"
+	case MatDirt:
+		return "dirt"
+	case MatGrass:
+		return "grass"
+	case MatWater:
+		return "water"
+	case MatOre:
+		return "ore"
+	}
+	return "unknown"
+}
+
+// IsSolid returns true if the material is neither air nor a liquid.
+func (m Material) IsSolid() bool {
+	return m != MatAir && m != MatWater
+}
+
+// Color returns the diffuse color of the material (used for OBJ material files).
+func (m Material) Color() [3]float64 {
+	switch m {
+	case MatStone:
+		return [3]float64{0.5, 0.5, 0.5}
+	case MatDirt:
+		return [3]float64{0.45, 0.3, 0.15}
+	case MatGrass:
+		return [3]float64{0.2, 0.6, 0.15}
+	case MatWater:
+		return [3]float64{0.1, 0.3, 0.8}
+	case MatOre:
+		return [3]float64{0.7, 0.45, 0.2}
+	}
+	return [3]float64{1, 1, 1}
+}
+
+// isFaceVisible returns true if the face of a voxel with material 'm' towards
+// a neighbor with material 'nb' is visible.
+func isFaceVisible(m, nb Material) bool {
+	if m == MatAir {
+		return false
+	}
+	if m == MatWater {
+		// Water is only visible towards air, otherwise we'd render
+		// all the faces below the surface.
+		return nb == MatAir
+	}
+	return !nb.IsSolid()
+}
@@ -0,0 +1,236 @@
+package genmapvoxel
+
+import (
+	"bufio"
+	"fmt"
+
+	"github.com/Flokey82/go_gens/vectors"
+)
+
+// Mesh is an indexed quad mesh generated from voxel data.
+type Mesh struct {
+	Vertices  []vectors.Vec3
+	Normals   []vectors.Vec3
+	UVs       [][2]float64 // Texture coordinates in voxel units (tiled once per voxel).
+	Quads     [][4]int     // Vertex indices of each quad (counter-clockwise).
+	Materials []Material   // Material of each quad.
+
+	vertexIdx map[meshVertex]int // Lookup for shared vertices.
+}
+
+// meshVertex is used to identify vertices that can be shared between quads.
+// Since the normal and UV coordinates are derived from the position and face
+// direction, all quads facing the same way can share their corners.
+type meshVertex struct {
+	pos vectors.Vec3
+	dir int
+}
+
+// newMesh returns a new, empty mesh.
+func newMesh() *Mesh {
+	return &Mesh{
+		vertexIdx: make(map[meshVertex]int),
+	}
+}
+
+// NumTriangles returns the number of triangles of the mesh (two per quad).
+func (m *Mesh) NumTriangles() int {
+	return 2 * len(m.Quads)
+}
+
+// addVertex adds a vertex to the mesh (if it doesn't exist yet) and returns
+// its index.
+func (m *Mesh) addVertex(pos vectors.Vec3, dir int) int {
+	key := meshVertex{pos: pos, dir: dir}
+	if idx, ok := m.vertexIdx[key]; ok {
+		return idx
+	}
+	d := faceDirs[dir]
+	m.Vertices = append(m.Vertices, pos)
+	m.Normals = append(m.Normals, vectors.NewVec3(float64(d[0]), float64(d[1]), float64(d[2])))
+	m.UVs = append(m.UVs, faceUV(pos, dir))
+	m.vertexIdx[key] = len(m.Vertices) - 1
+	return len(m.Vertices) - 1
+}
+
+// addQuad adds a quad facing in the given direction (index into faceDirs)
+// with the given material to the mesh.
+// NOTE: The corners are expected to be in counter-clockwise order when seen
+// from the direction the quad is facing.
+func (m *Mesh) addQuad(corners [4]vectors.Vec3, dir int, mat Material) {
+	var q [4]int
+	for i, c := range corners {
+		q[i] = m.addVertex(c, dir)
+	}
+	m.Quads = append(m.Quads, q)
+	m.Materials = append(m.Materials, mat)
+}
+
+// faceUV returns the texture coordinates of the given position on a face
+// facing in the given direction (index into faceDirs). The coordinates are
+// the world position along the two axes spanning the face, so textures are
+// tiled once per voxel, even across merged faces.
+func faceUV(pos vectors.Vec3, dir int) [2]float64 {
+	p := [3]float64{pos.X, pos.Y, pos.Z}
+	d := dir / 2
+	return [2]float64{p[(d+1)%3], p[(d+2)%3]}
+}
+
+// voxelFaces is implemented by voxel volumes that can be meshed.
+type voxelFaces interface {
+	// faceAt returns the material and height (0.0-1.0) of the face of the
+	// voxel at the given position in the given direction (index into
+	// faceDirs). If the face is not visible, MatAir is returned.
+	faceAt(x, y, z int64, dir int) (Material, float64)
+}
+
+// faceKey identifies faces that can be merged.
+type faceKey struct {
+	mat    Material
+	height float64
+}
+
+// greedyMesh adds the visible faces of all voxels from min to max (exclusive)
+// to the mesh, merging adjacent coplanar faces of the same material into
+// larger quads.
+func greedyMesh(src voxelFaces, min, max [3]int64, m *Mesh) {
+	var size [3]int64
+	for a := range size {
+		size[a] = max[a] - min[a]
+	}
+	for dir := range faceDirs {
+		d := dir / 2           // Axis of the face normal.
+		positive := dir%2 == 1 // Facing towards the positive axis?
+		u := (d + 1) % 3
+		v := (d + 2) % 3
+		su, sv := size[u], size[v]
+		mask := make([]faceKey, su*sv)
+		for slice := int64(0); slice < size[d]; slice++ {
+			// Build the mask of visible faces in this slice.
+			var pos [3]int64
+			pos[d] = min[d] + slice
+			for j := int64(0); j < sv; j++ {
+				for i := int64(0); i < su; i++ {
+					pos[u] = min[u] + i
+					pos[v] = min[v] + j
+					mat, h := src.faceAt(pos[0], pos[1], pos[2], dir)
+					mask[j*su+i] = faceKey{mat: mat, height: h}
+				}
+			}
+
+			// Merge faces with the same key into rectangles.
+			for j := int64(0); j < sv; j++ {
+				for i := int64(0); i < su; {
+					k := mask[j*su+i]
+					if k.mat == MatAir {
+						i++
+						continue
+					}
+
+					// Partial voxels can't be merged vertically.
+					canGrow := func(a int) bool {
+						return a != 2 || k.height == 1.0
+					}
+
+					// Determine the width of the rectangle.
+					w := int64(1)
+					for canGrow(u) && i+w < su && mask[j*su+i+w] == k {
+						w++
+					}
+
+					// Determine the height of the rectangle.
+					h := int64(1)
+				grow:
+					for canGrow(v) && j+h < sv {
+						for x := int64(0); x < w; x++ {
+							if mask[(j+h)*su+i+x] != k {
+								break grow
+							}
+						}
+						h++
+					}
+
+					m.addQuad(quadCorners(d, u, v, positive, pos[d], min[u]+i, min[v]+j, w, h, k.height), dir, k.mat)
+
+					// Clear the merged faces.
+					for y := int64(0); y < h; y++ {
+						for x := int64(0); x < w; x++ {
+							mask[(j+y)*su+i+x] = faceKey{}
+						}
+					}
+					i += w
+				}
+			}
+		}
+	}
+}
+
+// quadCorners returns the corners of a quad in the plane of axis d at the
+// given slice, starting at the voxel (i, j) along the axes u and v, spanning
+// w by h voxels.
+// NOTE: Voxels are centered on their position, so a voxel at (0, 0, 0)
+// spans from -0.5 to 0.5 on all axes.
+func quadCorners(d, u, v int, positive bool, slice, i, j, w, h int64, height float64) [4]vectors.Vec3 {
+	// extent returns the size of the quad along the given axis.
+	extent := func(a int, n int64) float64 {
+		if a == 2 && height != 1.0 {
+			return height
+		}
+		return float64(n)
+	}
+	var p0, du, dv [3]float64
+	p0[d] = float64(slice) - 0.5
+	if positive {
+		p0[d] += extent(d, 1)
+	}
+	p0[u] = float64(i) - 0.5
+	p0[v] = float64(j) - 0.5
+	du[u] = extent(u, w)
+	dv[v] = extent(v, h)
+
+	toVec := func(a, b, c [3]float64) vectors.Vec3 {
+		return vectors.NewVec3(a[0]+b[0]+c[0], a[1]+b[1]+c[1], a[2]+b[2]+c[2])
+	}
+	var zero [3]float64
+	if positive {
+		return [4]vectors.Vec3{toVec(p0, zero, zero), toVec(p0, du, zero), toVec(p0, du, dv), toVec(p0, zero, dv)}
+	}
+	return [4]vectors.Vec3{toVec(p0, zero, zero), toVec(p0, zero, dv), toVec(p0, du, dv), toVec(p0, du, zero)}
+}
+
+// writeOBJ writes the mesh to the given writer using the given vertex index
+// offset (for writing multiple meshes into one file) and returns the new offset.
+// Quads are grouped by material.
+func (m *Mesh) writeOBJ(wr *bufio.Writer, offset int) (int, error) {
+	// Write all the vertices, normals and texture coordinates.
+	// NOTE: I switched Y and Z since importing into Blender would have the Y axis as the up axis.
+	for i, v := range m.Vertices {
+		n := m.Normals[i]
+		uv := m.UVs[i]
+		if _, err := wr.WriteString(fmt.Sprintf("v %f %f %f \nvn %f %f %f \nvt %f %f \n", v.X, v.Z, v.Y, n.X, n.Z, n.Y, uv[0], uv[1])); err != nil {
+			return offset, err
+		}
+	}
+
+	// Write all the quads grouped by material.
+	for mat := MatAir + 1; mat < MatMax; mat++ {
+		var header bool
+		for i, q := range m.Quads {
+			if m.Materials[i] != mat {
+				continue
+			}
+			if !header {
+				if _, err := wr.WriteString(fmt.Sprintf("usemtl %s\n", mat)); err != nil {
+					return offset, err
+				}
+				header = true
+			}
+			// NOTE: Since Y and Z are switched, the winding order needs to be reversed.
+			a, b, c, d := q[3]+offset+1, q[2]+offset+1, q[1]+offset+1, q[0]+offset+1
+			if _, err := wr.WriteString(fmt.Sprintf("f %d/%d/%d %d/%d/%d %d/%d/%d %d/%d/%d \n", a, a, a, b, b, b, c, c, c, d, d, d)); err != nil {
+				return offset, err
+			}
+		}
+	}
+	return offset + len(m.Vertices), nil
+}
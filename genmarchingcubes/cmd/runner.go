@@ -5,6 +5,7 @@ import (
 
 	"github.com/Flokey82/go_gens/genmapvoxel"
 	"github.com/Flokey82/go_gens/genmarchingcubes"
+	"github.com/Flokey82/go_gens/vectors"
 )
 
 func main() {
@@ -24,4 +25,18 @@ func main() {
 	if err := genmarchingcubes.ExportToOBJ("tmp.obj", tris); err != nil {
 		log.Println(err)
 	}
+
+	// Mesh the voxel values directly, welding the vertices and computing normals.
+	m := genmarchingcubes.MarchingCubesField(genmarchingcubes.VoxelField(wld.Values), vectors.Vec3{}, h, w, d, 1.0, 0.5)
+	if err := m.ExportToOBJ("tmp_welded.obj"); err != nil {
+		log.Println(err)
+	}
+
+	// Alternative extractors that preserve sharp features better.
+	if err := genmarchingcubes.SurfaceNets(h, w, d, values, 0.5).ExportToObj("tmp_surfacenets.obj"); err != nil {
+		log.Println(err)
+	}
+	if err := genmarchingcubes.DualContouring(h, w, d, values, 0.5).ExportToObj("tmp_dualcontouring.obj"); err != nil {
+		log.Println(err)
+	}
 }
@@ -0,0 +1,224 @@
+package genmarchingcubes
+
+import (
+	"math"
+
+	"github.com/Flokey82/go_gens/gengeometry"
+	"github.com/Flokey82/go_gens/vectors"
+)
+
+// SurfaceNets extracts the isosurface at the given value from the data grid
+// with the given width, height, and depth (same input as MarchingCubesGrid)
+// using the (naive) surface nets algorithm.
+//
+// Each cube the surface passes through gets a single vertex (at the average
+// of the points where the surface crosses the edges of the cube), and each
+// edge of the grid that is crossed by the surface results in a quad connecting
+// the vertices of the four cubes sharing that edge.
+//
+// This results in much smoother (and smaller) meshes than marching cubes.
+func SurfaceNets(w, h, d int, data []float64, value float64) *gengeometry.Mesh {
+	return dualMesh(w, h, d, data, value, func(_ [2]vectors.Vec3, hermite []hermitePoint) vectors.Vec3 {
+		return massPoint(hermite)
+	})
+}
+
+// DualContouring extracts the isosurface at the given value from the data grid
+// with the given width, height, and depth (same input as MarchingCubesGrid)
+// using dual contouring with Hermite data.
+//
+// Like SurfaceNets, each cube the surface passes through gets a single vertex,
+// but instead of averaging the edge crossings, the vertex is placed where the
+// planes defined by the edge crossings and the surface normals (derived from
+// the gradient of the data) intersect best. This preserves sharp edges and
+// corners that marching cubes and surface nets round off.
+func DualContouring(w, h, d int, data []float64, value float64) *gengeometry.Mesh {
+	return dualMesh(w, h, d, data, value, solveQEF)
+}
+
+// hermitePoint is a point where the surface crosses an edge of a cube, with
+// the surface normal at that point.
+type hermitePoint struct {
+	pos    vectors.Vec3
+	normal vectors.Vec3
+}
+
+// dualMesh generates a mesh with one vertex per cube that is crossed by the
+// surface (placed using the given function) and a quad for each crossed edge.
+func dualMesh(w, h, d int, data []float64, value float64, place func(bounds [2]vectors.Vec3, hermite []hermitePoint) vectors.Vec3) *gengeometry.Mesh {
+	field := GridField(w, h, d, data)
+	n := [3]int{w, h, d}
+	idx := func(p [3]int) int {
+		return p[0] + p[1]*w + p[2]*w*h
+	}
+
+	// Place a vertex in each cube that is crossed by the surface.
+	m := &gengeometry.Mesh{}
+	cubeVertex := make(map[int]int)
+	for z := 0; z < d-1; z++ {
+		for y := 0; y < h-1; y++ {
+			for x := 0; x < w-1; x++ {
+				var hermite []hermitePoint
+				for _, pair := range pairTable {
+					ca, cb := cornerOffsets[pair[0]], cornerOffsets[pair[1]]
+					pa := [3]int{x + ca[0], y + ca[1], z + ca[2]}
+					pb := [3]int{x + cb[0], y + cb[1], z + cb[2]}
+					va, vb := data[idx(pa)], data[idx(pb)]
+					if (va < value) == (vb < value) {
+						continue
+					}
+					p := Interpolate(toVec3(pa), toVec3(pb), va, vb, value)
+					hermite = append(hermite, hermitePoint{
+						pos:    p,
+						normal: fieldNormal(field, p, 0.5),
+					})
+				}
+				if len(hermite) == 0 {
+					continue
+				}
+				bounds := [2]vectors.Vec3{toVec3([3]int{x, y, z}), toVec3([3]int{x + 1, y + 1, z + 1})}
+				cubeVertex[idx([3]int{x, y, z})] = len(m.Vertices)
+				m.Vertices = append(m.Vertices, place(bounds, hermite))
+			}
+		}
+	}
+
+	// Connect the vertices of the four cubes around each edge that is
+	// crossed by the surface.
+	for z := 0; z < d; z++ {
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				p := [3]int{x, y, z}
+				for axis := 0; axis < 3; axis++ {
+					u := (axis + 1) % 3
+					v := (axis + 2) % 3
+
+					// Skip edges on the border of the grid, since they are
+					// not surrounded by four cubes.
+					if p[axis]+1 >= n[axis] || p[u] < 1 || p[v] < 1 || p[u] >= n[u]-1 || p[v] >= n[v]-1 {
+						continue
+					}
+					q := p
+					q[axis]++
+					va, vb := data[idx(p)], data[idx(q)]
+					if (va < value) == (vb < value) {
+						continue
+					}
+
+					// The cubes around the edge, counter-clockwise around the axis.
+					var quad [4]int
+					for i, o := range [4][2]int{{-1, -1}, {0, -1}, {0, 0}, {-1, 0}} {
+						c := p
+						c[u] += o[0]
+						c[v] += o[1]
+						quad[i] = cubeVertex[idx(c)]
+					}
+
+					// Make sure the quad faces towards the lower values.
+					if va < value {
+						quad[1], quad[3] = quad[3], quad[1]
+					}
+					m.Triangles = append(m.Triangles,
+						quad[0], quad[1], quad[2],
+						quad[0], quad[2], quad[3],
+					)
+				}
+			}
+		}
+	}
+	return m
+}
+
+// toVec3 converts grid coordinates to a vector.
+func toVec3(p [3]int) vectors.Vec3 {
+	return vectors.Vec3{X: float64(p[0]), Y: float64(p[1]), Z: float64(p[2])}
+}
+
+// fieldNormal returns the normal of the given field at the given position,
+// pointing towards the lower values (using central differences).
+func fieldNormal(f ScalarField, p vectors.Vec3, h float64) vectors.Vec3 {
+	n := vectors.Vec3{
+		X: f(p.X-h, p.Y, p.Z) - f(p.X+h, p.Y, p.Z),
+		Y: f(p.X, p.Y-h, p.Z) - f(p.X, p.Y+h, p.Z),
+		Z: f(p.X, p.Y, p.Z-h) - f(p.X, p.Y, p.Z+h),
+	}
+	if n.Len() < eps {
+		return vectors.Vec3{}
+	}
+	return n.Normalize()
+}
+
+// massPoint returns the average position of the given hermite points.
+func massPoint(hermite []hermitePoint) vectors.Vec3 {
+	var c vectors.Vec3
+	for _, hp := range hermite {
+		c.AddToThis(hp.pos)
+	}
+	return c.Mul(1 / float64(len(hermite)))
+}
+
+// qefBias is the weight that pulls the solution of the QEF towards the mass
+// point, which keeps the solution stable if the planes are (almost) parallel.
+const qefBias = 0.05
+
+// solveQEF returns the point that minimizes the quadratic error function
+// (the sum of the squared distances to the planes defined by the hermite
+// points), biased towards the mass point.
+// If the solution lies outside of the given cube bounds, the mass point is
+// returned instead.
+func solveQEF(bounds [2]vectors.Vec3, hermite []hermitePoint) vectors.Vec3 {
+	c := massPoint(hermite)
+
+	// Set up the normal equations (A^T A + bias * I) x = A^T b + bias * c,
+	// relative to the mass point for numerical stability.
+	var ata [3][3]float64
+	var atb [3]float64
+	for _, hp := range hermite {
+		n := [3]float64{hp.normal.X, hp.normal.Y, hp.normal.Z}
+		b := hp.normal.Dot(hp.pos.Sub(c))
+		for i := 0; i < 3; i++ {
+			for j := 0; j < 3; j++ {
+				ata[i][j] += n[i] * n[j]
+			}
+			atb[i] += n[i] * b
+		}
+	}
+	for i := 0; i < 3; i++ {
+		ata[i][i] += qefBias
+	}
+	x, ok := solve3(ata, atb)
+	if !ok {
+		return c
+	}
+	p := c.Add(vectors.Vec3{X: x[0], Y: x[1], Z: x[2]})
+
+	// Keep the vertex within its cube to avoid self intersections.
+	const margin = 1e-6
+	if p.X < bounds[0].X-margin || p.Y < bounds[0].Y-margin || p.Z < bounds[0].Z-margin ||
+		p.X > bounds[1].X+margin || p.Y > bounds[1].Y+margin || p.Z > bounds[1].Z+margin {
+		return c
+	}
+	return p
+}
+
+// solve3 solves the linear system a * x = b using Cramer's rule.
+func solve3(a [3][3]float64, b [3]float64) ([3]float64, bool) {
+	det := func(m [3][3]float64) float64 {
+		return m[0][0]*(m[1][1]*m[2][2]-m[1][2]*m[2][1]) -
+			m[0][1]*(m[1][0]*m[2][2]-m[1][2]*m[2][0]) +
+			m[0][2]*(m[1][0]*m[2][1]-m[1][1]*m[2][0])
+	}
+	d := det(a)
+	if math.Abs(d) < eps {
+		return [3]float64{}, false
+	}
+	var x [3]float64
+	for col := 0; col < 3; col++ {
+		m := a
+		for row := 0; row < 3; row++ {
+			m[row][col] = b[row]
+		}
+		x[col] = det(m) / d
+	}
+	return x, true
+}
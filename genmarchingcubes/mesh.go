@@ -0,0 +1,337 @@
+package genmarchingcubes
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"runtime"
+	"sync"
+
+	"github.com/Flokey82/go_gens/vectors"
+)
+
+// ScalarField returns the value of a scalar field at the given position.
+//
+// NOTE: Values above the iso value are considered inside (like in a density
+// field), so a signed distance function (which is negative inside) has to be
+// negated, otherwise the resulting surface is inside out.
+type ScalarField func(x, y, z float64) float64
+
+// GridField returns a scalar field that samples the data grid with the given
+// width, height, and depth (same layout as used by MarchingCubesGrid) using
+// trilinear interpolation. Grid points are spaced 1.0 apart, starting at 0.0.
+// NOTE: Positions outside of the grid are clamped to the border.
+func GridField(w, h, d int, data []float64) ScalarField {
+	return trilinear(w, h, d, func(x, y, z int) float64 {
+		return data[x+y*w+z*w*h]
+	})
+}
+
+// VoxelField returns a scalar field that samples the given voxel values
+// (indexed as [x][y][z], like genmapvoxel.World.Values) using trilinear
+// interpolation. Voxels are spaced 1.0 apart, starting at 0.0.
+// NOTE: Positions outside of the grid are clamped to the border.
+func VoxelField(values [][][]float64) ScalarField {
+	w := len(values)
+	h := len(values[0])
+	d := len(values[0][0])
+	return trilinear(w, h, d, func(x, y, z int) float64 {
+		return values[x][y][z]
+	})
+}
+
+// trilinear returns a scalar field interpolating the grid values returned by
+// the given function.
+func trilinear(w, h, d int, get func(x, y, z int) float64) ScalarField {
+	clamp := func(v float64, max int) (int, int, float64) {
+		v = math.Max(0, math.Min(v, float64(max-1)))
+		i0 := int(v)
+		i1 := i0 + 1
+		if i1 > max-1 {
+			i1 = max - 1
+		}
+		return i0, i1, v - float64(i0)
+	}
+	lerp := func(a, b, t float64) float64 {
+		return a + (b-a)*t
+	}
+	return func(x, y, z float64) float64 {
+		x0, x1, tx := clamp(x, w)
+		y0, y1, ty := clamp(y, h)
+		z0, z1, tz := clamp(z, d)
+		c00 := lerp(get(x0, y0, z0), get(x1, y0, z0), tx)
+		c10 := lerp(get(x0, y1, z0), get(x1, y1, z0), tx)
+		c01 := lerp(get(x0, y0, z1), get(x1, y0, z1), tx)
+		c11 := lerp(get(x0, y1, z1), get(x1, y1, z1), tx)
+		return lerp(lerp(c00, c10, ty), lerp(c01, c11, ty), tz)
+	}
+}
+
+// Mesh is an indexed triangle mesh with per-vertex normals.
+type Mesh struct {
+	Vertices  []vectors.Vec3
+	Normals   []vectors.Vec3
+	Triangles [][3]int // Vertex indices of each triangle.
+}
+
+// ExportToOBJ exports the mesh to an OBJ file.
+func (m *Mesh) ExportToOBJ(filename string) error {
+	// Open/create the destination file.
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// Initialize a new bufio writer.
+	wr := bufio.NewWriter(f)
+
+	// Write all the vertices and normals to the file.
+	for i, v := range m.Vertices {
+		n := m.Normals[i]
+		// NOTE: I switched Y and Z since importing into Blender would have the Y axis as the up axis.
+		if _, err := wr.WriteString(fmt.Sprintf("v %f %f %f \nvn %f %f %f \n", v.X, v.Z, v.Y, n.X, n.Z, n.Y)); err != nil {
+			return err
+		}
+	}
+
+	// Write all the faces to the file.
+	// NOTE: Since Y and Z are switched, the winding order needs to be reversed.
+	for _, t := range m.Triangles {
+		a, b, c := t[0]+1, t[2]+1, t[1]+1
+		if _, err := wr.WriteString(fmt.Sprintf("f %d//%d %d//%d %d//%d \n", a, a, b, b, c, c)); err != nil {
+			return err
+		}
+	}
+	return wr.Flush()
+}
+
+// DefaultChunkSize is the default number of cubes along each axis that are
+// processed as one unit of work by MarchingCubesField.
+const DefaultChunkSize = 32
+
+// MarchingCubesField samples the given scalar field on a grid of nx*ny*nz
+// points, spaced 'step' apart, starting at 'origin' and returns the welded
+// mesh of the isosurface at the given value.
+//
+// The volume is split into chunks of DefaultChunkSize cubes, which are
+// processed in parallel. Vertices on the borders between chunks are shared,
+// so the resulting mesh is seamless.
+//
+// The vertex normals are calculated from the gradient of the field.
+// NOTE: Like with MarchingCubesGrid, the surface faces towards the lower
+// values, so signed distance functions need to be negated (see ScalarField).
+func MarchingCubesField(f ScalarField, origin vectors.Vec3, nx, ny, nz int, step, value float64) *Mesh {
+	return MarchingCubesFieldChunked(f, origin, nx, ny, nz, step, value, DefaultChunkSize)
+}
+
+// MarchingCubesFieldChunked is like MarchingCubesField, but allows to specify
+// the number of cubes along each axis of a chunk.
+func MarchingCubesFieldChunked(f ScalarField, origin vectors.Vec3, nx, ny, nz int, step, value float64, chunkSize int) *Mesh {
+	if chunkSize < 1 {
+		chunkSize = DefaultChunkSize
+	}
+	g := &fieldGrid{
+		f:      f,
+		origin: origin,
+		n:      [3]int{nx, ny, nz},
+		step:   step,
+		value:  value,
+	}
+
+	// Split the cubes (one less than the number of points) into chunks.
+	var chunks [][3]int
+	for z := 0; z < nz-1; z += chunkSize {
+		for y := 0; y < ny-1; y += chunkSize {
+			for x := 0; x < nx-1; x += chunkSize {
+				chunks = append(chunks, [3]int{x, y, z})
+			}
+		}
+	}
+
+	// Process the chunks in parallel.
+	results := make([]*chunkMesh, len(chunks))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < runtime.NumCPU(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c := range jobs {
+				results[c] = g.marchChunk(chunks[c], chunkSize)
+			}
+		}()
+	}
+	for c := range chunks {
+		jobs <- c
+	}
+	close(jobs)
+	wg.Wait()
+
+	// Merge the chunks into one mesh, welding the vertices that were
+	// generated on the same grid edge.
+	m := &Mesh{}
+	vertexIdx := make(map[gridEdge]int)
+	for _, cm := range results {
+		remap := make([]int, len(cm.edges))
+		for i, e := range cm.edges {
+			idx, ok := vertexIdx[e]
+			if !ok {
+				idx = len(m.Vertices)
+				vertexIdx[e] = idx
+				m.Vertices = append(m.Vertices, cm.vertices[i])
+				m.Normals = append(m.Normals, cm.normals[i])
+			}
+			remap[i] = idx
+		}
+		for _, t := range cm.triangles {
+			m.Triangles = append(m.Triangles, [3]int{remap[t[0]], remap[t[1]], remap[t[2]]})
+		}
+	}
+	return m
+}
+
+// gridEdge identifies the edge of the sample grid starting at the grid point
+// with the given index along the given axis.
+type gridEdge struct {
+	point int
+	axis  int
+}
+
+// chunkMesh is the intermediate mesh of a single chunk.
+type chunkMesh struct {
+	edges     []gridEdge // Grid edge of each vertex.
+	vertices  []vectors.Vec3
+	normals   []vectors.Vec3
+	triangles [][3]int
+}
+
+// fieldGrid is a scalar field sampled on a regular grid.
+type fieldGrid struct {
+	f      ScalarField
+	origin vectors.Vec3
+	n      [3]int // Number of grid points along each axis.
+	step   float64
+	value  float64
+}
+
+// pos returns the position of the grid point with the given coordinates.
+func (g *fieldGrid) pos(x, y, z int) vectors.Vec3 {
+	return vectors.Vec3{
+		X: g.origin.X + float64(x)*g.step,
+		Y: g.origin.Y + float64(y)*g.step,
+		Z: g.origin.Z + float64(z)*g.step,
+	}
+}
+
+// normal returns the normal at the given position, which is derived from the
+// gradient of the field (using central differences).
+func (g *fieldGrid) normal(p vectors.Vec3) vectors.Vec3 {
+	return fieldNormal(g.f, p, g.step*0.5)
+}
+
+// cornerOffsets are the grid offsets of the eight corners of a cube (in the
+// same order as used by Polygonize).
+var cornerOffsets = [8][3]int{
+	{0, 0, 0},
+	{1, 0, 0},
+	{1, 1, 0},
+	{0, 1, 0},
+	{0, 0, 1},
+	{1, 0, 1},
+	{1, 1, 1},
+	{0, 1, 1},
+}
+
+// marchChunk polygonizes the cubes of the chunk starting at the given cube.
+func (g *fieldGrid) marchChunk(start [3]int, size int) *chunkMesh {
+	// Determine the number of grid points in this chunk (including the
+	// points on the far border, which are shared with the next chunk).
+	var n [3]int
+	for a := range n {
+		n[a] = size + 1
+		if start[a]+n[a] > g.n[a] {
+			n[a] = g.n[a] - start[a]
+		}
+	}
+
+	// Sample the field.
+	samples := make([]float64, n[0]*n[1]*n[2])
+	for z := 0; z < n[2]; z++ {
+		for y := 0; y < n[1]; y++ {
+			for x := 0; x < n[0]; x++ {
+				p := g.pos(start[0]+x, start[1]+y, start[2]+z)
+				samples[x+y*n[0]+z*n[0]*n[1]] = g.f(p.X, p.Y, p.Z)
+			}
+		}
+	}
+
+	cm := &chunkMesh{}
+	vertexIdx := make(map[gridEdge]int)
+	for z := 0; z < n[2]-1; z++ {
+		for y := 0; y < n[1]-1; y++ {
+			for x := 0; x < n[0]-1; x++ {
+				var v [8]float64
+				var index int
+				for i, o := range cornerOffsets {
+					v[i] = samples[(x+o[0])+(y+o[1])*n[0]+(z+o[2])*n[0]*n[1]]
+					if v[i] < g.value {
+						index |= 1 << uint(i)
+					}
+				}
+				if edgeTable[index] == 0 {
+					continue
+				}
+
+				// Look up (or create) the vertices on the edges of the cube.
+				var points [12]int
+				for i := 0; i < 12; i++ {
+					if edgeTable[index]&(1<<uint(i)) == 0 {
+						continue
+					}
+					a := pairTable[i][0]
+					b := pairTable[i][1]
+					ca, cb := cornerOffsets[a], cornerOffsets[b]
+
+					// The edge is identified by the corner with the lower
+					// coordinates and the axis along which it runs.
+					gx, gy, gz := start[0]+x+ca[0], start[1]+y+ca[1], start[2]+z+ca[2]
+					var axis int
+					for ax := range ca {
+						if ca[ax] != cb[ax] {
+							axis = ax
+							if cb[ax] < ca[ax] {
+								gx, gy, gz = start[0]+x+cb[0], start[1]+y+cb[1], start[2]+z+cb[2]
+							}
+						}
+					}
+					e := gridEdge{point: gx + gy*g.n[0] + gz*g.n[0]*g.n[1], axis: axis}
+					idx, ok := vertexIdx[e]
+					if !ok {
+						pa := g.pos(start[0]+x+ca[0], start[1]+y+ca[1], start[2]+z+ca[2])
+						pb := g.pos(start[0]+x+cb[0], start[1]+y+cb[1], start[2]+z+cb[2])
+						p := Interpolate(pa, pb, v[a], v[b], g.value)
+						idx = len(cm.vertices)
+						vertexIdx[e] = idx
+						cm.edges = append(cm.edges, e)
+						cm.vertices = append(cm.vertices, p)
+						cm.normals = append(cm.normals, g.normal(p))
+					}
+					points[i] = idx
+				}
+
+				// Build the triangles, skipping degenerate ones.
+				table := triangleTable[index]
+				for i := 0; i+2 < len(table); i += 3 {
+					t := [3]int{points[table[i]], points[table[i+1]], points[table[i+2]]}
+					if t[0] == t[1] || t[1] == t[2] || t[0] == t[2] {
+						continue
+					}
+					cm.triangles = append(cm.triangles, t)
+				}
+			}
+		}
+	}
+	return cm
+}
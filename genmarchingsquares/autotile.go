@@ -0,0 +1,171 @@
+package genmarchingsquares
+
+import "sort"
+
+// Atlas16 maps the 16 possible tile encodings of MarchSquares to the tile
+// indices of a tileset (dual grid autotiling).
+//
+// NOTE: Since the tiles are generated from the corners, the tile at x/y
+// covers the pixels from x/y to x+1/y+1, so the tile grid is offset by half
+// a tile (and one tile smaller) compared to the pixel grid.
+type Atlas16 [16]int
+
+// DefaultAtlas16 is an atlas where the tile index is identical to the encoded tile.
+var DefaultAtlas16 = Atlas16{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+// Map returns the tile indices for the given encoded tiles.
+func (a *Atlas16) Map(squares [][]byte) [][]int {
+	res := make([][]int, len(squares))
+	for x := range squares {
+		res[x] = make([]int, len(squares[x]))
+		for y, enc := range squares[x] {
+			res[x][y] = a[enc&0xF]
+		}
+	}
+	return res
+}
+
+// Autotile16 returns the tile indices of the dual grid for the given pixel grid
+// using the given atlas.
+func Autotile16(pixels [][]bool, dimX, dimY int, atlas *Atlas16) [][]int {
+	return atlas.Map(MarchSquares(pixels, dimX, dimY))
+}
+
+// Neighbor bits of a blob mask.
+const (
+	BlobN byte = 1 << iota
+	BlobNE
+	BlobE
+	BlobSE
+	BlobS
+	BlobSW
+	BlobW
+	BlobNW
+)
+
+// blobNeighbors contains the offsets of the neighbors for each bit of a blob mask.
+var blobNeighbors = [8][2]int{
+	{0, -1},  // N
+	{1, -1},  // NE
+	{1, 0},   // E
+	{1, 1},   // SE
+	{0, 1},   // S
+	{-1, 1},  // SW
+	{-1, 0},  // W
+	{-1, -1}, // NW
+}
+
+// reduceBlobMask removes the corner bits from the given mask where at least
+// one of the two adjacent sides is not set, since they don't change the look
+// of the tile.
+func reduceBlobMask(mask byte) byte {
+	corners := [4][3]byte{
+		{BlobNE, BlobN, BlobE},
+		{BlobSE, BlobS, BlobE},
+		{BlobSW, BlobS, BlobW},
+		{BlobNW, BlobN, BlobW},
+	}
+	for _, c := range corners {
+		if mask&c[1] == 0 || mask&c[2] == 0 {
+			mask &^= c[0]
+		}
+	}
+	return mask
+}
+
+var (
+	blobMasks []byte   // The 47 unique reduced blob masks in ascending order.
+	blobIndex [256]int // Index into blobMasks for each (unreduced) mask.
+)
+
+func init() {
+	for m := 0; m < 256; m++ {
+		if reduceBlobMask(byte(m)) == byte(m) {
+			blobMasks = append(blobMasks, byte(m))
+		}
+	}
+	for m := 0; m < 256; m++ {
+		r := reduceBlobMask(byte(m))
+		blobIndex[m] = sort.Search(len(blobMasks), func(i int) bool {
+			return blobMasks[i] >= r
+		})
+	}
+}
+
+// BlobMasks returns the 47 unique blob masks in the order used by Atlas47.
+func BlobMasks() []byte {
+	return append([]byte{}, blobMasks...)
+}
+
+// BlobIndex returns the index (0-46) of the blob tile for the given mask.
+func BlobIndex(mask byte) int {
+	return blobIndex[mask]
+}
+
+// BlobMask returns the mask of set neighbors of the pixel at x/y.
+// Neighbors outside of the grid are considered set if 'border' is true, which
+// is useful for layers that continue beyond the edge of the map (like water).
+func BlobMask(pixels [][]bool, dimX, dimY, x, y int, border bool) byte {
+	var mask byte
+	for i, o := range blobNeighbors {
+		nx, ny := x+o[0], y+o[1]
+		set := border
+		if nx >= 0 && nx < dimX && ny >= 0 && ny < dimY {
+			set = pixels[nx][ny]
+		}
+		if set {
+			mask |= 1 << uint(i)
+		}
+	}
+	return mask
+}
+
+// Atlas47 maps the 47 unique blob masks (see BlobMasks) to the tile indices
+// of a tileset.
+type Atlas47 [47]int
+
+// DefaultAtlas47 is an atlas where the tile index is identical to the blob index.
+var DefaultAtlas47 = func() Atlas47 {
+	var a Atlas47
+	for i := range a {
+		a[i] = i
+	}
+	return a
+}()
+
+// Map returns the tile indices for all pixels of the given pixel grid, where
+// the tile of each set pixel is chosen based on its eight neighbors.
+// Unset pixels are assigned -1.
+// See BlobMask for an explanation of 'border'.
+func (a *Atlas47) Map(pixels [][]bool, dimX, dimY int, border bool) [][]int {
+	res := make([][]int, dimX)
+	for x := range res {
+		res[x] = make([]int, dimY)
+		for y := range res[x] {
+			if !pixels[x][y] {
+				res[x][y] = -1
+				continue
+			}
+			res[x][y] = a[BlobIndex(BlobMask(pixels, dimX, dimY, x, y, border))]
+		}
+	}
+	return res
+}
+
+// MaskFromTiles returns a pixel grid for the given row-major tile layer (like
+// the layers in simvillage_tiles), where all tiles matching one of the given
+// tile IDs are set.
+func MaskFromTiles(tiles []int, width, height int, match ...int) [][]bool {
+	isMatch := make(map[int]bool)
+	for _, t := range match {
+		isMatch[t] = true
+	}
+	pixels := make([][]bool, width)
+	for x := range pixels {
+		pixels[x] = make([]bool, height)
+		for y := range pixels[x] {
+			pixels[x][y] = isMatch[tiles[y*width+x]]
+		}
+	}
+	return pixels
+}
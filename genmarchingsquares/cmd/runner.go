@@ -20,4 +20,12 @@ func main() {
 	if err := genmarchingsquares.ExportToPNG(squares, dimX-1, dimY-1, 128, "squares.png"); err != nil {
 		log.Println(err)
 	}
+
+	// Trace the contours of the set pixels.
+	for _, c := range genmarchingsquares.ContoursBool(pixels, dimX, dimY) {
+		log.Printf("contour (closed: %t): %v", c.Closed, c.Simplify(0.1).Points)
+	}
+
+	// Map the pixels to blob tiles.
+	log.Println(genmarchingsquares.DefaultAtlas47.Map(pixels, dimX, dimY, false))
 }
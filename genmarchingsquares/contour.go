@@ -0,0 +1,227 @@
+package genmarchingsquares
+
+import (
+	"github.com/Flokey82/go_gens/gengeometry"
+	"github.com/Flokey82/go_gens/vectors"
+)
+
+// MarchSquaresScalar returns a grid of tiles encoded as 4 bit values (like
+// MarchSquares) that are generated from the given scalar grid, where all
+// values greater or equal to the threshold are considered set.
+func MarchSquaresScalar(values [][]float64, dimX, dimY int, threshold float64) [][]byte {
+	squares := make([][]byte, dimX-1)
+	for i := range squares {
+		squares[i] = make([]byte, dimY-1)
+	}
+	for x := 0; x < dimX-1; x++ {
+		for y := 0; y < dimY-1; y++ {
+			squares[x][y] = encodeTile(
+				values[x][y] >= threshold,
+				values[x+1][y] >= threshold,
+				values[x+1][y+1] >= threshold,
+				values[x][y+1] >= threshold,
+			)
+		}
+	}
+	return squares
+}
+
+// Contour is a polyline tracing the border of a set region.
+type Contour struct {
+	Points []vectors.Vec2 // Points in pixel grid coordinates.
+	Closed bool           // True if the last point connects back to the first.
+}
+
+// Simplify returns a simplified copy of the contour using the given tolerance
+// (see gengeometry.SimplifyPolyline).
+func (c *Contour) Simplify(tolerance float64) *Contour {
+	if !c.Closed {
+		return &Contour{
+			Points: gengeometry.SimplifyPolyline(c.Points, tolerance, true),
+		}
+	}
+
+	// Close the loop so that the last segment is taken into account as
+	// well, then remove the duplicate point again.
+	points := append(append([]vectors.Vec2{}, c.Points...), c.Points[0])
+	points = gengeometry.SimplifyPolyline(points, tolerance, true)
+	return &Contour{
+		Points: points[:len(points)-1],
+		Closed: true,
+	}
+}
+
+// ContoursBool returns the contours of the set regions of the given pixel grid.
+// The contour points are placed halfway between set and unset pixels.
+func ContoursBool(pixels [][]bool, dimX, dimY int) []*Contour {
+	values := make([][]float64, dimX)
+	for x := range values {
+		values[x] = make([]float64, dimY)
+		for y := range values[x] {
+			if pixels[x][y] {
+				values[x][y] = 1
+			}
+		}
+	}
+	return Contours(values, dimX, dimY, 0.5)
+}
+
+// Contours returns the contours of the regions of the given scalar grid where
+// the values are greater or equal to the threshold.
+//
+// The contour points are linearly interpolated along the grid edges based on
+// the values of the two adjacent pixels. Contours that reach the border of the
+// grid are open, all others are closed.
+//
+// The contours are oriented consistently: Walking along a contour, the set
+// region is always on the left (in screen coordinates, where y points down),
+// so closed contours around set regions run counter-clockwise and holes clockwise.
+func Contours(values [][]float64, dimX, dimY int, threshold float64) []*Contour {
+	c := &contourTracer{
+		values:    values,
+		threshold: threshold,
+		next:      make(map[contourEdge]contourEdge),
+		hasPrev:   make(map[contourEdge]bool),
+	}
+	for x := 0; x < dimX-1; x++ {
+		for y := 0; y < dimY-1; y++ {
+			c.addCell(x, y)
+		}
+	}
+	return c.trace()
+}
+
+// contourEdge identifies the grid edge starting at the given pixel, running
+// either to the right (horizontal) or downwards.
+type contourEdge struct {
+	x, y       int
+	horizontal bool
+}
+
+// contourTracer collects the contour segments of all cells and links them
+// into polylines.
+type contourTracer struct {
+	values    [][]float64
+	threshold float64
+	starts    []contourEdge               // Start edges of all segments (in the order they were added).
+	next      map[contourEdge]contourEdge // Segment end edge for each start edge.
+	hasPrev   map[contourEdge]bool        // Edges that are the end of a segment.
+}
+
+// isSet returns true if the pixel at the given position is set.
+func (c *contourTracer) isSet(x, y int) bool {
+	return c.values[x][y] >= c.threshold
+}
+
+// addCell adds the contour segments of the cell with the given top left pixel.
+func (c *contourTracer) addCell(x, y int) {
+	// The corners in clockwise order (nw, ne, se, sw) and the edges between
+	// them (n, e, s, w).
+	corners := [4][2]int{{x, y}, {x + 1, y}, {x + 1, y + 1}, {x, y + 1}}
+	edges := [4]contourEdge{
+		{x: x, y: y, horizontal: true},
+		{x: x + 1, y: y},
+		{x: x, y: y + 1, horizontal: true},
+		{x: x, y: y},
+	}
+
+	// Walking around the cell, we enter the set region on some edges and
+	// exit it on others. Each segment runs from an entry to an exit edge,
+	// which keeps the orientation consistent across cells since adjacent
+	// cells walk their shared edge in opposite directions.
+	var enter, exit []int
+	for i := range edges {
+		a, b := corners[i], corners[(i+1)%4]
+		setA, setB := c.isSet(a[0], a[1]), c.isSet(b[0], b[1])
+		if setA == setB {
+			continue
+		}
+		if setB {
+			enter = append(enter, i)
+		} else {
+			exit = append(exit, i)
+		}
+	}
+	switch len(enter) {
+	case 0:
+		return
+	case 1:
+		c.addSegment(edges[enter[0]], edges[exit[0]])
+	case 2:
+		// Saddle point. The average of the corners decides if the center
+		// of the cell is set, which connects the set corners.
+		var sum float64
+		for _, p := range corners {
+			sum += c.values[p[0]][p[1]]
+		}
+		offset := 1
+		if sum/4 >= c.threshold {
+			offset = 3
+		}
+		for _, i := range enter {
+			c.addSegment(edges[i], edges[(i+offset)%4])
+		}
+	}
+}
+
+// addSegment adds a segment running from the edge 'from' to the edge 'to'.
+func (c *contourTracer) addSegment(from, to contourEdge) {
+	c.starts = append(c.starts, from)
+	c.next[from] = to
+	c.hasPrev[to] = true
+}
+
+// point returns the interpolated position of the contour on the given edge.
+func (c *contourTracer) point(e contourEdge) vectors.Vec2 {
+	bx, by := e.x, e.y+1
+	if e.horizontal {
+		bx, by = e.x+1, e.y
+	}
+	va, vb := c.values[e.x][e.y], c.values[bx][by]
+	t := 0.5
+	if va != vb {
+		t = (c.threshold - va) / (vb - va)
+	}
+	return vectors.Vec2{
+		X: float64(e.x) + t*float64(bx-e.x),
+		Y: float64(e.y) + t*float64(by-e.y),
+	}
+}
+
+// trace links the segments into polylines.
+func (c *contourTracer) trace() []*Contour {
+	var contours []*Contour
+	visited := make(map[contourEdge]bool)
+	follow := func(start contourEdge) *Contour {
+		res := &Contour{}
+		e := start
+		for {
+			visited[e] = true
+			res.Points = append(res.Points, c.point(e))
+			n, ok := c.next[e]
+			if !ok {
+				return res // Reached the border.
+			}
+			if n == start {
+				res.Closed = true
+				return res
+			}
+			e = n
+		}
+	}
+
+	// Open contours start at the border of the grid, so we trace them first.
+	for _, e := range c.starts {
+		if !c.hasPrev[e] {
+			contours = append(contours, follow(e))
+		}
+	}
+
+	// All remaining segments are part of closed contours.
+	for _, e := range c.starts {
+		if !visited[e] {
+			contours = append(contours, follow(e))
+		}
+	}
+	return contours
+}
@@ -3,13 +3,22 @@ package genvillage
 // Building represents an instance of BuildingType.
 type Building struct {
 	*BuildingType
+	Age int // Age of the building in years.
 }
 
 // BuildingType represents a class of building requiring and/or providing
 // specific resources.
 type BuildingType struct {
-	Name        string // Name of the building type.
-	*Production        // Produces and requires which resources.
+	Name        string  // Name of the building type.
+	Cost        float64 // Cost of constructing the building.
+	Land        float64 // Land occupied by the building.
+	Workers     int     // Number of people working in the building.
+	*Production         // Produces and requires which resources.
+
+	// The following fields are only used by the growth simulation.
+	MinPopulation int           // Population required to unlock the building type.
+	Lifetime      int           // Years until the building wears out (0 for never).
+	Upgrade       *BuildingType // Building type a worn out building can be upgraded to.
 }
 
 // NewBuildingType returns a new building type with the given name.
@@ -12,6 +12,8 @@ const (
 	resGrain  = "grain"
 	resFlour  = "flour"
 	resBread  = "bread"
+	resFood   = "food"
+	resHouse  = "housing"
 )
 
 func main() {
@@ -21,16 +23,19 @@ func main() {
 	bFishery := genvillage.NewBuildingType("fishery")
 	bFishery.Requires[resWorker] = 2
 	bFishery.Provides[resFish] = 10
+	bFishery.Cost, bFishery.Land, bFishery.Workers = 40, 2, 2
 	p.AddType(bFishery)
 
 	bHousing := genvillage.NewBuildingType("housing")
 	bHousing.Requires[resBread] = 4
 	bHousing.Provides[resWorker] = 4
+	bHousing.Cost, bHousing.Land = 20, 1
 	p.AddType(bHousing)
 
 	bFarm := genvillage.NewBuildingType("farm")
 	bFarm.Requires[resWorker] = 1
 	bFarm.Provides[resGrain] = 10
+	bFarm.Cost, bFarm.Land, bFarm.Workers = 15, 10, 1
 	// FIXME: A farm usually doubles as housing, but we don't check
 	// if buildings provide a net-positive on provided resources yet, which
 	// might lead to many farms being randomly added when workers are needed.
@@ -41,12 +46,14 @@ func main() {
 	bMill.Requires[resGrain] = 10
 	bMill.Requires[resWorker] = 1
 	bMill.Provides[resFlour] = 10
+	bMill.Cost, bMill.Land, bMill.Workers = 60, 1, 1
 	p.AddType(bMill)
 
 	bBakery := genvillage.NewBuildingType("bakery")
 	bBakery.Requires[resFlour] = 2
 	bBakery.Requires[resWorker] = 1
 	bBakery.Provides[resBread] = 8
+	bBakery.Cost, bBakery.Land, bBakery.Workers = 30, 1, 1
 	p.AddType(bBakery)
 
 	// Create a new settlement and add the fishery to seed
@@ -59,4 +66,87 @@ func main() {
 
 	// Print all buildings of the settlement.
 	log.Println(v.Buildings)
+
+	// Find the cheapest set of buildings for a settlement with little land,
+	// which can import flour and export its surplus fish.
+	v = genvillage.NewSettlement(p)
+	v.AddBuilding(bFishery.NewBuilding())
+	sol, err := v.Optimize(&genvillage.Constraints{
+		Land:       10,
+		Population: 20,
+		Imports:    map[string]float64{resFlour: 5},
+		Exports:    map[string]float64{resFish: 2},
+	})
+	if err != nil {
+		log.Println(err)
+	}
+	log.Printf("buildings: %v, imports: %v, exports: %v, cost: %.0f (+%.0f imports, %.0f income)", sol.Buildings, sol.Imports, sol.Exports, sol.Cost, sol.ImportCost, sol.Income)
+	v.ApplySolution(sol)
+
+	// Without imports, the economy can't be satisfied on that little land.
+	v = genvillage.NewSettlement(p)
+	v.AddBuilding(bFishery.NewBuilding())
+	if _, err := v.Optimize(&genvillage.Constraints{Land: 10}); err != nil {
+		log.Println(err)
+	}
+
+	// Simulate the growth of a fishing thorpe over 100 years.
+	simulate()
+}
+
+func simulate() {
+	p := genvillage.NewBuildingPool()
+	bHut := genvillage.NewBuildingType("hut")
+	bHut.Provides[resHouse] = 5
+	bHut.Cost, bHut.Land, bHut.Lifetime = 5, 0.5, 25
+	p.AddType(bHut)
+
+	// Huts are upgraded to houses once the settlement is a hamlet.
+	bHouse := genvillage.NewBuildingType("house")
+	bHouse.Provides[resHouse] = 8
+	bHouse.Cost, bHouse.Land, bHouse.Lifetime, bHouse.MinPopulation = 20, 0.5, 60, 50
+	bHut.Upgrade = bHouse
+	p.AddType(bHouse)
+
+	bFishery := genvillage.NewBuildingType("fishery")
+	bFishery.Provides[resFood] = 10
+	bFishery.Cost, bFishery.Land, bFishery.Workers, bFishery.Lifetime = 40, 4, 2, 30
+	p.AddType(bFishery)
+
+	bFarm := genvillage.NewBuildingType("farm")
+	bFarm.Provides[resGrain] = 30
+	bFarm.Cost, bFarm.Land, bFarm.Workers, bFarm.Lifetime = 10, 5, 2, 40
+	p.AddType(bFarm)
+
+	// Bread only becomes available once the settlement is large enough
+	// for a mill and a bakery.
+	bMill := genvillage.NewBuildingType("mill")
+	bMill.Requires[resGrain] = 30
+	bMill.Provides[resFlour] = 30
+	bMill.Cost, bMill.Land, bMill.Workers, bMill.Lifetime, bMill.MinPopulation = 20, 1, 1, 50, 100
+	p.AddType(bMill)
+
+	bBakery := genvillage.NewBuildingType("bakery")
+	bBakery.Requires[resFlour] = 30
+	bBakery.Provides[resFood] = 40
+	bBakery.Cost, bBakery.Land, bBakery.Workers, bBakery.Lifetime, bBakery.MinPopulation = 15, 0.5, 1, 40, 200
+	p.AddType(bBakery)
+
+	v := genvillage.NewSettlement(p)
+	for i := 0; i < 4; i++ {
+		v.AddBuilding(bHut.NewBuilding())
+	}
+	for i := 0; i < 3; i++ {
+		v.AddBuilding(bFishery.NewBuilding())
+	}
+
+	params := genvillage.DefaultSimulationParams
+	params.Needs = map[string]float64{resFood: 1}
+	params.Housing = resHouse
+	params.GrowthRate = 0.05
+	params.Land = 200
+	params.Exports = map[string]float64{resFood: 0.5}
+	sim := genvillage.NewSimulation(v, 20, params, 1)
+	sim.Run(100)
+	sim.Log()
 }
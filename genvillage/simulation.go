@@ -0,0 +1,402 @@
+package genvillage
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"strings"
+
+	"github.com/Flokey82/go_gens/gameconstants"
+)
+
+// SimulationParams are the parameters of the growth simulation.
+type SimulationParams struct {
+	Needs            map[string]float64 // Resources consumed per person and year.
+	Housing          string             // Resource housing one person per unit (empty for no limit).
+	LaborShare       float64            // Share of the population working in buildings.
+	GrowthRate       float64            // Yearly growth rate if all needs are in surplus.
+	EmigrationRate   float64            // Share of the undersupplied population leaving each year.
+	Tax              float64            // Income per person and year.
+	Exports          map[string]float64 // Price per unit of surplus resources sold each year.
+	Land             float64            // Available land (0 for no limit).
+	SnapshotInterval int                // Years between snapshots (0 for none).
+	MaxSteps         int                // Maximum number of search steps when planning new buildings.
+}
+
+// DefaultSimulationParams are the default parameters for the growth
+// simulation. Needs and Housing depend on the resources of the building
+// pool and have to be set by the caller.
+var DefaultSimulationParams = SimulationParams{
+	LaborShare:       0.5,
+	GrowthRate:       0.03,
+	EmigrationRate:   0.5,
+	Tax:              1,
+	SnapshotInterval: 10,
+	MaxSteps:         2000,
+}
+
+// Simulation simulates the growth of a settlement over the years.
+type Simulation struct {
+	*Settlement
+	Params     SimulationParams
+	Year       int
+	Population int
+	Treasury   float64
+	History    []*YearRecord // Yearly history.
+	Snapshots  []*Snapshot   // Snapshots every SnapshotInterval years.
+	rng        *rand.Rand
+}
+
+// YearRecord is the record of a simulated year.
+type YearRecord struct {
+	Year       int
+	Population int      // Population at the end of the year.
+	Growth     int      // Number of people born or moved in.
+	Emigrants  int      // Number of people who left.
+	Supply     float64  // Share of the needs of the population that were met.
+	Treasury   float64  // Treasury at the end of the year.
+	Events     []string // Notable events (construction, upgrades, ...).
+}
+
+// String implements the stringer interface.
+func (r *YearRecord) String() string {
+	str := fmt.Sprintf("Year %d: %d people (+%d, -%d), supply %.0f%%, treasury %.0f", r.Year, r.Population, r.Growth, r.Emigrants, r.Supply*100, r.Treasury)
+	if len(r.Events) > 0 {
+		str += ": " + strings.Join(r.Events, "; ")
+	}
+	return str
+}
+
+// Snapshot is the state of the settlement in a given year.
+type Snapshot struct {
+	Year       int
+	Population int
+	Type       gameconstants.SettlementType
+	Treasury   float64
+	Buildings  map[string]int // Number of buildings by type.
+	Surplus    map[string]int // Surplus resources.
+	Missing    map[string]int // Missing resources.
+}
+
+// String implements the stringer interface.
+func (s *Snapshot) String() string {
+	var bs []string
+	for _, name := range sortedKeys(s.Buildings) {
+		bs = append(bs, fmt.Sprintf("%d %s", s.Buildings[name], name))
+	}
+	return fmt.Sprintf("Year %d: %s of %d people, treasury %.0f, buildings: %s, surplus: %v, missing: %v", s.Year, s.Type, s.Population, s.Treasury, strings.Join(bs, ", "), s.Surplus, s.Missing)
+}
+
+// NewSimulation returns a new growth simulation of the given settlement,
+// starting with the given population.
+func NewSimulation(s *Settlement, population int, params SimulationParams, seed int64) *Simulation {
+	sim := &Simulation{
+		Settlement: s,
+		Params:     params,
+		Population: population,
+		rng:        rand.New(rand.NewSource(seed)),
+	}
+	if params.SnapshotInterval > 0 {
+		sim.Snapshots = append(sim.Snapshots, sim.Snapshot())
+	}
+	return sim
+}
+
+// Run simulates the given number of years.
+func (sim *Simulation) Run(years int) {
+	for i := 0; i < years; i++ {
+		sim.Step()
+	}
+}
+
+// Step simulates a single year and returns its record.
+//
+// Each year, buildings age and wear out once they exceed their lifetime
+// (see wearBuildings). Then, the buildings produce resources
+// (limited by the available workers and inputs) and the population consumes
+// its needs. If there is a surplus of everything, the population grows,
+// while shortages and a lack of housing cause emigration. Finally, taxes
+// and exports fill the treasury, which pays for new buildings that are
+// needed for the next year's growth (see Optimize).
+func (sim *Simulation) Step() *YearRecord {
+	sim.Year++
+	rec := &YearRecord{Year: sim.Year}
+	oldPopulation := sim.Population
+	oldType := gameconstants.GetSettlementType(sim.Population)
+
+	sim.wearBuildings(rec)
+
+	// Produce and consume resources.
+	supply, demand := sim.balance()
+	rec.Supply = 1
+	var short string
+	for _, r := range sortedKeys(sim.Params.Needs) {
+		need := float64(sim.Population) * sim.Params.Needs[r]
+		if need <= 0 {
+			continue
+		}
+		if ratio := math.Max(supply[r]-(demand[r]-need), 0) / need; ratio < rec.Supply {
+			rec.Supply, short = ratio, r
+		}
+	}
+	capacity := math.Inf(1)
+	if sim.Params.Housing != "" {
+		capacity = supply[sim.Params.Housing]
+	}
+
+	// Update the population.
+	if rec.Supply < 1 {
+		rec.Emigrants = int(math.Ceil(float64(sim.Population) * (1 - rec.Supply) * sim.Params.EmigrationRate))
+		rec.Events = append(rec.Events, fmt.Sprintf("%d people left due to a shortage of %s", rec.Emigrants, short))
+	} else {
+		growth := float64(sim.Population) * sim.Params.GrowthRate
+		for _, r := range sortedKeys(sim.Params.Needs) {
+			if n := sim.Params.Needs[r]; n > 0 {
+				growth = math.Min(growth, (supply[r]-demand[r])/n)
+			}
+		}
+		growth = math.Min(growth, capacity-float64(sim.Population))
+		rec.Growth = sim.round(math.Max(growth, 0))
+	}
+	if homeless := sim.Population - rec.Emigrants - int(math.Min(capacity, float64(sim.Population))); homeless > 0 {
+		rec.Emigrants += homeless
+		rec.Events = append(rec.Events, fmt.Sprintf("%d people left due to a lack of housing", homeless))
+	}
+	if rec.Emigrants > sim.Population {
+		rec.Emigrants = sim.Population
+	}
+	sim.Population += rec.Growth - rec.Emigrants
+	if newType := gameconstants.GetSettlementType(sim.Population); sim.Population == 0 {
+		if oldPopulation > 0 {
+			rec.Events = append(rec.Events, "the settlement was abandoned")
+		}
+	} else if newType > oldType {
+		rec.Events = append(rec.Events, fmt.Sprintf("grew into a %s", newType))
+	} else if newType < oldType {
+		rec.Events = append(rec.Events, fmt.Sprintf("shrank into a %s", newType))
+	}
+
+	// Collect taxes and export surplus resources.
+	sim.Treasury += float64(sim.Population) * sim.Params.Tax
+	for _, r := range sortedKeys(sim.Params.Exports) {
+		if supply[r] > demand[r] {
+			sim.Treasury += math.Floor(supply[r]-demand[r]) * sim.Params.Exports[r]
+		}
+	}
+
+	if sim.Population > 0 {
+		sim.construct(rec)
+	}
+	rec.Population = sim.Population
+	rec.Treasury = sim.Treasury
+	sim.History = append(sim.History, rec)
+	if sim.Params.SnapshotInterval > 0 && sim.Year%sim.Params.SnapshotInterval == 0 {
+		sim.Snapshots = append(sim.Snapshots, sim.Snapshot())
+	}
+	return rec
+}
+
+// wearBuildings ages all buildings and replaces worn out buildings. Once
+// past their lifetime, buildings wear out with a chance of 50% each year.
+// Worn out buildings are upgraded if the upgrade is unlocked and
+// affordable, otherwise they are rebuilt or, if the treasury can't pay for
+// that either, abandoned.
+func (sim *Simulation) wearBuildings(rec *YearRecord) {
+	upgraded := make(map[string]int)
+	rebuilt := make(map[string]int)
+	abandoned := make(map[string]int)
+	var buildings []*Building
+	for _, b := range sim.Buildings {
+		b.Age++
+		if b.Lifetime <= 0 || b.Age < b.Lifetime || sim.rng.Intn(2) == 0 {
+			buildings = append(buildings, b)
+			continue
+		}
+		if up := b.Upgrade; up != nil && sim.unlocked(up) && up.Cost <= sim.Treasury {
+			upgraded[b.Name+" to "+up.Name]++
+			sim.Treasury -= up.Cost
+			b.BuildingType, b.Age = up, 0
+		} else if b.Cost <= sim.Treasury {
+			rebuilt[b.Name]++
+			sim.Treasury -= b.Cost
+			b.Age = 0
+		} else {
+			abandoned[b.Name]++
+			continue
+		}
+		buildings = append(buildings, b)
+	}
+	sim.Buildings = buildings
+	sim.Update()
+	for _, key := range sortedKeys(upgraded) {
+		rec.Events = append(rec.Events, fmt.Sprintf("upgraded %d %s", upgraded[key], key))
+	}
+	for _, name := range sortedKeys(rebuilt) {
+		rec.Events = append(rec.Events, fmt.Sprintf("rebuilt %d %s", rebuilt[name], name))
+	}
+	for _, name := range sortedKeys(abandoned) {
+		rec.Events = append(rec.Events, fmt.Sprintf("abandoned %d worn out %s", abandoned[name], name))
+	}
+}
+
+// construct plans and builds the buildings needed to sustain the next
+// year's population with the unlocked building types and the treasury.
+func (sim *Simulation) construct(rec *YearRecord) {
+	target := sim.Population + int(math.Ceil(float64(sim.Population)*sim.Params.GrowthRate))
+
+	// The population is represented by a pseudo building requiring the
+	// needs of the target population.
+	residents := NewBuildingType("residents")
+	for r, n := range sim.Params.Needs {
+		residents.Requires[r] = int(math.Ceil(float64(target) * n))
+	}
+	if sim.Params.Housing != "" {
+		residents.Requires[sim.Params.Housing] = target
+	}
+
+	// Only unlocked building types can be built.
+	pool := NewBuildingPool()
+	for _, bt := range sim.BuildingPool.Types {
+		if sim.unlocked(bt) {
+			pool.Types = append(pool.Types, bt)
+		}
+	}
+	pool.Update()
+	plan := NewSettlement(pool)
+	plan.Buildings = append(append(plan.Buildings, sim.Buildings...), residents.NewBuilding())
+	plan.Update()
+
+	// If the economy can't be satisfied, we still build the best partial
+	// solution that we can afford. NOTE: A budget of zero means no limit,
+	// so an empty treasury only allows free buildings.
+	sol, _ := plan.Optimize(&Constraints{
+		Land:       sim.Params.Land,
+		Population: int(math.Max(float64(target)*sim.Params.LaborShare, 1)),
+		Budget:     math.Max(sim.Treasury, 1e-9),
+		MaxSteps:   sim.Params.MaxSteps,
+	})
+	if sol == nil || len(sol.Buildings) == 0 {
+		return
+	}
+	sim.ApplySolution(sol)
+	sim.Treasury -= sol.Cost
+	built := make(map[string]int)
+	for bt, n := range sol.Buildings {
+		built[bt.Name] += n
+	}
+	for _, name := range sortedKeys(built) {
+		rec.Events = append(rec.Events, fmt.Sprintf("built %d %s", built[name], name))
+	}
+}
+
+// balance returns the resources supplied by the buildings and demanded by
+// the buildings and the population (including housing).
+//
+// Buildings lacking workers or inputs produce (and consume) less, which in
+// turn affects the buildings that depend on them, so the balance is
+// approximated iteratively.
+func (sim *Simulation) balance() (supply, demand map[string]float64) {
+	var workers int
+	for _, b := range sim.Buildings {
+		workers += b.Workers
+	}
+	labor := 1.0
+	if workers > 0 {
+		labor = math.Min(1, float64(sim.Population)*sim.Params.LaborShare/float64(workers))
+	}
+	eff := make([]float64, len(sim.Buildings))
+	for i, b := range sim.Buildings {
+		eff[i] = 1
+		if b.Workers > 0 {
+			eff[i] = labor
+		}
+	}
+	for iter := 0; iter < 10; iter++ {
+		supply = make(map[string]float64)
+		demand = make(map[string]float64)
+		for i, b := range sim.Buildings {
+			for r, v := range b.Provides {
+				supply[r] += float64(v) * eff[i]
+			}
+			for r, v := range b.Requires {
+				demand[r] += float64(v) * eff[i]
+			}
+		}
+		for r, n := range sim.Params.Needs {
+			demand[r] += float64(sim.Population) * n
+		}
+		if sim.Params.Housing != "" {
+			demand[sim.Params.Housing] += float64(sim.Population)
+		}
+
+		// Scale down the buildings by their scarcest input.
+		for i, b := range sim.Buildings {
+			e := 1.0
+			if b.Workers > 0 {
+				e = labor
+			}
+			for r := range b.Requires {
+				if demand[r] > 0 {
+					e = math.Min(e, supply[r]/demand[r])
+				}
+			}
+			eff[i] = e
+		}
+	}
+	return supply, demand
+}
+
+// unlocked returns true if the building type is unlocked for the current
+// population.
+func (sim *Simulation) unlocked(bt *BuildingType) bool {
+	return sim.Population >= bt.MinPopulation
+}
+
+// round rounds the value randomly up or down, weighted by its fraction,
+// so that small settlements can grow as well.
+func (sim *Simulation) round(v float64) int {
+	n := math.Floor(v)
+	if sim.rng.Float64() < v-n {
+		n++
+	}
+	return int(n)
+}
+
+// Snapshot returns a snapshot of the current state of the settlement.
+func (sim *Simulation) Snapshot() *Snapshot {
+	snap := &Snapshot{
+		Year:       sim.Year,
+		Population: sim.Population,
+		Type:       gameconstants.GetSettlementType(sim.Population),
+		Treasury:   sim.Treasury,
+		Buildings:  make(map[string]int),
+		Surplus:    make(map[string]int),
+		Missing:    make(map[string]int),
+	}
+	for _, b := range sim.Buildings {
+		snap.Buildings[b.Name]++
+	}
+	supply, demand := sim.balance()
+	for r, v := range supply {
+		if d := int(math.Floor(v - demand[r])); d > 0 {
+			snap.Surplus[r] = d
+		}
+	}
+	for r, v := range demand {
+		if d := int(math.Ceil(v - supply[r])); d > 0 {
+			snap.Missing[r] = d
+		}
+	}
+	return snap
+}
+
+// Log prints the history and the snapshots to the console.
+func (sim *Simulation) Log() {
+	for _, rec := range sim.History {
+		log.Println(rec)
+	}
+	for _, snap := range sim.Snapshots {
+		log.Println(snap)
+	}
+}
@@ -0,0 +1,512 @@
+package genvillage
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Objective is the objective of the economy solver.
+type Objective int
+
+// The supported objectives.
+const (
+	ObjectiveCost      Objective = iota // Minimize the cost of buildings and imports, less the export income.
+	ObjectiveBuildings                  // Minimize the number of buildings and imported resources.
+)
+
+// NOTE: With ObjectiveBuildings, each imported resource counts like a
+// building (someone has to trade for it), and the costs only break ties
+// between solutions with the same count. So a resource is imported if
+// producing it locally would take more buildings (e.g. a whole production
+// chain), but produced by a single building rather than imported if the
+// building is cheaper.
+
+// Constraints limit the buildings the solver can add to a settlement.
+// Zero values mean no limit.
+type Constraints struct {
+	Objective  Objective
+	Land       float64            // Available land.
+	Population int                // Available workers.
+	Budget     float64            // Maximum cost of all added buildings.
+	Imports    map[string]float64 // Price per unit of importable resources.
+	Exports    map[string]float64 // Price per unit of exportable resources.
+	MaxSteps   int                // Maximum number of search steps (default 100000).
+}
+
+// defaultMaxSteps is the default maximum number of search steps.
+const defaultMaxSteps = 100000
+
+// Solution is the result of the economy solver.
+type Solution struct {
+	Buildings  map[*BuildingType]int // Number of added buildings by type.
+	Imports    map[string]int        // Imported resources.
+	Exports    map[string]int        // Exported surplus resources.
+	Cost       float64               // Cost of the added buildings.
+	ImportCost float64               // Cost of the imports.
+	Income     float64               // Income from the exports.
+	Land       float64               // Land used by all buildings.
+	Workers    int                   // Workers required by all buildings.
+	Shortfalls []*Shortfall          // Resources that could not be satisfied.
+}
+
+// Shortfall is a resource that the solver could not satisfy.
+type Shortfall struct {
+	Resource string
+	Missing  int    // Missing amount.
+	Reason   string // Why the resource could not be satisfied.
+}
+
+// String implements the stringer interface.
+func (s *Shortfall) String() string {
+	return fmt.Sprintf("%s (missing %d): %s", s.Resource, s.Missing, s.Reason)
+}
+
+// ErrUnsatisfiable is returned if the economy of a settlement cannot be
+// balanced with the given constraints.
+var ErrUnsatisfiable = errors.New("economy cannot be satisfied")
+
+// solverState is a node of the solver's search.
+type solverState struct {
+	counts  []int          // Number of added buildings by building type index.
+	imports map[string]int // Imported resources.
+	spent   float64        // Increase of the objective by buildings and imports.
+	cost    float64        // Value of the objective.
+	index   int            // Index in the priority queue.
+}
+
+// key returns a unique key for the state.
+func (st *solverState) key() string {
+	var sb strings.Builder
+	for _, c := range st.counts {
+		fmt.Fprintf(&sb, "%d,", c)
+	}
+	for _, r := range sortedKeys(st.imports) {
+		fmt.Fprintf(&sb, "%s=%d,", r, st.imports[r])
+	}
+	return sb.String()
+}
+
+// solverQueue is a priority queue of states by cost.
+type solverQueue []*solverState
+
+func (q solverQueue) Len() int           { return len(q) }
+func (q solverQueue) Less(i, j int) bool { return q[i].cost < q[j].cost }
+func (q solverQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *solverQueue) Push(x interface{}) {
+	st := x.(*solverState)
+	st.index = len(*q)
+	*q = append(*q, st)
+}
+
+func (q *solverQueue) Pop() interface{} {
+	old := *q
+	st := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return st
+}
+
+// solver finds the optimal set of buildings for a settlement.
+type solver struct {
+	s          *Settlement
+	c          *Constraints
+	types      []*BuildingType
+	producible map[string]bool // Resources that can be produced or imported.
+}
+
+// Optimize finds the set of buildings from the building pool (and imports)
+// that satisfies the resources required by the settlement at the lowest
+// cost (or with the fewest buildings), without exceeding the land,
+// population and budget. Surplus resources that can be exported are
+// reported as exports, and with ObjectiveCost, their income is subtracted
+// from the cost. Buildings are only added to provide missing resources,
+// never just for their exports, so if a building type earns more from its
+// exports than it costs, the solution might not be the cheapest one.
+//
+// The settlement is not modified; use ApplySolution to add the buildings
+// and imports.
+// If the economy can't be satisfied, the best partial solution is returned
+// along with an error wrapping ErrUnsatisfiable, and the solution lists
+// the shortfalls and their reasons.
+func (s *Settlement) Optimize(c *Constraints) (*Solution, error) {
+	if c == nil {
+		c = &Constraints{}
+	}
+	sv := &solver{s: s, c: c, types: s.BuildingPool.Types}
+	sv.findProducible()
+	maxSteps := c.MaxSteps
+	if maxSteps <= 0 {
+		maxSteps = defaultMaxSteps
+	}
+
+	start := &solverState{counts: make([]int, len(sv.types)), imports: make(map[string]int)}
+	start.cost = -sv.exportIncome(start)
+	if !sv.withinLimits(start) {
+		sol := sv.solution(start)
+		sol.Shortfalls = append(sol.Shortfalls, &Shortfall{Reason: "the existing buildings already exceed the constraints"})
+		return sol, fmt.Errorf("%w: the existing buildings already exceed the constraints", ErrUnsatisfiable)
+	}
+	q := &solverQueue{start}
+	seen := map[string]float64{start.key(): start.cost}
+	closest, closestDeficit := start, math.MaxInt
+	var steps int
+	for q.Len() > 0 && steps < maxSteps {
+		st := heap.Pop(q).(*solverState)
+		steps++
+		missing := sv.missing(st)
+		if len(missing) == 0 {
+			return sv.solution(st), nil
+		}
+		if d := sumValues(missing); d < closestDeficit {
+			closest, closestDeficit = st, d
+		}
+
+		// Pick the resource with the largest deficit and try all ways to
+		// provide it. Every solution has to provide it somehow.
+		res := largestDeficit(missing)
+		var next []*solverState
+		for _, bt := range sv.s.BuildingPool.Provides[res] {
+			if !sv.inputsProducible(bt) {
+				// This would only add more resources we can't satisfy.
+				continue
+			}
+			i := sv.typeIndex(bt)
+			ns := &solverState{counts: append([]int(nil), st.counts...), imports: st.imports}
+			ns.counts[i]++
+			ns.spent = st.spent + sv.buildingCost(bt)
+			next = append(next, ns)
+		}
+		if price, ok := sv.c.Imports[res]; ok {
+			ns := &solverState{counts: st.counts, imports: make(map[string]int, len(st.imports)+1)}
+			for r, n := range st.imports {
+				ns.imports[r] = n
+			}
+			ns.imports[res] += missing[res]
+			ns.spent = st.spent + sv.importCost(price, missing[res], st.imports[res] == 0)
+			next = append(next, ns)
+		}
+		for _, ns := range next {
+			if !sv.withinLimits(ns) {
+				continue
+			}
+			ns.cost = ns.spent - sv.exportIncome(ns)
+			k := ns.key()
+			if c, ok := seen[k]; ok && c <= ns.cost {
+				continue
+			}
+			seen[k] = ns.cost
+			heap.Push(q, ns)
+		}
+	}
+
+	// No solution was found, so we report the closest state and why the
+	// missing resources can't be satisfied.
+	sol := sv.solution(closest)
+	missing := sv.missing(closest)
+	reasons := sv.reasons(steps >= maxSteps)
+	var msgs []string
+	for _, r := range sortedKeys(missing) {
+		sf := &Shortfall{Resource: r, Missing: missing[r], Reason: reasons[r]}
+		sol.Shortfalls = append(sol.Shortfalls, sf)
+		msgs = append(msgs, sf.String())
+	}
+	return sol, fmt.Errorf("%w: %s", ErrUnsatisfiable, strings.Join(msgs, "; "))
+}
+
+// ApplySolution adds the buildings and imports of the given solution to the
+// settlement. The imports are added to the resources provided to the
+// settlement, so they are no longer reported as missing.
+func (s *Settlement) ApplySolution(sol *Solution) {
+	if len(sol.Imports) > 0 && s.Imports == nil {
+		s.Imports = make(map[string]int)
+	}
+	addToMap(s.Imports, sol.Imports)
+	types := make([]*BuildingType, 0, len(sol.Buildings))
+	for bt := range sol.Buildings {
+		types = append(types, bt)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i].Name < types[j].Name })
+	for _, bt := range types {
+		for i := 0; i < sol.Buildings[bt]; i++ {
+			s.Buildings = append(s.Buildings, bt.NewBuilding())
+		}
+	}
+	s.Update()
+}
+
+// typeIndex returns the index of the building type in the pool.
+func (sv *solver) typeIndex(bt *BuildingType) int {
+	for i, t := range sv.types {
+		if t == bt {
+			return i
+		}
+	}
+	return -1
+}
+
+// buildingCost returns the increase of the objective when adding a building
+// of the given type. A small value is added so that the solver prefers
+// fewer buildings if they are free.
+func (sv *solver) buildingCost(bt *BuildingType) float64 {
+	if sv.c.Objective == ObjectiveBuildings {
+		return 1 + bt.Cost*1e-6
+	}
+	return bt.Cost + 1e-6
+}
+
+// importCost returns the increase of the objective when importing the given
+// amount of a resource at the given price. With ObjectiveBuildings, a new
+// imported resource counts like a building and the cost only breaks ties.
+func (sv *solver) importCost(price float64, amount int, newResource bool) float64 {
+	if sv.c.Objective != ObjectiveBuildings {
+		return price * float64(amount)
+	}
+	cost := price * float64(amount) * 1e-6
+	if newResource {
+		cost++
+	}
+	return cost
+}
+
+// exportIncome returns the decrease of the objective by the exports of the
+// given state (only with ObjectiveCost).
+func (sv *solver) exportIncome(st *solverState) float64 {
+	if sv.c.Objective != ObjectiveCost || len(sv.c.Exports) == 0 {
+		return 0
+	}
+	_, income := sv.exports(st)
+	return income
+}
+
+// exports returns the surplus resources that can be exported in the given
+// state and the income from them.
+func (sv *solver) exports(st *solverState) (map[string]int, float64) {
+	res := make(map[string]int)
+	var income float64
+	requires, provides := sv.totals(st)
+	for r, n := range compMaps(provides, requires) {
+		if price, ok := sv.c.Exports[r]; ok {
+			res[r] = n
+			income += price * float64(n)
+		}
+	}
+	return res, income
+}
+
+// totals returns the resources required and provided in the given state.
+func (sv *solver) totals(st *solverState) (requires, provides map[string]int) {
+	requires = addMaps(sv.s.Production.Requires, nil)
+	provides = addMaps(sv.s.Production.Provides, st.imports)
+	for i, n := range st.counts {
+		for r, v := range sv.types[i].Requires {
+			requires[r] += v * n
+		}
+		for r, v := range sv.types[i].Provides {
+			provides[r] += v * n
+		}
+	}
+	return requires, provides
+}
+
+// missing returns the resources missing in the given state.
+func (sv *solver) missing(st *solverState) map[string]int {
+	requires, provides := sv.totals(st)
+	return compMaps(requires, provides)
+}
+
+// usage returns the cost, land and workers of all buildings in the given
+// state (including the existing buildings, except for the cost).
+func (sv *solver) usage(st *solverState) (cost, land float64, workers int) {
+	for _, b := range sv.s.Buildings {
+		land += b.Land
+		workers += b.Workers
+	}
+	for i, n := range st.counts {
+		cost += sv.types[i].Cost * float64(n)
+		land += sv.types[i].Land * float64(n)
+		workers += sv.types[i].Workers * n
+	}
+	return cost, land, workers
+}
+
+// withinLimits returns true if the state does not exceed the constraints.
+func (sv *solver) withinLimits(st *solverState) bool {
+	cost, land, workers := sv.usage(st)
+	return (sv.c.Budget <= 0 || cost <= sv.c.Budget) &&
+		(sv.c.Land <= 0 || land <= sv.c.Land) &&
+		(sv.c.Population <= 0 || workers <= sv.c.Population)
+}
+
+// solution returns the solution for the given state.
+func (sv *solver) solution(st *solverState) *Solution {
+	sol := &Solution{
+		Buildings: make(map[*BuildingType]int),
+		Imports:   make(map[string]int),
+	}
+	for i, n := range st.counts {
+		if n > 0 {
+			sol.Buildings[sv.types[i]] = n
+		}
+	}
+	for r, n := range st.imports {
+		sol.Imports[r] = n
+		sol.ImportCost += sv.c.Imports[r] * float64(n)
+	}
+	sol.Cost, sol.Land, sol.Workers = sv.usage(st)
+	sol.Exports, sol.Income = sv.exports(st)
+	return sol
+}
+
+// reasons determines for each resource why it can't be satisfied.
+func (sv *solver) reasons(limitReached bool) map[string]string {
+	res := make(map[string]string)
+	producible := sv.producible
+
+	// Resources that are required by the settlement or any building type.
+	resources := make(map[string]bool)
+	for r := range sv.s.Production.Requires {
+		resources[r] = true
+	}
+	for r := range sv.s.BuildingPool.Requires {
+		resources[r] = true
+	}
+	for r := range resources {
+		bts := sv.s.BuildingPool.Provides[r]
+		switch {
+		case len(bts) == 0 && !producible[r]:
+			res[r] = "no building provides it and it cannot be imported"
+		case !producible[r]:
+			var parts []string
+			for _, bt := range bts {
+				var inputs []string
+				for _, in := range sortedKeys(bt.GetMissing()) {
+					if !producible[in] {
+						inputs = append(inputs, in)
+					}
+				}
+				parts = append(parts, fmt.Sprintf("%s requires %s", bt.Name, strings.Join(inputs, ", ")))
+			}
+			sort.Strings(parts)
+			res[r] = strings.Join(parts, "; ")
+			if roots := sv.rootCauses(r, make(map[string]bool)); len(roots) > 0 {
+				res[r] += fmt.Sprintf(" (ultimately %s, which no building provides and cannot be imported)", strings.Join(sortedKeys(roots), ", "))
+			}
+		case limitReached:
+			res[r] = "no solution found within the maximum number of search steps"
+		default:
+			res[r] = sv.limitReason(bts)
+		}
+	}
+	return res
+}
+
+// findProducible determines which resources can be produced at all
+// (ignoring the constraints). Starting with all resources that are provided
+// by any building type or can be imported, we remove resources until all
+// remaining ones have a provider whose inputs can be produced, so that
+// buildings depending on each other (e.g. housing and a bakery) are
+// considered producible.
+func (sv *solver) findProducible() {
+	sv.producible = make(map[string]bool)
+	for r := range sv.c.Imports {
+		sv.producible[r] = true
+	}
+	for r := range sv.s.BuildingPool.Provides {
+		sv.producible[r] = true
+	}
+	for changed := true; changed; {
+		changed = false
+		for r, bts := range sv.s.BuildingPool.Provides {
+			if _, ok := sv.c.Imports[r]; ok || !sv.producible[r] {
+				continue
+			}
+			var ok bool
+			for _, bt := range bts {
+				if sv.inputsProducible(bt) {
+					ok = true
+					break
+				}
+			}
+			if !ok {
+				sv.producible[r] = false
+				changed = true
+			}
+		}
+	}
+}
+
+// rootCauses returns the resources that can't be produced because no
+// building provides them (and they can't be imported), which prevent the
+// given resource from being produced.
+func (sv *solver) rootCauses(r string, visited map[string]bool) map[string]int {
+	res := make(map[string]int)
+	if visited[r] || sv.producible[r] {
+		return res
+	}
+	visited[r] = true
+	bts := sv.s.BuildingPool.Provides[r]
+	if len(bts) == 0 {
+		res[r]++
+		return res
+	}
+	for _, bt := range bts {
+		for in := range bt.GetMissing() {
+			addToMap(res, sv.rootCauses(in, visited))
+		}
+	}
+	return res
+}
+
+// inputsProducible returns true if all resources required by the building
+// type (and not provided by itself) can be produced.
+func (sv *solver) inputsProducible(bt *BuildingType) bool {
+	for in := range bt.GetMissing() {
+		if !sv.producible[in] {
+			return false
+		}
+	}
+	return true
+}
+
+// limitReason returns which constraints prevent the building types from
+// being added.
+func (sv *solver) limitReason(bts []*BuildingType) string {
+	var limits []string
+	if sv.c.Budget > 0 {
+		limits = append(limits, fmt.Sprintf("budget of %.0f", sv.c.Budget))
+	}
+	if sv.c.Land > 0 {
+		limits = append(limits, fmt.Sprintf("land of %.0f", sv.c.Land))
+	}
+	if sv.c.Population > 0 {
+		limits = append(limits, fmt.Sprintf("population of %d", sv.c.Population))
+	}
+	var names []string
+	for _, bt := range bts {
+		names = append(names, bt.Name)
+	}
+	if len(limits) == 0 {
+		return fmt.Sprintf("providers (%s) cannot be added", strings.Join(names, ", "))
+	}
+	return fmt.Sprintf("no combination of providers (%s) and their inputs fits within the %s", strings.Join(names, ", "), strings.Join(limits, ", "))
+}
+
+// largestDeficit returns the resource with the largest deficit (sorted by
+// name for equal deficits).
+func largestDeficit(missing map[string]int) string {
+	var best string
+	for _, r := range sortedKeys(missing) {
+		if best == "" || missing[r] > missing[best] {
+			best = r
+		}
+	}
+	return best
+}
@@ -0,0 +1,175 @@
+package genvillage
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+// testSettlement returns a settlement with a town hall and a pool of
+// building types to provide for it.
+func testSettlement() *Settlement {
+	p := NewBuildingPool()
+	for _, bt := range []struct {
+		name     string
+		cost     float64
+		land     float64
+		workers  int
+		requires map[string]int
+		provides map[string]int
+	}{
+		{"housing", 10, 2, 0, map[string]int{"bread": 4}, map[string]int{"worker": 4}},
+		{"farm", 5, 4, 1, map[string]int{"worker": 1}, map[string]int{"grain": 10}},
+		{"mill", 8, 1, 1, map[string]int{"grain": 10, "worker": 1}, map[string]int{"flour": 10}},
+		{"bakery", 6, 1, 1, map[string]int{"flour": 2, "worker": 1}, map[string]int{"bread": 10}},
+		{"large bakery", 8, 1, 2, map[string]int{"flour": 4, "worker": 2}, map[string]int{"bread": 20}},
+		{"fishery", 4, 1, 2, map[string]int{"worker": 2}, map[string]int{"fish": 10}},
+		{"well", 3, 0, 0, nil, map[string]int{"water": 10}},
+	} {
+		t := NewBuildingType(bt.name)
+		t.Cost, t.Land, t.Workers = bt.cost, bt.land, bt.workers
+		addToMap(t.Requires, bt.requires)
+		addToMap(t.Provides, bt.provides)
+		p.AddType(t)
+	}
+	hall := NewBuildingType("town hall")
+	hall.Land, hall.Workers = 1, 2
+	addToMap(hall.Requires, map[string]int{"bread": 10, "fish": 5, "water": 5, "worker": 2})
+
+	s := NewSettlement(p)
+	s.AddBuilding(hall.NewBuilding())
+	return s
+}
+
+// bruteForce returns the best value of the objective by trying all
+// combinations of up to maxCount buildings of each type and importing
+// whatever is missing (math.Inf(1) if there is no solution).
+func bruteForce(s *Settlement, c *Constraints, maxCount int) float64 {
+	types := s.BuildingPool.Types
+	counts := make([]int, len(types))
+	best := math.Inf(1)
+	for {
+		requires := addMaps(s.Production.Requires, nil)
+		provides := addMaps(s.Production.Provides, nil)
+		var cost, land float64
+		var workers, buildings int
+		for _, b := range s.Buildings {
+			land += b.Land
+			workers += b.Workers
+		}
+		for i, n := range counts {
+			for j := 0; j < n; j++ {
+				addToMap(requires, types[i].Requires)
+				addToMap(provides, types[i].Provides)
+			}
+			cost += types[i].Cost * float64(n)
+			land += types[i].Land * float64(n)
+			workers += types[i].Workers * n
+			buildings += n
+		}
+		ok := (c.Budget <= 0 || cost <= c.Budget) &&
+			(c.Land <= 0 || land <= c.Land) &&
+			(c.Population <= 0 || workers <= c.Population)
+		for r, n := range compMaps(requires, provides) {
+			price, importable := c.Imports[r]
+			ok = ok && importable
+			cost += price * float64(n)
+			buildings++
+		}
+		for r, n := range compMaps(provides, requires) {
+			cost -= c.Exports[r] * float64(n)
+		}
+		if ok {
+			if c.Objective == ObjectiveBuildings {
+				best = math.Min(best, float64(buildings))
+			} else {
+				best = math.Min(best, cost)
+			}
+		}
+
+		// Next combination.
+		i := 0
+		for ; i < len(counts) && counts[i] == maxCount; i++ {
+			counts[i] = 0
+		}
+		if i == len(counts) {
+			return best
+		}
+		counts[i]++
+	}
+}
+
+func TestOptimize(t *testing.T) {
+	imports := map[string]float64{"flour": 1, "grain": 0.5, "worker": 3, "water": 1, "fish": 1}
+	// No building earns more from its exports than it costs.
+	exports := map[string]float64{"bread": 0.3, "fish": 0.3, "grain": 0.2, "flour": 0.3, "water": 0.1}
+	for _, tc := range []struct {
+		name string
+		c    *Constraints
+	}{
+		{"cost", &Constraints{}},
+		{"imports", &Constraints{Imports: imports}},
+		{"exports", &Constraints{Exports: exports}},
+		{"trade", &Constraints{Imports: imports, Exports: exports}},
+		{"trade with limits", &Constraints{Imports: imports, Exports: exports, Land: 12, Population: 10}},
+		{"trade with budget", &Constraints{Imports: imports, Exports: exports, Budget: 40}},
+		{"buildings", &Constraints{Objective: ObjectiveBuildings}},
+		{"buildings with imports", &Constraints{Objective: ObjectiveBuildings, Imports: imports}},
+		{"buildings with limits", &Constraints{Objective: ObjectiveBuildings, Imports: imports, Exports: exports, Population: 8}},
+	} {
+		s := testSettlement()
+		want := bruteForce(s, tc.c, 3)
+		sol, err := s.Optimize(tc.c)
+		if math.IsInf(want, 1) {
+			if !errors.Is(err, ErrUnsatisfiable) {
+				t.Errorf("%s: got error %v, want %v", tc.name, err, ErrUnsatisfiable)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		got := sol.Cost + sol.ImportCost - sol.Income
+		if tc.c.Objective == ObjectiveBuildings {
+			got = float64(len(sol.Imports))
+			for _, n := range sol.Buildings {
+				got += float64(n)
+			}
+		}
+		if math.Abs(got-want) > 1e-3 {
+			t.Errorf("%s: got objective %.3f, want %.3f (buildings %v, imports %v, exports %v)", tc.name, got, want, sol.Buildings, sol.Imports, sol.Exports)
+		}
+
+		// The solution satisfies the settlement.
+		s.ApplySolution(sol)
+		if missing := s.GetMissing(); len(missing) > 0 {
+			t.Errorf("%s: got missing resources %v", tc.name, missing)
+		}
+	}
+}
+
+func TestOptimizeTradeOff(t *testing.T) {
+	s := testSettlement()
+	imports := map[string]float64{"bread": 1, "fish": 1, "worker": 1, "water": 1}
+
+	// A well is cheaper than importing water and counts just as much as
+	// importing it.
+	sol, err := s.Optimize(&Constraints{Objective: ObjectiveBuildings, Imports: imports})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sol.Imports["water"] != 0 || sol.Buildings[s.FindType("well")] != 1 {
+		t.Errorf("got imports %v and buildings %v, want a well", sol.Imports, sol.Buildings)
+	}
+
+	// Selling the surplus bread of a large bakery makes it cheaper than a
+	// bakery that just covers the demand.
+	sol, err = s.Optimize(&Constraints{Imports: map[string]float64{"flour": 0.1, "worker": 0.1, "fish": 1, "water": 1}, Exports: map[string]float64{"bread": 0.3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sol.Buildings[s.FindType("large bakery")] != 1 || sol.Exports["bread"] != 10 {
+		t.Errorf("got buildings %v and exports %v, want a large bakery selling 10 bread", sol.Buildings, sol.Exports)
+	}
+}
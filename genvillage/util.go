@@ -1,5 +1,7 @@
 package genvillage
 
+import "sort"
+
 // compMaps returns the count of keys in 'a' missing or exceeding
 // their counterpart in 'b' (but not the other way round).
 //
@@ -43,3 +45,22 @@ func addMaps(a, b map[string]int) map[string]int {
 	}
 	return res
 }
+
+// sumValues returns the sum of all values in the map.
+func sumValues(a map[string]int) int {
+	var res int
+	for _, val := range a {
+		res += val
+	}
+	return res
+}
+
+// sortedKeys returns the keys of the map in sorted order.
+func sortedKeys[T any](a map[string]T) []string {
+	keys := make([]string, 0, len(a))
+	for key := range a {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
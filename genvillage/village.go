@@ -9,9 +9,10 @@ import (
 
 // Settlement represents a village with a number of buildings.
 type Settlement struct {
-	Buildings     []*Building // All buildings in the settlement.
-	*Production               // Production totals for the settlement.
-	*BuildingPool             // All known building types.
+	Buildings     []*Building    // All buildings in the settlement.
+	Imports       map[string]int // Resources imported from elsewhere (see ApplySolution).
+	*Production                  // Production totals for the settlement.
+	*BuildingPool                // All known building types.
 }
 
 // NewSettlement returns a new settlement with the given BuildingPool.
@@ -37,12 +38,16 @@ func (s *Settlement) Update() {
 		addToMap(s.Production.Requires, b.Requires)
 		addToMap(s.Production.Provides, b.Provides)
 	}
+	addToMap(s.Production.Provides, s.Imports)
 }
 
 const maxAttempts = 1000
 
 // Solve attempts to add known building types to the settlements until
 // the local economy is self-sustaining.
+//
+// NOTE: This picks random buildings and ignores costs and constraints, see
+// Optimize for a solver that finds the cheapest set of buildings.
 func (s *Settlement) Solve() {
 	// Here we'll keep track if the local economy is stable.
 	var changed bool
@@ -2,4 +2,9 @@ module github.com/Flokey82/go_gens
 
 go 1.18
 
-require github.com/ojrac/opensimplex-go v1.0.2 // indirect
+require (
+	github.com/ajstarks/svgo v0.0.0-20211024235047-1546f124cd8b
+	github.com/fogleman/delaunay v0.0.0-20180910191513-63f09b4c883d
+	github.com/ojrac/opensimplex-go v1.0.2 // indirect
+	github.com/pzsz/voronoi v0.0.0-20130609164533-4314be88c79f
+)
@@ -3,6 +3,7 @@ package vmesh
 import (
 	"bufio"
 	"fmt"
+	"math"
 	"os"
 
 	"github.com/Flokey82/go_gens/genheightmap"
@@ -39,12 +40,20 @@ func (h *Heightmap) ExportOBJ(path string) error {
 	defer f.Close()
 	w := bufio.NewWriter(f)
 	for i, p := range tr.Points {
+		if h.IsSphere() {
+			// Displace the vertex along the normal of the sphere, using
+			// the same scale as the flat map (pole to pole equals the
+			// height of the extent).
+			v := h.XYZ[i].Mul(1 + h.Values[i]*0.2*math.Pi/h.Extent.Height)
+			w.WriteString(fmt.Sprintf("v %f %f %f \n", v.X, v.Y, v.Z))
+			continue
+		}
 		w.WriteString(fmt.Sprintf("v %f %f %f \n", p.X, h.Values[i]*0.2, p.Y)) //
 	}
 	for i := 0; i < len(tr.Triangles); i += 3 {
 		w.WriteString(fmt.Sprintf("f %d %d %d \n", tr.Triangles[i]+1, tr.Triangles[i+1]+1, tr.Triangles[i+2]+1))
 	}
-	return nil
+	return w.Flush()
 }
 
 func (h *Heightmap) Diff(hms *Heightmap) *Heightmap {
@@ -74,9 +83,11 @@ func (h *Heightmap) TriSlope(i int) [2]float64 {
 		return [2]float64{0, 0}
 	}
 
-	p0 := h.Mesh.Vertices[nbs[0]]
-	p1 := h.Mesh.Vertices[nbs[1]]
-	p2 := h.Mesh.Vertices[nbs[2]]
+	// NOTE: We use the local offsets of the neighbours, so that the slope
+	// is also correct on a sphere.
+	p0 := h.LocalOffset(i, nbs[0])
+	p1 := h.LocalOffset(i, nbs[1])
+	p2 := h.LocalOffset(i, nbs[2])
 
 	x1 := p1.X - p0.X
 	x2 := p2.X - p0.X
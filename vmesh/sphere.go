@@ -0,0 +1,215 @@
+package vmesh
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+
+	"github.com/Flokey82/go_gens/vectors"
+	"github.com/fogleman/delaunay"
+	"github.com/pzsz/voronoi"
+)
+
+// GenerateSphereMesh generates a seamless mesh covering the surface of a unit
+// sphere, based on n points distributed using a Fibonacci sphere.
+//
+// The jitter parameter (0.0 - 1.0) randomizes the point positions a little, to
+// avoid the regular spiral pattern of the Fibonacci sphere.
+//
+// NOTE: The 2D coordinates of all points and vertices (used by all code
+// that is not aware of the sphere) are the equirectangular projection
+// of their position on the sphere, scaled to fit the given extent.
+func GenerateSphereMesh(n int, jitter float64, extent *Extent) (*Mesh, error) {
+	return MakeSphereMesh(generateFibonacciSphere(n, jitter), extent)
+}
+
+// MakeSphereMesh generates a mesh from the given points on the unit sphere.
+//
+// The voronoi vertices of the mesh are the circumcenters of the triangles of
+// the spherical delaunay triangulation, which means that each vertex has
+// exactly three neighbours and there are no edges or borders.
+func MakeSphereMesh(pts []vectors.Vec3, extent *Extent) (*Mesh, error) {
+	if extent == nil {
+		extent = defaultExtent
+	}
+	tris, err := sphericalDelaunay(pts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Set up the voronoi cells, one for each site on the sphere.
+	sites := make([]voronoi.Vertex, len(pts))
+	cells := make([]*voronoi.Cell, len(pts))
+	for i, p := range pts {
+		sites[i] = projectEquirectangular(p, extent)
+		cells[i] = &voronoi.Cell{Site: sites[i]}
+	}
+
+	// Each triangle corresponds to a voronoi vertex, which is located at
+	// the circumcenter of the triangle (projected onto the sphere).
+	numTris := len(tris) / 3
+	vxs := make([]voronoi.Vertex, numTris)
+	xyz := make([]vectors.Vec3, numTris)
+	tris2cells := make(map[int][]*voronoi.Cell, numTris)
+	halfedgeToTri := make(map[[2]int]int, len(tris))
+	for t := 0; t < numTris; t++ {
+		a, b, c := pts[tris[3*t]], pts[tris[3*t+1]], pts[tris[3*t+2]]
+		xyz[t] = b.Sub(a).Cross(c.Sub(a)).Normalize()
+		vxs[t] = projectEquirectangular(xyz[t], extent)
+		for i := 0; i < 3; i++ {
+			tris2cells[t] = append(tris2cells[t], cells[tris[3*t+i]])
+			halfedgeToTri[[2]int{tris[3*t+i], tris[3*t+(i+1)%3]}] = t
+		}
+	}
+
+	// Connect all triangles that share a delaunay edge, which gives us
+	// the voronoi edges and the adjacency of the voronoi vertices.
+	vor := &voronoi.Diagram{Cells: cells}
+	var edges []Edge
+	adj := make(map[int][]int, numTris)
+	for i, a := range tris {
+		t := i / 3
+		he := [2]int{a, tris[3*t+(i+1)%3]}
+		if he[0] > he[1] {
+			continue // Visit each edge only once.
+		}
+		opp, ok := halfedgeToTri[[2]int{he[1], he[0]}]
+		if !ok {
+			return nil, errors.New("vmesh: triangulation is not closed")
+		}
+		adj[t] = append(adj[t], opp)
+		adj[opp] = append(adj[opp], t)
+		edges = append(edges, Edge{
+			IdxA:  t,
+			IdxB:  opp,
+			Left:  cells[he[0]],
+			Right: cells[he[1]],
+		})
+
+		e := &voronoi.Edge{
+			LeftCell:  cells[he[0]],
+			RightCell: cells[he[1]],
+			Va:        voronoi.EdgeVertex{Vertex: vxs[t]},
+			Vb:        voronoi.EdgeVertex{Vertex: vxs[opp]},
+		}
+		vor.Edges = append(vor.Edges, e)
+		cells[he[0]].Halfedges = append(cells[he[0]].Halfedges, &voronoi.Halfedge{Cell: cells[he[0]], Edge: e})
+		cells[he[1]].Halfedges = append(cells[he[1]].Halfedges, &voronoi.Halfedge{Cell: cells[he[1]], Edge: e})
+	}
+
+	return &Mesh{
+		Points:      sites,
+		Voronoi:     vor,
+		Vertices:    vxs,
+		AdjacentVxs: adj,
+		VertexTris:  tris2cells,
+		Edges:       edges,
+		Extent:      extent,
+		XYZ:         xyz,
+	}, nil
+}
+
+// generateFibonacciSphere returns n points on the unit sphere, distributed
+// using the Fibonacci sphere (golden spiral) algorithm.
+func generateFibonacciSphere(n int, jitter float64) []vectors.Vec3 {
+	goldenAngle := math.Pi * (3 - math.Sqrt(5))
+	spacing := math.Sqrt(4 * math.Pi / float64(n)) // Approximate distance between points.
+	pts := make([]vectors.Vec3, n)
+	for i := 0; i < n; i++ {
+		z := 1 - (float64(i)+0.5)*2/float64(n)
+		r := math.Sqrt(1 - z*z)
+		theta := goldenAngle * float64(i)
+		p := vectors.NewVec3(r*math.Cos(theta), r*math.Sin(theta), z)
+		if jitter > 0 {
+			p = p.Add(vectors.NewVec3(
+				(rand.Float64()-0.5)*jitter*spacing,
+				(rand.Float64()-0.5)*jitter*spacing,
+				(rand.Float64()-0.5)*jitter*spacing,
+			)).Normalize()
+		}
+		pts[i] = p
+	}
+	return pts
+}
+
+// sphericalDelaunay returns the triangles (as triplets of point indices) of the
+// delaunay triangulation of the given points on the unit sphere.
+//
+// We rotate the sphere so that the last point sits at the north pole, project
+// all other points stereographically onto a plane (which preserves circles,
+// and with that the delaunay property) and triangulate them. The resulting
+// convex hull is then connected to the point at the pole to close the mesh.
+func sphericalDelaunay(pts []vectors.Vec3) ([]int, error) {
+	n := len(pts)
+	if n < 4 {
+		return nil, errors.New("vmesh: need at least 4 points for a sphere")
+	}
+	pole := pts[n-1].Normalize()
+	u, v := tangentBasis(pole)
+	proj := make([]delaunay.Point, n-1)
+	for i, p := range pts[:n-1] {
+		z := p.Dot(pole)
+		proj[i] = delaunay.Point{
+			X: p.Dot(u) / (1 - z),
+			Y: p.Dot(v) / (1 - z),
+		}
+	}
+	tr, err := delaunay.Triangulate(proj)
+	if err != nil {
+		return nil, err
+	}
+	tris := make([]int, len(tr.Triangles), len(tr.Triangles)+3*len(proj))
+	copy(tris, tr.Triangles)
+
+	// Halfedges without an opposite halfedge are part of the convex hull.
+	for e, opp := range tr.Halfedges {
+		if opp == -1 {
+			tris = append(tris, tr.Triangles[e], tr.Triangles[nextHalfedge(e)], n-1)
+		}
+	}
+
+	// Make sure that all triangles are wound counter-clockwise when seen from
+	// the outside, so their normals (and circumcenters) point outwards.
+	for t := 0; t < len(tris); t += 3 {
+		a, b, c := pts[tris[t]], pts[tris[t+1]], pts[tris[t+2]]
+		if b.Sub(a).Cross(c.Sub(a)).Dot(a) < 0 {
+			tris[t+1], tris[t+2] = tris[t+2], tris[t+1]
+		}
+	}
+	return tris, nil
+}
+
+// nextHalfedge returns the next halfedge within the same triangle.
+func nextHalfedge(e int) int {
+	if e%3 == 2 {
+		return e - 2
+	}
+	return e + 1
+}
+
+// tangentBasis returns two unit vectors that are perpendicular to each other
+// and to the given normal.
+func tangentBasis(n vectors.Vec3) (vectors.Vec3, vectors.Vec3) {
+	a := vectors.NewVec3(1, 0, 0)
+	if math.Abs(n.X) > 0.9 {
+		a = vectors.NewVec3(0, 1, 0)
+	}
+	u := a.Cross(n).Normalize()
+	return u, n.Cross(u)
+}
+
+// latLon returns the latitude and longitude (in radians) of the given point
+// on the unit sphere.
+func latLon(p vectors.Vec3) (float64, float64) {
+	return math.Asin(math.Max(-1, math.Min(1, p.Z))), math.Atan2(p.Y, p.X)
+}
+
+// projectEquirectangular projects the given point on the unit sphere onto the
+// given extent with north being at the top (negative Y).
+func projectEquirectangular(p vectors.Vec3, extent *Extent) voronoi.Vertex {
+	lat, lon := latLon(p)
+	return voronoi.Vertex{
+		X: lon / math.Pi * extent.Width / 2,
+		Y: -lat / (math.Pi / 2) * extent.Height / 2,
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"sort"
 
 	"github.com/Flokey82/go_gens/genheightmap"
+	"github.com/Flokey82/go_gens/vectors"
 	"github.com/fogleman/delaunay"
 	"github.com/pzsz/voronoi"
 	"github.com/pzsz/voronoi/utils"
@@ -42,6 +43,12 @@ type Mesh struct {
 	VertexTris  map[int][]*voronoi.Cell // Vertex index to bordering cells (triangles)
 	Edges       []Edge                  // Edges in voronoi diagram
 	Extent      *Extent
+	XYZ         []vectors.Vec3 // Vertex positions on the unit sphere (sphere mode only)
+}
+
+// IsSphere returns true if the mesh covers the surface of a sphere.
+func (m *Mesh) IsSphere() bool {
+	return m.XYZ != nil
 }
 
 func (m *Mesh) Triangulate() (*delaunay.Triangulation, error) {
@@ -49,20 +56,70 @@ func (m *Mesh) Triangulate() (*delaunay.Triangulation, error) {
 	for _, p := range m.Vertices {
 		pts = append(pts, delaunay.Point{X: p.X, Y: p.Y})
 	}
+	if m.IsSphere() {
+		// NOTE: Only the points and triangles are set, since the
+		// triangulation is closed and has no convex hull.
+		tris, err := sphericalDelaunay(m.XYZ)
+		if err != nil {
+			return nil, err
+		}
+		return &delaunay.Triangulation{
+			Points:    pts,
+			Triangles: tris,
+		}, nil
+	}
 	return delaunay.Triangulate(pts)
 }
 
 func (m *Mesh) Distance(i, j int) float64 {
+	if m.IsSphere() {
+		// Great-circle distance, scaled so that the distance from
+		// pole to pole equals the height of the extent.
+		dot := math.Max(-1, math.Min(1, m.XYZ[i].Dot(m.XYZ[j])))
+		return math.Acos(dot) / math.Pi * m.Extent.Height
+	}
 	p := m.Vertices[i]
 	q := m.Vertices[j]
 	return math.Sqrt((p.X-q.X)*(p.X-q.X) + (p.Y-q.Y)*(p.Y-q.Y))
 }
 
+// LocalOffset returns the position of vertex j relative to vertex i.
+//
+// On a sphere, the offset is projected onto the tangent plane at vertex i,
+// with X pointing east and Y pointing south (like the projection), and uses
+// the same scale as Distance. This way, local geometry (like slopes) can be
+// calculated the same way as on a flat map, even across the seam and near
+// the poles.
+func (m *Mesh) LocalOffset(i, j int) vectors.Vec2 {
+	if !m.IsSphere() {
+		return vectors.Vec2{
+			X: m.Vertices[j].X - m.Vertices[i].X,
+			Y: m.Vertices[j].Y - m.Vertices[i].Y,
+		}
+	}
+	p := m.XYZ[i]
+	east := vectors.NewVec3(-p.Y, p.X, 0)
+	if east.Len() < 1e-9 {
+		// We are at a pole, so any direction will do.
+		east = vectors.NewVec3(1, 0, 0)
+	}
+	east = east.Normalize()
+	north := p.Cross(east)
+	d := m.XYZ[j].Sub(p).Mul(m.Extent.Height / math.Pi)
+	return vectors.Vec2{
+		X: d.Dot(east),
+		Y: -d.Dot(north),
+	}
+}
+
 func (m *Mesh) IsEdge(i int) bool {
 	return len(m.AdjacentVxs[i]) < 3
 }
 
 func (m *Mesh) IsNearEdge(i int) bool {
+	if m.IsSphere() {
+		return false
+	}
 	x := m.Vertices[i].X
 	y := m.Vertices[i].Y
 	w := m.Extent.Width
@@ -82,6 +139,16 @@ func (m *Mesh) MapF(f func(v voronoi.Vertex) float64) *Heightmap {
 	return h
 }
 
+// MapXYZ returns a heightmap with the values returned by f for the position
+// of each vertex on the unit sphere (sphere mode only).
+func (m *Mesh) MapXYZ(f func(v vectors.Vec3) float64) *Heightmap {
+	h := NewHeightmap(m)
+	for i := range m.XYZ {
+		h.Values[i] = f(m.XYZ[i])
+	}
+	return h
+}
+
 func (m *Mesh) ApplyGen(f genheightmap.GenFunc) *Heightmap {
 	h := NewHeightmap(m)
 	for i := range m.Vertices {